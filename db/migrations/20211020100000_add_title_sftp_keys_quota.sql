@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE `titles` ADD `sftp_public_keys` TEXT COLLATE utf8_bin;
+UPDATE `titles` SET `sftp_public_keys` = '';
+ALTER TABLE `titles` ADD `sftp_quota` BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `titles` DROP COLUMN `sftp_public_keys`;
+ALTER TABLE `titles` DROP COLUMN `sftp_quota`;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `titles` ADD `auto_queue_hours` INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `titles` DROP COLUMN `auto_queue_hours`;
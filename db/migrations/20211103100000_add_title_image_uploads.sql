@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `titles` ADD `image_uploads` TINYINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `titles` DROP COLUMN `image_uploads`;
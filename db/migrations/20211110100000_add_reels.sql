@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE `reels` (
+  `id` INT(11) NOT NULL AUTO_INCREMENT,
+  `marc_org_code` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `lccn` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `reel_number` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `location` TINYTEXT NOT NULL COLLATE utf8_bin,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+ALTER TABLE `issues` ADD `reel_id` INT(11) NOT NULL DEFAULT 0;
+
+-- +goose Down
+DROP TABLE `reels`;
+ALTER TABLE `issues` DROP COLUMN `reel_id`;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE `titles` ADD `marc_frequency` TINYTEXT COLLATE utf8_bin;
+ALTER TABLE `titles` ADD `frequency` TINYTEXT COLLATE utf8_bin;
+
+CREATE TABLE `unpublished_dates` (
+  `id` INT(11) NOT NULL AUTO_INCREMENT,
+  `lccn` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `date` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `note` TEXT NOT NULL COLLATE utf8_bin,
+  `user_id` INT(11) NOT NULL,
+  `created_at` DATETIME,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `unpublished_dates_lccn_date` (`lccn`(100), `date`(10))
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `unpublished_dates`;
+ALTER TABLE `titles` DROP COLUMN `frequency`;
+ALTER TABLE `titles` DROP COLUMN `marc_frequency`;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE `titles` ADD `marc_start_year` TINYTEXT COLLATE utf8_bin;
+ALTER TABLE `titles` ADD `marc_end_year` TINYTEXT COLLATE utf8_bin;
+
+-- +goose Down
+ALTER TABLE `titles` DROP COLUMN `marc_start_year`;
+ALTER TABLE `titles` DROP COLUMN `marc_end_year`;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE `batches` ADD `version` INT(11) NOT NULL DEFAULT 1;
+ALTER TABLE `issues` ADD `withdrawn` TINYINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `issues` DROP COLUMN `withdrawn`;
+ALTER TABLE `batches` DROP COLUMN `version`;
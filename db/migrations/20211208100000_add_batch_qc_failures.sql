@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE `batch_qc_failures` (
+  `id` INT(11) NOT NULL AUTO_INCREMENT,
+  `batch_id` INT(11) NOT NULL,
+  `batch_version` INT(11) NOT NULL,
+  `user_id` INT(11) NOT NULL,
+  `notes` TEXT NOT NULL COLLATE utf8_bin,
+  `created_at` DATETIME,
+  PRIMARY KEY (`id`),
+  KEY `batch_qc_failures_batch_id` (`batch_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+CREATE TABLE `batch_qc_flags` (
+  `id` INT(11) NOT NULL AUTO_INCREMENT,
+  `qc_failure_id` INT(11) NOT NULL,
+  `issue_id` INT(11) NOT NULL,
+  `page` INT(11) NOT NULL DEFAULT 0,
+  `category` TINYTEXT NOT NULL COLLATE utf8_bin,
+  `comment` TEXT NOT NULL COLLATE utf8_bin,
+  PRIMARY KEY (`id`),
+  KEY `batch_qc_flags_qc_failure_id` (`qc_failure_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- +goose Down
+DROP TABLE `batch_qc_flags`;
+DROP TABLE `batch_qc_failures`;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `batches` ADD `stored_at` DATETIME;
+
+-- +goose Down
+ALTER TABLE `batches` DROP COLUMN `stored_at`;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE `batches` ADD `imported` TINYINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE `batches` DROP COLUMN `imported`;
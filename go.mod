@@ -5,14 +5,18 @@ go 1.14
 require (
 	github.com/Nerdmaster/magicsql v0.11.0
 	github.com/Nerdmaster/terminal v0.12.1
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-sql-driver/mysql v1.3.0
 	github.com/gorilla/mux v1.7.0
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/mattn/go-sqlite3 v1.10.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pkg/sftp v1.13.5
 	github.com/pressly/goose v2.7.0+incompatible // indirect
 	github.com/uoregon-libraries/gopkg v0.15.0
-	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // indirect
 	golang.org/x/tools v0.1.5 // indirect
 )
@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+#
+# fetch-ndnp-schemas.sh downloads the METS, MODS, and ALTO schemas used to
+# validate batches, along with every schema they import, into the given
+# directory.  Imports are rewritten to point at the local copies so xmllint
+# never needs network access, and ndnp.xsd is written to tie everything
+# together: point NDNP_SCHEMA at it.
+#
+# Any schema's URL can be overridden via METS_XSD_URL, MODS_XSD_URL, or
+# ALTO_XSD_URL.  The ALTO schema's namespace must match the OCR files' (NCA
+# generates ALTO in the "http://schema.ccs-gmbh.com/ALTO" namespace).
+set -eu
+
+if [[ $# -ne 1 ]]; then
+  echo "usage: $0 <schema directory>" >&2
+  exit 1
+fi
+
+dest=$1
+mets_url=${METS_XSD_URL:-http://www.loc.gov/standards/mets/version17/mets.v1-7.xsd}
+mods_url=${MODS_XSD_URL:-http://www.loc.gov/standards/mods/v3/mods-3-3.xsd}
+alto_url=${ALTO_XSD_URL:-http://www.loc.gov/standards/alto/alto-v1-4.xsd}
+
+mkdir -p "$dest"
+
+# fetch downloads a schema and everything it imports or includes, then
+# rewrites those locations to the local filenames
+fetch() {
+  local url=$1
+  local name=${url##*/}
+  if [[ -f "$dest/$name" ]]; then
+    return
+  fi
+
+  echo "Fetching $url"
+  if ! curl -fsSL -o "$dest/$name" "$url"; then
+    rm -f "$dest/$name"
+    echo "Unable to download $url" >&2
+    exit 1
+  fi
+
+  local loc
+  for loc in $(grep -o 'schemaLocation="[^" ]*"' "$dest/$name" | sed 's/^schemaLocation="//; s/"$//' | sort -u); do
+    if [[ $loc != *://* ]]; then
+      loc=${url%/*}/$loc
+    fi
+    fetch "$loc"
+  done
+  sed -i 's#schemaLocation="[^" ]*/\([^/" ]*\)"#schemaLocation="\1"#g' "$dest/$name"
+}
+
+# namespace prints the target namespace of the given local schema
+namespace() {
+  grep -o 'targetNamespace="[^"]*"' "$dest/$1" | head -n 1 | sed 's/^targetNamespace="//; s/"$//'
+}
+
+for url in "$mets_url" "$mods_url" "$alto_url"; do
+  fetch "$url"
+done
+
+{
+  echo '<?xml version="1.0" encoding="UTF-8"?>'
+  echo '<!-- Generated by fetch-ndnp-schemas.sh -->'
+  echo '<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">'
+  for url in "$mets_url" "$mods_url" "$alto_url"; do
+    name=${url##*/}
+    echo "  <xs:import namespace=\"$(namespace "$name")\" schemaLocation=\"$name\"/>"
+  done
+  echo '</xs:schema>'
+} > "$dest/ndnp.xsd"
+
+echo "Done: set NDNP_SCHEMA to $dest/ndnp.xsd"
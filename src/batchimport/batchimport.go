@@ -0,0 +1,279 @@
+// Package batchimport reads batches which were built outside NCA, such as
+// those produced before NCA was in use or by a vendor, so they can be added
+// to NCA's database.  Once imported, a batch can be managed like any other
+// live batch: its issues can be withdrawn and new versions built.
+package batchimport
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// Batch is a batch read from disk, holding the unsaved database records it
+// will become
+type Batch struct {
+	// Dir is the batch's directory
+	Dir string
+
+	Batch  *models.Batch
+	Issues []*Issue
+}
+
+// Issue ties an unsaved database issue to its location within the batch
+type Issue struct {
+	*models.Issue
+
+	// Dir is the issue's directory within the batch
+	Dir string
+
+	// ReelNumber is set if the issue was scanned from microfilm
+	ReelNumber string
+}
+
+// Read parses the batch XML and issue METS files from the batch in dir.  The
+// batch must follow the directory layout NCA uses, so that new versions of
+// it can be built from its issues.  Nothing is read from or written to the
+// database.
+func Read(dir string) (*Batch, error) {
+	dir = filepath.Clean(dir)
+	var name = filepath.Base(dir)
+	var sb, err = schema.ParseBatchname(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch name %q: %s", name, err)
+	}
+
+	var dataDir = filepath.Join(dir, "data")
+	var bx *chronam.BatchXML
+	bx, err = chronam.ParseBatchXML(filepath.Join(dataDir, "batch.xml"))
+	if err != nil {
+		return nil, err
+	}
+	if bx.Name != name {
+		return nil, fmt.Errorf("batch XML name %q doesn't match the batch directory", bx.Name)
+	}
+	if bx.Awardee != sb.MARCOrgCode {
+		return nil, fmt.Errorf("batch XML awardee %q doesn't match the batch name", bx.Awardee)
+	}
+	var year int
+	year, err = strconv.Atoi(bx.AwardYear)
+	if err != nil || year < 1 {
+		return nil, fmt.Errorf("invalid award year %q in batch XML", bx.AwardYear)
+	}
+	if len(bx.Issues) == 0 {
+		return nil, fmt.Errorf("batch XML lists no issues")
+	}
+
+	// The creation date is all NCA uses to produce a batch's award year, so
+	// we set it to the start of the award year to keep new versions the same
+	var b = &Batch{
+		Dir: dir,
+		Batch: &models.Batch{
+			MARCOrgCode: sb.MARCOrgCode,
+			Name:        sb.Keyword,
+			CreatedAt:   time.Date(year, 1, 1, 0, 0, 0, 0, time.Local),
+			Version:     sb.Version,
+			Imported:    true,
+		},
+	}
+
+	var seen = make(map[string]bool)
+	for _, bi := range bx.Issues {
+		var i *Issue
+		i, err = b.readIssue(dataDir, bi)
+		if err != nil {
+			return nil, err
+		}
+		if seen[i.Key()] {
+			return nil, fmt.Errorf("issue %q is listed more than once", i.Key())
+		}
+		seen[i.Key()] = true
+		b.Issues = append(b.Issues, i)
+	}
+
+	return b, nil
+}
+
+// readIssue parses the METS for one of the batch XML's issues, making sure
+// it's where NCA would have put it
+func (b *Batch) readIssue(dataDir string, bi chronam.BatchIssueXML) (*Issue, error) {
+	var rel = strings.TrimSpace(bi.Content)
+	var parts = strings.Split(rel, "/")
+	if len(parts) != 4 || path.Clean(rel) != rel {
+		return nil, fmt.Errorf("issue path %q must be <lccn>/<print or reel number>/<date and edition>/<date and edition>.xml", rel)
+	}
+
+	var fpath = filepath.Join(dataDir, filepath.FromSlash(rel))
+	var im, err = ParseIssueMETS(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read issue METS %q: %s", rel, err)
+	}
+
+	var i = &Issue{Issue: im.Issue, Dir: filepath.Dir(fpath)}
+	var ed, _ = strconv.Atoi(bi.EditionOrder)
+	if i.LCCN != bi.LCCN || i.Date != bi.Date || i.Edition != ed {
+		return nil, fmt.Errorf("issue METS %q is for %q, but the batch XML lists it as %q",
+			rel, i.Key(), schema.IssueKey(bi.LCCN, bi.Date, ed))
+	}
+
+	var de = i.DateEdition()
+	if parts[0] != i.LCCN || parts[2] != de || parts[3] != de+".xml" {
+		return nil, fmt.Errorf("issue %q must be in %q", i.Key(), path.Join(i.LCCN, parts[1], de, de+".xml"))
+	}
+	if parts[1] != "print" {
+		i.ReelNumber = parts[1]
+	}
+
+	i.MARCOrgCode = b.Batch.MARCOrgCode
+	i.Ignored = true
+	i.WorkflowStep = schema.WSInProduction
+	return i, nil
+}
+
+// Check looks in the database for anything which would keep the batch from
+// being imported: an unknown MARC org code or title, a batch with the same
+// name, or issues which are already in NCA.  A list of problems is returned;
+// the error is only set if the database couldn't be read.
+func (b *Batch) Check() ([]string, error) {
+	var problems []string
+
+	var moc, err = models.FindMOCByCode(b.Batch.MARCOrgCode)
+	if err != nil {
+		return nil, err
+	}
+	if moc == nil {
+		problems = append(problems, fmt.Sprintf("MARC org code %q isn't in the database", b.Batch.MARCOrgCode))
+	}
+
+	var existing *models.Batch
+	existing, err = models.FindBatchByFullName(b.Batch.FullName())
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		problems = append(problems, fmt.Sprintf("batch %q is already in the database (id %d)", b.Batch.FullName(), existing.ID))
+	}
+
+	var titles models.TitleList
+	titles, err = models.Titles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Pull each title's issues once so we can look for existing issues
+	// without a query per issue
+	var keys = make(map[string]*models.Issue)
+	var seenLCCN = make(map[string]bool)
+	for _, i := range b.Issues {
+		if seenLCCN[i.LCCN] {
+			continue
+		}
+		seenLCCN[i.LCCN] = true
+
+		if titles.FindByLCCN(i.LCCN) == nil {
+			problems = append(problems, fmt.Sprintf("title %q isn't in the database", i.LCCN))
+			continue
+		}
+
+		var list []*models.Issue
+		list, err = models.Issues().IncludeIgnored().LCCN(i.LCCN).Fetch()
+		if err != nil {
+			return nil, err
+		}
+		for _, dbi := range list {
+			keys[dbi.Key()] = dbi
+		}
+	}
+
+	for _, i := range b.Issues {
+		var dbi = keys[i.Key()]
+		if dbi != nil {
+			problems = append(problems, fmt.Sprintf("issue %q is already in the database (id %d)", i.Key(), dbi.ID))
+		}
+	}
+
+	return problems, nil
+}
+
+// Save writes the batch and its issues to the database with the given
+// status, which must be live or live_done.  Live batches keep pointing to
+// the batch directory, so they can be archived and rebuilt like batches NCA
+// built.  Live-done batches are considered complete, so neither the batch
+// nor its issues are given a location.  Reels are looked up by LCCN and reel
+// number, and created if they don't exist.  Everything is saved in a single
+// transaction, so a failed import leaves nothing behind.
+//
+// Issue files in imported batches are never removed by the live-done cleanup,
+// as the batch directory isn't part of NCA's workflow area.
+//
+// The caller should call Check first to get a full list of problems.  Save
+// only checks again for issues which are already in the database, in case
+// one was added since the check, and fails on the first it finds.
+func (b *Batch) Save(status string, wentLiveAt time.Time) error {
+	var live bool
+	switch status {
+	case models.BatchStatusLive:
+		live = true
+	case models.BatchStatusLiveDone:
+	default:
+		return fmt.Errorf("imported batches must be %q or %q, not %q", models.BatchStatusLive, models.BatchStatusLiveDone, status)
+	}
+
+	b.Batch.Status = status
+	b.Batch.WentLiveAt = wentLiveAt
+	if live {
+		b.Batch.Location = b.Dir
+	}
+
+	var issues []*models.Issue
+	var reels = make(map[*models.Issue]*models.Reel)
+	var reelsByKey = make(map[string]*models.Reel)
+	for _, i := range b.Issues {
+		if live {
+			i.Location = i.Dir
+		}
+		if i.ReelNumber != "" {
+			var reel, err = findReel(reelsByKey, i, live)
+			if err != nil {
+				return fmt.Errorf("unable to look up reel %q for issue %q: %s", i.ReelNumber, i.Key(), err)
+			}
+			reels[i.Issue] = reel
+		}
+		issues = append(issues, i.Issue)
+	}
+
+	return models.ImportBatch(b.Batch, issues, reels)
+}
+
+// findReel returns the reel the issue was scanned from, reusing one already
+// seen for this import, then looking in the database, and finally setting up
+// a new, unsaved reel.  A live batch's reel directory is used as the reel's
+// location unless the reel already has one.
+func findReel(seen map[string]*models.Reel, i *Issue, live bool) (*models.Reel, error) {
+	var key = i.LCCN + "/" + i.ReelNumber
+	var reel = seen[key]
+	if reel != nil {
+		return reel, nil
+	}
+
+	var err error
+	reel, err = models.FindReelByNumber(i.LCCN, i.ReelNumber)
+	if err != nil {
+		return nil, err
+	}
+	if reel == nil {
+		reel = &models.Reel{MARCOrgCode: i.MARCOrgCode, LCCN: i.LCCN, ReelNumber: i.ReelNumber}
+	}
+	if live && reel.Location == "" {
+		reel.Location = filepath.Dir(i.Dir)
+	}
+	seen[key] = reel
+	return reel, nil
+}
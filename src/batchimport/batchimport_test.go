@@ -0,0 +1,153 @@
+package batchimport
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/testhelper"
+)
+
+const batchName = "batch_oru_courage_3_ver02"
+
+// makeBatch copies the test batch, which has one print issue and one
+// microfilm issue, to a temp dir and returns the copy's path
+func makeBatch(t *testing.T) string {
+	return testhelper.CopyDir(t, filepath.Join("testdata", batchName))
+}
+
+func TestRead(t *testing.T) {
+	var dir = makeBatch(t)
+	var b, err = Read(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if got := b.Batch.FullName(); got != batchName {
+		t.Errorf("Expected batch name %q, got %q", batchName, got)
+	}
+	if got := b.Batch.AwardYear(); got != 2012 {
+		t.Errorf("Expected award year 2012, got %d", got)
+	}
+	if len(b.Issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d", len(b.Issues))
+	}
+
+	var i = b.Issues[0]
+	if i.Key() != "sn12345678/2001010101" {
+		t.Errorf("Expected first issue to be sn12345678/2001010101, got %q", i.Key())
+	}
+	if i.MARCOrgCode != "oru" || i.Volume != "3" || i.Issue.Issue != "12" || i.DateAsLabeled != "2001-01-00" {
+		t.Errorf("Issue metadata wasn't read: %#v", i.Issue)
+	}
+	if !reflect.DeepEqual(i.PageLabels, []string{"1", "0"}) {
+		t.Errorf("Expected page labels [1 0], got %v", i.PageLabels)
+	}
+	if want := filepath.Join(dir, "data", "sn12345678", "print", "2001010101"); i.Dir != want {
+		t.Errorf("Expected issue dir %q, got %q", want, i.Dir)
+	}
+	if i.ReelNumber != "" {
+		t.Errorf("Print issue shouldn't have a reel number; got %q", i.ReelNumber)
+	}
+	if !i.Ignored || i.Location != "" || i.BatchID != 0 {
+		t.Errorf("Issue workflow data is wrong: %#v", i.Issue)
+	}
+	if b.Issues[1].ReelNumber != "00279552046" {
+		t.Errorf("Expected reel number 00279552046, got %q", b.Issues[1].ReelNumber)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var replace = func(file, old, new string) func(*testing.T, string) {
+		return func(t *testing.T, dir string) {
+			testhelper.Replace(t, filepath.Join(dir, "data", filepath.FromSlash(file)), old, new)
+		}
+	}
+	var printMETS = "sn12345678/print/2001010101/2001010101.xml"
+
+	var tests = map[string]struct {
+		change func(t *testing.T, dir string)
+		want   string
+	}{
+		"wrong batch name": {
+			replace("batch.xml", `name="batch_oru_courage_3_ver02"`, `name="batch_oru_courage_3_ver01"`),
+			`batch XML name "batch_oru_courage_3_ver01" doesn't match the batch directory`,
+		},
+		"wrong awardee": {
+			replace("batch.xml", `awardee="oru"`, `awardee="hoo"`),
+			`batch XML awardee "hoo" doesn't match the batch name`,
+		},
+		"no award year": {
+			replace("batch.xml", `awardYear="2012"`, ""),
+			`invalid award year ""`,
+		},
+		"bad layout": {
+			replace("batch.xml", "sn12345678/print/2001010101/2001010101.xml", "2001010101/2001010101.xml"),
+			`issue path "2001010101/2001010101.xml" must be`,
+		},
+		"misnamed METS": {
+			func(t *testing.T, dir string) {
+				replace("batch.xml", printMETS, "sn12345678/print/2001010101/mets.xml")(t, dir)
+				var issueDir = filepath.Join(dir, "data", "sn12345678", "print", "2001010101")
+				var err = os.Rename(filepath.Join(issueDir, "2001010101.xml"), filepath.Join(issueDir, "mets.xml"))
+				if err != nil {
+					t.Fatalf("Unable to rename METS: %s", err)
+				}
+			},
+			`issue "sn12345678/2001010101" must be in "sn12345678/print/2001010101/2001010101.xml"`,
+		},
+		"missing METS": {
+			func(t *testing.T, dir string) { os.Remove(filepath.Join(dir, "data", filepath.FromSlash(printMETS))) },
+			`unable to read issue METS "sn12345678/print/2001010101/2001010101.xml"`,
+		},
+		"batch XML mismatch": {
+			replace("batch.xml", `issueDate="2001-01-01"`, `issueDate="2001-01-02"`),
+			`is for "sn12345678/2001010101", but the batch XML lists it as "sn12345678/2001010201"`,
+		},
+		"duplicate issue": {
+			replace("batch.xml", "</ndnp:batch>", `<issue lccn="sn12345678" issueDate="2001-01-01" editionOrder="01">`+printMETS+"</issue></ndnp:batch>"),
+			`issue "sn12345678/2001010101" is listed more than once`,
+		},
+		"bad date qualifier": {
+			replace(printMETS, `qualifier="questionable"`, `qualifier="approximate"`),
+			`unknown date qualifier: "approximate"`,
+		},
+		"missing page": {
+			replace(printMETS, "<mods:start>1</mods:start>", "<mods:start>3</mods:start>"),
+			"no metadata for page 1",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var dir = makeBatch(t)
+			tc.change(t, dir)
+
+			var _, err = Read(dir)
+			if err == nil {
+				t.Fatalf("Expected an error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected an error containing %q; got %q", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParseIssueMETS(t *testing.T) {
+	var dir = makeBatch(t)
+	var im, err = ParseIssueMETS(filepath.Join(dir, "data", "sn12345678", "print", "2001010101", "2001010101.xml"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if im.Title.Name != "The Courage" || im.Title.LCCN != "sn12345678" {
+		t.Errorf("Title wasn't read: %#v", im.Title)
+	}
+	if want := time.Date(2012, 3, 4, 5, 6, 7, 0, time.UTC); !im.CreatedAt.Equal(want) {
+		t.Errorf("Expected METS creation date %s, got %s", want, im.CreatedAt)
+	}
+}
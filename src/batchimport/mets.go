@@ -0,0 +1,194 @@
+package batchimport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/mets"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/mods"
+)
+
+// IssueMETS holds the unsaved issue and title data read from an issue's METS
+// XML, along with the METS file's creation date
+type IssueMETS struct {
+	Issue     *models.Issue
+	Title     *models.Title
+	CreatedAt time.Time
+}
+
+// ParseIssueMETS reads the METS XML file at fpath and returns the issue and
+// title data it describes.  Only metadata is read: the issue's location,
+// batch, and workflow information are left for the caller to fill in.
+func ParseIssueMETS(fpath string) (*IssueMETS, error) {
+	var mxml, err = chronam.ParseMETSIssueXML(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var im = &IssueMETS{Issue: models.NewIssue("", "", "", 0), Title: new(models.Title)}
+	im.CreatedAt, err = time.Parse(mets.TimeFormat, mxml.Header.CreateDate)
+	if err != nil {
+		return nil, fmt.Errorf("bad METS header time %q: %s", mxml.Header.CreateDate, err)
+	}
+
+	// Parse the issue metadata separately from the page metadata
+	for _, dmd := range mxml.DMDSecs {
+		if dmd.ID == "issueModsBib" {
+			err = im.parseIssueData(dmd.Data)
+		} else {
+			err = im.parsePageData(dmd.Data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if im.Issue.LCCN == "" {
+		return nil, fmt.Errorf("no LCCN found")
+	}
+	if im.Issue.Date == "" {
+		return nil, fmt.Errorf("no issue date found")
+	}
+	if im.Issue.Edition == 0 {
+		return nil, fmt.Errorf("no issue edition found")
+	}
+	for i, label := range im.Issue.PageLabels {
+		if label == "" {
+			return nil, fmt.Errorf("no metadata for page %d", i+1)
+		}
+	}
+
+	// Now we should have issue date, so we can split up the label to get the title
+	var parts = strings.Split(mxml.Label, ", "+im.Issue.Date)
+	im.Title.Name = parts[0]
+
+	return im, nil
+}
+
+func (im *IssueMETS) parseIssueData(data mods.Data) error {
+	im.Title.Rights = data.Rights
+	// Go through the "relatedItem" tags to pull out the LCCN and dive into the
+	// issue metadata "detail" parts
+	for _, item := range data.RelatedItems {
+		var err = im.parseRelatedItems(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Origin info gives us the issue date and a possible date-as-labeled value
+	for _, info := range data.OriginInfos {
+		var err = im.parseOriginInfo(info)
+		if err != nil {
+			return err
+		}
+	}
+
+	// If there weren't any "questionable" dates, then DateAsLabeled is the same
+	// as the actual issue date
+	if im.Issue.DateAsLabeled == "" {
+		im.Issue.DateAsLabeled = im.Issue.Date
+	}
+	return nil
+}
+
+func (im *IssueMETS) parseRelatedItems(item mods.RelItem) error {
+	if item.Type != "host" {
+		return nil
+	}
+
+	for _, id := range item.IDs {
+		if id.Type == "lccn" {
+			im.Title.LCCN = id.Label
+			im.Issue.LCCN = id.Label
+		}
+	}
+	// Each "part" can have multiple details, each of which contain our issue
+	// metadata: volume, issue, edition number, edition label
+	for _, part := range item.Parts {
+		for _, detail := range part.Details {
+			switch detail.Type {
+			case "volume":
+				im.Issue.Volume = detail.Number
+			case "issue":
+				im.Issue.Issue = detail.Number
+			case "edition":
+				var err error
+				im.Issue.Edition, err = strconv.Atoi(detail.Number)
+				if err != nil || im.Issue.Edition == 0 {
+					return fmt.Errorf("invalid value for issue edition: %q", detail.Number)
+				}
+				im.Issue.EditionLabel = detail.Caption
+			}
+		}
+	}
+	return nil
+}
+
+func (im *IssueMETS) parseOriginInfo(info mods.OriginInfo) error {
+	for _, date := range info.Dates {
+		switch date.Qualifier {
+		case "":
+			if im.Issue.Date != "" {
+				return fmt.Errorf("too many dates found")
+			}
+			im.Issue.Date = date.Date
+
+		case "questionable":
+			if im.Issue.DateAsLabeled != "" {
+				return fmt.Errorf("too many dates with 'questionable' qualifier found")
+			}
+			im.Issue.DateAsLabeled = date.Date
+
+		default:
+			return fmt.Errorf("unknown date qualifier: %q", date.Qualifier)
+		}
+	}
+	return nil
+}
+
+func (im *IssueMETS) parsePageData(data mods.Data) error {
+	// Iterate over all parts to get page and optionally page labels
+	for pNum, part := range data.Parts {
+		// "extent" must be present, and gives us the page number so we can sort properly
+		var pageNumber int
+		for eNum, extent := range part.Extents {
+			if eNum > 0 {
+				return fmt.Errorf("too many 'extent' elements in page data part %d", pNum)
+			}
+			var err error
+			pageNumber, err = strconv.Atoi(extent.Start)
+			if err != nil {
+				return fmt.Errorf("invalid page number in page data part %d: %q", pNum, extent.Start)
+			}
+		}
+
+		if pageNumber < 1 {
+			return fmt.Errorf("missing page number in page data part %d", pNum)
+		}
+
+		var pageLabel string
+
+		// "detail" may or may not be present; if so, its "number" is our page label
+		for dNum, detail := range part.Details {
+			if dNum > 0 {
+				return fmt.Errorf("too many 'detail' elements in page data part %d", pNum)
+			}
+			pageLabel = detail.Number
+		}
+
+		if pageLabel == "" {
+			pageLabel = "0"
+		}
+
+		for pageNumber > len(im.Issue.PageLabels) {
+			im.Issue.PageLabels = append(im.Issue.PageLabels, "")
+		}
+		im.Issue.PageLabels[pageNumber-1] = pageLabel
+	}
+	return nil
+}
@@ -1,4 +1,7 @@
-package main
+// Package batchqueue groups issues which are ready for batching into proposed
+// batches per MARC Org Code.  The proposal is a Plan, which can be reviewed
+// (as text or JSON) before it's committed to the database.
+package batchqueue
 
 import (
 	"fmt"
@@ -13,11 +16,14 @@ type issue struct {
 	*models.Issue
 	title     *models.Title
 	pages     int
+	bytes     int64
 	daysStale float64
 	embargoed bool
+
+	embargoLiftDate time.Time
 }
 
-func wrapIssue(dbIssue *models.Issue) (*issue, error) {
+func wrapIssue(dbIssue *models.Issue, titles models.TitleList) (*issue, error) {
 	var issueDate, err = time.Parse("2006-01-02", dbIssue.Date)
 	if err != nil {
 		return nil, fmt.Errorf("%q is an invalid date: %s", dbIssue.Date, err)
@@ -30,21 +36,20 @@ func wrapIssue(dbIssue *models.Issue) (*issue, error) {
 		return nil, fmt.Errorf("LCCN %q has no database title", i.LCCN)
 	}
 
-	var embargoLiftDate time.Time
-	embargoLiftDate, err = i.title.CalculateEmbargoLiftDate(issueDate)
+	i.embargoLiftDate, err = i.title.CalculateEmbargoLiftDate(issueDate)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to parse title's embargo duration: %s", err)
 	}
 
-	if embargoLiftDate.After(time.Now()) {
+	if i.embargoLiftDate.After(time.Now()) {
 		i.embargoed = true
 	}
 
 	// Embargoed issues can be waiting for a while before their embargo is
 	// lifted, so we have to consider them stale based on the newer date:
 	// metadata approval or embargo lifting.
-	if i.MetadataApprovedAt.Before(embargoLiftDate) {
-		i.daysStale = time.Since(embargoLiftDate).Hours() / 24.0
+	if i.MetadataApprovedAt.Before(i.embargoLiftDate) {
+		i.daysStale = time.Since(i.embargoLiftDate).Hours() / 24.0
 	} else {
 		i.daysStale = time.Since(i.MetadataApprovedAt).Hours() / 24.0
 	}
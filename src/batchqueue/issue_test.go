@@ -1,4 +1,4 @@
-package main
+package batchqueue
 
 import (
 	"math"
@@ -19,6 +19,8 @@ var (
 	embargoPeriod = "30 days"
 )
 
+var titles models.TitleList
+
 func overrideLookup() {
 	titles = models.TitleList{
 		&models.Title{LCCN: lccnSimple},
@@ -33,7 +35,7 @@ func makeIssue(lccn, date string) *models.Issue {
 }
 
 func mustWrap(dbi *models.Issue, t *testing.T) *issue {
-	var i, err = wrapIssue(dbi)
+	var i, err = wrapIssue(dbi, titles)
 	if err != nil {
 		t.Errorf("Error wrapping issue: %s", err)
 	}
@@ -49,14 +51,14 @@ func TestWrapIssue(t *testing.T) {
 	var err error
 
 	dbi = makeIssue(badlccn, goodDate)
-	i, err = wrapIssue(dbi)
+	i, err = wrapIssue(dbi, titles)
 	if err == nil {
 		t.Errorf("Issue with bad lccn shouldn't have worked")
 	}
 	t.Logf("Got error (this is expected): %s", err)
 
 	dbi = makeIssue(lccnSimple, invalidDate)
-	i, err = wrapIssue(dbi)
+	i, err = wrapIssue(dbi, titles)
 	if err == nil {
 		t.Errorf("Issue with bad date shouldn't have worked")
 	}
@@ -0,0 +1,290 @@
+package batchqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/humanize"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/metadatarules"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// SkipReason is a short explanation of why an issue isn't in a plan's batches
+type SkipReason string
+
+// All possible reasons an issue which is ready for batching might be skipped
+const (
+	SkipInvalid     SkipReason = "invalid"
+	SkipEmbargoed   SkipReason = "embargoed"
+	SkipRules       SkipReason = "metadata rules"
+	SkipTooFewPages SkipReason = "too few pages"
+)
+
+// Plan is the list of batches which would be created from the issues
+// currently ready for batching, along with the issues which would be left out
+type Plan struct {
+	CreatedAt time.Time `json:"created_at"`
+	Strategy  string    `json:"strategy"`
+	MinPages  int       `json:"min_pages"`
+	Limits
+	Batches []*PlannedBatch `json:"batches"`
+	Skipped []*SkippedIssue `json:"skipped"`
+}
+
+// PlannedBatch is a single proposed batch.  LongWait is true when the batch
+// has fewer than the minimum number of pages, but is being created anyway
+// because at least one issue has been waiting too long.
+type PlannedBatch struct {
+	MARCOrgCode string          `json:"moc"`
+	Pages       int             `json:"pages"`
+	Bytes       int64           `json:"bytes"`
+	LongWait    bool            `json:"long_wait"`
+	Issues      []*PlannedIssue `json:"issues"`
+}
+
+// PlannedIssue identifies an issue in a proposed batch
+type PlannedIssue struct {
+	ID        int     `json:"id"`
+	Key       string  `json:"key"`
+	Title     string  `json:"title"`
+	Pages     int     `json:"pages"`
+	Bytes     int64   `json:"bytes"`
+	DaysStale float64 `json:"days_stale"`
+}
+
+// SkippedIssue is an issue which is ready for batching, but won't be put into
+// any of the plan's batches
+type SkippedIssue struct {
+	ID     int        `json:"id"`
+	Key    string     `json:"key"`
+	Reason SkipReason `json:"reason"`
+	Detail string     `json:"detail"`
+}
+
+// Options controls how batches are planned
+type Options struct {
+	// Strategy is the name of the strategy used to choose a batch's issues
+	Strategy string
+
+	// MinPages is the fewest pages a batch may have unless an issue has been
+	// waiting too long
+	MinPages int
+
+	Limits
+}
+
+// ConfigOptions returns the batch planning options from NCA's configuration
+func ConfigOptions(c *config.Config) Options {
+	return Options{
+		Strategy: c.BatchStrategy,
+		MinPages: c.MinBatchSize,
+		Limits:   Limits{MaxPages: c.MaxBatchSize, MaxBytes: c.MaxBatchBytes, MaxIssues: c.MaxBatchIssues},
+	}
+}
+
+// BuildPlan finds all issues ready for batching and proposes batches for each
+// MARC Org Code.  Batches are built using the strategy named in o, and have
+// at least o.MinPages pages (unless an issue has waited too long) without
+// exceeding o's limits.  Nothing is written to the database.
+func BuildPlan(o Options, titles models.TitleList, rules *metadatarules.RuleSet) (*Plan, error) {
+	var s, err = getStrategy(o.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	var q = newBatchQueue(o.MinPages, s, o.Limits)
+	q.titles = titles
+	q.rules = rules
+	q.plan.Strategy = o.Strategy
+	if q.plan.Strategy == "" {
+		q.plan.Strategy = DefaultStrategy
+	}
+
+	err = q.FindReadyIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var _, ok = q.NextBatch()
+		if !ok {
+			break
+		}
+	}
+
+	return q.plan, nil
+}
+
+// ParsePlan reads a plan from JSON data, such as a file written by WriteJSON
+func ParsePlan(data []byte) (*Plan, error) {
+	var p = new(Plan)
+	var err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch plan: %s", err)
+	}
+	return p, nil
+}
+
+func (p *Plan) skip(i *models.Issue, reason SkipReason, detail string) {
+	p.Skipped = append(p.Skipped, &SkippedIssue{ID: i.ID, Key: i.Key(), Reason: reason, Detail: detail})
+}
+
+// WriteJSON writes the plan to w in a format ParsePlan can read
+func (p *Plan) WriteJSON(w io.Writer) error {
+	var data, err = json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteText writes a human-readable summary of the plan to w
+func (p *Plan) WriteText(w io.Writer) error {
+	var lines = []string{
+		fmt.Sprintf("Batch plan created %s using the %q strategy", p.CreatedAt.Format("2006-01-02 15:04"), p.Strategy),
+		"Batch limits: " + p.DescribeLimits(),
+	}
+
+	if len(p.Batches) == 0 {
+		lines = append(lines, "", "No batches would be created")
+	}
+	for n, b := range p.Batches {
+		lines = append(lines, "", fmt.Sprintf("Batch %d: MOC %q, %s, %d issues", n+1, b.MARCOrgCode, b.Size(), len(b.Issues)))
+		if b.LongWait {
+			lines = append(lines, fmt.Sprintf("  (under the minimum page count, but an issue has waited more than %d days)", longWaitDays))
+		}
+		for _, i := range b.Issues {
+			lines = append(lines, fmt.Sprintf("  - %s (%s): %d pages, waiting %.0f days", i.Key, i.Title, i.Pages, i.DaysStale))
+		}
+	}
+
+	if len(p.Skipped) > 0 {
+		lines = append(lines, "", "Skipped issues:")
+	}
+	for _, i := range p.Skipped {
+		lines = append(lines, fmt.Sprintf("  - %s: %s (%s)", i.Key, i.Reason, i.Detail))
+	}
+
+	var _, err = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// DescribeLimits returns a short human-readable list of the plan's limits
+func (p *Plan) DescribeLimits() string {
+	var parts = []string{fmt.Sprintf("at least %d pages", p.MinPages)}
+	if p.MaxPages > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d pages", p.MaxPages))
+	}
+	if p.MaxBytes > 0 {
+		parts = append(parts, fmt.Sprintf("at most %s", humanize.Bytes(p.MaxBytes)))
+	}
+	if p.MaxIssues > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d issues", p.MaxIssues))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Size returns the batch's page count, and its byte count if it's known
+func (b *PlannedBatch) Size() string {
+	if b.Bytes > 0 {
+		return fmt.Sprintf("%d pages (%s)", b.Pages, humanize.Bytes(b.Bytes))
+	}
+	return fmt.Sprintf("%d pages", b.Pages)
+}
+
+// Commit creates the plan's batches and queues the jobs to build them on
+// disk.  The plan is rejected if any of its issues have changed such that
+// they can no longer be batched as planned, so an approved plan is created
+// exactly as it was reviewed.  Issues are checked inside the same transaction
+// that batches them, and their rows stay locked until it ends, so nothing can
+// change them in between.  Either all batches are created or none are.
+func (p *Plan) Commit(webroot, batchOutputPath string) ([]*models.Batch, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+
+	var lists, err = p.load(func(id int) (*models.Issue, error) { return models.FindIssueForUpdateOp(op, id) })
+	if err != nil {
+		op.Rollback()
+		return nil, err
+	}
+
+	var batches []*models.Batch
+	for n, pb := range p.Batches {
+		var b *models.Batch
+		b, err = models.CreateBatchOp(op, webroot, pb.MARCOrgCode, lists[n])
+		if err == nil {
+			err = jobs.QueueSerialOp(op, jobs.GetJobsForMakeBatch(b, batchOutputPath)...)
+		}
+		if err != nil {
+			op.Rollback()
+			return nil, fmt.Errorf("creating batch %d: %s", n+1, err)
+		}
+		batches = append(batches, b)
+	}
+
+	op.EndTransaction()
+	return batches, op.Err()
+}
+
+// load reads each planned issue using find.  If any issue can no longer be
+// batched as planned, an error describing all such issues is returned
+// instead.
+func (p *Plan) load(find func(id int) (*models.Issue, error)) ([][]*models.Issue, error) {
+	var seen = make(map[int]bool)
+	var problems []string
+	var lists = make([][]*models.Issue, len(p.Batches))
+	for n, pb := range p.Batches {
+		for _, pi := range pb.Issues {
+			if seen[pi.ID] {
+				problems = append(problems, fmt.Sprintf("%s is planned for more than one batch", pi.Key))
+				continue
+			}
+			seen[pi.ID] = true
+
+			var i, err = find(pi.ID)
+			if err != nil {
+				return nil, fmt.Errorf("reading issue %d (%s): %s", pi.ID, pi.Key, err)
+			}
+			var problem = checkIssue(i, pi, pb.MARCOrgCode)
+			if problem != "" {
+				problems = append(problems, fmt.Sprintf("%s %s", pi.Key, problem))
+				continue
+			}
+			lists[n] = append(lists[n], i)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("the plan is out of date and must be rebuilt: %s", strings.Join(problems, "; "))
+	}
+	return lists, nil
+}
+
+// checkIssue returns a description of why the database issue can't be
+// batched as planned, or an empty string if nothing has changed
+func checkIssue(i *models.Issue, pi *PlannedIssue, moc string) string {
+	switch {
+	case i == nil:
+		return "no longer exists"
+	case i.WorkflowStep != schema.WSReadyForBatching:
+		return "is no longer ready for batching"
+	case i.BatchID != 0:
+		return "is already in a batch"
+	case i.Ignored:
+		return "has been removed from the workflow"
+	case i.MARCOrgCode != moc:
+		return fmt.Sprintf("is no longer assigned to MARC Org Code %q", moc)
+	case i.Key() != pi.Key || len(i.PageLabels) != pi.Pages:
+		return "has changed since the plan was built"
+	}
+	return ""
+}
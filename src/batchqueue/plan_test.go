@@ -0,0 +1,185 @@
+package batchqueue
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func drain(q *batchQueue) {
+	for {
+		var _, ok = q.NextBatch()
+		if !ok {
+			return
+		}
+	}
+}
+
+func TestNextBatchPlans(t *testing.T) {
+	var q = setup(t)
+	drain(q)
+
+	var issues, pages int
+	for _, b := range q.plan.Batches {
+		if b.Pages > q.limits.MaxPages {
+			t.Errorf("batch for %q has %d pages (max is %d)", b.MARCOrgCode, b.Pages, q.limits.MaxPages)
+		}
+		if b.LongWait {
+			t.Errorf("batch for %q shouldn't be flagged as a long wait", b.MARCOrgCode)
+		}
+		issues += len(b.Issues)
+		pages += b.Pages
+	}
+
+	assertEqualI("planned issues", issues, 84, t)
+	assertEqualI("planned pages", pages, 600, t)
+	assertEqualI("skipped issues", len(q.plan.Skipped), 0, t)
+}
+
+func TestNextBatchTooFewPages(t *testing.T) {
+	var q = setup(t)
+	q.minPages = 250
+	q.limits.MaxPages = 300
+	drain(q)
+
+	// moc1 gets one batch, leaving 60 pages to be skipped; moc2 has only 240
+	// pages, so it's skipped entirely
+	assertEqualI("planned batches", len(q.plan.Batches), 1, t)
+	for _, s := range q.plan.Skipped {
+		if s.Reason != SkipTooFewPages {
+			t.Errorf("issue %s skipped for %q; expected %q", s.Key, s.Reason, SkipTooFewPages)
+		}
+	}
+	assertEqualI("planned and skipped issues", len(q.plan.Batches[0].Issues)+len(q.plan.Skipped), 84, t)
+}
+
+func TestNextBatchLongWait(t *testing.T) {
+	var q = setup(t)
+	q.minPages = 1000
+	for _, i := range q.mocQueue["moc2"].list {
+		i.daysStale = longWaitDays + 1
+	}
+	q.mocQueue["moc2"].longWait = true
+	drain(q)
+
+	assertEqualI("planned batches", len(q.plan.Batches), 3, t)
+	for _, b := range q.plan.Batches {
+		if b.MARCOrgCode != "moc2" {
+			t.Errorf("batch for %q shouldn't have been planned", b.MARCOrgCode)
+		}
+		if !b.LongWait {
+			t.Errorf("small batch should be flagged as a long wait")
+		}
+	}
+}
+
+func TestPlanJSON(t *testing.T) {
+	var q = setup(t)
+	drain(q)
+	q.plan.CreatedAt = time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	var err = q.plan.WriteJSON(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write JSON: %s", err)
+	}
+
+	var p *Plan
+	p, err = ParsePlan(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unable to parse JSON: %s", err)
+	}
+	assertEqualI("parsed batches", len(p.Batches), len(q.plan.Batches), t)
+	for n, b := range p.Batches {
+		var orig = q.plan.Batches[n]
+		assertEqualI("parsed batch pages", b.Pages, orig.Pages, t)
+		assertEqualI("parsed batch issues", len(b.Issues), len(orig.Issues), t)
+		if b.Issues[0].Key != orig.Issues[0].Key {
+			t.Errorf("first issue's key was %q; expected %q", b.Issues[0].Key, orig.Issues[0].Key)
+		}
+	}
+	if !p.CreatedAt.Equal(q.plan.CreatedAt) {
+		t.Errorf("creation time was %s; expected %s", p.CreatedAt, q.plan.CreatedAt)
+	}
+
+	_, err = ParsePlan([]byte("{"))
+	if err == nil {
+		t.Errorf("invalid JSON should fail to parse")
+	}
+}
+
+func TestPlanLoad(t *testing.T) {
+	var dbIssues = make(map[int]*models.Issue)
+	var planned = func(id int, moc string, change func(i *models.Issue)) *PlannedIssue {
+		var i = models.NewIssue(moc, "sn12345678", fmt.Sprintf("2021-01-%02d", id), 1)
+		i.ID = id
+		i.WorkflowStep = schema.WSReadyForBatching
+		i.PageLabels = []string{"1", "2"}
+		dbIssues[id] = i
+		var pi = &PlannedIssue{ID: id, Key: i.Key(), Pages: len(i.PageLabels)}
+		if change != nil {
+			change(i)
+		}
+		return pi
+	}
+	var find = func(id int) (*models.Issue, error) { return dbIssues[id], nil }
+
+	var p = &Plan{Batches: []*PlannedBatch{
+		{MARCOrgCode: "oru", Issues: []*PlannedIssue{planned(1, "oru", nil), planned(2, "oru", nil)}},
+		{MARCOrgCode: "hoo", Issues: []*PlannedIssue{planned(3, "hoo", nil)}},
+	}}
+	var lists, err = p.load(find)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	assertEqualI("first batch issues", len(lists[0]), 2, t)
+	assertEqualI("second batch issues", len(lists[1]), 1, t)
+
+	var tests = map[string]struct {
+		issue *PlannedIssue
+		twice bool
+		want  string
+	}{
+		"unchanged":     {issue: planned(4, "oru", nil)},
+		"deleted":       {issue: planned(5, "oru", func(i *models.Issue) { delete(dbIssues, 5) }), want: "no longer exists"},
+		"batched":       {issue: planned(6, "oru", func(i *models.Issue) { i.BatchID = 12 }), want: "already in a batch"},
+		"sent back":     {issue: planned(7, "oru", func(i *models.Issue) { i.WorkflowStep = schema.WSAwaitingMetadataReview }), want: "no longer ready"},
+		"ignored":       {issue: planned(8, "oru", func(i *models.Issue) { i.Ignored = true }), want: "removed from the workflow"},
+		"moved":         {issue: planned(9, "oru", func(i *models.Issue) { i.MARCOrgCode = "hoo" }), want: "no longer assigned"},
+		"pages changed": {issue: planned(10, "oru", func(i *models.Issue) { i.PageLabels = []string{"1"} }), want: "has changed"},
+		"planned twice": {issue: planned(11, "oru", nil), twice: true, want: "more than one batch"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p2 = &Plan{Batches: []*PlannedBatch{
+				{MARCOrgCode: "oru", Issues: []*PlannedIssue{planned(1, "oru", nil), tc.issue}},
+			}}
+			if tc.twice {
+				p2.Batches = append(p2.Batches, &PlannedBatch{MARCOrgCode: "hoo", Issues: []*PlannedIssue{tc.issue}})
+			}
+
+			var lists, err = p2.load(find)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected an error containing %q, got lists %v", tc.want, lists)
+			}
+			if lists != nil {
+				t.Errorf("Expected no issues to be returned with an error, got %v", lists)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected an error containing %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package batchqueue
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/metadatarules"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// longWaitDays is how long an issue may wait before its queue is batched
+// regardless of the minimum page count
+const longWaitDays = 30
+
+// issueQueue is a list of issues for a given MOC to ease batching.  It acts as
+// a CS set in that you can append the same issue multiple times without having
+// duplicates.
+type issueQueue struct {
+	list     []*issue
+	seen     map[*issue]bool
+	pages    int
+	bytes    int64
+	longWait bool
+}
+
+func newMOCIssueQueue() *issueQueue {
+	var q = new(issueQueue)
+	q.emptyList()
+	return q
+}
+
+func (q *issueQueue) append(i *issue) {
+	if q.seen[i] {
+		return
+	}
+
+	q.list = append(q.list, i)
+	q.pages += i.pages
+	q.bytes += i.bytes
+	q.seen[i] = true
+
+	// Mark this queue as stale (e.g., needs batching even if we're under the
+	// usual limit) if any single issue has been sitting for 30 days longer than
+	// desired
+	if !q.longWait {
+		q.longWait = i.daysStale > longWaitDays
+	}
+}
+
+func (q *issueQueue) emptyList() {
+	q.seen = make(map[*issue]bool)
+	q.pages = 0
+	q.bytes = 0
+	q.list = nil
+	q.longWait = false
+}
+
+// splitQueue picks the issues which will be included in the next batch, as
+// chosen by the given strategy within the given limits, and puts them into a
+// new issueQueue.  Issues put in the returned queue are *removed* from this
+// queue's issues list.
+//
+// If no issues fit (e.g., a single issue is larger than the limits allow),
+// the longest-waiting issue is put into a batch by itself rather than being
+// left in the queue forever.
+func (q *issueQueue) splitQueue(s strategy, l Limits) *issueQueue {
+	var list = make([]*issue, len(q.list))
+	copy(list, q.list)
+	q.emptyList()
+
+	var popped = newMOCIssueQueue()
+	if len(list) == 0 {
+		return popped
+	}
+
+	s.fill(popped, list, l)
+	if len(popped.list) == 0 {
+		byApproval(list)
+		logger.Warnf("Issue %s exceeds the batch limits; it will be batched alone", list[0].Key())
+		popped.append(list[0])
+	}
+
+	for _, i := range list {
+		if !popped.seen[i] {
+			q.append(i)
+		}
+	}
+
+	return popped
+}
+
+type batchQueue struct {
+	currentMOC string
+	mocList    []string
+	mocQueue   map[string]*issueQueue
+	minPages   int
+	limits     Limits
+	strategy   strategy
+	titles     models.TitleList
+	rules      *metadatarules.RuleSet
+	plan       *Plan
+}
+
+func newBatchQueue(minPages int, s strategy, l Limits) *batchQueue {
+	return &batchQueue{
+		minPages: minPages,
+		limits:   l,
+		strategy: s,
+		mocQueue: make(map[string]*issueQueue),
+		plan:     &Plan{CreatedAt: time.Now(), MinPages: minPages, Limits: l},
+	}
+}
+
+// FindReadyIssues looks at all issues in the database which are able to be
+// batched and adds them to internal queues per MARC Org Code.  Some basic
+// metadata validation takes place here as well.  Issues which can't be
+// batched are recorded in the plan's skipped list.
+//
+// TODO: Ensure files haven't changed (add sha checksums when issues first move
+// to the metadata entry phase; store file-level info in the database so we
+// have an easy checksum that's 100% separate from the filesystem)
+func (q *batchQueue) FindReadyIssues() error {
+	var issues, err = models.Issues().InWorkflowStep(schema.WSReadyForBatching).BatchID(0).Fetch()
+	if err != nil {
+		return fmt.Errorf("finding issues: %s", err)
+	}
+
+	for _, dbIssue := range issues {
+		var i, err = wrapIssue(dbIssue, q.titles)
+		if err != nil {
+			q.plan.skip(dbIssue, SkipInvalid, err.Error())
+			continue
+		}
+
+		if i.embargoed {
+			q.plan.skip(dbIssue, SkipEmbargoed, "embargoed until "+i.embargoLiftDate.Format("2006-01-02"))
+			continue
+		}
+
+		if !q.passesRules(i) {
+			continue
+		}
+
+		if q.limits.MaxBytes > 0 {
+			i.bytes, err = diskspace.DirBytes(i.Location)
+			if err != nil {
+				q.plan.skip(dbIssue, SkipInvalid, fmt.Sprintf("unable to read issue files: %s", err))
+				continue
+			}
+		}
+
+		logger.Debugf("Adding %s to batch queue", i.Key())
+		var moc = i.MARCOrgCode
+		var mocQ, ok = q.mocQueue[moc]
+		if !ok {
+			mocQ = newMOCIssueQueue()
+			q.mocQueue[moc] = mocQ
+			q.mocList = append(q.mocList, moc)
+		}
+		mocQ.append(i)
+	}
+
+	return nil
+}
+
+// passesRules runs the configured metadata rules against the issue.  Warnings
+// were already accepted when metadata was entered, so they're only logged;
+// errors keep an issue from being batched.
+func (q *batchQueue) passesRules(i *issue) bool {
+	var failures []string
+	for _, err := range q.rules.Check(i.Issue) {
+		if err.Warning() {
+			logger.Warnf("Issue %s: %s", i.Key(), err.Message())
+			continue
+		}
+		failures = append(failures, err.Message())
+	}
+
+	if len(failures) > 0 {
+		q.plan.skip(i.Issue, SkipRules, strings.Join(failures, "; "))
+		return false
+	}
+	return true
+}
+
+// nextMOC calculates which MARC Org Code should be used for the next batch and
+// returns its issue queue.  Iterates through known MOCs when queues are empty
+// until a queue is found or no queues are left, in which case nil is returned.
+func (q *batchQueue) nextMOCQueue() *issueQueue {
+	var mq = q.mocQueue[q.currentMOC]
+	if mq != nil && len(mq.list) > 0 {
+		return mq
+	}
+
+	if len(q.mocList) == 0 {
+		return nil
+	}
+
+	q.currentMOC, q.mocList = q.mocList[0], q.mocList[1:]
+	return q.nextMOCQueue()
+}
+
+func (q *batchQueue) currentQueue() (*issueQueue, bool) {
+	var mq = q.nextMOCQueue()
+	return mq, mq != nil
+}
+
+// NextBatch returns the next proposed batch.  Every issue put into the batch
+// is removed from its queue so that each call to NextBatch returns a new
+// batch.  ok is false when there was nothing left to batch, and the batch is
+// nil if the current queue's issues didn't have enough pages for a batch.
+func (q *batchQueue) NextBatch() (pb *PlannedBatch, ok bool) {
+	for moc, mq := range q.mocQueue {
+		if mq.pages > 0 {
+			logger.Debugf("%q queue has %d pages left", moc, mq.pages)
+		}
+	}
+
+	var currentQ *issueQueue
+	currentQ, ok = q.currentQueue()
+	if !ok {
+		logger.Debugf("Operation complete: no issues were found in the remaining queue(s)")
+		return nil, false
+	}
+
+	var smallQ = currentQ.splitQueue(q.strategy, q.limits)
+	if smallQ.pages < q.minPages && !smallQ.longWait {
+		var detail = fmt.Sprintf("%q has only %d pages ready; batches need at least %d", q.currentMOC, smallQ.pages, q.minPages)
+		for _, i := range smallQ.list {
+			q.plan.skip(i.Issue, SkipTooFewPages, detail)
+		}
+		return nil, true
+	}
+
+	pb = &PlannedBatch{MARCOrgCode: q.currentMOC, Pages: smallQ.pages, Bytes: smallQ.bytes, LongWait: smallQ.pages < q.minPages}
+	for _, i := range smallQ.list {
+		var pi = &PlannedIssue{ID: i.ID, Key: i.Key(), Pages: i.pages, Bytes: i.bytes, DaysStale: i.daysStale}
+		if i.title != nil {
+			pi.Title = i.title.Name
+		}
+		pb.Issues = append(pb.Issues, pi)
+	}
+	q.plan.Batches = append(q.plan.Batches, pb)
+
+	return pb, true
+}
@@ -1,4 +1,4 @@
-package main
+package batchqueue
 
 import (
 	"math"
@@ -11,7 +11,7 @@ var testQ *batchQueue
 func setup(t *testing.T) *batchQueue {
 	overrideLookup()
 
-	testQ = newBatchQueue(1, 100)
+	testQ = newBatchQueue(1, oldestFirst{}, Limits{MaxPages: 100})
 	var dates = []string{
 		"2001-01-01", "2001-02-01", "2001-03-01", "2001-04-01",
 		"2001-05-01", "2001-06-01", "2001-07-01", "2001-08-01",
@@ -83,17 +83,17 @@ func TestQueueing(t *testing.T) {
 			t.FailNow()
 		}
 
-		var splitQ = iq.splitQueue(testQ.maxPages)
+		var splitQ = iq.splitQueue(testQ.strategy, testQ.limits)
 		pagesSplit += splitQ.pages
 		t.Logf("Split number %d", splits)
 		assertEqualI("total pages post-split", iq.pages+pagesSplit, queueSize, t)
 		assertEqualI("current queue pages post-split", iq.pages, queueSize-pagesSplit, t)
-		if iq.pages > 0 && (splitQ.pages < minPageSplit || splitQ.pages > testQ.maxPages) {
-			t.Errorf("split queue has %d pages (should have %d to %d)", splitQ.pages, minPageSplit, testQ.maxPages)
+		if iq.pages > 0 && (splitQ.pages < minPageSplit || splitQ.pages > testQ.limits.MaxPages) {
+			t.Errorf("split queue has %d pages (should have %d to %d)", splitQ.pages, minPageSplit, testQ.limits.MaxPages)
 		}
 	}
 
-	var minSplitCount = int(math.Ceil(float64(queueSize) / float64(testQ.maxPages)))
+	var minSplitCount = int(math.Ceil(float64(queueSize) / float64(testQ.limits.MaxPages)))
 	var maxSplitCount = int(math.Ceil(float64(queueSize) / float64(minPageSplit)))
 	if splits < minSplitCount || splits > maxSplitCount {
 		t.Errorf("split %d times (should have been %d to %d)", splits, minSplitCount, maxSplitCount)
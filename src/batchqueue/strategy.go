@@ -0,0 +1,135 @@
+package batchqueue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits caps the size of a single batch.  A zero value means there is no
+// limit of that type.
+type Limits struct {
+	MaxPages  int   `json:"max_pages"`
+	MaxBytes  int64 `json:"max_bytes"`
+	MaxIssues int   `json:"max_issues"`
+}
+
+// fits returns true if i can be added to q without exceeding any limits
+func (l Limits) fits(q *issueQueue, i *issue) bool {
+	if l.MaxPages > 0 && q.pages+i.pages > l.MaxPages {
+		return false
+	}
+	if l.MaxBytes > 0 && q.bytes+i.bytes > l.MaxBytes {
+		return false
+	}
+	if l.MaxIssues > 0 && len(q.list)+1 > l.MaxIssues {
+		return false
+	}
+	return true
+}
+
+// A strategy decides which of a MARC Org Code's queued issues go into its
+// next batch
+type strategy interface {
+	// fill adds issues from the list to batch, in the order they should appear
+	// in the batch, without exceeding the limits
+	fill(batch *issueQueue, list []*issue, l Limits)
+}
+
+// DefaultStrategy is used when no strategy is configured
+const DefaultStrategy = "oldest-first"
+
+// strategies maps each strategy's name to its implementation
+var strategies = map[string]strategy{
+	"oldest-first": oldestFirst{},
+	"title":        singleTitle{},
+	"date-runs":    dateRuns{},
+}
+
+// StrategyNames returns the names of all valid strategies, sorted
+func StrategyNames() []string {
+	var names []string
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getStrategy returns the named strategy, or the default strategy if name is
+// blank
+func getStrategy(name string) (strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	var s, ok = strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown batch strategy %q (valid strategies: %s)", name, strings.Join(StrategyNames(), ", "))
+	}
+	return s, nil
+}
+
+// byApproval sorts issues so those which were approved earliest come first
+func byApproval(list []*issue) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].MetadataApprovedAt.Before(list[j].MetadataApprovedAt)
+	})
+}
+
+// oldestFirst puts the longest-waiting issues into the batch first, skipping
+// any that would exceed a limit so smaller issues can fill the space
+type oldestFirst struct{}
+
+func (oldestFirst) fill(batch *issueQueue, list []*issue, l Limits) {
+	byApproval(list)
+	for _, i := range list {
+		if l.fits(batch, i) {
+			batch.append(i)
+		}
+	}
+}
+
+// singleTitle batches only the issues of the title with the longest-waiting
+// issue, oldest first, so a batch never mixes titles
+type singleTitle struct{}
+
+func (singleTitle) fill(batch *issueQueue, list []*issue, l Limits) {
+	byApproval(list)
+	var lccn = list[0].LCCN
+	for _, i := range list {
+		if i.LCCN == lccn && l.fits(batch, i) {
+			batch.append(i)
+		}
+	}
+}
+
+// dateRuns keeps each title's issues together in date order.  Titles are
+// added in order of their longest-waiting issue, and a title's issues are
+// added until one doesn't fit, so a batch never skips over an issue that
+// would leave a hole in a title's run of dates.
+type dateRuns struct{}
+
+func (dateRuns) fill(batch *issueQueue, list []*issue, l Limits) {
+	byApproval(list)
+	var lccns []string
+	var byTitle = make(map[string][]*issue)
+	for _, i := range list {
+		if byTitle[i.LCCN] == nil {
+			lccns = append(lccns, i.LCCN)
+		}
+		byTitle[i.LCCN] = append(byTitle[i.LCCN], i)
+	}
+
+	for _, lccn := range lccns {
+		var run = byTitle[lccn]
+		sort.SliceStable(run, func(i, j int) bool {
+			return run[i].DateEdition() < run[j].DateEdition()
+		})
+		for _, i := range run {
+			if !l.fits(batch, i) {
+				break
+			}
+			batch.append(i)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package batchqueue
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// stratIssue returns a wrapped issue with the given number of pages, approved
+// the given number of days ago
+func stratIssue(t *testing.T, lccn, date string, pages, daysAgo int) *issue {
+	var dbi = makeIssue(lccn, date)
+	dbi.PageLabels = make([]string, pages)
+	dbi.MetadataApprovedAt = time.Now().AddDate(0, 0, -daysAgo)
+	return mustWrap(dbi, t)
+}
+
+func keys(q *issueQueue) string {
+	var list []string
+	for _, i := range q.list {
+		list = append(list, i.Key())
+	}
+	return strings.Join(list, ",")
+}
+
+func assertKeys(t *testing.T, name string, q *issueQueue, expected ...string) {
+	var got = keys(q)
+	var exp = strings.Join(expected, ",")
+	if got != exp {
+		t.Errorf("%s: expected issues %q, got %q", name, exp, got)
+	}
+}
+
+// stratQueue sets up a queue with two titles' issues, approved in an order
+// that doesn't match their dates
+func stratQueue(t *testing.T) *issueQueue {
+	overrideLookup()
+	var q = newMOCIssueQueue()
+	q.append(stratIssue(t, lccnSimple, "2001-01-03", 4, 5))
+	q.append(stratIssue(t, lccnEmbargoed, "2001-01-01", 6, 9))
+	q.append(stratIssue(t, lccnSimple, "2001-01-01", 4, 8))
+	q.append(stratIssue(t, lccnSimple, "2001-01-02", 10, 7))
+	q.append(stratIssue(t, lccnEmbargoed, "2001-01-02", 6, 1))
+	return q
+}
+
+const (
+	simple1 = "lccn1/2001010101"
+	simple2 = "lccn1/2001010201"
+	simple3 = "lccn1/2001010301"
+	embgo1  = "lccn2/2001010101"
+	embgo2  = "lccn2/2001010201"
+)
+
+func TestOldestFirst(t *testing.T) {
+	var q = stratQueue(t)
+	var batch = q.splitQueue(oldestFirst{}, Limits{MaxPages: 15})
+	assertKeys(t, "oldest-first batch", batch, embgo1, simple1, simple3)
+	assertKeys(t, "oldest-first remainder", q, simple2, embgo2)
+	assertEqualI("oldest-first batch pages", batch.pages, 14, t)
+	assertEqualI("oldest-first remainder pages", q.pages, 16, t)
+}
+
+func TestSingleTitle(t *testing.T) {
+	var q = stratQueue(t)
+	var batch = q.splitQueue(singleTitle{}, Limits{MaxPages: 100})
+	assertKeys(t, "title batch", batch, embgo1, embgo2)
+	assertKeys(t, "title remainder", q, simple1, simple2, simple3)
+
+	batch = q.splitQueue(singleTitle{}, Limits{MaxPages: 100})
+	assertKeys(t, "second title batch", batch, simple1, simple2, simple3)
+	assertEqualI("remainder issues", len(q.list), 0, t)
+}
+
+func TestDateRuns(t *testing.T) {
+	var q = stratQueue(t)
+
+	// lccn2 has the oldest approval, so its run goes first.  lccn1's run stops
+	// at the ten-page issue rather than skipping ahead to 2001-01-03.
+	var batch = q.splitQueue(dateRuns{}, Limits{MaxPages: 20})
+	assertKeys(t, "date-runs batch", batch, embgo1, embgo2, simple1)
+	assertKeys(t, "date-runs remainder", q, simple2, simple3)
+}
+
+func TestMaxIssues(t *testing.T) {
+	var q = stratQueue(t)
+	var batch = q.splitQueue(oldestFirst{}, Limits{MaxPages: 100, MaxIssues: 2})
+	assertKeys(t, "max-issues batch", batch, embgo1, simple1)
+	assertEqualI("max-issues remainder", len(q.list), 3, t)
+}
+
+func TestMaxBytes(t *testing.T) {
+	var q = stratQueue(t)
+	for _, i := range q.list {
+		i.bytes = int64(i.pages) * 1000
+	}
+	q.bytes = 30000
+
+	var batch = q.splitQueue(oldestFirst{}, Limits{MaxBytes: 10000})
+	assertKeys(t, "max-bytes batch", batch, embgo1, simple1)
+	if batch.bytes != 10000 {
+		t.Errorf("max-bytes batch should have 10000 bytes; got %d", batch.bytes)
+	}
+	if q.bytes != 20000 {
+		t.Errorf("max-bytes remainder should have 20000 bytes; got %d", q.bytes)
+	}
+}
+
+func TestOversizedIssue(t *testing.T) {
+	var q = stratQueue(t)
+	var batch = q.splitQueue(oldestFirst{}, Limits{MaxPages: 2})
+	assertKeys(t, "oversized batch", batch, embgo1)
+	assertEqualI("oversized remainder", len(q.list), 4, t)
+}
+
+func TestGetStrategy(t *testing.T) {
+	var s, err = getStrategy("")
+	if err != nil {
+		t.Fatalf("default strategy returned an error: %s", err)
+	}
+	if _, ok := s.(oldestFirst); !ok {
+		t.Errorf("default strategy should be oldest-first, got %#v", s)
+	}
+
+	for _, name := range StrategyNames() {
+		_, err = getStrategy(name)
+		if err != nil {
+			t.Errorf("strategy %q returned an error: %s", name, err)
+		}
+	}
+
+	_, err = getStrategy("biggest-first")
+	if err == nil {
+		t.Errorf("unknown strategy should return an error")
+	}
+}
@@ -41,6 +41,14 @@ type TitleJSON struct {
 	Subject            []string
 }
 
+// IssueJSON is what we get from an issue-details API request
+type IssueJSON struct {
+	Date    string `json:"date_issued"`
+	Volume  string
+	Number  string
+	Edition int
+}
+
 // BatchJSON is what we get from a batch-details API request
 type BatchJSON struct {
 	Name   string
@@ -74,3 +82,12 @@ func ParseTitleJSON(encoded []byte) (*TitleJSON, error) {
 	var err = json.Unmarshal(encoded, t)
 	return t, err
 }
+
+// ParseIssueJSON takes a pile of bytes and attempts to convert them into an
+// IssueJSON structure.  If json.Unmarshal has an error, it will be returned
+// along with a nil object.
+func ParseIssueJSON(encoded []byte) (*IssueJSON, error) {
+	var i = &IssueJSON{}
+	var err = json.Unmarshal(encoded, i)
+	return i, err
+}
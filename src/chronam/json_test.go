@@ -73,3 +73,28 @@ func TestBatchJSON(t *testing.T) {
 		t.Fatalf("Third issue date was %#v; expected to see %#v", actualDate, expectedDate)
 	}
 }
+
+func TestIssueJSON(t *testing.T) {
+	var encoded = `
+{
+	"title": {
+		"url": "http://oregonnews.uoregon.edu/lccn/sn84022643.json", "name": "The Albany register."
+	},
+	"url": "http://oregonnews.uoregon.edu/lccn/sn84022643/1868-12-05/ed-1.json",
+	"date_issued": "1868-12-05",
+	"volume": "1",
+	"number": "12",
+	"edition": 1,
+	"pages": []
+}
+		`
+
+	var i, err = ParseIssueJSON([]byte(encoded))
+	if err != nil {
+		t.Fatalf("JSON wouldn't parse: %s", err)
+	}
+
+	if i.Date != "1868-12-05" || i.Volume != "1" || i.Number != "12" || i.Edition != 1 {
+		t.Fatalf("Issue JSON parsed incorrectly: %#v", i)
+	}
+}
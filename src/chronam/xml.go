@@ -10,8 +10,11 @@ import (
 
 // BatchXML is used to deserialize batch.xml files to get at their issues list
 type BatchXML struct {
-	XMLName xml.Name        `xml:"batch"`
-	Issues  []BatchIssueXML `xml:"issue"`
+	XMLName   xml.Name        `xml:"batch"`
+	Name      string          `xml:"name,attr"`
+	Awardee   string          `xml:"awardee,attr"`
+	AwardYear string          `xml:"awardYear,attr"`
+	Issues    []BatchIssueXML `xml:"issue"`
 }
 
 // BatchIssueXML describes each <issue> element in the batch XML
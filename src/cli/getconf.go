@@ -10,6 +10,7 @@ import (
 	"github.com/uoregon-libraries/gopkg/wordutils"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/version"
 )
 
@@ -64,10 +65,21 @@ func (c *CLI) GetConf() *config.Config {
 	if err != nil {
 		logger.Fatalf("Config error: %s", err)
 	}
+	ConfigureLogger(conf)
+	shell.SetTimeouts(conf.ExecTimeouts)
 
 	return conf
 }
 
+// ConfigureLogger sets up NCA's global log format and levels from the given
+// configuration, exiting the application if the settings aren't valid
+func ConfigureLogger(conf *config.Config) {
+	var err = logger.Configure(conf.LogFormat, conf.LogLevels)
+	if err != nil {
+		logger.Fatalf("Config error: %s", err)
+	}
+}
+
 // Parse just runs the flags parser with some of our custom logic for handling
 // errors and the help flag
 func (c *CLI) Parse() {
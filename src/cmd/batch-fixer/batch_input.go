@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
@@ -13,11 +14,13 @@ func (i *Input) makeBatchMenu() (*menu, string) {
 	var m = i.makeMenu()
 	var st = i.batch.db.Status
 	if st == models.BatchStatusQCReady || st == models.BatchStatusOnStaging {
-		m.add("failqc", "Marks the batch as needing work before being put into production", i.failQCHandler)
+		m.add("failqc", "Marks the batch as needing work before being put into production.  Any arguments "+
+			"are saved as notes in the batch's QC history", i.failQCHandler)
 	}
 	if st == models.BatchStatusFailedQC {
 		m.add("load", "Loads an issue by its id, allowing removal from the batch", i.loadIssueHandler)
-		m.add("removeissue", "Finds an issue by key and removes it with less interaction", i.removeIssue)
+		m.add("removeissue", "Finds an issue by key and removes it with less interaction, optionally "+
+			"recording a QC problem category in the batch's QC history", i.removeIssue)
 		m.add("delete", "Deletes the entire batch from disk and resets associated issues in "+
 			"the database to their 'ready for batching' state, for cases where a full rebatch "+
 			"is easier than pulling individual issues (e.g., bad org code, dozens of bad issues, "+
@@ -26,8 +29,18 @@ func (i *Input) makeBatchMenu() (*menu, string) {
 		m.add("remove-all-and-delete", "Deletes the batch and removes all issues with a single error message.  This is a shortcut for removing each issue individually and then deleting the batch.  This is very dangerous and requires extra confirmation.", i.removeAllAndDelete)
 		m.add("requeue", "Creates a job in the database to requeue this batch", i.requeueBatchHandler)
 	}
-	if st != models.BatchStatusLive && st != models.BatchStatusLiveDone {
+	if st == models.BatchStatusLive {
+		m.add("withdraw", "Withdraws an issue from this live batch, queueing a new version of the batch "+
+			"to be built without it", i.withdrawIssueHandler)
+		if conf.ObjectStoreURL != "" && i.batch.db.Location != "" {
+			m.add("store", "Queues a job to copy this live batch to object storage, for batches which went "+
+				"live without passing QC in NCA", i.storeBatchHandler)
+		}
+	}
+	if st != models.BatchStatusLiveDone {
 		m.add("list", "Lists all issues associated with this batch", i.listIssueHandler)
+	}
+	if st != models.BatchStatusLive && st != models.BatchStatusLiveDone {
 		m.add("search", "searches issues by various parameters.  Values are formatted as regular expressions "+
 			`for the search.  e.g., "search date=19[0-6].* lccn=sn12345678 key=.*02" would find any issue `+
 			"for the given lccn which was a second edition published from 1900 - 1960", i.searchIssuesHandler)
@@ -88,9 +101,9 @@ func (i *Input) printIssueList(list IssueList) {
 	}
 }
 
-func (i *Input) failQCHandler([]string) {
+func (i *Input) failQCHandler(args []string) {
 	i.println("Removing batch...")
-	var err = i.batch.Fail()
+	var err = i.batch.Fail(strings.Join(args, " "))
 	if err != nil {
 		i.printerrln("unable to remove batch: " + err.Error())
 		return
@@ -103,13 +116,18 @@ func (i *Input) removeIssue(args []string) {
 	var usage = func(errmsg string) {
 		i.printerrln(errmsg)
 		i.println("")
-		i.println("usage: removeissue <type> <key> <reason>")
+		i.println("usage: removeissue <type> <key> <reason> [category=<category>]")
 		i.println("")
 		i.println(`type must be either "error" or "reject"`)
 		i.println("key must be in the standard issuekey format: LCCN/YYYYMMDDEE")
+		i.println("category is optional, and records the problem in the batch's QC history.  It must be one of:")
+		for _, cat := range models.QCCategories {
+			i.println(fmt.Sprintf("    %s: %s", cat, cat.Describe()))
+		}
 		i.println("examples:")
 		i.println("    removeissue reject sn99063854/1949012701 image 2 has a page label")
-		i.println("    removeissue error sn99063854/1949012701 pages are missing - need reupload")
+		i.println("    removeissue reject sn99063854/1949012701 image 2 has a page label category=page-labels")
+		i.println("    removeissue error sn99063854/1949012701 pages are missing - need reupload category=missing-pages")
 	}
 
 	if len(args) < 3 {
@@ -117,24 +135,86 @@ func (i *Input) removeIssue(args []string) {
 		return
 	}
 
-	var returnToMetadata bool
+	var typ invaliationType
+	var dest string
 	switch args[0] {
 	case "reject":
-		returnToMetadata = true
+		typ, dest = iTypeReject, "put back into the metadata entry queue"
 	case "error":
-		returnToMetadata = false
+		typ, dest = iTypeError, "removed from the workflow"
 	default:
 		usage("Invalid type")
 		return
 	}
 
-	var key = args[1]
+	var match = i.findIssueByKey(args[1])
+	if match == nil {
+		return
+	}
+
+	var reasonArgs = args[2:]
+	var cat models.QCCategory
+	var last = reasonArgs[len(reasonArgs)-1]
+	if strings.HasPrefix(last, "category=") {
+		cat = models.QCCategory(strings.TrimPrefix(last, "category="))
+		reasonArgs = reasonArgs[:len(reasonArgs)-1]
+		if !cat.Valid() {
+			usage("Invalid category")
+			return
+		}
+		if len(reasonArgs) == 0 {
+			usage("A reason is required")
+			return
+		}
+	}
+
+	var reason = strings.Join(reasonArgs, " ")
+	var msg = reason
+	if cat != "" {
+		msg = "failed QC: " + (&models.QCFlag{Category: string(cat), Comment: reason}).Describe()
+	}
+	i.println(fmt.Sprintf("%q will be removed from the batch and %s with a message of %q.", match.db.Key(), dest, msg))
+	if !i.confirmYN() {
+		return
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	var err = match.invalidateFromBatchOp(op, typ, msg)
+	if err == nil && cat != "" {
+		err = i.batch.flagIssueOp(op, match, cat, reason)
+	}
+	if err != nil {
+		op.Rollback()
+	} else {
+		op.EndTransaction()
+		err = op.Err()
+	}
+	if err == nil {
+		err = match.finishInvalidation()
+	}
+
+	// Reload regardless of success so we don't keep the in-memory changes of a
+	// failed removal
+	if !i.reloadBatch() {
+		return
+	}
+	if err != nil {
+		i.printerrln("unable to remove issue: " + err.Error())
+		return
+	}
+	i.println("Issue has been removed from the batch and " + dest)
+}
 
+// findIssueByKey returns the batch's issue with the given key, printing an
+// error and returning nil if there isn't exactly one match
+func (i *Input) findIssueByKey(key string) *Issue {
 	var search = new(queries)
 	var err = search.add("key=" + key)
 	if err != nil {
 		i.printerrln(err.Error())
-		return
+		return nil
 	}
 
 	var match *Issue
@@ -142,7 +222,7 @@ func (i *Input) removeIssue(args []string) {
 		if search.match(issue) {
 			if match != nil {
 				i.printerrln(fmt.Sprintf("More than one match for %q", key))
-				return
+				return nil
 			}
 
 			match = issue
@@ -151,16 +231,65 @@ func (i *Input) removeIssue(args []string) {
 
 	if match == nil {
 		i.printerrln(fmt.Sprintf("No issues found for %q", key))
+	}
+	return match
+}
+
+func (i *Input) withdrawIssueHandler(args []string) {
+	if len(args) < 2 {
+		i.printerrln("Invalid invocation of withdraw")
+		i.println("")
+		i.println("usage: withdraw <key> <reason>")
+		i.println("")
+		i.println("key must be in the standard issuekey format: LCCN/YYYYMMDDEE")
+		i.println("example:")
+		i.println("    withdraw sn99063854/1949012701 takedown request from publisher")
 		return
 	}
 
-	var reasonArgs = args[2:]
-	i.issue = match
-	if returnToMetadata {
-		i.rejectIssueHandler(reasonArgs)
+	var match = i.findIssueByKey(args[0])
+	if match == nil {
+		return
+	}
+
+	var b = i.batch.db
+	var oldName = b.FullName()
+	var msg = strings.Join(args[1:], " ")
+	i.println(fmt.Sprintf("What will happen to batch %q:", oldName))
+	i.println(fmt.Sprintf("- %q will be removed from the batch and flagged as withdrawn, with a reason of %q", match.db.Key(), msg))
+	i.println("- The issue will not be batched again unless it is reinstated")
+	i.println("- A new version of the batch will be built from the remaining issues, which will go back through QC")
+	if !i.confirmYN() {
+		i.println("Aborted...")
+		return
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	var err = b.WithdrawIssueOp(op, match.db, models.SystemUser.ID, msg)
+	if err == nil {
+		err = jobs.QueueSerialOp(op, jobs.GetJobsForMakeBatch(b, conf.BatchOutputPath)...)
+	}
+	if err != nil {
+		op.Rollback()
 	} else {
-		i.errorIssueHandler(reasonArgs)
+		op.EndTransaction()
+		err = op.Err()
 	}
+
+	// Reload regardless of success so we don't keep the in-memory changes of a
+	// failed withdrawal
+	if !i.reloadBatch() {
+		return
+	}
+	if err != nil {
+		i.printerrln(fmt.Sprintf("Unable to withdraw issue: %s", err))
+		return
+	}
+
+	i.println(fmt.Sprintf("Issue withdrawn.  %q is being built.", i.batch.db.FullName()))
+	i.println("Once the new version passes QC, " + oldName + " will be purged from production before the new version is loaded.")
 }
 
 func (i *Input) searchIssuesHandler(args []string) {
@@ -195,12 +324,26 @@ func (i *Input) searchIssuesHandler(args []string) {
 	}
 }
 
+func (i *Input) storeBatchHandler([]string) {
+	var err = jobs.QueueSerial(jobs.PrepareBatchJobAdvanced(models.JobTypeStoreBatch, i.batch.db, nil))
+	if err != nil {
+		i.printerrln(fmt.Sprintf("Error queueing batch storage: %s", err))
+		return
+	}
+	i.println("Batch storage job queued")
+}
+
 func (i *Input) batchInfoHandler([]string) {
+	var stored = "never"
+	if !i.batch.db.StoredAt.IsZero() {
+		stored = i.batch.db.StoredAt.Format("2006-01-02 15:04:05")
+	}
 	i.printDataList(
 		datum{"Name", i.batch.db.FullName()},
 		datum{"Location", i.batch.db.Location},
 		datum{"Status", i.batch.db.Status},
 		datum{"Creation", i.batch.db.CreatedAt.Format("2006-01-02 15:04:05")},
+		datum{"Stored", stored},
 	)
 }
 
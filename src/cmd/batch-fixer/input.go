@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/Nerdmaster/terminal"
@@ -28,6 +29,7 @@ func (i *Input) topMenu() (*menu, string) {
 	var m = i.makeMenu()
 	m.add("load", "Loads a batch by id", i.loadBatchHandler)
 	m.add("list", "Lists all batches that haven't gone live", i.listBatchesHandler)
+	m.add("reinstate", "Reinstates an issue which was withdrawn from a live batch, allowing it to be batched again", i.reinstateIssueHandler)
 	m.add("quit", "Ends the batch modification session", i.quitHandler)
 	return m, "No batch or issue loaded.  Enter a command:"
 }
@@ -186,6 +188,41 @@ func (i *Input) listBatchesHandler([]string) {
 	}
 }
 
+func (i *Input) reinstateIssueHandler(args []string) {
+	if len(args) < 2 {
+		i.printerrln("Invalid invocation of reinstate")
+		i.println("")
+		i.println("usage: reinstate <issue id> <reason>")
+		return
+	}
+
+	var id, err = strconv.Atoi(args[0])
+	if err != nil {
+		i.printerrln(fmt.Sprintf("%q is not a valid database id", args[0]))
+		return
+	}
+
+	var issue *Issue
+	issue, err = FindIssue(id)
+	if err != nil {
+		i.printerrln(fmt.Sprintf("unable to load issue %d: %s", id, err))
+		return
+	}
+
+	var msg = strings.Join(args[1:], " ")
+	i.println(fmt.Sprintf("%q will be put back in the queue for batching, with a reason of %q.", issue.db.Key(), msg))
+	if !i.confirmYN() {
+		return
+	}
+
+	err = issue.db.Reinstate(models.SystemUser.ID, msg)
+	if err != nil {
+		i.printerrln(fmt.Sprintf("unable to reinstate issue: %s", err))
+		return
+	}
+	i.println("Issue reinstated; it will be included the next time batches are queued")
+}
+
 func (i *Input) close() {
 	terminal.Restore(i.inputfd, i.termState)
 }
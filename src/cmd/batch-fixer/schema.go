@@ -5,7 +5,9 @@ import (
 	"os"
 	"sort"
 
+	"github.com/Nerdmaster/magicsql"
 	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
@@ -38,8 +40,9 @@ func FindBatch(id int) (*Batch, error) {
 // Fail deletes all batch files from disk - these are all bagit files or
 // hard-links, so we can easily replace everything removed.  The batch location
 // is cleared, and its status is then set to "failed_qc" so it's clear it needs
-// to be reprocessed in some way.
-func (b *Batch) Fail() error {
+// to be reprocessed in some way.  A QC failure is recorded with the given
+// notes so the batch's QC history shows why it failed.
+func (b *Batch) Fail(notes string) error {
 	if !fileutil.IsDir(b.db.Location) {
 		return fmt.Errorf("removing batch files: %q does not exist", b.db.Location)
 	}
@@ -49,18 +52,47 @@ func (b *Batch) Fail() error {
 		return fmt.Errorf("removing batch files: %s", err)
 	}
 
+	var f = b.db.NewQCFailure(models.SystemUser.ID, notes)
 	b.db.Status = models.BatchStatusFailedQC
 	b.db.Location = ""
-	err = b.db.Save()
-	if err != nil {
-		return fmt.Errorf("updating database status: %s", err)
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	b.db.SaveOp(op)
+	f.SaveOp(op)
+	op.EndTransaction()
+	if op.Err() != nil {
+		return fmt.Errorf("updating database status: %s", op.Err())
 	}
 
 	return nil
 }
 
+// flagIssueOp records a QC flag for the issue against the batch's most recent
+// QC failure.  Batches which failed QC before failures were recorded get a
+// new failure record.
+func (b *Batch) flagIssueOp(op *magicsql.Operation, i *Issue, cat models.QCCategory, comment string) error {
+	var f, err = models.LatestQCFailure(b.db.ID)
+	if err != nil {
+		return err
+	}
+
+	if f == nil {
+		f = b.db.NewQCFailure(models.SystemUser.ID, "")
+		f.SaveOp(op)
+	}
+	return f.AddFlagOp(op, &models.QCFlag{IssueID: i.db.ID, Category: string(cat), Comment: comment})
+}
+
 func (b *Batch) loadIssues() error {
-	var dbIssues, err = b.db.Issues()
+	var dbIssues []*models.Issue
+	var err error
+	if b.db.Status == models.BatchStatusLive {
+		dbIssues, err = b.db.WithdrawableIssues()
+	} else {
+		dbIssues, err = b.db.Issues()
+	}
 
 	b.Issues = make(IssueList, len(dbIssues))
 	for i, dbi := range dbIssues {
@@ -128,15 +160,35 @@ const (
 // rejected from a batch to get a fix, or needs to be pulled from NCA entirely.
 // The iType determines which lower-level function to use for this.
 func (i *Issue) invalidateFromBatch(typ invaliationType, msg string) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	var err = i.invalidateFromBatchOp(op, typ, msg)
+	if err != nil {
+		op.Rollback()
+		return err
+	}
+	op.EndTransaction()
+	if op.Err() != nil {
+		return fmt.Errorf("unable to report/reject issue: %s", op.Err())
+	}
+
+	return i.finishInvalidation()
+}
+
+// invalidateFromBatchOp makes invalidateFromBatch's database changes within
+// the given operation.  Once the operation completes, the caller must call
+// finishInvalidation to remove the issue's METS file.
+func (i *Issue) invalidateFromBatchOp(op *magicsql.Operation, typ invaliationType, msg string) error {
 	var err error
 
 	i.db.BatchID = 0
 
 	switch typ {
 	case iTypeError:
-		err = i.db.ReportError(models.SystemUser.ID, msg)
+		err = i.db.ReportErrorOp(op, models.SystemUser.ID, msg)
 	case iTypeReject:
-		err = i.db.RejectMetadata(models.SystemUser.ID, msg)
+		err = i.db.RejectMetadataOp(op, models.SystemUser.ID, msg)
 	default:
 		err = fmt.Errorf("unknown invalidation type")
 	}
@@ -144,8 +196,13 @@ func (i *Issue) invalidateFromBatch(typ invaliationType, msg string) error {
 	if err != nil {
 		return fmt.Errorf("unable to report/reject issue: %s", err)
 	}
+	return nil
+}
 
-	err = i.removeMETS()
+// finishInvalidation removes the METS file of an issue which was pulled from
+// its batch
+func (i *Issue) finishInvalidation() error {
+	var err = i.removeMETS()
 	if err != nil {
 		return fmt.Errorf("unable to remove METS file: %s", err)
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -107,7 +108,7 @@ func main() {
 	for _, issue := range issues {
 		var i = uploads.New(issue, globalScanner, conf)
 		logger.Infof("Looking at issue %q", i.Key())
-		i.ValidateAll()
+		i.ValidateAll(context.Background())
 		if i.Errors.Len() != 0 {
 			var errorList []string
 			for _, e := range i.Errors.All() {
@@ -117,7 +118,7 @@ func main() {
 			continue
 		}
 
-		var err = i.Queue()
+		var err = i.Queue(context.Background())
 		if err != nil {
 			logger.Warnf("Skipping %q: %s", i.Key(), err)
 		}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/objstore"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
@@ -25,17 +27,25 @@ type _opts struct {
 
 var opts _opts
 var database *sql.DB
+var store objstore.Store
 
 func getConfig() {
 	var c = cli.New(&opts)
 	c.AppendUsage("Deletes all issues from the workflow locations if they're " +
-		"part of a batch which has been flagged as being completely done.")
+		"part of a batch which has been flagged as being completely done.  When " +
+		"an object store is configured, batches are only closed once a verified " +
+		"copy is in the store.")
 
 	var conf = c.GetConf()
 	var err = dbi.Connect(conf.DatabaseConnect)
 	if err != nil {
 		logger.Fatalf("Error trying to connect to database: %s", err)
 	}
+
+	store, err = objstore.New(conf)
+	if err != nil {
+		logger.Fatalf("Error trying to set up object store: %s", err)
+	}
 }
 
 func main() {
@@ -48,6 +58,13 @@ func main() {
 	}
 
 	for _, b := range batches {
+		if store != nil {
+			err = objstore.VerifyBatch(context.Background(), store, b)
+			if err != nil {
+				logger.Warnf("Not closing batch %q: %s", b.FullName(), err)
+				continue
+			}
+		}
 		logger.Infof("Closing batch %q", b.FullName())
 		b.Close()
 	}
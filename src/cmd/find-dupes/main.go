@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/uoregon-libraries/gopkg/fileutil"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
@@ -19,8 +18,8 @@ func main() {
 	var conf = cli.Simple().GetConf()
 	var scanner = issuewatcher.NewScanner(conf)
 	var cacheFile = scanner.CacheFile()
-	if !fileutil.IsFile(cacheFile) {
-		logger.Fatalf("cache-file %#v is not a valid file", cacheFile)
+	if !scanner.HasCache() {
+		logger.Fatalf("cache-file %#v does not exist", cacheFile)
 	}
 	var err = scanner.Deserialize()
 	if err != nil {
@@ -10,7 +10,6 @@ import (
 	"io/ioutil"
 	"strings"
 
-	"github.com/uoregon-libraries/gopkg/fileutil"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
@@ -104,22 +103,26 @@ func getOpts() {
 func main() {
 	getOpts()
 	var scanner = issuewatcher.NewScanner(conf)
-	if !fileutil.Exists(scanner.CacheFile()) {
+	if !scanner.HasCache() {
 		logger.Fatalf("Unable to deserialize the scanner: %s cannot be read", scanner.CacheFile())
 	}
 
-	var err = scanner.Deserialize()
-	if err != nil {
-		logger.Fatalf("Unable to deserialize the scanner: %s", err)
-	}
-
 	if opts.All {
+		var err = scanner.Deserialize()
+		if err != nil {
+			logger.Fatalf("Unable to deserialize the scanner: %s", err)
+		}
 		reportIssues(scanner.Finder.Issues)
 		return
 	}
 
+	// Key searches use the store's index so we don't have to load everything
 	for _, k := range issueSearchKeys {
-		reportIssues(scanner.LookupIssues(k))
+		var issues, err = scanner.StoredIssues(k)
+		if err != nil {
+			logger.Fatalf("Unable to search for %q: %s", k, err)
+		}
+		reportIssues(issues)
 	}
 }
 
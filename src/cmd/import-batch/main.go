@@ -0,0 +1,131 @@
+// import-batch adds a batch which was built outside NCA, such as one produced
+// before NCA was in use or by a vendor, to NCA's database.  The batch and its
+// issues are created in a live or live_done status so NCA can manage them
+// like the batches it builds.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/batchimport"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/ndnpxml"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	Batch          string `short:"b" long:"batch" description:"path to the batch directory" required:"true"`
+	Status         string `long:"status" description:"status for the imported batch: 'live' or 'live_done'" default:"live"`
+	WentLive       string `long:"went-live" description:"date the batch went live, as YYYY-MM-DD"`
+	SkipValidation bool   `long:"skip-validation" description:"import the batch even if its XML doesn't pass validation"`
+	DryRun         bool   `long:"dry-run" description:"report what would be imported without changing the database"`
+}
+
+var opts _opts
+var wentLive time.Time
+
+func getOpts() *config.Config {
+	var c = cli.New(&opts)
+	c.AppendUsage("Reads the batch XML and issue METS from a batch directory and " +
+		"creates the batch and its issues in the database.  The batch directory " +
+		"must use the layout NCA uses when it builds batches, and the batch's " +
+		"MARC org code and titles must already be in the database.")
+	c.AppendUsage("Use the 'live' status if the batch directory should stay " +
+		"where it is and be managed by NCA: its issues can be withdrawn and new " +
+		"versions built.  NCA never removes the directory's files, even once " +
+		"the batch is archived and closed.  Use 'live_done' for batches " +
+		"which are only being recorded: they're given no location on disk.")
+	var conf = c.GetConf()
+
+	if opts.Status != models.BatchStatusLive && opts.Status != models.BatchStatusLiveDone {
+		c.UsageFail("%q is not a valid status", opts.Status)
+	}
+	if opts.WentLive != "" {
+		var err error
+		wentLive, err = time.ParseInLocation("2006-01-02", opts.WentLive, time.Local)
+		if err != nil {
+			c.UsageFail("%q is not a valid date", opts.WentLive)
+		}
+	}
+
+	var err = dbi.Connect(conf.DatabaseConnect)
+	if err != nil {
+		logger.Fatalf("Error trying to connect to database: %s", err)
+	}
+
+	return conf
+}
+
+func main() {
+	var conf = getOpts()
+
+	var dir, err = filepath.Abs(opts.Batch)
+	if err != nil {
+		logger.Fatalf("Unable to resolve batch path %q: %s", opts.Batch, err)
+	}
+
+	if !opts.SkipValidation {
+		validate(conf, dir)
+	}
+
+	var b *batchimport.Batch
+	b, err = batchimport.Read(dir)
+	if err != nil {
+		logger.Fatalf("Unable to read batch %q: %s", dir, err)
+	}
+
+	var problems []string
+	problems, err = b.Check()
+	if err != nil {
+		logger.Fatalf("Unable to check batch %q against the database: %s", dir, err)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Batch %q can't be imported:\n", b.Batch.FullName())
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", p)
+		}
+		os.Exit(1)
+	}
+
+	if opts.DryRun {
+		fmt.Printf("(DRY RUN) Would import batch %q with status %q and %d issue(s):\n", b.Batch.FullName(), opts.Status, len(b.Issues))
+		for _, i := range b.Issues {
+			fmt.Printf("  - %s\n", i.Key())
+		}
+		return
+	}
+
+	err = b.Save(opts.Status, wentLive)
+	if err != nil {
+		logger.Fatalf("Unable to import batch %q: %s", b.Batch.FullName(), err)
+	}
+	logger.Infof("Imported batch %q (id %d) with %d issue(s)", b.Batch.FullName(), b.Batch.ID, len(b.Issues))
+}
+
+// validate checks the batch's XML the same way NCA does before packaging its
+// own batches, exiting if there are any problems
+func validate(conf *config.Config, dir string) {
+	var problems, err = ndnpxml.New(conf.XMLLint, conf.NDNPSchema).ValidateBatch(dir, filepath.Base(filepath.Clean(dir)))
+	if err != nil {
+		logger.Fatalf("Unable to validate %q: %s", dir, err)
+	}
+	if len(problems) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %d problem(s)\n", dir, len(problems))
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "  %s\n", p)
+	}
+	fmt.Fprintln(os.Stderr, "Fix the batch, or use --skip-validation to import it anyway")
+	os.Exit(1)
+}
@@ -9,6 +9,7 @@ import (
 
 	flags "github.com/jessevdk/go-flags"
 	"github.com/uoregon-libraries/gopkg/wordutils"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
@@ -48,6 +49,7 @@ func getConf() {
 	if err != nil {
 		logger.Fatalf("Config error: %s", err)
 	}
+	cli.ConfigureLogger(conf)
 
 	err = dbi.Connect(conf.DatabaseConnect)
 	if err != nil {
@@ -66,7 +68,7 @@ func main() {
 	}
 
 	logger.Debugf("Serializing to disk")
-	err = scanner.Finder.Serialize(scanner.CacheFile())
+	err = scanner.Serialize()
 	if err != nil {
 		logger.Fatalf("Error trying to serialize: %s", err)
 	}
@@ -1,21 +1,32 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/batchqueue"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
-	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/metadatarules"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
 // Command-line options
 type _opts struct {
 	cli.BaseOptions
+	Plan   bool   `long:"plan" description:"Print the batches which would be created, but don't create them"`
+	Format string `long:"format" description:"Output format for --plan: 'text' or 'json'" default:"text"`
+	Commit string `long:"commit" description:"Create the batches described in a JSON plan file (from --plan --format=json)"`
+
+	Strategy  string `long:"strategy" description:"How issues are chosen for a batch; overrides BATCH_STRATEGY"`
+	MaxBytes  int64  `long:"max-batch-bytes" description:"Maximum bytes of issue files per batch; overrides MAX_BATCH_BYTES"`
+	MaxIssues int    `long:"max-batch-issues" description:"Maximum issues per batch; overrides MAX_BATCH_ISSUES"`
 }
 
 var opts _opts
-var titles models.TitleList
 
 func getOpts() *config.Config {
 	var c = cli.New(&opts)
@@ -23,56 +34,120 @@ func getOpts() *config.Config {
 		"issues in the database which are flagged as ready for batching.  See " +
 		"the MAX_BATCH_SIZE and MIN_BATCH_SIZE settings to control how many " +
 		"pages a batch may contain.")
+	c.AppendUsage("Valid strategies: " + strings.Join(batchqueue.StrategyNames(), ", ") + ".")
+	c.AppendUsage("Use --plan to see what would be batched without creating " +
+		"anything.  A JSON plan can be saved, reviewed, and then created as-is " +
+		"with --commit, which fails if any planned issue has changed since.")
 	var conf = c.GetConf()
-	var err = dbi.Connect(conf.DatabaseConnect)
-	if err != nil {
-		logger.Fatalf("Error trying to connect to database: %s", err)
+
+	if opts.Plan && opts.Commit != "" {
+		c.UsageFail("--plan and --commit cannot be used together")
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		c.UsageFail("%q is not a valid format", opts.Format)
+	}
+	if opts.Strategy != "" {
+		conf.BatchStrategy = opts.Strategy
+	}
+	if opts.MaxBytes > 0 {
+		conf.MaxBatchBytes = opts.MaxBytes
+	}
+	if opts.MaxIssues > 0 {
+		conf.MaxBatchIssues = opts.MaxIssues
 	}
 
-	titles, err = models.Titles()
+	var err = dbi.Connect(conf.DatabaseConnect)
 	if err != nil {
-		logger.Fatalf("Unable to find titles in the database: %s", err)
+		logger.Fatalf("Error trying to connect to database: %s", err)
 	}
 
 	return conf
 }
 
-var conf *config.Config
-
 func main() {
-	conf = getOpts()
-	logger.Infof("Scanning ready issues for batchability")
+	var conf = getOpts()
 
-	var q = newBatchQueue(conf.MinBatchSize, conf.MaxBatchSize)
-	q.FindReadyIssues()
-	for {
-		var batch, ok = q.NextBatch()
-		if !ok {
-			logger.Debugf("No more batches")
-			break
-		}
+	var plan *batchqueue.Plan
+	if opts.Commit != "" {
+		plan = readPlan(opts.Commit)
+	} else {
+		plan = buildPlan(conf)
+	}
 
-		if batch == nil {
-			continue
-		}
+	if opts.Plan {
+		writePlan(plan)
+		return
+	}
 
-		var issues, err = batch.Issues()
-		if err != nil {
-			// No idea what this could mean other than maybe an SQL typo
-			logger.Fatalf("Unable to pull issues for pending batch: %s", err)
-		}
+	for _, s := range plan.Skipped {
+		logger.Infof("Skipping %s (%s: %s)", s.Key, s.Reason, s.Detail)
+	}
 
-		logger.Infof("Starting a new batch, %q", batch.Name)
+	var batches, err = plan.Commit(conf.Webroot, conf.BatchOutputPath)
+	if err != nil {
+		logger.Fatalf("Unable to create batches: %s", err)
+	}
+	if len(batches) == 0 {
+		logger.Infof("No batches were created")
+	}
 
-		for _, issue := range issues {
-			logger.Debugf("Adding %q to batch", issue.Key())
+	for n, batch := range batches {
+		var pb = plan.Batches[n]
+		logger.Infof("Created batch %q (%q, %d pages)", batch.Name, pb.MARCOrgCode, pb.Pages)
+		if pb.LongWait {
+			logger.Infof("Small batch pushed due to age of longest-waiting issue")
 		}
-
-		// Queue the batch
-		logger.Infof("Sending %q to job runner for creation", batch.Name)
-		err = jobs.QueueMakeBatch(batch, conf.BatchOutputPath)
-		if err != nil {
-			logger.Fatalf("Unable to queue batch %q: %s", batch.Name, err)
+		for _, i := range pb.Issues {
+			logger.Debugf("Added %q to batch", i.Key)
 		}
+		logger.Infof("Sent %q to job runner for creation", batch.Name)
+	}
+}
+
+func buildPlan(conf *config.Config) *batchqueue.Plan {
+	var titles, err = models.Titles()
+	if err != nil {
+		logger.Fatalf("Unable to find titles in the database: %s", err)
+	}
+
+	var rules *metadatarules.RuleSet
+	rules, err = metadatarules.Load(conf.MetadataRulesPath)
+	if err != nil {
+		logger.Fatalf("Unable to load metadata rules: %s", err)
+	}
+
+	logger.Infof("Scanning ready issues for batchability")
+	var plan *batchqueue.Plan
+	plan, err = batchqueue.BuildPlan(batchqueue.ConfigOptions(conf), titles, rules)
+	if err != nil {
+		logger.Fatalf("Unable to plan batches: %s", err)
+	}
+	return plan
+}
+
+func readPlan(fname string) *batchqueue.Plan {
+	var data, err = ioutil.ReadFile(fname)
+	if err != nil {
+		logger.Fatalf("Unable to read plan file: %s", err)
+	}
+
+	var plan *batchqueue.Plan
+	plan, err = batchqueue.ParsePlan(data)
+	if err != nil {
+		logger.Fatalf("Unable to read plan file: %s", err)
+	}
+	return plan
+}
+
+func writePlan(plan *batchqueue.Plan) {
+	var err error
+	switch opts.Format {
+	case "json":
+		err = plan.WriteJSON(os.Stdout)
+	default:
+		err = plan.WriteText(os.Stdout)
+	}
+	if err != nil {
+		logger.Fatalf("Unable to write plan: %s", err)
 	}
 }
@@ -17,12 +17,15 @@ import (
 	"github.com/uoregon-libraries/gopkg/interrupts"
 	ltype "github.com/uoregon-libraries/gopkg/logger"
 	"github.com/uoregon-libraries/gopkg/wordutils"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuearchive"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
 )
 
 var runners struct {
@@ -62,7 +65,6 @@ var titles = make(map[string]*schema.Title)
 
 var validQueues = make(map[string]bool)
 var validQueueList []string
-var logLevel ltype.LogLevel
 
 // wrap is a helper to wrap a usage message at 80 characters and print a
 // newline afterward
@@ -103,6 +105,14 @@ func usageFail(format string, args ...interface{}) {
 	wrapBullet(`* watch-scans: Watches for issues in the "scans" folder which are ` +
 		"ready to be moved for metadata entry.  No job is associated with this action, " +
 		"hence it must run on its own, and should only have one copy running at a time.")
+	wrapBullet("* watch-uploads: Watches for publishers' uploaded issues which " +
+		"are ready to be queued automatically, per their titles' auto-queue delay.  " +
+		"No job is associated with this action, hence it must run on its own, and " +
+		"should only have one copy running at a time.")
+	wrapBullet("* watch-archives: Watches for ZIP and TAR issue archives in " +
+		"publishers' uploads, expanding them into issue folders once they've been " +
+		"untouched for ten minutes.  No job is associated with this action, hence it " +
+		"must run on its own, and should only have one copy running at a time.")
 	wrapBullet("* force-rerun <job id>: Creates a new job by cloning the " +
 		"given job and running the new clone.  This is NOT a good idea unless you know " +
 		"exactly what the job(s) you're cloning can affect.  This is wonderful for " +
@@ -124,18 +134,18 @@ func getOpts() (*config.Config, []string) {
 
 	// run-jobs' logging defaults to Info level logs, but "-v" can make it spit
 	// out debug logs.  Jobs' logs written to the database are never filtered.
-	if opts.Verbose {
-		logLevel = ltype.Debug
-	} else {
-		logLevel = ltype.Info
-	}
-	logger.Logger = ltype.New(logLevel, false)
+	logger.SetLevel(ltype.Info)
 
 	var c *config.Config
 	c, err = config.Parse(opts.ConfigFile)
 	if err != nil {
 		logger.Fatalf("Invalid configuration: %s", err)
 	}
+	cli.ConfigureLogger(c)
+	shell.SetTimeouts(c.ExecTimeouts)
+	if opts.Verbose {
+		logger.SetLevel(ltype.Debug)
+	}
 
 	err = dbi.Connect(c.DatabaseConnect)
 	if err != nil {
@@ -175,6 +185,10 @@ func main() {
 		watchDigitizedScans(c)
 	case "watch-page-review":
 		watchPageReview(c)
+	case "watch-uploads":
+		watchUploads(c)
+	case "watch-archives":
+		watchArchives(c)
 	case "watchall":
 		runAllQueues(c)
 	case "force-rerun":
@@ -291,7 +305,7 @@ func watch(c *config.Config, queues ...string) {
 }
 
 func watchJobTypes(c *config.Config, jobTypes ...models.JobType) {
-	var r = jobs.NewRunner(c, logLevel, jobTypes...)
+	var r = jobs.NewRunner(c, jobTypes...)
 	addRunner(r)
 	r.Watch(time.Second * 10)
 }
@@ -326,13 +340,47 @@ func watchDigitizedScans(c *config.Config) {
 	}
 }
 
+func watchUploads(c *config.Config) {
+	logger.Infof("Watching publisher uploads for auto-queueing")
+
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			autoQueueUploads(c)
+			nextAttempt = time.Now().Add(time.Hour)
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
+
+func watchArchives(c *config.Config) {
+	logger.Infof("Watching publisher uploads for issue archives")
+
+	var nextAttempt time.Time
+	for !done() {
+		if time.Now().After(nextAttempt) {
+			var err = issuearchive.ExpandAll(c.PDFUploadPath)
+			if err != nil {
+				logger.Errorf("Unable to read SFTP uploads for issue archives: %s", err)
+			}
+			nextAttempt = time.Now().Add(time.Minute)
+		}
+
+		// Try not to eat all the CPU
+		time.Sleep(time.Second)
+	}
+}
+
 // runAllQueues fires up multiple goroutines to watch all the queues in a
 // fairly sane way so that important processes like moving SFTP issues can
 // happen quickly, while CPU-bound processes won't fight each other.
 func runAllQueues(c *config.Config) {
-	waitFor(
+	var watchers = []func(){
 		func() { watchPageReview(c) },
 		func() { watchDigitizedScans(c) },
+		func() { watchArchives(c) },
 		func() {
 			// Jobs which are exclusively disk IO are in the first runner to avoid
 			// too much FS stuff hapenning concurrently
@@ -341,6 +389,7 @@ func runAllQueues(c *config.Config) {
 				models.JobTypeMoveDerivatives,
 				models.JobTypeSyncDir,
 				models.JobTypeKillDir,
+				models.JobTypeCleanReelDir,
 				models.JobTypeWriteBagitManifest,
 			)
 		},
@@ -349,7 +398,9 @@ func runAllQueues(c *config.Config) {
 			// share CPU too much
 			watchJobTypes(c,
 				models.JobTypePageSplit,
+				models.JobTypeImagesToPDF,
 				models.JobTypeMakeDerivatives,
+				models.JobTypeValidateBatchXML,
 			)
 		},
 		func() {
@@ -365,13 +416,30 @@ func runAllQueues(c *config.Config) {
 				models.JobTypeCleanFiles,
 				models.JobTypeWriteActionLog,
 				models.JobTypeRenumberPages,
+				models.JobTypeWriteQCReport,
+			)
+		},
+		func() {
+			// ONI jobs mostly wait on another server, so they get their own runner
+			// rather than holding up local work
+			watchJobTypes(c,
+				models.JobTypeONILoadBatch,
+				models.JobTypeONIPurgeBatch,
+			)
+		},
+		func() {
+			// Object storage uploads can take hours for large batches, so they
+			// don't share a runner with anything else
+			watchJobTypes(c,
+				models.JobTypeStoreBatch,
+				models.JobTypeStoreIssueArchive,
 			)
 		},
 		func() {
 			// Extremely fast data-setting jobs get a custom runner that operates
 			// every second to ensure nearly real-time updates to things like a job's
 			// workflow state
-			var r = jobs.NewRunner(c, logLevel,
+			var r = jobs.NewRunner(c,
 				models.JobTypeSetIssueWS,
 				models.JobTypeSetIssueBackupLoc,
 				models.JobTypeSetIssueLocation,
@@ -383,7 +451,13 @@ func runAllQueues(c *config.Config) {
 			addRunner(r)
 			r.Watch(time.Second * 1)
 		},
-	)
+	}
+
+	// The upload queuer skips any pass where no title auto-queues, so it's
+	// always started: a title can turn on auto-queueing without a restart
+	watchers = append(watchers, func() { watchUploads(c) })
+
+	waitFor(watchers...)
 }
 
 // waitFor runs all the passed-in functions concurrently and returns when
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
@@ -26,7 +28,7 @@ func scanScannerIssues(c *config.Config) {
 		}
 
 		var i = uploads.New(issue, scanner, c)
-		i.ValidateAll()
+		i.ValidateAll(context.Background())
 		if i.Errors.Major().Len() != 0 {
 			var errs []string
 			for _, err := range i.Errors.Major().All() {
@@ -37,7 +39,7 @@ func scanScannerIssues(c *config.Config) {
 		}
 
 		logger.Infof("scanner-scanner: moving issue %q into NCA", i.Key())
-		var err = i.Queue()
+		var err = i.Queue(context.Background())
 		if err != nil {
 			logger.Warnf("scanner-scanner: skipping %q: %s", i.Key(), err)
 		}
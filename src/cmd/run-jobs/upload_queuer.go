@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/uploads"
+)
+
+// autoQueueDelays returns the auto-queue delay, in hours, for every title
+// which has one, keyed by LCCN
+func autoQueueDelays(titles models.TitleList) map[string]int {
+	var delays = make(map[string]int)
+	for _, t := range titles {
+		if t.AutoQueueHours > 0 {
+			delays[t.LCCN] = t.AutoQueueHours
+		}
+	}
+	return delays
+}
+
+// readAutoQueueDelays pulls titles from the database and returns their
+// auto-queue delays
+func readAutoQueueDelays() (map[string]int, error) {
+	var titles, err = models.Titles()
+	if err != nil {
+		return nil, err
+	}
+	return autoQueueDelays(titles), nil
+}
+
+// queueDelay returns the auto-queue delay for an issue, or zero if the issue
+// isn't a publisher upload or its title doesn't auto-queue
+func queueDelay(issue *schema.Issue, delays map[string]int) int {
+	if issue.WorkflowStep != schema.WSSFTP || issue.Title == nil {
+		return 0
+	}
+	return delays[issue.Title.LCCN]
+}
+
+// autoQueueUploads queues publishers' uploaded issues whose titles have an
+// auto-queue delay, so long as the issue has been untouched that long and has
+// no errors or warnings at all.  Only the SFTP uploads are scanned; dupes
+// elsewhere are found by checking the issue store the server's watcher keeps.
+func autoQueueUploads(c *config.Config) {
+	logger.Infof("upload-queuer: checking for uploaded issues ready to auto-queue")
+
+	var delays, err = readAutoQueueDelays()
+	if err != nil {
+		logger.Criticalf("upload-queuer: unable to read titles: %s", err)
+		return
+	}
+	if len(delays) == 0 {
+		logger.Debugf("upload-queuer: no titles have auto-queueing enabled")
+		return
+	}
+
+	// Without the issue store we can't know if an upload is already live or in
+	// process, so we don't risk queueing anything
+	var store = issuewatcher.NewScanner(c)
+	if !store.HasCache() {
+		logger.Warnf("upload-queuer: issue store %q doesn't exist yet; skipping auto-queue", store.CacheFile())
+		return
+	}
+
+	var scanner = issuewatcher.NewScanner(c).DisableWeb().DisableScannedUpload().DisableDB()
+	err = scanner.Scan()
+	if err != nil {
+		logger.Criticalf("upload-queuer: unable to read uploaded issues: %s", err)
+		return
+	}
+
+	for _, issue := range scanner.Finder.Issues {
+		var hrs = queueDelay(issue, delays)
+		if hrs == 0 {
+			continue
+		}
+		if time.Since(issue.LastModified()) < time.Hour*time.Duration(hrs) {
+			continue
+		}
+
+		autoQueue(uploads.New(issue, scanner, c), store, hrs)
+	}
+}
+
+// checkStoredDupes flags i as a dupe of any issue in stored which is further
+// along in the workflow
+func checkStoredDupes(i *schema.Issue, stored schema.IssueList) {
+	var lookup = schema.NewLookup()
+	lookup.Populate(stored)
+	i.CheckDupes(lookup)
+}
+
+// autoQueue validates and queues a single issue, writing an audit log on
+// success
+func autoQueue(i *uploads.Issue, store *issuewatcher.Scanner, hrs int) {
+	// An issue NCA already knows about has most likely failed a previous move,
+	// and should be looked at by a human rather than retried blindly.  This is
+	// just a quick check to skip the slow validations; QueueNew checks again
+	// when it actually queues the issue.
+	var existing, err = models.FindIssueByKey(i.Key())
+	if err != nil {
+		logger.Criticalf("upload-queuer: unable to look up issue %q: %s", i.Key(), err)
+		return
+	}
+	if existing != nil {
+		logger.Debugf("upload-queuer: skipping issue %q: already in the database", i.Key())
+		return
+	}
+
+	var key *schema.Key
+	key, err = schema.ParseSearchKey(i.Key())
+	if err == nil {
+		var stored schema.IssueList
+		stored, err = store.StoredIssues(key)
+		if err != nil {
+			logger.Errorf("upload-queuer: unable to read issue store for %q: %s", i.Key(), err)
+			return
+		}
+		checkStoredDupes(i.Issue, stored)
+	}
+
+	i.ValidateAll(context.Background())
+	if i.Errors.Len() != 0 {
+		var errs []string
+		for _, err := range i.Errors.All() {
+			errs = append(errs, err.Message())
+		}
+		logger.Debugf("upload-queuer: skipping issue %q: %#v", i.Key(), errs)
+		return
+	}
+
+	logger.Infof("upload-queuer: queueing issue %q", i.Key())
+	// The issue store can be an hour stale, but any issue queued or imported
+	// since it was written is in the database, and QueueNew won't queue an
+	// issue the database already has
+	var qErr = i.QueueNew(context.Background())
+	if qErr != nil {
+		logger.Warnf("upload-queuer: unable to queue %q: %s", i.Key(), qErr.Message())
+		return
+	}
+
+	var msg = fmt.Sprintf("Issue from %q auto-queued after %d hour(s) without changes", i.Location, hrs)
+	err = models.CreateAuditLog("", models.SystemUser.Login, models.AuditActionAutoQueue, msg)
+	if err != nil {
+		logger.Criticalf("upload-queuer: unable to write audit log %q: %s", msg, err)
+	}
+}
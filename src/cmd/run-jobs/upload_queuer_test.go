@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestAutoQueueDelays(t *testing.T) {
+	var titles = models.TitleList{
+		{LCCN: "sn00000001", AutoQueueHours: 48},
+		{LCCN: "sn00000002"},
+		{LCCN: "sn00000003", AutoQueueHours: 1},
+	}
+	var delays = autoQueueDelays(titles)
+	if len(delays) != 2 || delays["sn00000001"] != 48 || delays["sn00000003"] != 1 {
+		t.Fatalf("Expected delays for sn00000001 and sn00000003 only, got %#v", delays)
+	}
+
+	if len(autoQueueDelays(models.TitleList{{LCCN: "sn00000002"}})) != 0 {
+		t.Fatalf("Expected no delays when no title auto-queues")
+	}
+}
+
+func TestQueueDelay(t *testing.T) {
+	var delays = map[string]int{"sn00000001": 48}
+	var tests = map[string]struct {
+		lccn     string
+		ws       schema.WorkflowStep
+		noTitle  bool
+		expected int
+	}{
+		"upload, auto-queued title":   {lccn: "sn00000001", ws: schema.WSSFTP, expected: 48},
+		"upload, other title":         {lccn: "sn00000002", ws: schema.WSSFTP, expected: 0},
+		"scan, auto-queued title":     {lccn: "sn00000001", ws: schema.WSScan, expected: 0},
+		"in production":               {lccn: "sn00000001", ws: schema.WSInProduction, expected: 0},
+		"upload with no title loaded": {ws: schema.WSSFTP, noTitle: true, expected: 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = &schema.Issue{WorkflowStep: tc.ws}
+			if !tc.noTitle {
+				i.Title = &schema.Title{LCCN: tc.lccn}
+			}
+			var got = queueDelay(i, delays)
+			if got != tc.expected {
+				t.Fatalf("Expected delay %d, got %d", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckStoredDupes(t *testing.T) {
+	var newIssue = func(ws schema.WorkflowStep, loc string) *schema.Issue {
+		var i = &schema.Issue{RawDate: "2020-12-25", Edition: 1, Location: loc, WorkflowStep: ws}
+		i.Title = &schema.Title{LCCN: "sn00000001"}
+		if ws == schema.WSInProduction {
+			i.Batch = &schema.Batch{MARCOrgCode: "oru", Keyword: "foo", Version: 1, Location: loc}
+		}
+		return i
+	}
+
+	var tests = map[string]struct {
+		stored   schema.IssueList
+		expected bool
+	}{
+		"nothing stored":         {stored: nil, expected: false},
+		"only the upload itself": {stored: schema.IssueList{newIssue(schema.WSSFTP, "/sftp/a")}, expected: false},
+		"live copy":              {stored: schema.IssueList{newIssue(schema.WSInProduction, "https://oni/a")}, expected: true},
+		"in-process copy":        {stored: schema.IssueList{newIssue(schema.WSReadyForBatching, "/wf/a")}, expected: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = newIssue(schema.WSSFTP, "/sftp/a")
+			checkStoredDupes(i, tc.stored)
+			var got = i.Errors.Len() != 0
+			if got != tc.expected {
+				t.Fatalf("Expected dupe to be %v, got %v (errors: %#v)", tc.expected, got, i.Errors.All())
+			}
+		})
+	}
+}
@@ -24,8 +24,10 @@ type form struct {
 }
 
 var actionLookup = map[string][]models.AuditAction{
-	"Uploads":        {models.AuditActionQueue},
+	"Uploads":        {models.AuditActionQueue, models.AuditActionAutoQueue},
 	"Titles":         {models.AuditActionSaveTitle, models.AuditActionValidateTitle},
+	"Issue Gaps":     {models.AuditActionMarkUnpublished, models.AuditActionUnmarkUnpublished},
+	"Batches":        {models.AuditActionCreateBatches, models.AuditActionFailQC, models.AuditActionPassQC},
 	"MARC Org Codes": {models.AuditActionCreateMoc, models.AuditActionUpdateMoc, models.AuditActionDeleteMoc},
 	"Users":          {models.AuditActionSaveUser, models.AuditActionDeactivateUser},
 	"Issue Workflow": {
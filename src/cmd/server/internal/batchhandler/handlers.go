@@ -0,0 +1,112 @@
+// Package batchhandler lists batches which are in the QC process and serves
+// their QC reports
+package batchhandler
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/qcreport"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+	conf     *config.Config
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// listTmpl shows all in-process batches
+	listTmpl *tmpl.Template
+
+	// qcFailTmpl is the form for flagging a batch's problem issues
+	qcFailTmpl *tmpl.Template
+
+	// qcHistoryTmpl lists a batch's QC failures
+	qcHistoryTmpl *tmpl.Template
+)
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
+	conf = c
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canView(listHandler))
+	s.PathPrefix("/{batch_id:[0-9]+}/qc/").Handler(canView(qcReportHandler))
+	s.Path("/{batch_id:[0-9]+}/qc-history").Handler(canView(qcHistoryHandler))
+	s.Path("/{batch_id:[0-9]+}/qc-fail").Methods("GET").Handler(canFail(qcFailFormHandler))
+	s.Path("/{batch_id:[0-9]+}/qc-fail").Methods("POST").Handler(canFail(qcFailSaveHandler))
+	s.Path("/{batch_id:[0-9]+}/qc-pass").Methods("POST").Handler(canPass(qcPassHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{
+		"BatchesHomeURL": func() string { return basePath },
+		"QCReportURL":    qcReportURL,
+		"QCFailURL":      qcFailURL,
+		"QCPassURL":      qcPassURL,
+		"QCHistoryURL":   qcHistoryURL,
+	})
+	layout.Path = path.Join(layout.Path, "batches")
+	listTmpl = layout.MustBuild("list.go.html")
+	qcFailTmpl = layout.MustBuild("qc-fail.go.html")
+	qcHistoryTmpl = layout.MustBuild("qc-history.go.html")
+}
+
+// canView verifies the user can see batches and their QC reports
+func canView(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.ViewQCReports, h)
+}
+
+func qcReportURL(b *models.Batch) string {
+	return path.Join(basePath, strconv.Itoa(b.ID), "qc") + "/"
+}
+
+// batch wraps a models.Batch for display
+type batch struct {
+	*models.Batch
+	HasQCReport bool
+}
+
+// listHandler shows all batches in the QC process
+func listHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var list, err = models.InProcessBatches()
+	if err != nil {
+		logger.Errorf("Unable to look up in-process batches: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to find batches - try again or contact support")
+		return
+	}
+
+	var batches []*batch
+	for _, b := range list {
+		var _, err = os.Stat(filepath.Join(qcreport.Dir(conf.BatchOutputPath, b), qcreport.HTMLFile))
+		batches = append(batches, &batch{Batch: b, HasQCReport: err == nil})
+	}
+
+	r.Vars.Title = "Batches"
+	r.Vars.Data["Batches"] = batches
+	r.Render(listTmpl)
+}
+
+// qcReportHandler serves the files in a batch's QC report directory
+func qcReportHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var b = findBatch(r)
+	if b == nil {
+		return
+	}
+
+	var dir = qcreport.Dir(conf.BatchOutputPath, b)
+	http.StripPrefix(qcReportURL(b), http.FileServer(http.Dir(dir))).ServeHTTP(w, req)
+}
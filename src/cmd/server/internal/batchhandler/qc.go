@@ -0,0 +1,304 @@
+package batchhandler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+)
+
+// canFail verifies the user can fail a batch's QC
+func canFail(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.FailQC, h)
+}
+
+// canPass verifies the user can pass a batch's QC
+func canPass(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.PassQC, h)
+}
+
+func qcFailURL(b *models.Batch) string {
+	return path.Join(basePath, strconv.Itoa(b.ID), "qc-fail")
+}
+
+func qcPassURL(b *models.Batch) string {
+	return path.Join(basePath, strconv.Itoa(b.ID), "qc-pass")
+}
+
+func qcHistoryURL(b *models.Batch) string {
+	return path.Join(basePath, strconv.Itoa(b.ID), "qc-history")
+}
+
+// findBatch reads the batch from the request's batch id, rendering an error
+// page and returning nil if it can't be found
+func findBatch(r *responder.Responder) *models.Batch {
+	var id, _ = strconv.Atoi(mux.Vars(r.Request)["batch_id"])
+	var b, err = models.FindBatch(id)
+	if err != nil {
+		logger.Errorf("Unable to look up batch %d: %s", id, err)
+		r.Error(http.StatusInternalServerError, "Error trying to find batch - try again or contact support")
+		return nil
+	}
+	if b == nil {
+		r.Error(http.StatusNotFound, "Unable to find batch")
+		return nil
+	}
+	return b
+}
+
+// qcFailFormHandler shows the form for flagging a batch's problem issues
+func qcFailFormHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var b = findBatch(r)
+	if b == nil {
+		return
+	}
+	renderQCFailForm(r, b)
+}
+
+func renderQCFailForm(r *responder.Responder, b *models.Batch) {
+	if !b.AwaitingQC() {
+		r.Error(http.StatusBadRequest, fmt.Sprintf("Batch %q is %q and cannot fail QC", b.FullName(), b.Status))
+		return
+	}
+
+	var issues, err = b.Issues()
+	if err != nil {
+		logger.Errorf("Unable to read issues for batch %d: %s", b.ID, err)
+		r.Error(http.StatusInternalServerError, "Error trying to find the batch's issues - try again or contact support")
+		return
+	}
+
+	r.Vars.Title = "Fail QC: " + b.FullName()
+	r.Vars.Data["Batch"] = b
+	r.Vars.Data["Issues"] = issues
+	r.Vars.Data["Categories"] = models.QCCategories
+	r.Render(qcFailTmpl)
+}
+
+// qcFailSaveHandler records the QC failure, sends the flagged issues back to
+// curation or the error queue, and queues the batch to be rebuilt
+func qcFailSaveHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var b = findBatch(r)
+	if b == nil {
+		return
+	}
+	if !b.AwaitingQC() {
+		r.Error(http.StatusBadRequest, fmt.Sprintf("Batch %q is %q and cannot fail QC", b.FullName(), b.Status))
+		return
+	}
+
+	var f, err = readQCFailure(req, b, r.Vars.User.ID)
+	if err == nil && len(f.Flags) == 0 {
+		err = fmt.Errorf("at least one issue must be flagged")
+	}
+	if err != nil {
+		r.Vars.Alert = template.HTML(template.HTMLEscapeString("Unable to fail batch: " + err.Error()))
+		renderQCFailForm(r, b)
+		return
+	}
+
+	var name, oldLocation, oldStatus = b.FullName(), b.Location, b.Status
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	err = b.FailQCOp(op, f)
+	if err == nil {
+		err = jobs.QueueSerialOp(op, jobs.GetJobsForFailedQC(b, oldLocation, conf.BatchOutputPath, oldStatus == models.BatchStatusOnStaging)...)
+	}
+	if err != nil {
+		op.Rollback()
+	} else {
+		op.EndTransaction()
+		err = op.Err()
+	}
+	if err != nil {
+		logger.Errorf("Unable to fail QC for batch %d: %s", b.ID, err)
+		r.Vars.Alert = template.HTML(template.HTMLEscapeString("Unable to fail batch: " + err.Error()))
+		// Reload so the form doesn't reflect the failed changes
+		b = findBatch(r)
+		if b != nil {
+			renderQCFailForm(r, b)
+		}
+		return
+	}
+
+	r.Audit(models.AuditActionFailQC, fmt.Sprintf("batch %q, %d flag(s)", name, len(f.Flags)))
+	var msg = fmt.Sprintf("Batch %q failed QC and is being rebuilt without the flagged issues.", name)
+	if b.Status == models.BatchStatusDeleted {
+		msg = fmt.Sprintf("Batch %q failed QC.  Every issue was flagged, so the batch has been removed.", name)
+	}
+	if oldStatus == models.BatchStatusOnStaging && conf.ONIStagingPurgeCommand == "" {
+		msg += "  The old batch must be purged from staging."
+	}
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: msg, Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
+
+// qcPassHandler flags the batch as having passed QC and queues the jobs to
+// move it from staging into production
+func qcPassHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var b = findBatch(r)
+	if b == nil {
+		return
+	}
+	if !b.AwaitingQC() {
+		r.Error(http.StatusBadRequest, fmt.Sprintf("Batch %q is %q and cannot pass QC", b.FullName(), b.Status))
+		return
+	}
+
+	var name, onStaging = b.FullName(), b.Status == models.BatchStatusOnStaging
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	var err = b.PassQCOp(op)
+	if err == nil {
+		err = jobs.QueueSerialOp(op, jobs.GetJobsForPassQC(b, onStaging)...)
+	}
+	if err != nil {
+		op.Rollback()
+	} else {
+		op.EndTransaction()
+		err = op.Err()
+	}
+	if err != nil {
+		logger.Errorf("Unable to pass QC for batch %d: %s", b.ID, err)
+		http.SetCookie(w, &http.Cookie{Name: "Alert", Value: "Unable to pass batch - try again or contact support", Path: "/"})
+		http.Redirect(w, req, basePath, http.StatusFound)
+		return
+	}
+
+	r.Audit(models.AuditActionPassQC, fmt.Sprintf("batch %q", name))
+	var msg = fmt.Sprintf("Batch %q passed QC and is being loaded into production.", name)
+	if conf.ONIProductionLoadCommand == "" {
+		msg = fmt.Sprintf("Batch %q passed QC.  It must be loaded into production manually.", name)
+	}
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: msg, Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
+
+// readQCFailure builds a QC failure from the form data.  Each of the batch's
+// issues has a category, a list of pages, and a comment; issues without a
+// category aren't flagged.
+func readQCFailure(req *http.Request, b *models.Batch, userID int) (*models.QCFailure, error) {
+	var issues, err = b.Issues()
+	if err != nil {
+		return nil, err
+	}
+
+	var f = b.NewQCFailure(userID, strings.TrimSpace(req.FormValue("notes")))
+	for _, i := range issues {
+		var id = strconv.Itoa(i.ID)
+		var cat = req.FormValue("category-" + id)
+		if cat == "" {
+			continue
+		}
+
+		var pages []int
+		pages, err = parsePages(req.FormValue("pages-" + id))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", i.Key(), err)
+		}
+		var comment = strings.TrimSpace(req.FormValue("comment-" + id))
+		for _, p := range pages {
+			f.Flags = append(f.Flags, &models.QCFlag{IssueID: i.ID, Page: p, Category: cat, Comment: comment})
+		}
+	}
+
+	return f, nil
+}
+
+// parsePages splits a list of page numbers separated by commas or spaces.  An
+// empty list means the whole issue, which is page zero.
+func parsePages(s string) ([]int, error) {
+	var fields = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	if len(fields) == 0 {
+		return []int{0}, nil
+	}
+
+	var pages []int
+	for _, field := range fields {
+		var n, err = strconv.Atoi(field)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a valid page number", field)
+		}
+		pages = append(pages, n)
+	}
+	return pages, nil
+}
+
+// qcFailure wraps a models.QCFailure for display
+type qcFailure struct {
+	*models.QCFailure
+	Reviewer string
+	Flags    []*qcFlag
+}
+
+// qcFlag wraps a models.QCFlag for display
+type qcFlag struct {
+	*models.QCFlag
+	IssueKey string
+	Reason   string
+}
+
+// qcHistoryHandler lists every time the batch has failed QC
+func qcHistoryHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var b = findBatch(r)
+	if b == nil {
+		return
+	}
+
+	var list, err = models.FindQCFailures(b.ID)
+	if err != nil {
+		logger.Errorf("Unable to read QC history for batch %d: %s", b.ID, err)
+		r.Error(http.StatusInternalServerError, "Error trying to read the batch's QC history - try again or contact support")
+		return
+	}
+
+	// Flagged issues are no longer in the batch, so keys are looked up one at a
+	// time; QC failures rarely flag more than a handful of issues
+	var keys = make(map[int]string)
+	var failures []*qcFailure
+	for _, f := range list {
+		var qf = &qcFailure{QCFailure: f, Reviewer: models.FindUserByID(f.UserID).Login}
+		for _, flag := range f.Flags {
+			if keys[flag.IssueID] == "" {
+				keys[flag.IssueID] = issueKey(flag.IssueID)
+			}
+			var reason = models.QCCategory(flag.Category).Describe()
+			qf.Flags = append(qf.Flags, &qcFlag{QCFlag: flag, IssueKey: keys[flag.IssueID], Reason: reason})
+		}
+		failures = append(failures, qf)
+	}
+
+	r.Vars.Title = "QC History: " + b.FullName()
+	r.Vars.Data["Batch"] = b
+	r.Vars.Data["Failures"] = failures
+	r.Render(qcHistoryTmpl)
+}
+
+// issueKey returns the issue's key, or a placeholder if it can't be read
+func issueKey(id int) string {
+	var i, err = models.FindIssue(id)
+	if err != nil {
+		logger.Warnf("Unable to look up issue %d: %s", id, err)
+	}
+	if i == nil {
+		return fmt.Sprintf("issue id %d", id)
+	}
+	return i.Key()
+}
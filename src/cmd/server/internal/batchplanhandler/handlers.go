@@ -0,0 +1,138 @@
+// Package batchplanhandler shows the batches which would be created from the
+// issues currently ready for batching, and lets workflow managers create
+// exactly those batches once they've reviewed the plan.
+package batchplanhandler
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/batchqueue"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/metadatarules"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+	conf     *config.Config
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// planTmpl shows the proposed batches
+	planTmpl *tmpl.Template
+)
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
+	conf = c
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canQueue(planHandler))
+	s.Path("/commit").Methods("POST").Handler(canQueue(commitHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{
+		"BatchPlanCommitURL": func() string { return path.Join(basePath, "commit") },
+		"BatchNumber":        func(n int) int { return n + 1 },
+	})
+	layout.Path = path.Join(layout.Path, "batchplan")
+	planTmpl = layout.MustBuild("plan.go.html")
+}
+
+// canQueue verifies the user can see the plan and create batches
+func canQueue(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.QueueBatches, h)
+}
+
+// buildPlan reads titles and metadata rules, then builds a new batch plan
+func buildPlan() (*batchqueue.Plan, error) {
+	var titles, err = models.Titles()
+	if err != nil {
+		return nil, fmt.Errorf("reading titles: %s", err)
+	}
+
+	var rules *metadatarules.RuleSet
+	rules, err = metadatarules.Load(conf.MetadataRulesPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading metadata rules: %s", err)
+	}
+
+	return batchqueue.BuildPlan(batchqueue.ConfigOptions(conf), titles, rules)
+}
+
+// planHandler shows what would be batched right now
+func planHandler(w http.ResponseWriter, req *http.Request) {
+	renderPlan(responder.Response(w, req))
+}
+
+// renderPlan builds and displays a new plan.  The plan is embedded in the
+// page's form as JSON so that committing it creates exactly what the user
+// reviewed.
+func renderPlan(r *responder.Responder) {
+	var plan, err = buildPlan()
+	if err != nil {
+		logger.Errorf("Unable to build batch plan: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to plan batches - try again or contact support")
+		return
+	}
+
+	var buf strings.Builder
+	err = plan.WriteJSON(&buf)
+	if err != nil {
+		logger.Errorf("Unable to serialize batch plan: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to plan batches - try again or contact support")
+		return
+	}
+
+	r.Vars.Title = "Batch Plan"
+	r.Vars.Data["Plan"] = plan
+	r.Vars.Data["PlanJSON"] = buf.String()
+	r.Render(planTmpl)
+}
+
+// commitHandler creates the batches in the submitted plan
+func commitHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var plan, err = batchqueue.ParsePlan([]byte(req.FormValue("plan")))
+	if err != nil {
+		r.Error(http.StatusBadRequest, "Invalid batch plan")
+		return
+	}
+
+	var batches []*models.Batch
+	batches, err = plan.Commit(conf.Webroot, conf.BatchOutputPath)
+	if err != nil {
+		// The error can list many issues, so we show it with a freshly built
+		// plan rather than trying to squeeze it into a cookie
+		logger.Warnf("Unable to commit batch plan: %s", err)
+		r.Vars.Alert = template.HTML(template.HTMLEscapeString("Unable to create batches: " + err.Error()))
+		renderPlan(r)
+		return
+	}
+
+	if len(batches) == 0 {
+		http.SetCookie(w, &http.Cookie{Name: "Info", Value: "The plan had no batches to create", Path: "/"})
+		http.Redirect(w, req, basePath, http.StatusFound)
+		return
+	}
+
+	var names []string
+	for _, b := range batches {
+		names = append(names, b.Name)
+	}
+	r.Audit(models.AuditActionCreateBatches, strings.Join(names, ", "))
+	var msg = fmt.Sprintf("Created %d batch(es): %s", len(batches), strings.Join(names, ", "))
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: msg, Path: "/"})
+	http.Redirect(w, req, basePath, http.StatusFound)
+}
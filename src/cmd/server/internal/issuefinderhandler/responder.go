@@ -105,7 +105,7 @@ func (r *resp) setupResults() {
 		Day:   day,
 	}
 
-	r.Issues = watcher.Scanner.LookupIssues(key)
+	r.Issues = watcher.CurrentScanner().LookupIssues(key)
 	r.LCCN = lccn
 	r.Year = year
 	r.Month = month
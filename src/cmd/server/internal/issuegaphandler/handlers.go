@@ -0,0 +1,280 @@
+// Package issuegaphandler reports on dates a title should have issues for,
+// based on its publication frequency, but doesn't have anywhere: uploads, the
+// workflow, or the live site.  Staff can mark known gaps as "not published"
+// so they stop being reported.
+package issuegaphandler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+var (
+	basePath string
+	conf     *config.Config
+	watcher  *issuewatcher.Watcher
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// listTmpl shows a summary of missing issues for all titles
+	listTmpl *tmpl.Template
+
+	// titleTmpl shows a single title's missing dates for a year
+	titleTmpl *tmpl.Template
+)
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config, w *issuewatcher.Watcher) {
+	conf = c
+	watcher = w
+	basePath = baseWebPath
+	var s = r.PathPrefix(basePath).Subrouter()
+	s.Path("").Handler(canView(listHandler))
+	s.Path("/title").Handler(canView(titleHandler))
+	s.Path("/unpublished").Methods("POST").Handler(canMark(markHandler))
+	s.Path("/unpublished/delete").Methods("POST").Handler(canMark(unmarkHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Funcs(tmpl.FuncMap{
+		"GapsHomeURL":  func() string { return basePath },
+		"GapsTitleURL": titleURL,
+	})
+	layout.Path = path.Join(layout.Path, "issuegaps")
+	listTmpl = layout.MustBuild("list.go.html")
+	titleTmpl = layout.MustBuild("title.go.html")
+}
+
+// canView verifies the user can see issue gaps
+func canView(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.SearchIssues, h)
+}
+
+// canMark verifies the user can mark and unmark dates as not published
+func canMark(h http.HandlerFunc) http.Handler {
+	return responder.MustHavePrivilege(privilege.MarkUnpublishedDates, h)
+}
+
+func titleURL(lccn string, year int) string {
+	var v = url.Values{"lccn": {lccn}, "year": {strconv.Itoa(year)}}
+	return path.Join(basePath, "title") + "?" + v.Encode()
+}
+
+// reports builds the gap report for every title, sorted by name
+func reports() ([]*titleReport, error) {
+	var titles, err = models.Titles()
+	if err != nil {
+		return nil, fmt.Errorf("reading titles: %s", err)
+	}
+	var unpublished []*models.UnpublishedDate
+	unpublished, err = models.UnpublishedDates()
+	if err != nil {
+		return nil, fmt.Errorf("reading unpublished dates: %s", err)
+	}
+	var unpubByLCCN = make(map[string][]*models.UnpublishedDate)
+	for _, u := range unpublished {
+		unpubByLCCN[u.LCCN] = append(unpubByLCCN[u.LCCN], u)
+	}
+
+	var scanner = watcher.CurrentScanner()
+	var now = time.Now()
+	var list []*titleReport
+	for _, t := range titles {
+		var issues = scanner.LookupIssues(&schema.Key{LCCN: t.LCCN})
+		list = append(list, buildReport(t, issues, unpubByLCCN[t.LCCN], now))
+	}
+	sortReports(list)
+	return list, nil
+}
+
+// report builds the gap report for a single title, returning nil if the
+// title doesn't exist
+func report(lccn string) (*titleReport, error) {
+	var t, err = models.FindTitle("lccn = ?", lccn)
+	if err != nil {
+		return nil, fmt.Errorf("reading title: %s", err)
+	}
+	if t == nil {
+		return nil, nil
+	}
+	var unpublished []*models.UnpublishedDate
+	unpublished, err = models.UnpublishedDatesForTitle(lccn)
+	if err != nil {
+		return nil, fmt.Errorf("reading unpublished dates: %s", err)
+	}
+
+	var issues = watcher.CurrentScanner().LookupIssues(&schema.Key{LCCN: t.LCCN})
+	return buildReport(t, issues, unpublished, time.Now()), nil
+}
+
+// listHandler shows every title's missing issue counts by year
+func listHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var list, err = reports()
+	if err != nil {
+		logger.Errorf("Unable to build issue gap report: %s", err)
+		r.Error(http.StatusInternalServerError, "Error trying to build the report - try again or contact support")
+		return
+	}
+
+	var withGaps, noReport []*titleReport
+	for _, tr := range list {
+		switch {
+		case tr.Problem != "":
+			noReport = append(noReport, tr)
+		case tr.MissingCount() > 0:
+			withGaps = append(withGaps, tr)
+		}
+	}
+
+	r.Vars.Title = "Missing Issues"
+	r.Vars.Data["Reports"] = withGaps
+	r.Vars.Data["NoReport"] = noReport
+	r.Render(listTmpl)
+}
+
+// getReport builds the requested title's report, responding with an error if
+// it can't be built
+func getReport(r *responder.Responder, lccn string) (tr *titleReport, handled bool) {
+	var err error
+	tr, err = report(lccn)
+	if err != nil {
+		logger.Errorf("Unable to build issue gap report for %q: %s", lccn, err)
+		r.Error(http.StatusInternalServerError, "Error trying to build the report - try again or contact support")
+		return nil, true
+	}
+	if tr == nil {
+		r.Error(http.StatusNotFound, "Unable to find title")
+		return nil, true
+	}
+	return tr, false
+}
+
+// titleHandler shows one title's missing dates for a year, with options to
+// mark them as not published
+func titleHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var tr, handled = getReport(r, req.FormValue("lccn"))
+	if handled {
+		return
+	}
+
+	var year, _ = strconv.Atoi(req.FormValue("year"))
+	var yg = tr.Year(year)
+	if yg == nil {
+		yg = &yearGaps{Year: year}
+	}
+
+	r.Vars.Title = fmt.Sprintf("Missing Issues: %s, %d", tr.Title.Name, year)
+	r.Vars.Data["Report"] = tr
+	r.Vars.Data["Year"] = yg
+	r.Render(titleTmpl)
+}
+
+// markHandler records a date as not published.  A date that's already marked
+// (e.g., from a double-submitted form) is left as-is.
+func markHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var lccn = req.FormValue("lccn")
+	var t, err = models.FindTitle("lccn = ?", lccn)
+	if err != nil {
+		logger.Errorf("Unable to look up title %q: %s", lccn, err)
+		r.Error(http.StatusInternalServerError, "Error trying to find title - try again or contact support")
+		return
+	}
+	if t == nil {
+		r.Error(http.StatusNotFound, "Unable to find title")
+		return
+	}
+
+	var date = req.FormValue("date")
+	var dt time.Time
+	dt, err = time.Parse(dateFormat, date)
+	if err != nil {
+		r.Error(http.StatusBadRequest, "Invalid date")
+		return
+	}
+
+	var existing *models.UnpublishedDate
+	existing, err = models.FindUnpublishedDateFor(lccn, date)
+	if err != nil {
+		logger.Errorf("Unable to look up unpublished date %s for %q: %s", date, lccn, err)
+		r.Error(http.StatusInternalServerError, "Error trying to save - try again or contact support")
+		return
+	}
+	if existing != nil {
+		http.SetCookie(w, &http.Cookie{Name: "Info", Value: date + " was already marked as not published", Path: "/"})
+		http.Redirect(w, req, titleURL(lccn, dt.Year()), http.StatusFound)
+		return
+	}
+
+	var u = &models.UnpublishedDate{
+		LCCN:   lccn,
+		Date:   date,
+		Note:   strings.TrimSpace(req.FormValue("note")),
+		UserID: r.Vars.User.ID,
+	}
+	err = u.Save()
+	if err != nil {
+		// The table's unique key stops a concurrent submission from adding a
+		// duplicate, which is fine as long as the date got marked
+		existing, _ = models.FindUnpublishedDateFor(lccn, date)
+		if existing == nil {
+			logger.Errorf("Unable to mark %s as unpublished for %q: %s", date, lccn, err)
+			r.Error(http.StatusInternalServerError, "Error trying to save - try again or contact support")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "Info", Value: date + " was already marked as not published", Path: "/"})
+		http.Redirect(w, req, titleURL(lccn, dt.Year()), http.StatusFound)
+		return
+	}
+
+	r.Audit(models.AuditActionMarkUnpublished, fmt.Sprintf("%s: %s (%q)", lccn, date, u.Note))
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: date + " marked as not published", Path: "/"})
+	http.Redirect(w, req, titleURL(lccn, dt.Year()), http.StatusFound)
+}
+
+// unmarkHandler removes a "not published" record so the date is reported as
+// missing again
+func unmarkHandler(w http.ResponseWriter, req *http.Request) {
+	var r = responder.Response(w, req)
+	var id, _ = strconv.Atoi(req.FormValue("id"))
+	var u, err = models.FindUnpublishedDate(id)
+	if err != nil {
+		logger.Errorf("Unable to look up unpublished date %d: %s", id, err)
+		r.Error(http.StatusInternalServerError, "Error trying to find date - try again or contact support")
+		return
+	}
+	if u == nil {
+		r.Error(http.StatusNotFound, "Unable to find date")
+		return
+	}
+
+	err = u.Delete()
+	if err != nil {
+		logger.Errorf("Unable to delete unpublished date %d: %s", id, err)
+		r.Error(http.StatusInternalServerError, "Error trying to save - try again or contact support")
+		return
+	}
+
+	var dt, _ = time.Parse(dateFormat, u.Date)
+	r.Audit(models.AuditActionUnmarkUnpublished, fmt.Sprintf("%s: %s", u.LCCN, u.Date))
+	http.SetCookie(w, &http.Cookie{Name: "Info", Value: u.Date + " is no longer marked as not published", Path: "/"})
+	http.Redirect(w, req, titleURL(u.LCCN, dt.Year()), http.StatusFound)
+}
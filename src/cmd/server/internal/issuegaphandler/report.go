@@ -0,0 +1,173 @@
+package issuegaphandler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/frequency"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+const dateFormat = "2006-01-02"
+
+// yearGaps holds a title's expected-but-missing dates for a single year, as
+// well as the dates staff have marked as not published
+type yearGaps struct {
+	Year        int
+	Expected    int
+	Missing     []string
+	Unpublished []*models.UnpublishedDate
+}
+
+// titleReport is the gap report for a single title.  Problem explains why a
+// title has no report, such as a missing or unsupported frequency.
+type titleReport struct {
+	Title     *models.Title
+	Frequency string
+	Days      string
+	Problem   string
+	Years     []*yearGaps
+}
+
+// MissingCount returns the total number of missing dates across all years
+func (tr *titleReport) MissingCount() int {
+	var n int
+	for _, y := range tr.Years {
+		n += len(y.Missing)
+	}
+	return n
+}
+
+// Year returns the report for the given year, or nil if the year isn't in
+// the report
+func (tr *titleReport) Year(year int) *yearGaps {
+	for _, y := range tr.Years {
+		if y.Year == year {
+			return y
+		}
+	}
+	return nil
+}
+
+// knownYear parses a MARC 008 year, returning false if it has unknown digits
+// (e.g., "19uu") or means "still published" (9999)
+func knownYear(s string) (int, bool) {
+	var y, err = strconv.Atoi(s)
+	return y, err == nil && y > 0 && y != 9999
+}
+
+// reportRange returns the first and last dates a title's report covers: every
+// year from the title's first year of publication to its last, per the MARC
+// record, or the years of its first and last known issues when the MARC years
+// aren't known.  In a first or last year of publication we have an issue for,
+// the paper may have started or stopped partway through, so the range starts
+// or ends at the known issue.  Nothing after today is included.
+func reportRange(t *models.Title, first, last, today time.Time) (start, end time.Time) {
+	start = time.Date(first.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	var sy, ok = knownYear(t.MARCStartYear)
+	switch {
+	case ok && sy == first.Year():
+		start = first
+	case ok && sy < first.Year():
+		start = time.Date(sy, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	end = time.Date(last.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+	var ey int
+	ey, ok = knownYear(t.MARCEndYear)
+	switch {
+	case ok && ey == last.Year():
+		end = last
+	case ok && ey > last.Year():
+		end = time.Date(ey, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if end.After(today) {
+		end = today
+	}
+	return start, end
+}
+
+// buildReport finds the dates a title should have issues for, based on its
+// publication frequency, across its years of publication (see reportRange).
+// Dates with an issue anywhere (uploads, workflow, or live) or marked as not
+// published aren't considered missing.
+func buildReport(t *models.Title, issues schema.IssueList, unpublished []*models.UnpublishedDate, today time.Time) *titleReport {
+	var tr = &titleReport{Title: t, Frequency: t.PublicationFrequency()}
+	if tr.Frequency == "" {
+		tr.Problem = "No publication frequency is known"
+		return tr
+	}
+	var f, err = frequency.Parse(tr.Frequency)
+	if err != nil {
+		tr.Problem = "Publication frequency can't be used: " + err.Error()
+		return tr
+	}
+
+	var have = make(map[string]bool)
+	var known []time.Time
+	for _, i := range issues {
+		var dt, err = time.Parse(dateFormat, i.RawDate)
+		if err != nil || have[i.RawDate] {
+			continue
+		}
+		have[i.RawDate] = true
+		known = append(known, dt)
+	}
+	if len(known) == 0 {
+		tr.Problem = "No issues have been found"
+		return tr
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].Before(known[j]) })
+
+	var days = f.Weekdays(known)
+	var names []string
+	for _, d := range days {
+		names = append(names, d.String()[:3])
+	}
+	tr.Days = strings.Join(names, ", ")
+
+	var skip = make(map[string]*models.UnpublishedDate)
+	for _, u := range unpublished {
+		skip[u.Date] = u
+	}
+
+	var byYear = make(map[int]*yearGaps)
+	var getYear = func(y int) *yearGaps {
+		if byYear[y] == nil {
+			byYear[y] = &yearGaps{Year: y}
+			tr.Years = append(tr.Years, byYear[y])
+		}
+		return byYear[y]
+	}
+
+	var start, end = reportRange(t, known[0], known[len(known)-1], today)
+	for _, dt := range frequency.Expected(days, start, end) {
+		var yg = getYear(dt.Year())
+		yg.Expected++
+		var ds = dt.Format(dateFormat)
+		switch {
+		case have[ds]:
+			continue
+		case skip[ds] != nil:
+			yg.Unpublished = append(yg.Unpublished, skip[ds])
+		default:
+			yg.Missing = append(yg.Missing, ds)
+		}
+	}
+
+	return tr
+}
+
+// sortReports orders reports by title name, ignoring common prefixes like
+// "The", then LCCN
+func sortReports(list []*titleReport) {
+	var key = func(tr *titleReport) string {
+		return strings.ToLower(schema.TrimCommonPrefixes(tr.Title.Name)) + "\x00" + tr.Title.LCCN
+	}
+	sort.Slice(list, func(i, j int) bool { return key(list[i]) < key(list[j]) })
+}
@@ -0,0 +1,127 @@
+package issuegaphandler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func testIssues(dates ...string) schema.IssueList {
+	var list schema.IssueList
+	for _, d := range dates {
+		list = append(list, &schema.Issue{RawDate: d})
+	}
+	return list
+}
+
+func mustDate(s string) time.Time {
+	var dt, err = time.Parse(dateFormat, s)
+	if err != nil {
+		panic(err)
+	}
+	return dt
+}
+
+func TestBuildReport(t *testing.T) {
+	var tests = map[string]struct {
+		title       *models.Title
+		issues      schema.IssueList
+		unpublished []*models.UnpublishedDate
+		today       string
+		problem     bool
+		years       []int
+		firstYear   string
+		lastYear    string
+	}{
+		"no frequency": {
+			title:   &models.Title{},
+			issues:  testIssues("2021-01-06"),
+			today:   "2021-02-01",
+			problem: true,
+		},
+		"no issues": {
+			title:   &models.Title{Frequency: "Mon, Wed, Fri"},
+			today:   "2021-02-01",
+			problem: true,
+		},
+		"unknown publication years cover the issues' full years, up to today": {
+			title:     &models.Title{Frequency: "Mon, Wed, Fri", MARCStartYear: "20uu", MARCEndYear: "9999"},
+			issues:    testIssues("2021-01-06", "2021-01-15"),
+			today:     "2021-01-20",
+			years:     []int{2021},
+			firstYear: "2021-01-01,2021-01-04,2021-01-08,2021-01-11,2021-01-13,2021-01-18,2021-01-20",
+		},
+		"first and last years of publication start and end at the known issues": {
+			title:     &models.Title{Frequency: "Mon, Wed, Fri", MARCStartYear: "2021", MARCEndYear: "2021"},
+			issues:    testIssues("2021-01-06", "2021-01-15"),
+			today:     "2030-01-01",
+			years:     []int{2021},
+			firstYear: "2021-01-08,2021-01-11,2021-01-13",
+		},
+		"unpublished dates aren't missing": {
+			title:       &models.Title{Frequency: "Mon, Wed, Fri", MARCStartYear: "2021", MARCEndYear: "2021"},
+			issues:      testIssues("2021-01-06", "2021-01-15"),
+			unpublished: []*models.UnpublishedDate{{LCCN: "sn1", Date: "2021-01-11"}},
+			today:       "2030-01-01",
+			years:       []int{2021},
+			firstYear:   "2021-01-08,2021-01-13",
+		},
+		"earlier and later years of publication are reported": {
+			title:     &models.Title{Frequency: "Mon, Wed, Fri", MARCStartYear: "2020", MARCEndYear: "2022"},
+			issues:    testIssues("2021-06-02"),
+			today:     "2030-01-01",
+			years:     []int{2020, 2021, 2022},
+			firstYear: "2020-01-01,2020-01-03,2020-01-06",
+			lastYear:  "2022-12-26,2022-12-28,2022-12-30",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tr = buildReport(tc.title, tc.issues, tc.unpublished, mustDate(tc.today))
+			if tc.problem {
+				if tr.Problem == "" {
+					t.Fatalf("Expected a problem, got none")
+				}
+				return
+			}
+			if tr.Problem != "" {
+				t.Fatalf("Unexpected problem: %s", tr.Problem)
+			}
+
+			if len(tr.Years) != len(tc.years) {
+				t.Fatalf("Expected %d years, got %d", len(tc.years), len(tr.Years))
+			}
+			for i, y := range tc.years {
+				if tr.Years[i].Year != y {
+					t.Errorf("Expected year %d at position %d, got %d", y, i, tr.Years[i].Year)
+				}
+			}
+
+			var first = strings.Join(tr.Years[0].Missing, ",")
+			if !strings.HasPrefix(first, tc.firstYear) {
+				t.Errorf("Expected first year's missing dates to start with %q, got %q", tc.firstYear, first)
+			}
+			if tc.lastYear != "" {
+				var last = strings.Join(tr.Years[len(tr.Years)-1].Missing, ",")
+				if !strings.HasSuffix(last, tc.lastYear) {
+					t.Errorf("Expected last year's missing dates to end with %q, got %q", tc.lastYear, last)
+				}
+			}
+		})
+	}
+}
+
+func TestReportRangeStopsToday(t *testing.T) {
+	var title = &models.Title{MARCStartYear: "2021", MARCEndYear: "9999"}
+	var start, end = reportRange(title, mustDate("2021-03-01"), mustDate("2021-03-05"), time.Date(2021, 3, 10, 15, 4, 5, 0, time.Local))
+	if !start.Equal(mustDate("2021-03-01")) {
+		t.Errorf("Expected range to start at the first issue, got %s", start)
+	}
+	if !end.Equal(mustDate("2021-03-10")) {
+		t.Errorf("Expected range to end today, got %s", end)
+	}
+}
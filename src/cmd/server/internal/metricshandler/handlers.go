@@ -0,0 +1,60 @@
+// Package metricshandler reports NCA's disk usage for monitoring systems
+package metricshandler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
+)
+
+// metric describes one gauge reported for every volume
+type metric struct {
+	name  string
+	help  string
+	value func(u *diskspace.Usage) int64
+}
+
+var diskMetrics = []metric{
+	{"nca_disk_free_bytes", "Bytes available to NCA on the volume", func(u *diskspace.Usage) int64 { return u.Free }},
+	{"nca_disk_size_bytes", "Total size of the volume in bytes", func(u *diskspace.Usage) int64 { return u.Total }},
+	{"nca_disk_minimum_free_bytes", "Free space below which jobs writing to the volume pause", func(u *diskspace.Usage) int64 { return u.Minimum }},
+	{"nca_disk_warning_free_bytes", "Free space below which NCA warns about the volume", func(u *diskspace.Usage) int64 { return u.Warning }},
+	{"nca_disk_low", "1 if jobs writing to the volume are paused for lack of space", func(u *diskspace.Usage) int64 { return boolGauge(u.Low()) }},
+	{"nca_disk_check_failed", "1 if the volume's free space couldn't be read", func(u *diskspace.Usage) int64 { return boolGauge(u.Err != nil) }},
+}
+
+func boolGauge(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Setup registers the metrics page at baseWebPath, reporting the monitor's
+// most recent disk usage
+func Setup(r *mux.Router, baseWebPath string, m *diskspace.Monitor) {
+	r.NewRoute().Path(baseWebPath).Handler(metricsHandler(m))
+}
+
+// metricsHandler reports disk usage in Prometheus's text format.  No login
+// is required so monitoring systems can scrape it; sites which don't want it
+// public should restrict it at the proxy.
+func metricsHandler(m *diskspace.Monitor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeDiskMetrics(w, m.Usage())
+	})
+}
+
+func writeDiskMetrics(w io.Writer, usage []*diskspace.Usage) {
+	for _, dm := range diskMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n", dm.name, dm.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", dm.name)
+		for _, u := range usage {
+			fmt.Fprintf(w, "%s{setting=%q} %d\n", dm.name, u.Setting, dm.value(u))
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package publisherhandler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// maxFailures is how many failed logins a client may have within
+// failureWindow before we stop checking its credentials
+const maxFailures = 5
+
+// failureWindow is how long failed logins count against a client
+const failureWindow = 15 * time.Minute
+
+// authCacheTTL is how long a successful login is remembered
+const authCacheTTL = 10 * time.Minute
+
+var (
+	failures = &failureLog{m: make(map[string][]time.Time)}
+	logins   = &loginCache{m: make(map[string]cachedLogin)}
+)
+
+// clientIP returns the address we use to track a client's failed logins
+func clientIP(req *http.Request) string {
+	var ip = responder.GetUserIP(req)
+	if ip != "" {
+		return ip
+	}
+	var host, _, err = net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// failureLog tracks recent failed logins per client
+type failureLog struct {
+	sync.Mutex
+	m map[string][]time.Time
+}
+
+// recent returns the client's failures within the window, dropping older ones.
+// The caller must hold the lock.
+func (fl *failureLog) recent(ip string, now time.Time) []time.Time {
+	var list []time.Time
+	for _, t := range fl.m[ip] {
+		if now.Sub(t) < failureWindow {
+			list = append(list, t)
+		}
+	}
+	if len(list) == 0 {
+		delete(fl.m, ip)
+	} else {
+		fl.m[ip] = list
+	}
+	return list
+}
+
+// blocked returns true if the client has failed too many logins recently
+func (fl *failureLog) blocked(ip string) bool {
+	fl.Lock()
+	defer fl.Unlock()
+	return len(fl.recent(ip, time.Now())) >= maxFailures
+}
+
+// add records a failed login for the client
+func (fl *failureLog) add(ip string) {
+	fl.Lock()
+	defer fl.Unlock()
+	var now = time.Now()
+	fl.m[ip] = append(fl.recent(ip, now), now)
+}
+
+// cachedLogin remembers a successful login: the stored password it was
+// checked against and a digest of what the client sent
+type cachedLogin struct {
+	stored  string
+	digest  [sha256.Size]byte
+	expires time.Time
+}
+
+// loginCache holds recent successful logins keyed by SFTP username
+type loginCache struct {
+	sync.Mutex
+	m map[string]cachedLogin
+}
+
+// matches returns true if pass was recently verified against t's current
+// password
+func (lc *loginCache) matches(t *models.Title, pass string) bool {
+	lc.Lock()
+	var c, ok = lc.m[t.SFTPUser]
+	lc.Unlock()
+
+	if !ok || c.stored != t.SFTPPass || time.Now().After(c.expires) {
+		return false
+	}
+	var d = sha256.Sum256([]byte(pass))
+	return subtle.ConstantTimeCompare(d[:], c.digest[:]) == 1
+}
+
+// add remembers that pass is valid for t
+func (lc *loginCache) add(t *models.Title, pass string) {
+	lc.Lock()
+	defer lc.Unlock()
+	lc.m[t.SFTPUser] = cachedLogin{stored: t.SFTPPass, digest: sha256.Sum256([]byte(pass)), expires: time.Now().Add(authCacheTTL)}
+}
+
+// passwordMatches checks pass against t's SFTP password, skipping the
+// (deliberately slow) bcrypt comparison if this login was recently verified
+func passwordMatches(t *models.Title, pass string) bool {
+	if logins.matches(t, pass) {
+		return true
+	}
+	if !t.SFTPPasswordMatches(pass) {
+		return false
+	}
+	logins.add(t, pass)
+	return true
+}
@@ -0,0 +1,66 @@
+package publisherhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func TestFailureLog(t *testing.T) {
+	var fl = &failureLog{m: make(map[string][]time.Time)}
+	for n := 0; n < maxFailures-1; n++ {
+		fl.add("10.0.0.1")
+	}
+	if fl.blocked("10.0.0.1") {
+		t.Errorf("Expected a client under the limit not to be blocked")
+	}
+
+	fl.add("10.0.0.1")
+	if !fl.blocked("10.0.0.1") {
+		t.Errorf("Expected a client at the limit to be blocked")
+	}
+	if fl.blocked("10.0.0.2") {
+		t.Errorf("Expected other clients not to be blocked")
+	}
+
+	// Failures outside the window no longer count
+	for i := range fl.m["10.0.0.1"] {
+		fl.m["10.0.0.1"][i] = time.Now().Add(-failureWindow)
+	}
+	if fl.blocked("10.0.0.1") {
+		t.Errorf("Expected old failures to be forgotten")
+	}
+	if len(fl.m) != 0 {
+		t.Errorf("Expected clients without recent failures to be dropped, got %v", fl.m)
+	}
+}
+
+func TestLoginCache(t *testing.T) {
+	var lc = &loginCache{m: make(map[string]cachedLogin)}
+	var title = &models.Title{SFTPUser: "pub", SFTPPass: "hash-one"}
+
+	if lc.matches(title, "secret") {
+		t.Errorf("Expected no match before a login is cached")
+	}
+
+	lc.add(title, "secret")
+	if !lc.matches(title, "secret") {
+		t.Errorf("Expected the cached login to match")
+	}
+	if lc.matches(title, "wrong") {
+		t.Errorf("Expected a different password not to match")
+	}
+
+	var changed = &models.Title{SFTPUser: "pub", SFTPPass: "hash-two"}
+	if lc.matches(changed, "secret") {
+		t.Errorf("Expected a changed password to invalidate the cached login")
+	}
+
+	var c = lc.m["pub"]
+	c.expires = time.Now().Add(-time.Second)
+	lc.m["pub"] = c
+	if lc.matches(title, "secret") {
+		t.Errorf("Expected an expired login not to match")
+	}
+}
@@ -0,0 +1,139 @@
+// Package publisherhandler is a small, read-only portal for publishers to see
+// the status of their uploads.  Publishers log in with their title's SFTP
+// credentials rather than the Apache-provided login NCA staff use, and can
+// only see the one title those credentials belong to.
+package publisherhandler
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/uploads"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+)
+
+// realm is sent to browsers when asking for credentials
+const realm = "NCA Publisher Portal"
+
+var (
+	conf    *config.Config
+	watcher *issuewatcher.Watcher
+
+	// layout is the base template, cloned from the responder's layout, from
+	// which all subpages are built
+	layout *tmpl.TRoot
+
+	// homeTmpl shows a publisher their uploaded and processed issues
+	homeTmpl *tmpl.Template
+)
+
+// Setup sets up all the routing rules and other configuration
+func Setup(r *mux.Router, baseWebPath string, c *config.Config, w *issuewatcher.Watcher) {
+	conf = c
+	watcher = w
+	var s = r.PathPrefix(baseWebPath).Subrouter()
+	s.Path("").Handler(mustAuth(homeHandler))
+
+	layout = responder.Layout.Clone()
+	layout.Path = path.Join(layout.Path, "publisher")
+	homeTmpl = layout.MustBuild("home.go.html")
+}
+
+// handlerFunc is a handler which needs to know which title is logged in
+type handlerFunc func(w http.ResponseWriter, req *http.Request, t *models.Title)
+
+// mustAuth requires HTTP basic auth using a title's SFTP credentials.
+// Clients with too many recent failures are turned away before we look at
+// their credentials, and recent successful logins are remembered so browsers
+// resending credentials on every request don't cost a bcrypt comparison each
+// time.
+func mustAuth(h handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ip = clientIP(req)
+		if failures.blocked(ip) {
+			logger.Warnf("Rejecting publisher portal login from %s: too many failed attempts", ip)
+			http.Error(w, "Too many failed logins - wait a few minutes and try again", http.StatusTooManyRequests)
+			return
+		}
+
+		var user, pass, ok = req.BasicAuth()
+		var t *models.Title
+		if ok && user != "" {
+			var titles, err = models.Titles()
+			if err != nil {
+				logger.Errorf("Unable to read titles for publisher login: %s", err)
+				http.Error(w, "Unable to check your login - try again or contact support", http.StatusInternalServerError)
+				return
+			}
+			t = titles.FindBySFTPUser(user)
+		}
+		if t == nil || t.SFTPDir == "" || !passwordMatches(t, pass) {
+			if ok {
+				logger.Warnf("Failed publisher portal login for %q from %s", user, ip)
+				failures.add(ip)
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+			http.Error(w, "You must log in with your upload username and password", http.StatusUnauthorized)
+			return
+		}
+
+		h(w, req, t)
+	})
+}
+
+// homeHandler lists the title's uploaded issues and those which are already
+// in NCA's workflow or live
+func homeHandler(w http.ResponseWriter, req *http.Request, t *models.Title) {
+	var r = responder.Response(w, req)
+	r.Vars.Title = "Upload status: " + t.Name
+
+	// We scan the title's directory fresh rather than using the watcher's data
+	// so publishers see their uploads immediately, and so the validations
+	// below can't touch the shared issue data
+	var s = issuefinder.NewSearcher(issuefinder.SFTPUpload, conf.PDFUploadPath)
+	var issues, err = s.RefreshTitlePath(filepath.Join(conf.PDFUploadPath, t.SFTPDir), conf.PDFBatchMARCOrgCode)
+	if err != nil {
+		logger.Errorf("Unable to scan uploads for title %q: %s", t.Name, err)
+		r.Error(http.StatusInternalServerError, "Unable to read your uploads - try again or contact support")
+		return
+	}
+
+	var scanner = watcher.CurrentScanner()
+	var uploaded []*UploadedIssue
+	for _, i := range issues {
+		var ui = uploads.New(i, scanner, conf)
+		ui.ValidateFast()
+		uploaded = append(uploaded, wrapUpload(ui, t))
+	}
+	sort.Slice(uploaded, func(i, j int) bool {
+		return uploaded[i].Key() < uploaded[j].Key()
+	})
+
+	var dbIssues []*models.Issue
+	dbIssues, err = models.Issues().IncludeIgnored().LCCN(t.LCCN).OrderBy("date, edition").Fetch()
+	if err != nil {
+		logger.Errorf("Unable to read issues for title %q: %s", t.Name, err)
+		r.Error(http.StatusInternalServerError, "Unable to read your processed issues - try again or contact support")
+		return
+	}
+	var processed []*ProcessedIssue
+	for _, i := range dbIssues {
+		if p := wrapProcessed(i); p != nil {
+			processed = append(processed, p)
+		}
+	}
+
+	r.Vars.Data["Title"] = t
+	r.Vars.Data["Uploaded"] = uploaded
+	r.Vars.Data["Processed"] = processed
+	r.Render(homeTmpl)
+}
@@ -0,0 +1,98 @@
+package publisherhandler
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/uploads"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/web/webutil"
+)
+
+// plainErrors maps issue errors' short descriptions to language that makes
+// sense to somebody who isn't familiar with NCA's internals
+var plainErrors = map[string]string{
+	"too new for processing":            "This issue was uploaded very recently. We wait a little while after uploads finish before processing them, so no action is needed.",
+	"may be too new":                    "This issue was uploaded recently. If you are still uploading files for it, no action is needed.",
+	"duplicate of another issue":        "We already have an issue for this date and edition. If this is a correction, please contact us.",
+	"missing / invalid folder contents": "The issue's folder is missing files or has files we can't use. Issues should contain only PDFs.",
+	"no files":                          "The issue's folder is empty.",
+	"invalid folder name":               "The issue's folder name isn't a valid date. Folders must be named YYYY-MM-DD, e.g., 2021-03-15.",
+	"issue linked to invalid title":     "There is a problem with this title's setup on our end. Please contact us.",
+	"invalid image":                     "One of the page images couldn't be read. Please make sure it's a JPEG, PNG, or TIFF and upload it again.",
+	"invalid archive":                   "We couldn't unpack this issue's archive. It may be damaged, too large, or contain something other than files and folders. Please upload it again or send the files in a folder.",
+}
+
+// imageUploadErrors replaces plainErrors' language for titles which upload
+// page images rather than PDFs
+var imageUploadErrors = map[string]string{
+	"missing / invalid folder contents": "The issue's folder is missing files or has files we can't use. Issues should contain only page images (JPEG, PNG, or TIFF).",
+}
+
+// stepDescriptions explains where a processed issue is in our workflow
+var stepDescriptions = map[schema.WorkflowStep]string{
+	schema.WSAwaitingProcessing:     "Received; being prepared for review",
+	schema.WSAwaitingPageReview:     "Received; awaiting page review",
+	schema.WSReadyForMetadataEntry:  "In review",
+	schema.WSAwaitingMetadataReview: "In review",
+	schema.WSUnfixableMetadataError: "On hold; we may contact you about a problem with this issue",
+	schema.WSReadyForMETSXML:        "Reviewed; awaiting publication",
+	schema.WSReadyForBatching:       "Reviewed; awaiting publication",
+	schema.WSInProduction:           "Published",
+}
+
+// UploadedIssue is an issue still sitting in the title's upload directory
+type UploadedIssue struct {
+	*uploads.Issue
+	Errors   []string
+	Warnings []string
+}
+
+func wrapUpload(i *uploads.Issue, t *models.Title) *UploadedIssue {
+	var u = &UploadedIssue{Issue: i}
+	for _, err := range i.Errors.All() {
+		var msg, ok = plainErrors[err.Error()]
+		if t.ImageUploads {
+			if imgMsg, imgOK := imageUploadErrors[err.Error()]; imgOK {
+				msg, ok = imgMsg, imgOK
+			}
+		}
+		if !ok {
+			msg = err.Message()
+		}
+		if err.Warning() {
+			u.Warnings = append(u.Warnings, msg)
+		} else {
+			u.Errors = append(u.Errors, msg)
+		}
+	}
+	return u
+}
+
+// ProcessedIssue is an issue which has been moved into NCA's workflow
+type ProcessedIssue struct {
+	*models.Issue
+	Status  string
+	LiveURL string
+}
+
+// wrapProcessed returns a ProcessedIssue for i, or nil if the issue isn't in
+// a workflow step a publisher would care about
+func wrapProcessed(i *models.Issue) *ProcessedIssue {
+	var status, ok = stepDescriptions[i.WorkflowStep]
+	if !ok {
+		return nil
+	}
+
+	var p = &ProcessedIssue{Issue: i, Status: status}
+	if i.WorkflowStep == schema.WSInProduction {
+		var u, err = url.Parse(webutil.ProductionURL)
+		if err == nil {
+			u.Path = path.Join(u.Path, "lccn", i.LCCN, i.Date, "ed-"+strconv.Itoa(i.Edition))
+			p.LiveURL = u.String()
+		}
+	}
+	return p
+}
@@ -11,24 +11,30 @@ import (
 	"net/http"
 	"time"
 
+	ltype "github.com/uoregon-libraries/gopkg/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/version"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
 )
 
+// DiskMonitor, when set, supplies disk space warnings shown to logged-in users
+var DiskMonitor *diskspace.Monitor
+
 // GenericVars holds anything specialized that doesn't make sense to have in PageVars
 type GenericVars map[string]interface{}
 
 // PageVars is the generic list of data all pages may need, and the catch-all
 // "Data" map for specialized one-off data
 type PageVars struct {
-	Title   string
-	Version string
-	Alert   template.HTML
-	Info    template.HTML
-	User    *models.User
-	Data    GenericVars
+	Title    string
+	Version  string
+	Alert    template.HTML
+	Info     template.HTML
+	Warnings []string
+	User     *models.User
+	Data     GenericVars
 }
 
 // Responder wraps common response logic
@@ -36,6 +42,9 @@ type Responder struct {
 	Writer  http.ResponseWriter
 	Request *http.Request
 	Vars    *PageVars
+
+	// Logger attaches the request's correlation fields to log entries
+	Logger *ltype.Logger
 }
 
 // Response generates a Responder with basic data all pages will need: request,
@@ -43,7 +52,12 @@ type Responder struct {
 func Response(w http.ResponseWriter, req *http.Request) *Responder {
 	var u = models.FindActiveUserWithLogin(GetUserLogin(w, req))
 	u.IP = GetUserIP(req)
-	return &Responder{Writer: w, Request: req, Vars: &PageVars{User: u, Data: make(GenericVars)}}
+	return &Responder{
+		Writer:  w,
+		Request: req,
+		Vars:    &PageVars{User: u, Data: make(GenericVars)},
+		Logger:  logger.FromContext(req.Context(), "server"),
+	}
 }
 
 // injectDefaultTemplateVars sets up default variables used in multiple templates
@@ -52,6 +66,9 @@ func (r *Responder) injectDefaultTemplateVars() {
 	if r.Vars.Title == "" {
 		r.Vars.Title = "Newspaper Curation App"
 	}
+	if DiskMonitor != nil && r.Vars.User != nil && !r.Vars.User.Guest {
+		r.Vars.Warnings = DiskMonitor.Warnings()
+	}
 }
 
 // Render uses the responder's data to render the given template
@@ -79,13 +96,13 @@ func (r *Responder) Render(t *tmpl.Template) {
 	var buffer = new(bytes.Buffer)
 	err = t.Execute(buffer, r.Vars)
 	if err != nil {
-		logger.Criticalf("Unable to render template %q: %s", t.Path, err)
+		r.Logger.Criticalf("Unable to render template %q: %s", t.Path, err)
 		http.Error(r.Writer, "NCA has experienced an internal error while trying to render the page. Please contact the administrator for assistance.", http.StatusInternalServerError)
 		return
 	}
 	_, err = io.Copy(r.Writer, buffer)
 	if err != nil {
-		logger.Errorf("Unable to copy template %q from buffer: %s", t.Name, err)
+		r.Logger.Errorf("Unable to copy template %q from buffer: %s", t.Name, err)
 	}
 }
 
@@ -95,7 +112,7 @@ func (r *Responder) Audit(action models.AuditAction, msg string) {
 	var u = r.Vars.User
 	var err = models.CreateAuditLog(u.IP, u.Login, action, msg)
 	if err != nil {
-		logger.Criticalf("Unable to write AuditLog{%s (%s), %q, %s}: %s", u.Login, u.IP, action, msg, err)
+		r.Logger.Criticalf("Unable to write AuditLog{%s (%s), %q, %s}: %s", u.Login, u.IP, action, msg, err)
 	}
 }
 
@@ -127,6 +127,11 @@ func InitRootTemplate(templatePath string) {
 		"ModifyUploadedIssues":     func() *privilege.Privilege { return privilege.ModifyUploadedIssues },
 		"ViewTitleSFTPCredentials": func() *privilege.Privilege { return privilege.ViewTitleSFTPCredentials },
 		"SearchIssues":             func() *privilege.Privilege { return privilege.SearchIssues },
+		"MarkUnpublishedDates":     func() *privilege.Privilege { return privilege.MarkUnpublishedDates },
+		"QueueBatches":             func() *privilege.Privilege { return privilege.QueueBatches },
+		"ViewQCReports":            func() *privilege.Privilege { return privilege.ViewQCReports },
+		"FailQC":                   func() *privilege.Privilege { return privilege.FailQC },
+		"PassQC":                   func() *privilege.Privilege { return privilege.PassQC },
 		"ModifyValidatedLCCNs":     func() *privilege.Privilege { return privilege.ModifyValidatedLCCNs },
 		"ModifyTitleSFTP":          func() *privilege.Privilege { return privilege.ModifyTitleSFTP },
 		"ListAuditLogs":            func() *privilege.Privilege { return privilege.ListAuditLogs },
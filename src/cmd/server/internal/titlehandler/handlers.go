@@ -1,19 +1,23 @@
 package titlehandler
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/duration"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/frequency"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/privilege"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
+	"golang.org/x/crypto/ssh"
 )
 
 var (
@@ -44,7 +48,8 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 
 	layout = responder.Layout.Clone()
 	layout.Funcs(tmpl.FuncMap{
-		"TitlesHomeURL": func() string { return basePath },
+		"TitlesHomeURL":     func() string { return basePath },
+		"HashSFTPPasswords": hashSFTPPasswords,
 	})
 	layout.Path = path.Join(layout.Path, "titles")
 
@@ -52,6 +57,14 @@ func Setup(r *mux.Router, baseWebPath string, c *config.Config) {
 	formTmpl = layout.MustBuild("form.go.html")
 }
 
+// hashSFTPPasswords returns true if SFTP passwords should be stored as bcrypt
+// hashes.  This is only done when NCA's built-in SFTP server is enabled: sites
+// which run their own SFTP daemon may provision it from the plain-text
+// password.
+func hashSFTPPasswords() bool {
+	return conf.SFTPListenAddress != ""
+}
+
 func getTitle(r *responder.Responder) (t *Title, handled bool) {
 	var idStr = r.Request.FormValue("id")
 	var id, _ = strconv.Atoi(idStr)
@@ -151,10 +164,64 @@ func setTitleData(r *responder.Responder, t *Title) (vErrors []string, handled b
 		t.EmbargoPeriod = embargoPeriod.String()
 	}
 
+	t.ImageUploads = form.Get("image_uploads") == "1"
+
+	t.Frequency = strings.TrimSpace(form.Get("frequency"))
+	if t.Frequency != "" {
+		var _, err = frequency.Parse(t.Frequency)
+		if err != nil {
+			vErrors = append(vErrors, fmt.Sprintf("Publication frequency %q isn't understood: %s", t.Frequency, err))
+		}
+	}
+
+	var autoQueue = strings.TrimSpace(form.Get("auto_queue_hours"))
+	t.AutoQueueHours = 0
+	if autoQueue != "" {
+		var hrs, err = strconv.Atoi(autoQueue)
+		if err != nil || hrs < 0 {
+			vErrors = append(vErrors, "Auto-queue delay must be a whole number of hours (or blank to disable)")
+		} else {
+			t.AutoQueueHours = hrs
+		}
+	}
+
 	if r.Vars.User.PermittedTo(privilege.ModifyTitleSFTP) {
 		t.SFTPDir = form.Get("sftpdir")
 		t.SFTPUser = form.Get("sftpuser")
-		t.SFTPPass = form.Get("sftppass")
+		// Hashed passwords can't be shown on the form, so a blank field means
+		// the password isn't being changed
+		var pass = form.Get("sftppass")
+		if !hashSFTPPasswords() {
+			t.SFTPPass = pass
+		} else if pass != "" {
+			var err = t.SetSFTPPassword(pass)
+			if err != nil {
+				logger.Errorf("Unable to hash SFTP password for title %q: %s", t.LCCN, err)
+				vErrors = append(vErrors, "Unable to save the SFTP password; try again or contact support")
+			}
+		}
+		t.SFTPPublicKeys = strings.TrimSpace(form.Get("sftpkeys"))
+
+		var rest = []byte(t.SFTPPublicKeys)
+		for len(bytes.TrimSpace(rest)) > 0 {
+			var err error
+			_, _, _, rest, err = ssh.ParseAuthorizedKey(rest)
+			if err != nil {
+				vErrors = append(vErrors, fmt.Sprintf("SFTP public keys are invalid: %s", err))
+				break
+			}
+		}
+
+		var quota = strings.TrimSpace(form.Get("sftpquota"))
+		t.SFTPQuota = 0
+		if quota != "" {
+			var mb, err = strconv.ParseInt(quota, 10, 64)
+			if err != nil || mb < 0 {
+				vErrors = append(vErrors, "SFTP quota must be a whole number of megabytes (or blank for no limit)")
+			} else {
+				t.SFTPQuota = mb * 1024 * 1024
+			}
+		}
 	}
 
 	if !t.ValidLCCN || r.Vars.User.PermittedTo(privilege.ModifyValidatedLCCNs) {
@@ -43,6 +43,9 @@ func pullMARCForTitle(t *Title) {
 	t.ValidLCCN = false
 	t.MARCTitle = ""
 	t.MARCLocation = ""
+	t.MARCFrequency = ""
+	t.MARCStartYear = ""
+	t.MARCEndYear = ""
 
 	var marcLocs = []string{
 		strings.Replace(conf.MARCLocation1, "{{lccn}}", t.LCCN, -1),
@@ -92,8 +95,25 @@ func lookupMARC(t *Title, marcLoc string) error {
 	}
 
 	var m marc
+	var formerFrequency string
 	xml.Unmarshal(data, &m)
 	for _, df := range m.Datafields {
+		// Current frequency is in 310; former frequencies (321) are only used if
+		// there's no current frequency, such as for some ceased titles
+		if df.Tag == "310" || df.Tag == "321" {
+			for _, sf := range df.Subfields {
+				if sf.Code != "a" {
+					continue
+				}
+				var freq = marcStripLocRE.ReplaceAllString(strings.TrimSpace(sf.Data), "")
+				if df.Tag == "310" {
+					t.MARCFrequency = freq
+				} else {
+					formerFrequency = freq
+				}
+			}
+		}
+
 		if df.Tag == "245" {
 			for _, sf := range df.Subfields {
 				if sf.Code == "a" {
@@ -110,10 +130,15 @@ func lookupMARC(t *Title, marcLoc string) error {
 			}
 		}
 	}
+	if t.MARCFrequency == "" {
+		t.MARCFrequency = formerFrequency
+	}
 	for _, cf := range m.Controlfields {
 		if cf.Tag == "008" {
 			runes := []rune(cf.Data)
 			t.LangCode3 = string(runes[35:38])
+			t.MARCStartYear = strings.TrimSpace(string(runes[7:11]))
+			t.MARCEndYear = strings.TrimSpace(string(runes[11:15]))
 		}
 	}
 	if t.MARCTitle != "" && t.MARCLocation != "" {
@@ -25,6 +25,12 @@ func WrapTitle(t *models.Title) *Title {
 	return &Title{t, strings.ToLower(re.ReplaceAllString(schema.TrimCommonPrefixes(t.Name)+t.LCCN, "-"))}
 }
 
+// SFTPQuotaMB returns the title's SFTP quota in megabytes for display and
+// editing
+func (t *Title) SFTPQuotaMB() int64 {
+	return t.SFTPQuota / (1024 * 1024)
+}
+
 // WrapTitles takes a models.TitleList and wraps each title individually
 func WrapTitles(list models.TitleList) []*Title {
 	var titles = make([]*Title, len(list))
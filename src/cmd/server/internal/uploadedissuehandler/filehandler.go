@@ -32,8 +32,10 @@ func FileHandler(w http.ResponseWriter, req *http.Request) {
 
 	var path = file.Location
 	var ext = strings.ToUpper(filepath.Ext(path))
-	if ext != ".PDF" && ext != ".TIF" && ext != ".TIFF" {
-		r.Vars.Alert = template.HTML(fmt.Sprintf("%q is not a valid file type (PDF/TIFF only), and cannot be viewed", path))
+	switch ext {
+	case ".PDF", ".TIF", ".TIFF", ".JPG", ".JPEG", ".PNG":
+	default:
+		r.Vars.Alert = template.HTML(fmt.Sprintf("%q is not a valid file type (PDF/TIFF/JPEG/PNG only), and cannot be viewed", path))
 		r.Render(responder.Empty)
 		return
 	}
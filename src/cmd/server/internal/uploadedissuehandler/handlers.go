@@ -105,7 +105,7 @@ func IssueWorkflowHandler(w http.ResponseWriter, req *http.Request) {
 
 	switch r.vars["action"] {
 	case "queue":
-		var err = r.issue.Queue()
+		var err = r.issue.Queue(r.Request.Context())
 		var cname, msg string
 		if err == nil {
 			cname = "Info"
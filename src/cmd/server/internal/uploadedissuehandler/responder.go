@@ -107,7 +107,7 @@ func (r *resp) Render(t *tmpl.Template) {
 
 	// If we've pulled a single issue, validate it fully
 	if r.issue.Issue != nil {
-		r.issue.ValidateAll()
+		r.issue.ValidateAll(r.Request.Context())
 		r.Vars.Data["Issue"] = r.issue
 	}
 
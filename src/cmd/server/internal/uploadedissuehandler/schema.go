@@ -53,7 +53,7 @@ func (t *Title) decorateIssues(issueList []*schema.Issue) {
 }
 
 func (t *Title) appendSchemaIssue(i *schema.Issue) *Issue {
-	var uIssue = uploads.New(i, watcher.Scanner, conf)
+	var uIssue = uploads.New(i, watcher.CurrentScanner(), conf)
 	var issue = &Issue{
 		Issue: uIssue,
 		Slug:  i.DateEdition(),
@@ -182,6 +182,7 @@ func unclaimIssueHandler(resp *responder.Responder, i *Issue) {
 
 // enterMetadataHandler shows the metadata entry form for the issue
 func enterMetadataHandler(resp *responder.Responder, i *Issue) {
+	i.prefillSuggestions()
 	i.ValidateMetadata()
 	resp.Vars.Title = "Issue Metadata / Page Numbers"
 	resp.Vars.Data["Issue"] = i
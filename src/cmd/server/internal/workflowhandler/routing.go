@@ -6,7 +6,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/metadatarules"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/tmpl"
 )
 
@@ -19,6 +21,9 @@ var (
 	// watcher is used to look for dupes when queueing an issue for review
 	watcher *issuewatcher.Watcher
 
+	// rules holds the configured metadata validation rules
+	rules *metadatarules.RuleSet
+
 	// Layout is the base template, cloned from the responder's layout, from
 	// which all workflow pages are built
 	Layout *tmpl.TRoot
@@ -58,6 +63,12 @@ func Setup(r *mux.Router, webPath string, c *config.Config, w *issuewatcher.Watc
 	basePath = webPath
 	watcher = w
 
+	var err error
+	rules, err = metadatarules.Load(conf.MetadataRulesPath)
+	if err != nil {
+		logger.Fatalf("Unable to load metadata rules: %s", err)
+	}
+
 	// Base path (desk view)
 	var s = r.PathPrefix(basePath).Subrouter()
 	s.Path("").Handler(handle(canView(homeHandler)))
@@ -138,7 +138,7 @@ func saveQueue(resp *responder.Responder, i *Issue, changes map[string]string) {
 		for _, e := range i.Errors().Minor().All() {
 			warns = append(warns, e.Message())
 		}
-		i.Save(models.ActionTypeInternalProcess, models.SystemUser.ID,
+		i.Save(models.ActionTypeAcceptWarnings, resp.Vars.User.ID,
 			fmt.Sprintf("ignoring warnings (approved by %q):\n\n%s", resp.Vars.User.Login, strings.Join(warns, "\n")))
 	}
 
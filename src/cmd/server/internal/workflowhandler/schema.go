@@ -10,6 +10,7 @@ import (
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuesequence"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
@@ -36,6 +37,7 @@ type Issue struct {
 
 	validationErrors *apperr.List
 	acceptWarnings   bool
+	suggestion       *issuesequence.Suggestion
 }
 
 func wrapDBIssue(dbIssue *models.Issue) *Issue {
@@ -179,6 +181,9 @@ func (i *Issue) ValidateMetadata() {
 	if i.Edition == 0 {
 		addError(apperr.New(`"Edition Number" cannot be zero`))
 	}
+	for _, err := range rules.Check(i.Issue) {
+		addError(err)
+	}
 
 	var numLabels = len(i.PageLabels)
 	var numFiles = len(i.JP2Files())
@@ -203,8 +208,12 @@ func (i *Issue) ValidateMetadata() {
 		return
 	}
 
-	// Check dupes on the schema issue, then pull those errors onto our validations
-	i.si.CheckDupes(watcher.Scanner.Lookup)
+	// Check dupes and the volume / issue number sequence on the schema issue,
+	// then pull those errors onto our validations
+	i.si.CheckDupes(watcher.CurrentScanner().Lookup)
+	for _, msg := range i.sequenceSuggestion().Check(i.Volume, i.Issue.Issue) {
+		i.si.WarnSequence(msg)
+	}
 	for _, err := range i.si.Errors.All() {
 		addError(err)
 	}
@@ -0,0 +1,169 @@
+package workflowhandler
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuesequence"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// Suggestion returns the volume, issue number, and edition label we expect
+// this issue to have based on the title's nearby issues.  Live issues' metadata
+// is fetched if it isn't already cached, so this should only be called when
+// displaying the metadata form.
+func (i *Issue) Suggestion() *issuesequence.Suggestion {
+	if i.suggestion == nil {
+		i.suggestion = i.suggest(true)
+	}
+	return i.suggestion
+}
+
+// sequenceSuggestion returns the suggestion for validating this issue's
+// volume and issue number.  Unless the form has already built a suggestion,
+// live issues are only considered if their metadata is already cached, so
+// saving an issue never waits on the live site.
+func (i *Issue) sequenceSuggestion() *issuesequence.Suggestion {
+	if i.suggestion != nil {
+		return i.suggestion
+	}
+	return i.suggest(false)
+}
+
+func (i *Issue) suggest(fetchLive bool) *issuesequence.Suggestion {
+	return issuesequence.Suggest(i.Issue.Date, i.Edition, i.neighbors(fetchLive))
+}
+
+// prefillSuggestions puts suggested values into any metadata fields which
+// haven't been filled in yet
+func (i *Issue) prefillSuggestions() {
+	var s = i.Suggestion()
+	if i.Volume == "" {
+		i.Volume = s.Volume
+	}
+	if i.Issue.Issue == "" {
+		i.Issue.Issue = s.Issue
+	}
+	if i.EditionLabel == "" {
+		i.EditionLabel = s.EditionLabel
+	}
+}
+
+// neighbors gathers the metadata of the issues closest to this one: the
+// nearest in-process issues on either side, the nearest on either side with
+// the same edition number (for the edition label), and any live issue which
+// is closer than the in-process issues
+func (i *Issue) neighbors(fetchLive bool) []*issuesequence.Neighbor {
+	var list []*issuesequence.Neighbor
+	var prevKey, nextKey string
+	var closest = func(f *models.IssueFinder, order string) *models.Issue {
+		var dbIssues, err = f.LCCN(i.LCCN()).HasSequence().OrderBy(order).Limit(1).Fetch()
+		if err != nil {
+			logger.Errorf("Unable to look up issues near %q: %s", i.Key(), err)
+			return nil
+		}
+		if len(dbIssues) == 0 {
+			return nil
+		}
+		return dbIssues[0]
+	}
+	var add = func(dbi *models.Issue) {
+		if dbi == nil {
+			return
+		}
+		list = append(list, &issuesequence.Neighbor{
+			Date:         dbi.Date,
+			Edition:      dbi.Edition,
+			Volume:       dbi.Volume,
+			Issue:        dbi.Issue,
+			EditionLabel: dbi.EditionLabel,
+		})
+	}
+
+	var date, ed = i.Issue.Date, i.Edition
+	var prev = closest(models.Issues().Before(date, ed), "date DESC, edition DESC")
+	var next = closest(models.Issues().After(date, ed), "date ASC, edition ASC")
+	add(prev)
+	add(next)
+	add(closest(models.Issues().Edition(ed).Before(date, ed), "date DESC"))
+	add(closest(models.Issues().Edition(ed).After(date, ed), "date ASC"))
+	if prev != nil {
+		prevKey = schema.IssueDateEdition(prev.Date, prev.Edition)
+	}
+	if next != nil {
+		nextKey = schema.IssueDateEdition(next.Date, next.Edition)
+	}
+
+	var self = schema.IssueDateEdition(date, ed)
+	var stored = watcher.CurrentScanner().LookupIssues(&schema.Key{LCCN: i.LCCN()})
+	for _, si := range closestLive(stored, self, prevKey, nextKey) {
+		var n, err = liveNeighbor(conf.IssueCachePath, si, fetchLive)
+		if err != nil {
+			logger.Warnf("Unable to read live issue metadata for %q: %s", si.Location, err)
+			continue
+		}
+		if n != nil {
+			list = append(list, n)
+		}
+	}
+
+	return list
+}
+
+// closestLive returns the live issues just before and after self (a key from
+// schema.IssueDateEdition), ignoring any which aren't closer than the
+// in-process issues' keys, prevKey and nextKey.  Blank keys mean there's no
+// in-process issue on that side.
+func closestLive(stored schema.IssueList, self, prevKey, nextKey string) []*schema.Issue {
+	var prev, next *schema.Issue
+	for _, si := range stored {
+		if si.WorkflowStep != schema.WSInProduction {
+			continue
+		}
+		var k = schema.IssueDateEdition(si.RawDate, si.Edition)
+		if k < self && k > prevKey {
+			prev, prevKey = si, k
+		}
+		if k > self && (nextKey == "" || k < nextKey) {
+			next, nextKey = si, k
+		}
+	}
+
+	var list []*schema.Issue
+	for _, si := range []*schema.Issue{prev, next} {
+		if si != nil {
+			list = append(list, si)
+		}
+	}
+	return list
+}
+
+// liveNeighbor reads a live issue's JSON to get its volume and issue number.
+// If fetch is false, only an already-cached copy is read, and nil is returned
+// when there isn't one.
+func liveNeighbor(cachePath string, si *schema.Issue, fetch bool) (*issuesequence.Neighbor, error) {
+	var c = httpcache.NewClient(cachePath, 0)
+	var r = httpcache.AutoRequest(si.Location, "issues")
+	if !fetch {
+		var _, err = os.Stat(r.CachePath(cachePath))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	var contents, err = c.GetCachedBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to GET %q: %s", si.Location, err)
+	}
+	var ij *chronam.IssueJSON
+	ij, err = chronam.ParseIssueJSON(contents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in %q: %s", si.Location, err)
+	}
+
+	return &issuesequence.Neighbor{Date: si.RawDate, Edition: si.Edition, Volume: ij.Volume, Issue: ij.Number}, nil
+}
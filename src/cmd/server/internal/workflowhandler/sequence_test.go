@@ -0,0 +1,109 @@
+package workflowhandler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/httpcache"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuesequence"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func liveIssue(date string, ed int, ws schema.WorkflowStep) *schema.Issue {
+	return &schema.Issue{RawDate: date, Edition: ed, WorkflowStep: ws, Location: "https://oni.example.edu/" + date + ".json"}
+}
+
+func TestClosestLive(t *testing.T) {
+	var stored = schema.IssueList{
+		liveIssue("2021-01-01", 1, schema.WSInProduction),
+		liveIssue("2021-01-04", 1, schema.WSInProduction),
+		liveIssue("2021-01-05", 1, schema.WSSFTP),
+		liveIssue("2021-01-08", 1, schema.WSInProduction),
+		liveIssue("2021-01-09", 1, schema.WSInProduction),
+	}
+	var self = schema.IssueDateEdition("2021-01-06", 1)
+
+	var tests = map[string]struct {
+		prevKey  string
+		nextKey  string
+		expected []string
+	}{
+		"no in-process neighbors": {
+			expected: []string{"2021-01-04", "2021-01-08"},
+		},
+		"in-process neighbors are closer": {
+			prevKey:  schema.IssueDateEdition("2021-01-05", 1),
+			nextKey:  schema.IssueDateEdition("2021-01-07", 1),
+			expected: nil,
+		},
+		"only the next live issue is closer": {
+			prevKey:  schema.IssueDateEdition("2021-01-05", 1),
+			nextKey:  schema.IssueDateEdition("2021-01-10", 1),
+			expected: []string{"2021-01-08"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, si := range closestLive(stored, self, tc.prevKey, tc.nextKey) {
+				got = append(got, si.RawDate)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("Expected live issues %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLiveNeighbor(t *testing.T) {
+	var requests int
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"date_issued": "2021-01-04", "volume": "12", "number": "34", "edition": 1}`)
+	}))
+	defer srv.Close()
+
+	var cachePath, err = ioutil.TempDir("", "sequence-")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(cachePath)
+
+	var si = &schema.Issue{RawDate: "2021-01-04", Edition: 1, Location: srv.URL + "/lccn/sn12345678/2021-01-04/ed-1.json"}
+
+	var n *issuesequence.Neighbor
+	n, err = liveNeighbor(cachePath, si, false)
+	if err != nil || n != nil {
+		t.Fatalf("Expected no neighbor or error without a cached copy, got %#v / %v", n, err)
+	}
+	if requests != 0 {
+		t.Fatalf("Expected no requests without fetching, got %d", requests)
+	}
+
+	n, err = liveNeighbor(cachePath, si, true)
+	if err != nil {
+		t.Fatalf("Unexpected error fetching: %s", err)
+	}
+	if n.Volume != "12" || n.Issue != "34" {
+		t.Fatalf("Expected volume 12, issue 34, got %#v", n)
+	}
+	var cacheFile = httpcache.AutoRequest(si.Location, "issues").CachePath(cachePath)
+	if _, err = os.Stat(cacheFile); err != nil {
+		t.Fatalf("Expected %q to be cached: %s", filepath.Base(cacheFile), err)
+	}
+
+	n, err = liveNeighbor(cachePath, si, false)
+	if err != nil || n == nil || n.Issue != "34" {
+		t.Fatalf("Expected the cached neighbor, got %#v / %v", n, err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected one request in total, got %d", requests)
+	}
+}
@@ -11,9 +11,15 @@ import (
 
 	"github.com/gorilla/mux"
 	flags "github.com/jessevdk/go-flags"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/audithandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/batchhandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/batchplanhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/issuefinderhandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/issuegaphandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/metricshandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/mochandler"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/publisherhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/settings"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/titlehandler"
@@ -22,8 +28,11 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/workflowhandler"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuewatcher"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/sftpd"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/web/webutil"
 )
 
@@ -48,6 +57,8 @@ func getConf() {
 	if err != nil {
 		logger.Fatalf("Config error: %s", err)
 	}
+	cli.ConfigureLogger(conf)
+	shell.SetTimeouts(conf.ExecTimeouts)
 
 	err = dbi.Connect(conf.DatabaseConnect)
 	if err != nil {
@@ -76,6 +87,19 @@ func makeRedirect(dest string, code int) http.Handler {
 	})
 }
 
+// startSFTPServer runs the built-in SFTP server in the background.  Failure
+// to start it is fatal, since publishers would otherwise be unable to upload.
+func startSFTPServer() {
+	var s, err = sftpd.New(conf.PDFUploadPath, conf.SFTPHostKeyPath)
+	if err != nil {
+		logger.Fatalf("Unable to start SFTP server: %s", err)
+	}
+	go func() {
+		var err = s.ListenAndServe(conf.SFTPListenAddress)
+		logger.Fatalf("SFTP server stopped: %s", err)
+	}()
+}
+
 func startServer() {
 	var r = mux.NewRouter()
 	var hp = webutil.HomePath()
@@ -93,11 +117,20 @@ func startServer() {
 	var staticPrefix = path.Join(hp, "static")
 	r.NewRoute().PathPrefix(staticPrefix).Handler(http.StripPrefix(staticPrefix, fileServer))
 
+	// When we can watch the upload directories for changes, full scans are
+	// just a safety net and can happen far less often
 	var watcher = issuewatcher.New(conf)
-	go watcher.Watch(5 * time.Minute)
+	var interval = 5 * time.Minute
+	var err = watcher.WatchFilesystem()
+	if err != nil {
+		logger.Warnf("Unable to watch upload directories; falling back to periodic scans: %s", err)
+	} else {
+		interval = time.Hour
+	}
+	go watcher.Watch(interval)
 
 	var waited, lastWaited int
-	for watcher.Scanner.Finder.Issues == nil {
+	for !watcher.Ready() {
 		if waited == 5 {
 			logger.Infof("Waiting for initial issue scan to complete.  This can take " +
 				"several minutes if the issues haven't been scanned in a while.  If this " +
@@ -111,14 +144,26 @@ func startServer() {
 		time.Sleep(1 * time.Second)
 	}
 
+	if conf.SFTPListenAddress != "" {
+		startSFTPServer()
+	}
+
 	// Set up routing for various "sub-apps"
 	uploadedissuehandler.Setup(r, path.Join(hp, "uploadedissues"), conf, watcher)
 	workflowhandler.Setup(r, path.Join(hp, "workflow"), conf, watcher)
 	issuefinderhandler.Setup(r, path.Join(hp, "find"), conf, watcher)
+	issuegaphandler.Setup(r, path.Join(hp, "gaps"), conf, watcher)
+	batchplanhandler.Setup(r, path.Join(hp, "batchplan"), conf)
+	batchhandler.Setup(r, path.Join(hp, "batches"), conf)
 	mochandler.Setup(r, path.Join(hp, "mocs"), conf)
 	userhandler.Setup(r, path.Join(hp, "users"), conf)
 	titlehandler.Setup(r, path.Join(hp, "titles"), conf)
 	audithandler.Setup(r, path.Join(hp, "logs"), conf)
+	publisherhandler.Setup(r, path.Join(hp, "publisher"), conf, watcher)
+
+	var monitor = diskspace.NewMonitor(conf, time.Minute)
+	responder.DiskMonitor = monitor
+	metricshandler.Setup(r, path.Join(hp, "metrics"), monitor)
 
 	r.NewRoute().Path(hp).HandlerFunc(home)
 
@@ -130,7 +175,7 @@ func startServer() {
 
 	logger.Infof("Listening on %s", conf.BindAddress)
 	// TODO: Get rid of this use of global http package state
-	if err := http.ListenAndServe(conf.BindAddress, nil); err != nil {
+	if err = http.ListenAndServe(conf.BindAddress, nil); err != nil {
 		logger.Fatalf("Error starting listener: %s", err)
 	}
 }
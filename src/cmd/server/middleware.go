@@ -1,12 +1,17 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/cmd/server/internal/responder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 )
 
+var startTime = time.Now()
+
 // nocache is a Middleware function to send back no-cache header
 func nocache(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -15,14 +20,29 @@ func nocache(next http.Handler) http.Handler {
 	})
 }
 
+var lastRequestID uint64
+
+// nextRequestID returns a unique-enough id for correlating a request's log
+// entries: the server's start time plus a counter
+func nextRequestID() string {
+	return fmt.Sprintf("%x-%d", startTime.Unix(), atomic.AddUint64(&lastRequestID, 1))
+}
+
+// logMiddleware assigns each request an id, attaches it to the request's
+// context for use in logging, and logs the request
 func logMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rid = nextRequestID()
+		w.Header().Set("X-Request-ID", rid)
+		r = r.WithContext(logger.WithFields(r.Context(), logger.Fields{RequestID: rid}))
+
+		var l = logger.FromContext(r.Context(), "server")
 		var u = responder.GetUserLogin(w, r)
 		var ip = responder.GetUserIP(r)
 		if u != "" {
-			logger.Infof("Request: [%s] [%s] %s", u, ip, r.URL)
+			l.Infof("Request: [%s] [%s] %s", u, ip, r.URL)
 		} else {
-			logger.Infof("Request: [nil] [%s] %s", ip, r.URL)
+			l.Infof("Request: [nil] [%s] %s", ip, r.URL)
 		}
 		next.ServeHTTP(w, r)
 	})
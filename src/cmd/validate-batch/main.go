@@ -0,0 +1,62 @@
+// validate-batch checks batch directories' XML against NDNP's requirements,
+// just as the job runner does before a batch is packaged, and prints every
+// problem found.  It doesn't need the batches to be in NCA's database, so it
+// can be used on batches built elsewhere.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/cli"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/ndnpxml"
+)
+
+// Command-line options
+type _opts struct {
+	cli.BaseOptions
+	Batches []string `short:"b" long:"batch" description:"path to a batch directory; may be repeated" required:"true"`
+	SkipXSD bool     `long:"skip-xsd" description:"only check NDNP's profile rules, even if XMLLINT and NDNP_SCHEMA are set"`
+}
+
+var opts _opts
+
+func main() {
+	var c = cli.New(&opts)
+	c.AppendUsage("Checks each batch's batch XML, issue and reel METS, and OCR files.  " +
+		"If XMLLINT and NDNP_SCHEMA are set, the METS and OCR files are also validated " +
+		"against the METS, MODS, and ALTO schemas.  Exits with a non-zero status if " +
+		"any batch has problems.")
+	var conf = c.GetConf()
+
+	var v = ndnpxml.New(conf.XMLLint, conf.NDNPSchema)
+	if opts.SkipXSD {
+		v.XMLLint = ""
+	}
+
+	var failed bool
+	for _, dir := range opts.Batches {
+		var problems, err = v.ValidateBatch(dir, filepath.Base(filepath.Clean(dir)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to validate %q: %s\n", dir, err)
+			failed = true
+			continue
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%s: valid\n", dir)
+			continue
+		}
+
+		failed = true
+		fmt.Printf("%s: %d problem(s)\n", dir, len(problems))
+		for _, p := range problems {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
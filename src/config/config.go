@@ -5,9 +5,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/uoregon-libraries/gopkg/bashconf"
+	"github.com/uoregon-libraries/gopkg/fileutil"
 )
 
 // Config holds the configuration needed for this application to work
@@ -20,17 +25,59 @@ type Config struct {
 	// to int is easier
 	DBPort int `setting:"DB_PORT" type:"int"`
 
+	// Logging: format is "text" or "json"; levels is a list like
+	// "INFO,jobs=DEBUG" (see the logger package for details)
+	LogFormat string `setting:"LOG_FORMAT"`
+	LogLevels string `setting:"LOG_LEVELS"`
+
 	// Binary paths
 	GhostScript   string `setting:"GHOSTSCRIPT"`
 	OPJCompress   string `setting:"OPJ_COMPRESS"`
 	OPJDecompress string `setting:"OPJ_DECOMPRESS"`
 
+	// Tesseract is optional; when set, page images uploaded by publishers are
+	// OCRed as they're converted to PDFs
+	Tesseract string `setting:"TESSERACT"`
+
+	// XMLLint and NDNPSchema are optional; when both are set, batches' METS
+	// and OCR XML are validated against the schema before being packaged
+	XMLLint    string `setting:"XMLLINT"`
+	NDNPSchema string `setting:"NDNP_SCHEMA"`
+
+	// ExecTimeouts is built from the EXEC_TIMEOUTS setting, and tells us how
+	// long a given binary may run before we kill it
+	ExecTimeouts map[string]time.Duration
+
 	// Web configuration
 	Webroot     string `setting:"WEBROOT" type:"url"`
 	BindAddress string `setting:"BIND_ADDRESS"`
 	IIIFBaseURL string `setting:"IIIF_BASE_URL" type:"url"`
 	NewsWebroot string `setting:"NEWS_WEBROOT" type:"url"`
 
+	// StagingNewsWebroot is optional; when set, NCA scans the staging site just
+	// like production so it can tell when batches have been loaded
+	StagingNewsWebroot string `setting:"STAGING_NEWS_WEBROOT"`
+
+	// ONI commands are optional shell command templates for loading batches
+	// into, and purging batches from, ONI staging and production
+	ONIStagingLoadCommand     string `setting:"ONI_STAGING_LOAD_COMMAND"`
+	ONIStagingPurgeCommand    string `setting:"ONI_STAGING_PURGE_COMMAND"`
+	ONIProductionLoadCommand  string `setting:"ONI_PRODUCTION_LOAD_COMMAND"`
+	ONIProductionPurgeCommand string `setting:"ONI_PRODUCTION_PURGE_COMMAND"`
+
+	// Object storage is optional; when configured, batches which pass QC and
+	// archived originals are copied there, and closing live batches requires a
+	// confirmed copy
+	ObjectStoreURL       string `setting:"OBJECT_STORE_URL"`
+	ObjectStoreEndpoint  string `setting:"OBJECT_STORE_ENDPOINT"`
+	ObjectStoreRegion    string `setting:"OBJECT_STORE_REGION"`
+	ObjectStoreAccessKey string `setting:"OBJECT_STORE_ACCESS_KEY"`
+	ObjectStoreSecretKey string `setting:"OBJECT_STORE_SECRET_KEY"`
+
+	// Built-in SFTP server: disabled unless a listen address is given
+	SFTPListenAddress string `setting:"SFTP_LISTEN_ADDRESS"`
+	SFTPHostKeyPath   string `setting:"SFTP_HOST_KEY"`
+
 	// MARC location(s) for getting XML for unknown titles
 	MARCLocation1 string `setting:"MARC_LOCATION_1"`
 	MARCLocation2 string `setting:"MARC_LOCATION_2"`
@@ -46,6 +93,7 @@ type Config struct {
 	IssueCachePath       string `setting:"ISSUE_CACHE_PATH" type:"path"`
 	AppRoot              string `setting:"APP_ROOT" type:"path"`
 	METSXMLTemplatePath  string `setting:"METS_XML_TEMPLATE_PATH" type:"file"`
+	ReelXMLTemplatePath  string `setting:"REEL_XML_TEMPLATE_PATH" type:"file"`
 	BatchXMLTemplatePath string `setting:"BATCH_XML_TEMPLATE_PATH" type:"file"`
 
 	// Issue processor / batch maker rules
@@ -53,6 +101,21 @@ type Config struct {
 	PDFBatchMARCOrgCode string `setting:"PDF_BATCH_MARC_ORG_CODE"`
 	MaxBatchSize        int    `setting:"MAX_BATCH_SIZE" type:"int"`
 	MinBatchSize        int    `setting:"MIN_BATCH_SIZE" type:"int"`
+	MetadataRulesPath   string `setting:"METADATA_RULES_PATH"`
+	BatchStrategy       string `setting:"BATCH_STRATEGY"`
+
+	// MaxBatchBytes and MaxBatchIssues are optional extra batch limits, built
+	// from MAX_BATCH_BYTES and MAX_BATCH_ISSUES; zero means no limit
+	MaxBatchBytes  int64
+	MaxBatchIssues int
+
+	// FreeSpaceMinimum and FreeSpaceWarning are built from FREE_SPACE_MINIMUM
+	// and FREE_SPACE_WARNING, and map path settings (e.g., "WORKFLOW_PATH") to
+	// byte counts.  The "default" key applies to any path not listed.  Job
+	// runners pause work which writes to a path below its minimum, and the web
+	// app warns when a path is below its warning level.
+	FreeSpaceMinimum map[string]int64
+	FreeSpaceWarning map[string]int64
 
 	// Derivative generation rules
 	DPI           int     `setting:"DPI" type:"int"`
@@ -82,10 +145,70 @@ func Parse(filename string) (*Config, error) {
 	c.DatabaseConnect = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", bc.Get("DB_USER"),
 		bc.Get("DB_PASSWORD"), bc.Get("DB_HOST"), c.DBPort, bc.Get("DB_DATABASE"))
 
+	c.ExecTimeouts, err = parseTimeouts(bc.Get("EXEC_TIMEOUTS"))
+	if err != nil {
+		errors = append(errors, fmt.Sprintf("invalid EXEC_TIMEOUTS: %s", err))
+	}
+
+	if c.StagingNewsWebroot != "" {
+		var u, err = url.Parse(c.StagingNewsWebroot)
+		if err != nil || u.Host == "" || !strings.HasPrefix(u.Scheme, "http") {
+			errors = append(errors, "invalid STAGING_NEWS_WEBROOT: must be blank or a full http(s) URL")
+		}
+	}
+
+	for name, cmd := range map[string]string{
+		"ONI_STAGING_LOAD_COMMAND":     c.ONIStagingLoadCommand,
+		"ONI_STAGING_PURGE_COMMAND":    c.ONIStagingPurgeCommand,
+		"ONI_PRODUCTION_LOAD_COMMAND":  c.ONIProductionLoadCommand,
+		"ONI_PRODUCTION_PURGE_COMMAND": c.ONIProductionPurgeCommand,
+	} {
+		var _, err = template.New(name).Parse(cmd)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid %s: %s", name, err))
+		}
+	}
+
+	errors = append(errors, c.validateObjectStore()...)
+
+	if c.NDNPSchema != "" {
+		if c.XMLLint == "" {
+			errors = append(errors, "invalid XMLLINT: must be set when NDNP_SCHEMA is set")
+		}
+		if !fileutil.IsFile(c.NDNPSchema) {
+			errors = append(errors, "invalid NDNP_SCHEMA: must be blank or the path to an XSD file")
+		}
+	}
+
+	if c.SFTPListenAddress != "" && c.SFTPHostKeyPath == "" {
+		errors = append(errors, "invalid SFTP_HOST_KEY: must be set when SFTP_LISTEN_ADDRESS is set")
+	}
+
 	if c.MinimumIssuePages < 1 {
 		errors = append(errors, "invalid MINIMUM_ISSUE_PAGES: must be numeric and greater than 0")
 	}
 
+	c.MaxBatchBytes, err = parseOptionalInt(bc.Get("MAX_BATCH_BYTES"))
+	if err != nil {
+		errors = append(errors, "invalid MAX_BATCH_BYTES: must be blank or a non-negative number")
+	}
+
+	var issues int64
+	issues, err = parseOptionalInt(bc.Get("MAX_BATCH_ISSUES"))
+	c.MaxBatchIssues = int(issues)
+	if err != nil {
+		errors = append(errors, "invalid MAX_BATCH_ISSUES: must be blank or a non-negative number")
+	}
+
+	c.FreeSpaceMinimum, err = parseSpaceLimits(bc.Get("FREE_SPACE_MINIMUM"))
+	if err != nil {
+		errors = append(errors, fmt.Sprintf("invalid FREE_SPACE_MINIMUM: %s", err))
+	}
+	c.FreeSpaceWarning, err = parseSpaceLimits(bc.Get("FREE_SPACE_WARNING"))
+	if err != nil {
+		errors = append(errors, fmt.Sprintf("invalid FREE_SPACE_WARNING: %s", err))
+	}
+
 	if c.DPI < 72 {
 		errors = append(errors, "invalid DPI: must be numeric and at least 72 (150 or higher is preferred)")
 	}
@@ -100,3 +223,177 @@ func Parse(filename string) (*Config, error) {
 
 	return c, nil
 }
+
+// validateObjectStore checks the OBJECT_STORE_* settings, filling in the
+// default region if it's blank
+func (c *Config) validateObjectStore() []string {
+	if c.ObjectStoreURL == "" {
+		return nil
+	}
+
+	var u, err = url.Parse(c.ObjectStoreURL)
+	if err != nil || (u.Scheme != "s3" && u.Scheme != "file") {
+		return []string{"invalid OBJECT_STORE_URL: must be blank, s3://bucket[/prefix], or file:///path"}
+	}
+	if u.Scheme == "file" {
+		if u.Path == "" || u.Host != "" {
+			return []string{"invalid OBJECT_STORE_URL: file URLs must be absolute, e.g., file:///mnt/archive"}
+		}
+		return nil
+	}
+
+	var errors []string
+	if u.Host == "" {
+		errors = append(errors, "invalid OBJECT_STORE_URL: s3 URLs must include a bucket")
+	}
+	var endpoint *url.URL
+	endpoint, err = url.Parse(c.ObjectStoreEndpoint)
+	if err != nil || endpoint.Host == "" || !strings.HasPrefix(endpoint.Scheme, "http") {
+		errors = append(errors, "invalid OBJECT_STORE_ENDPOINT: must be a full http(s) URL when OBJECT_STORE_URL is an s3 URL")
+	}
+	if c.ObjectStoreAccessKey == "" || c.ObjectStoreSecretKey == "" {
+		errors = append(errors, "invalid OBJECT_STORE_ACCESS_KEY / OBJECT_STORE_SECRET_KEY: both must be set when OBJECT_STORE_URL is an s3 URL")
+	}
+	if c.ObjectStoreRegion == "" {
+		c.ObjectStoreRegion = "us-east-1"
+	}
+
+	return errors
+}
+
+// parseTimeouts converts a string like "default=2h,gs=30m" into a map of
+// binary names to durations
+func parseTimeouts(s string) (map[string]time.Duration, error) {
+	var m = make(map[string]time.Duration)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var kv = strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("%q must be in the form <binary>=<duration>", part)
+		}
+		var d, err = time.ParseDuration(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s", part, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("%q: duration cannot be negative", part)
+		}
+		m[strings.TrimSpace(kv[0])] = d
+	}
+
+	return m, nil
+}
+
+// SpaceSettings lists the path settings which free space limits can be set
+// for: the locations NCA's jobs write to
+var SpaceSettings = []string{
+	"WORKFLOW_PATH",
+	"BATCH_OUTPUT_PATH",
+	"PDF_PAGE_REVIEW_PATH",
+	"ORIGINAL_PDF_BACKUP_PATH",
+	"ERRORED_ISSUES_PATH",
+}
+
+// SpaceSettingPath returns the configured path for one of the SpaceSettings
+func (c *Config) SpaceSettingPath(setting string) string {
+	switch setting {
+	case "WORKFLOW_PATH":
+		return c.WorkflowPath
+	case "BATCH_OUTPUT_PATH":
+		return c.BatchOutputPath
+	case "PDF_PAGE_REVIEW_PATH":
+		return c.PDFPageReviewPath
+	case "ORIGINAL_PDF_BACKUP_PATH":
+		return c.PDFBackupPath
+	case "ERRORED_ISSUES_PATH":
+		return c.ErroredIssuesPath
+	}
+	return ""
+}
+
+// SpaceLimits returns the minimum and warning free space for the given path
+// setting, falling back to the default limits.  Zero means no limit.
+func (c *Config) SpaceLimits(setting string) (min, warn int64) {
+	var limit = func(m map[string]int64) int64 {
+		if n, ok := m[setting]; ok {
+			return n
+		}
+		return m["default"]
+	}
+	return limit(c.FreeSpaceMinimum), limit(c.FreeSpaceWarning)
+}
+
+// parseSpaceLimits converts a string like "default=10G,BATCH_OUTPUT_PATH=100G"
+// into a map of path settings to byte counts
+func parseSpaceLimits(s string) (map[string]int64, error) {
+	var m = make(map[string]int64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var kv = strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%q must be in the form <setting>=<size>", part)
+		}
+		var key = strings.TrimSpace(kv[0])
+		if key != "default" && !validSpaceSetting(key) {
+			return nil, fmt.Errorf("%q: %q must be \"default\" or one of %s", part, key, strings.Join(SpaceSettings, ", "))
+		}
+		var n, err = parseSize(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s", part, err)
+		}
+		m[key] = n
+	}
+
+	return m, nil
+}
+
+func validSpaceSetting(key string) bool {
+	for _, setting := range SpaceSettings {
+		if key == setting {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize converts a byte count with an optional binary unit suffix (K, M,
+// G, or T) to a number, e.g., "10G" is 10 * 1024^3
+func parseSize(s string) (int64, error) {
+	var mult int64 = 1
+	var upper = strings.ToUpper(s)
+	for i, suffix := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(upper, suffix) {
+			mult = 1 << (10 * uint(i+1))
+			upper = strings.TrimSuffix(upper, suffix)
+			break
+		}
+	}
+
+	var n, err = strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid size", s)
+	}
+	return n * mult, nil
+}
+
+// parseOptionalInt converts s to a non-negative integer, treating a blank
+// string as zero
+func parseOptionalInt(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	var n, err = strconv.ParseInt(s, 10, 64)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("%d is negative", n)
+	}
+	return n, err
+}
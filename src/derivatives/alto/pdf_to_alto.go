@@ -2,6 +2,7 @@ package alto
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -31,6 +32,9 @@ type Transformer struct {
 	// gets set to the default logger
 	Logger *ltype.Logger
 
+	// Context is used to kill pdftotext when a job is told to stop
+	Context context.Context
+
 	err  error
 	html []byte
 	xml  []byte
@@ -45,6 +49,7 @@ func New(pdfFile, altoFile string, pdfDPI int, imgNo int, overwrite bool) *Trans
 		ImageNumber:        imgNo,
 		OverwriteXML:       overwrite,
 		Logger:             logger.Logger,
+		Context:            context.Background(),
 	}
 }
 
@@ -90,8 +95,9 @@ func (t *Transformer) pdfToText() {
 	}
 	defer os.Remove(tmpfile)
 
-	if !shell.ExecSubgroup("pdftotext", t.Logger, t.PDFFilename, "-bbox-layout", tmpfile) {
-		t.err = fmt.Errorf("unable to run pdftotext")
+	err = shell.ExecSubgroup(t.Context, "pdftotext", t.Logger, t.PDFFilename, "-bbox-layout", tmpfile)
+	if err != nil {
+		t.err = fmt.Errorf("unable to run pdftotext: %w", err)
 		return
 	}
 
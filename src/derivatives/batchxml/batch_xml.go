@@ -12,8 +12,8 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
-// Transformer takes a models.Batch and a list of models.Issues, and generates the XML
-// to a given file
+// Transformer takes a models.Batch, a list of models.Issues, and any
+// microfilm reels the issues came from, and generates the XML to a given file
 type Transformer struct {
 	tmpl    *template.Template
 	outFile string
@@ -23,19 +23,37 @@ type Transformer struct {
 
 type data struct {
 	*models.Batch
-	Issues []*models.Issue
+	Issues []*issue
+	Reels  []*models.Reel
+}
+
+// issue adds the issue's path within the batch so the template doesn't have
+// to know about reels
+type issue struct {
+	*models.Issue
+	Path string
 }
 
 // New returns a batch XML Transformer
 //
-// We need a batch as well as all issues in order to avoid DB lookups, reduce
-// unknowns, and allow for unsaved / faked data
-func New(templatePath string, outputFileName string, batch *models.Batch, issues []*models.Issue) *Transformer {
+// We need a batch as well as all issues and reels in order to avoid DB
+// lookups, reduce unknowns, and allow for unsaved / faked data
+func New(templatePath string, outputFileName string, batch *models.Batch, issues []*models.Issue, reels []*models.Reel) *Transformer {
 	var tmpl = template.New("batch")
 	tmpl.Funcs(
 		template.FuncMap{"incr": func(i int) int { return i + 1 }},
 	)
-	var t = &Transformer{tmpl, outputFileName, &data{batch, issues}, nil}
+
+	var reelByID = make(map[int]*models.Reel)
+	for _, r := range reels {
+		reelByID[r.ID] = r
+	}
+	var d = &data{Batch: batch, Reels: reels}
+	for _, i := range issues {
+		d.Issues = append(d.Issues, &issue{i, i.BatchPath(reelByID[i.ReelID])})
+	}
+
+	var t = &Transformer{tmpl, outputFileName, d, nil}
 	t.tmpl, t.err = tmpl.ParseFiles(templatePath)
 	return t
 }
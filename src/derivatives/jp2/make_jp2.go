@@ -3,6 +3,7 @@
 package jp2
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -38,6 +39,9 @@ type Transformer struct {
 
 	err    error
 	Logger *ltype.Logger
+
+	// Context is used to kill shell commands when a job is told to stop
+	Context context.Context
 }
 
 // New creates a new PDF/TIFF-to-JP2 transformer with default values for the
@@ -54,6 +58,7 @@ func New(source, output string, quality float64, resolution int, overwrite bool)
 		PDFResolution:  resolution,
 		OverwriteJP2:   overwrite,
 		Logger:         logger.Logger,
+		Context:        context.Background(),
 	}
 }
 
@@ -124,7 +129,7 @@ func (t *Transformer) makePNG() {
 		return
 	}
 
-	if !success {
+	if !success && t.err == nil {
 		t.err = fmt.Errorf("failed running PNG shell command")
 		return
 	}
@@ -171,7 +176,9 @@ func (t *Transformer) makeJP2() {
 		return
 	}
 
-	t.err = fmt.Errorf("could not create a valid JP2")
+	if t.err == nil {
+		t.err = fmt.Errorf("could not create a valid JP2")
+	}
 	return
 }
 
@@ -3,32 +3,44 @@
 package jp2
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
 )
 
+// exec runs the given binary, returning true on success.  Timeouts and
+// cancellations are stored in t.err so we don't keep trying other rates when
+// the problem isn't the JP2 encoding.
+func (t *Transformer) exec(binary string, args ...string) bool {
+	var err = shell.ExecSubgroup(t.Context, binary, t.Logger, args...)
+	if errors.Is(err, shell.ErrTimeout) || errors.Is(err, shell.ErrCanceled) {
+		t.err = err
+	}
+	return err == nil
+}
+
 func (t *Transformer) makePNGFromPDF() bool {
-	return shell.ExecSubgroup(t.GhostScript, t.Logger, "-dNOPAUSE", "-dUseCropBox",
+	return t.exec(t.GhostScript, "-dNOPAUSE", "-dUseCropBox",
 		"-sDEVICE=png16m", "-dBackgroundColor=16#ffffff", "-sOutputFile="+t.tmpPNG,
 		fmt.Sprintf("-r%d", t.PDFResolution), "-q", t.SourceFile, "-c", "quit")
 }
 
 func (t *Transformer) makePNGFromTIFF() bool {
-	return shell.ExecSubgroup(t.GraphicsMagick, t.Logger, "convert", "-background", "white",
+	return t.exec(t.GraphicsMagick, "convert", "-background", "white",
 		"-quality", "0", t.SourceFile, t.tmpPNG)
 }
 
 func (t *Transformer) makeJP2FromPNG(rate float64) bool {
-	return shell.ExecSubgroup(t.OPJCompress, t.Logger, "-i", t.tmpPNG, "-o", t.tmpJP2, "-t",
+	return t.exec(t.OPJCompress, "-i", t.tmpPNG, "-o", t.tmpJP2, "-t",
 		"1024,1024", "-r", fmt.Sprintf("%0.3f", rate))
 }
 
 func (t *Transformer) makeJP2FromPNGDashI(rate float64) bool {
-	return shell.ExecSubgroup(t.OPJCompress, t.Logger, "-i", t.tmpPNG, "-o", t.tmpJP2, "-t",
+	return t.exec(t.OPJCompress, "-i", t.tmpPNG, "-o", t.tmpJP2, "-t",
 		"1024,1024", "-r", fmt.Sprintf("%0.3f", rate), "-I")
 }
 
 func (t *Transformer) testJP2Decompress() bool {
-	return shell.ExecSubgroup(t.OPJDecompress, t.Logger, "-i", t.tmpJP2, "-r", "4", "-o", t.tmpPNGTest)
+	return t.exec(t.OPJDecompress, "-i", t.tmpJP2, "-r", "4", "-o", t.tmpPNGTest)
 }
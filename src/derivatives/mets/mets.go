@@ -16,11 +16,11 @@ import (
 // RFC3339 without a timezone
 const TimeFormat = "2006-01-02T15:04:05"
 
-// Transformer takes an issue and generates METS XML to a given file
+// Transformer takes an issue or reel and generates METS XML to a given file
 type Transformer struct {
 	tmpl    *template.Template
 	outFile string
-	d       *data
+	d       interface{}
 	err     error
 }
 
@@ -28,6 +28,13 @@ type data struct {
 	Issue      *models.Issue
 	Pages      []*Page
 	Title      *models.Title
+	Reel       *models.Reel
+	NowRFC3339 string
+}
+
+type reelData struct {
+	Reel       *models.Reel
+	Targets    []*Target
 	NowRFC3339 string
 }
 
@@ -57,6 +64,38 @@ func New(templatePath string, outputFileName string, issue *models.Issue, title
 	return t
 }
 
+// WithReel sets the microfilm reel an issue was scanned from so its METS can
+// describe the original as microfilm rather than print
+func (t *Transformer) WithReel(r *models.Reel) *Transformer {
+	if d, ok := t.d.(*data); ok {
+		d.Reel = r
+	}
+	return t
+}
+
+// NewReel returns a Transformer for generating a microfilm reel's METS XML.
+// targetPrefixes are the filenames, minus extension, of the reel's target
+// images, in reel order.
+func NewReel(templatePath string, outputFileName string, reel *models.Reel, targetPrefixes []string, createDate time.Time) *Transformer {
+	var tmpl = template.New("reelxml")
+	var targets = make([]*Target, len(targetPrefixes))
+	for i, prefix := range targetPrefixes {
+		targets[i] = &Target{Number: i + 1, Prefix: prefix}
+	}
+
+	var t = &Transformer{
+		tmpl:    tmpl,
+		outFile: outputFileName,
+		d: &reelData{
+			Reel:       reel,
+			Targets:    targets,
+			NowRFC3339: createDate.Format(TimeFormat),
+		},
+	}
+	t.tmpl, t.err = tmpl.ParseFiles(templatePath)
+	return t
+}
+
 // Transform generates the METS XML
 func (t *Transformer) Transform() error {
 	if t.err != nil {
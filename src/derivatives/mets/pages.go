@@ -23,6 +23,13 @@ func (p *Page) HasLabel() bool {
 	return p.Label != "0" && p.Label != ""
 }
 
+// Target represents one of a microfilm reel's target images: its position on
+// the reel and its filename prefix
+type Target struct {
+	Number int
+	Prefix string
+}
+
 func stripExt(path string) string {
 	for i := len(path) - 1; i >= 0 && !os.IsPathSeparator(path[i]); i-- {
 		if path[i] == '.' {
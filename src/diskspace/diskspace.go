@@ -0,0 +1,198 @@
+// Package diskspace checks free space on the filesystems NCA writes to, so
+// job runners can pause work before a full disk leaves half-written
+// directories behind
+package diskspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/humanize"
+)
+
+// Volume is a configured location NCA writes to, along with its free space
+// limits.  Minimum and Warning are byte counts; zero means no limit.
+type Volume struct {
+	Setting string
+	Path    string
+	Minimum int64
+	Warning int64
+}
+
+// Volumes returns the locations NCA's jobs write to, with their configured
+// limits
+func Volumes(c *config.Config) []*Volume {
+	var list []*Volume
+	for _, setting := range config.SpaceSettings {
+		var v = &Volume{Setting: setting, Path: c.SpaceSettingPath(setting)}
+		v.Minimum, v.Warning = c.SpaceLimits(setting)
+		list = append(list, v)
+	}
+	return list
+}
+
+// Usage is a volume's state when it was last checked
+type Usage struct {
+	*Volume
+	Free  int64
+	Total int64
+	Err   error
+}
+
+// Check reads the volume's current free space
+func (v *Volume) Check() *Usage {
+	var u = &Usage{Volume: v}
+	u.Free, u.Total, u.Err = statfs(v.Path)
+	return u
+}
+
+// Low returns true if the volume has less free space than its minimum, in
+// which case jobs writing to it should wait
+func (u *Usage) Low() bool {
+	return u.Err == nil && u.Minimum > 0 && u.Free < u.Minimum
+}
+
+// Warn returns true if the volume is low, or has less free space than its
+// warning level
+func (u *Usage) Warn() bool {
+	return u.Low() || (u.Err == nil && u.Warning > 0 && u.Free < u.Warning)
+}
+
+// TooSmall returns true if writing n bytes would put the volume below its
+// minimum even if the volume were empty
+func (u *Usage) TooSmall(n int64) bool {
+	return u.Err == nil && u.Total > 0 && u.Total-n < u.Minimum
+}
+
+// Message describes the volume's state for people
+func (u *Usage) Message() string {
+	if u.Err != nil {
+		return fmt.Sprintf("%s (%s): unable to check free space: %s", u.Setting, u.Path, u.Err)
+	}
+	var msg = fmt.Sprintf("%s (%s) has %s free", u.Setting, u.Path, humanize.Bytes(u.Free))
+	if u.Low() {
+		return msg + fmt.Sprintf(", below the minimum of %s; jobs which write there are paused", humanize.Bytes(u.Minimum))
+	}
+	if u.Warn() {
+		return msg + fmt.Sprintf(", below the warning level of %s", humanize.Bytes(u.Warning))
+	}
+	return msg
+}
+
+// Fits returns true if the filesystem holding path will still have its
+// minimum free space after n more bytes are written.  The minimum is that of
+// the configured volume containing path, or the default minimum for paths
+// outside all volumes.  A usage is returned so callers can report the
+// problem when there isn't enough room.
+func Fits(c *config.Config, path string, n int64) (bool, *Usage) {
+	var v = &Volume{Setting: "default", Path: path}
+	v.Minimum, v.Warning = c.SpaceLimits("default")
+	var longest int
+	for _, vol := range Volumes(c) {
+		if vol.Path != "" && len(vol.Path) > longest && within(path, vol.Path) {
+			longest = len(vol.Path)
+			v.Setting, v.Minimum, v.Warning = vol.Setting, vol.Minimum, vol.Warning
+		}
+	}
+
+	var u = v.Check()
+	if u.Err != nil {
+		return false, u
+	}
+	return u.Free-n >= u.Minimum, u
+}
+
+// within returns true if path is dir or is anywhere under it
+func within(path, dir string) bool {
+	var rel, err = filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// DirBytes returns the total size of all files under dir
+func DirBytes(dir string) (int64, error) {
+	var total int64
+	var err = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// statfs returns the space available to NCA and the total size of the
+// filesystem holding path.  Paths which don't exist yet are checked via their
+// closest existing parent, since jobs often check where they're about to
+// create a directory.
+func statfs(path string) (free, total int64, err error) {
+	path = filepath.Clean(path)
+	for {
+		var _, statErr = os.Stat(path)
+		if statErr == nil || !os.IsNotExist(statErr) || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+
+	var st syscall.Statfs_t
+	err = syscall.Statfs(path, &st)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), int64(st.Blocks) * int64(st.Bsize), nil
+}
+
+// Monitor caches volume usage for callers, like the web app, which check far
+// more often than free space meaningfully changes
+type Monitor struct {
+	sync.Mutex
+	conf    *config.Config
+	maxAge  time.Duration
+	checked time.Time
+	usage   []*Usage
+}
+
+// NewMonitor returns a Monitor which re-checks volumes at most once per
+// maxAge
+func NewMonitor(c *config.Config, maxAge time.Duration) *Monitor {
+	return &Monitor{conf: c, maxAge: maxAge}
+}
+
+// Usage returns the usage of every configured volume
+func (m *Monitor) Usage() []*Usage {
+	m.Lock()
+	defer m.Unlock()
+
+	if time.Since(m.checked) > m.maxAge {
+		m.usage = nil
+		for _, v := range Volumes(m.conf) {
+			m.usage = append(m.usage, v.Check())
+		}
+		m.checked = time.Now()
+	}
+	return m.usage
+}
+
+// Warnings returns a message for each volume with configured limits which is
+// low, below its warning level, or couldn't be checked
+func (m *Monitor) Warnings() []string {
+	var list []string
+	for _, u := range m.Usage() {
+		if u.Minimum == 0 && u.Warning == 0 {
+			continue
+		}
+		if u.Warn() || u.Err != nil {
+			list = append(list, u.Message())
+		}
+	}
+	return list
+}
@@ -0,0 +1,77 @@
+package diskspace
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithin(t *testing.T) {
+	var tests = map[string]struct {
+		path string
+		dir  string
+		want bool
+	}{
+		"same":      {"/mnt/news/workflow", "/mnt/news/workflow", true},
+		"child":     {"/mnt/news/workflow/sn12345678-2010010101", "/mnt/news/workflow", true},
+		"sibling":   {"/mnt/news/workflow-old", "/mnt/news/workflow", false},
+		"parent":    {"/mnt/news", "/mnt/news/workflow", false},
+		"dot-names": {"/mnt/news/workflow/..foo", "/mnt/news/workflow", true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = within(tc.path, tc.dir)
+			if got != tc.want {
+				t.Errorf("within(%q, %q) = %v; want %v", tc.path, tc.dir, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUsageLimits(t *testing.T) {
+	var v = &Volume{Setting: "WORKFLOW_PATH", Path: "/mnt/news/workflow", Minimum: 100, Warning: 500}
+	var tests = map[string]struct {
+		usage *Usage
+		low   bool
+		warn  bool
+	}{
+		"plenty":   {&Usage{Volume: v, Free: 1000}, false, false},
+		"warning":  {&Usage{Volume: v, Free: 400}, false, true},
+		"low":      {&Usage{Volume: v, Free: 50}, true, true},
+		"error":    {&Usage{Volume: v, Err: errors.New("no such device")}, false, false},
+		"no limit": {&Usage{Volume: &Volume{Setting: "WORKFLOW_PATH"}, Free: 0}, false, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.usage.Low() != tc.low {
+				t.Errorf("Low() = %v; want %v", tc.usage.Low(), tc.low)
+			}
+			if tc.usage.Warn() != tc.warn {
+				t.Errorf("Warn() = %v; want %v", tc.usage.Warn(), tc.warn)
+			}
+		})
+	}
+}
+
+func TestTooSmall(t *testing.T) {
+	var v = &Volume{Setting: "WORKFLOW_PATH", Path: "/mnt/news/workflow", Minimum: 100}
+	var tests = map[string]struct {
+		usage *Usage
+		n     int64
+		want  bool
+	}{
+		"fits when empty": {&Usage{Volume: v, Free: 50, Total: 1000}, 800, false},
+		"never fits":      {&Usage{Volume: v, Free: 950, Total: 1000}, 901, true},
+		"unknown total":   {&Usage{Volume: v, Free: 0}, 901, false},
+		"error":           {&Usage{Volume: v, Total: 1000, Err: errors.New("no such device")}, 5000, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.usage.TooSmall(tc.n); got != tc.want {
+				t.Errorf("TooSmall(%d) = %v; want %v", tc.n, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package frequency turns a newspaper's publication frequency, as described in
+// MARC (310/321 $a) or set by an admin, into the dates we expect to find
+// issues for
+package frequency
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned for frequencies which aren't daily or weekly in
+// nature (monthly, irregular, etc.); we can't predict issue dates for those
+var ErrUnsupported = errors.New("frequency is not daily or weekly")
+
+// Frequency describes which days of the week a title publishes.  When a
+// frequency only tells us how many issues come out each week ("weekly",
+// "semiweekly"), Days is empty and PerWeek says how many days to infer from
+// known issues.
+type Frequency struct {
+	Days    []time.Weekday
+	PerWeek int
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "sundays": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "mondays": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday, "tuesdays": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "wednesdays": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday, "thursdays": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "fridays": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "saturdays": time.Saturday,
+}
+
+var perWeek = map[string]int{
+	"weekly":             1,
+	"semiweekly":         2,
+	"semi-weekly":        2,
+	"twice a week":       2,
+	"twice weekly":       2,
+	"two times a week":   2,
+	"three times a week": 3,
+	"three times weekly": 3,
+	"four times a week":  4,
+	"five times a week":  5,
+	"six times a week":   6,
+}
+
+var wordRegex = regexp.MustCompile(`[a-z]+`)
+
+// Parse reads a frequency description such as "Daily (except Sunday)",
+// "Weekly", "Semiweekly", or a list of days like "Mon, Wed, Fri"
+func Parse(s string) (Frequency, error) {
+	var f Frequency
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimRight(s, " .,;:")
+	if s == "" {
+		return f, errors.New("no frequency given")
+	}
+
+	var main, except = s, ""
+	if idx := strings.Index(s, "except"); idx >= 0 {
+		main, except = s[:idx], s[idx+len("except"):]
+	}
+	main = strings.TrimSpace(strings.Trim(strings.TrimSpace(main), "(),"))
+
+	if main == "daily" {
+		var skip = parseDays(except)
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if !skip[d] {
+				f.Days = append(f.Days, d)
+			}
+		}
+		if strings.Contains(except, "weekend") {
+			f.Days = removeDays(f.Days, time.Saturday, time.Sunday)
+		}
+		return f, nil
+	}
+
+	if n, ok := perWeek[main]; ok {
+		f.PerWeek = n
+		return f, nil
+	}
+
+	// Last chance: a plain list of days
+	for _, word := range wordRegex.FindAllString(s, -1) {
+		if _, ok := dayNames[word]; !ok && word != "and" {
+			return f, ErrUnsupported
+		}
+	}
+	var days = parseDays(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if days[d] {
+			f.Days = append(f.Days, d)
+		}
+	}
+	if len(f.Days) == 0 {
+		return f, ErrUnsupported
+	}
+	return f, nil
+}
+
+// parseDays returns the weekdays named in s
+func parseDays(s string) map[time.Weekday]bool {
+	var days = make(map[time.Weekday]bool)
+	for _, word := range wordRegex.FindAllString(s, -1) {
+		if d, ok := dayNames[word]; ok {
+			days[d] = true
+		}
+	}
+	return days
+}
+
+func removeDays(days []time.Weekday, remove ...time.Weekday) []time.Weekday {
+	var out []time.Weekday
+	for _, d := range days {
+		var keep = true
+		for _, r := range remove {
+			if d == r {
+				keep = false
+			}
+		}
+		if keep {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Weekdays returns the days a title publishes.  If the frequency doesn't name
+// specific days, the most common weekdays among the known issue dates are
+// used; with no known dates, the result is empty.
+func (f Frequency) Weekdays(known []time.Time) []time.Weekday {
+	if len(f.Days) > 0 {
+		return f.Days
+	}
+
+	var counts [7]int
+	for _, dt := range known {
+		counts[dt.Weekday()]++
+	}
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if counts[d] > 0 {
+			days = append(days, d)
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool { return counts[days[i]] > counts[days[j]] })
+	if len(days) > f.PerWeek {
+		days = days[:f.PerWeek]
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	return days
+}
+
+// Expected returns every date from start to end, inclusive, which falls on
+// one of the given weekdays
+func Expected(days []time.Weekday, start, end time.Time) []time.Time {
+	var want [7]bool
+	for _, d := range days {
+		want[d] = true
+	}
+
+	var dates []time.Time
+	for dt := start; !dt.After(end); dt = dt.AddDate(0, 0, 1) {
+		if want[dt.Weekday()] {
+			dates = append(dates, dt)
+		}
+	}
+	return dates
+}
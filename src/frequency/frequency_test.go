@@ -0,0 +1,75 @@
+package frequency
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	var sun, mon, tue, wed, thu, fri, sat = time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday
+	var tests = map[string]struct {
+		in   string
+		want Frequency
+		ok   bool
+	}{
+		"daily":           {"Daily", Frequency{Days: []time.Weekday{sun, mon, tue, wed, thu, fri, sat}}, true},
+		"except sunday":   {"Daily (except Sunday)", Frequency{Days: []time.Weekday{mon, tue, wed, thu, fri, sat}}, true},
+		"except weekends": {"Daily (except weekends).", Frequency{Days: []time.Weekday{mon, tue, wed, thu, fri}}, true},
+		"except two days": {"daily except Sat. and Sun.", Frequency{Days: []time.Weekday{mon, tue, wed, thu, fri}}, true},
+		"weekly":          {"Weekly", Frequency{PerWeek: 1}, true},
+		"semiweekly":      {"Semiweekly", Frequency{PerWeek: 2}, true},
+		"three times":     {"Three times a week", Frequency{PerWeek: 3}, true},
+		"day list":        {"Tue, Fri", Frequency{Days: []time.Weekday{tue, fri}}, true},
+		"day list and":    {"Monday and Thursday", Frequency{Days: []time.Weekday{mon, thu}}, true},
+		"monthly":         {"Monthly", Frequency{}, false},
+		"irregular":       {"Irregular", Frequency{}, false},
+		"days plus junk":  {"Tuesday-ish", Frequency{}, false},
+		"empty":           {"", Frequency{}, false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got, err = Parse(tc.in)
+			if tc.ok != (err == nil) {
+				t.Fatalf("Parse(%q): expected ok=%v, got error %v", tc.in, tc.ok, err)
+			}
+			if tc.ok && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Parse(%q): expected %#v, got %#v", tc.in, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWeekdaysInferred(t *testing.T) {
+	var known []time.Time
+	// Two Tuesdays, three Fridays, one stray Wednesday
+	for _, s := range []string{"2021-01-05", "2021-01-12", "2021-01-08", "2021-01-15", "2021-01-22", "2021-01-13"} {
+		var dt, _ = time.Parse("2006-01-02", s)
+		known = append(known, dt)
+	}
+
+	var f = Frequency{PerWeek: 2}
+	var got = f.Weekdays(known)
+	var want = []time.Weekday{time.Tuesday, time.Friday}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got = f.Weekdays(nil); len(got) != 0 {
+		t.Errorf("Expected no days without known issues, got %v", got)
+	}
+}
+
+func TestExpected(t *testing.T) {
+	var start, _ = time.Parse("2006-01-02", "2021-01-01")
+	var end, _ = time.Parse("2006-01-02", "2021-01-14")
+	var got []string
+	for _, dt := range Expected([]time.Weekday{time.Monday, time.Friday}, start, end) {
+		got = append(got, dt.Format("2006-01-02"))
+	}
+	var want = []string{"2021-01-01", "2021-01-04", "2021-01-08", "2021-01-11"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,20 @@
+package humanize
+
+import (
+	"fmt"
+)
+
+// Bytes formats a byte count using the largest sensible binary unit, e.g.,
+// "1.5 GiB"
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	var div, exp = int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,25 @@
+package humanize
+
+import (
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	var tests = map[string]struct {
+		input int64
+		want  string
+	}{
+		"Bytes":     {1023, "1023 B"},
+		"Kibibytes": {1536, "1.5 KiB"},
+		"Gibibytes": {10 << 30, "10.0 GiB"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = Bytes(tc.input)
+			if tc.want != got {
+				t.Errorf("Expected %d to give us %q, got %q", tc.input, tc.want, got)
+			}
+		})
+	}
+}
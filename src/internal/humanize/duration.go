@@ -1,5 +1,5 @@
 // Package humanize is made for giving human-friendly output for otherwise
-// not-so-friendly values - currently just durations
+// not-so-friendly values: durations and byte counts
 package humanize
 
 import (
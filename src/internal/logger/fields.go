@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	l "github.com/uoregon-libraries/gopkg/logger"
+)
+
+// Fields holds correlation data attached to log entries so that a single
+// job, issue, batch, or web request can be traced across all of NCA's logs.
+// Zero values are omitted from output.
+type Fields struct {
+	RunnerID  int32  `json:"runner_id,omitempty"`
+	JobID     int    `json:"job_id,omitempty"`
+	JobType   string `json:"job_type,omitempty"`
+	IssueID   int    `json:"issue_id,omitempty"`
+	IssueKey  string `json:"issue_key,omitempty"`
+	BatchID   int    `json:"batch_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// prefix returns the text-mode tag we've historically put in front of runner
+// and job messages, plus the request id for web requests
+func (f Fields) prefix() string {
+	var s string
+	if f.RunnerID != 0 {
+		s += fmt.Sprintf("[runner %d] ", f.RunnerID)
+	}
+	if f.JobID != 0 {
+		s += fmt.Sprintf("[job %s:%d] ", f.JobType, f.JobID)
+	}
+	if f.RequestID != "" {
+		s += fmt.Sprintf("[req %s] ", f.RequestID)
+	}
+	return s
+}
+
+type ctxKey int
+
+const fieldsKey ctxKey = 1
+
+// WithFields returns a copy of ctx which carries the given fields
+func WithFields(ctx context.Context, f Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey, f)
+}
+
+// FieldsFrom returns the fields stored in ctx, if any
+func FieldsFrom(ctx context.Context) Fields {
+	var f, _ = ctx.Value(fieldsKey).(Fields)
+	return f
+}
+
+// FromContext returns a logger for the given package which attaches whatever
+// fields are stored in ctx
+func FromContext(ctx context.Context, pkg string) *l.Logger {
+	return New(pkg, FieldsFrom(ctx))
+}
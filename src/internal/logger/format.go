@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	l "github.com/uoregon-libraries/gopkg/logger"
+)
+
+// Format identifies how log entries are rendered
+type Format int
+
+// The available formats: traditional human-friendly lines, or one JSON object
+// per line for log aggregators
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+var (
+	m             sync.Mutex
+	output        io.Writer = os.Stderr
+	format                  = FormatText
+	defaultLevel            = l.Debug
+	packageLevels           = make(map[string]l.LogLevel)
+)
+
+// Configure sets the global output format and log levels.  formatName must be
+// "text", "json", or empty (which means "text").  levels is a comma-separated
+// list of level names, each optionally prefixed by a package name and an
+// equals sign, e.g., "INFO,jobs=DEBUG,issuefinder=WARN".  A level without a
+// package name sets the default level.  An empty levels string leaves the
+// levels alone so that commands can set up their own defaults first.
+func Configure(formatName, levels string) error {
+	var f, err = parseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	var def = defaultLevel
+	var pkgLevels = make(map[string]l.LogLevel)
+	for _, part := range strings.Split(levels, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var pkg, lvl string
+		var kv = strings.SplitN(part, "=", 2)
+		if len(kv) == 2 {
+			pkg, lvl = strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		} else {
+			lvl = kv[0]
+		}
+
+		var level = l.LogLevelFromString(strings.ToUpper(lvl))
+		if level == l.Invalid {
+			return fmt.Errorf("invalid log level %q", lvl)
+		}
+		if pkg == "" {
+			def = level
+		} else {
+			pkgLevels[pkg] = level
+		}
+	}
+
+	m.Lock()
+	format = f
+	defaultLevel = def
+	if len(pkgLevels) > 0 {
+		packageLevels = pkgLevels
+	}
+	m.Unlock()
+
+	return nil
+}
+
+// SetLevel changes the default log level, leaving package-specific levels
+// alone
+func SetLevel(level l.LogLevel) {
+	m.Lock()
+	defaultLevel = level
+	m.Unlock()
+}
+
+func parseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return FormatText, fmt.Errorf("invalid log format %q", name)
+}
+
+// Enabled returns true if a message at the given level should be logged for
+// the named package
+func Enabled(pkg string, level l.LogLevel) bool {
+	m.Lock()
+	var min, ok = packageLevels[pkg]
+	if !ok {
+		min = defaultLevel
+	}
+	m.Unlock()
+
+	return level >= min
+}
+
+// hasPackageLevels returns true if any package has its own log level, which
+// means callers' packages need to be looked up to filter their messages
+func hasPackageLevels() bool {
+	m.Lock()
+	defer m.Unlock()
+	return len(packageLevels) > 0
+}
+
+// jsonEntry is what we serialize for structured logs
+type jsonEntry struct {
+	Time    string `json:"time"`
+	App     string `json:"app"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Fields
+}
+
+// Write sends a single log entry to stderr in the configured format.  No
+// filtering is done; callers are responsible for checking Enabled first.
+func Write(f Fields, level l.LogLevel, message string) {
+	var now = time.Now()
+	var line string
+
+	m.Lock()
+	defer m.Unlock()
+
+	switch format {
+	case FormatJSON:
+		var data, err = json.Marshal(jsonEntry{
+			Time:    now.Format(time.RFC3339Nano),
+			App:     appName,
+			Level:   level.String(),
+			Message: message,
+			Fields:  f,
+		})
+		if err != nil {
+			line = fmt.Sprintf(`{"level":"CRIT","message":"unable to encode log entry: %s"}`, err)
+		} else {
+			line = string(data)
+		}
+	default:
+		line = fmt.Sprintf("%s - %s - %s - %s%s", now.Format(l.TimeFormat), appName, level, f.prefix(), message)
+	}
+
+	fmt.Fprintln(output, line)
+}
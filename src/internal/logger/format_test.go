@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	l "github.com/uoregon-libraries/gopkg/logger"
+)
+
+func resetGlobals() {
+	format = FormatText
+	defaultLevel = l.Debug
+	packageLevels = make(map[string]l.LogLevel)
+	output = os.Stderr
+}
+
+func TestConfigureLevels(t *testing.T) {
+	defer resetGlobals()
+
+	var err = Configure("text", "warn, jobs=DEBUG,issuefinder=ERROR")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var tests = map[string]struct {
+		pkg   string
+		level l.LogLevel
+		want  bool
+	}{
+		"default filtered":     {"uploads", l.Info, false},
+		"default allowed":      {"uploads", l.Warn, true},
+		"package override":     {"jobs", l.Debug, true},
+		"package stricter":     {"issuefinder", l.Warn, false},
+		"package stricter err": {"issuefinder", l.Err, true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = Enabled(tc.pkg, tc.level)
+			if got != tc.want {
+				t.Errorf("Enabled(%q, %s): expected %v, got %v", tc.pkg, tc.level, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigureErrors(t *testing.T) {
+	defer resetGlobals()
+
+	if Configure("xml", "") == nil {
+		t.Errorf("Expected an error for an invalid format")
+	}
+	if Configure("json", "jobs=LOUD") == nil {
+		t.Errorf("Expected an error for an invalid level")
+	}
+}
+
+func TestJSONWrite(t *testing.T) {
+	defer resetGlobals()
+	var buf = &bytes.Buffer{}
+	output = buf
+
+	var err = Configure("json", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	Write(Fields{JobID: 5, JobType: "page_split", IssueKey: "sn12345678/2001010101"}, l.Info, `a "quoted" message`)
+
+	var got map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("Unable to parse %q as JSON: %s", buf.String(), err)
+	}
+
+	var expected = map[string]interface{}{
+		"level":     "INFO",
+		"message":   `a "quoted" message`,
+		"job_id":    float64(5),
+		"job_type":  "page_split",
+		"issue_key": "sn12345678/2001010101",
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("Expected %q to be %#v, got %#v", k, v, got[k])
+		}
+	}
+	if _, ok := got["batch_id"]; ok {
+		t.Errorf("Expected zero-value fields to be omitted")
+	}
+}
+
+func TestTextWrite(t *testing.T) {
+	defer resetGlobals()
+	var buf = &bytes.Buffer{}
+	output = buf
+
+	Write(Fields{RunnerID: 2}, l.Warn, "hello")
+	var got = buf.String()
+	if !strings.HasSuffix(got, " - WARN - [runner 2] hello\n") {
+		t.Errorf("Unexpected text output: %q", got)
+	}
+}
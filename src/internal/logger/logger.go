@@ -8,41 +8,113 @@ package logger
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	l "github.com/uoregon-libraries/gopkg/logger"
 )
 
+// appName is what we report as the source of all log entries
+var appName = filepath.Base(os.Args[0])
+
+// root is the Loggable behind the global Logger.  The package-level logging
+// functions write to it directly so they can filter on the caller's package
+// rather than root's.
+var root = &entry{}
+
 // Logger is the global logging object for all of NCA to use.  If we need to
 // change the log level or otherwise customize it, this can be overwritten.
-var Logger = l.New(l.Debug, false)
+var Logger = &l.Logger{Loggable: root}
+
+// New returns a logger which filters messages using the level configured for
+// pkg, and attaches the given fields to every entry it writes
+func New(pkg string, f Fields) *l.Logger {
+	return &l.Logger{Loggable: &entry{pkg: pkg, fields: f}}
+}
+
+// entry is the Loggable behind every logger this package hands out
+type entry struct {
+	pkg    string
+	fields Fields
+}
+
+// Log implements l.Loggable, filtering and then writing the message
+func (e *entry) Log(level l.LogLevel, message string) {
+	if !Enabled(e.pkg, level) {
+		return
+	}
+	Write(e.fields, level, message)
+}
+
+// callerPackage returns the short name of the package which called one of the
+// global logging functions, e.g., "jobs" or "issuefinder".  Commands all
+// report their package as "main", so we return the app name for those
+// instead, allowing things like "run-jobs=DEBUG" to work as expected.
+func callerPackage() string {
+	var pc, _, _, ok = runtime.Caller(3)
+	if !ok {
+		return ""
+	}
+	var fn = runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+
+	// Function names look like "github.com/foo/bar/src/jobs.(*Runner).Watch",
+	// so we have to strip everything up to the last slash, and then everything
+	// after the first dot
+	var name = fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	var dot = strings.Index(name, ".")
+	if dot >= 0 {
+		name = name[:dot]
+	}
+	if name == "main" {
+		return appName
+	}
+	return name
+}
+
+// logf filters based on the caller's package, then writes to the root logger
+func logf(level l.LogLevel, format string, args ...interface{}) {
+	var pkg string
+	if hasPackageLevels() {
+		pkg = callerPackage()
+	}
+	if !Enabled(pkg, level) {
+		return
+	}
+	Write(Fields{}, level, fmt.Sprintf(format, args...))
+}
 
 // Debugf logs a debug-level message
 func Debugf(format string, args ...interface{}) {
-	Logger.Debugf(fmt.Sprintf(format, args...))
+	logf(l.Debug, format, args...)
 }
 
 // Infof logs an info-level message
 func Infof(format string, args ...interface{}) {
-	Logger.Infof(fmt.Sprintf(format, args...))
+	logf(l.Info, format, args...)
 }
 
 // Warnf logs a warn-level message
 func Warnf(format string, args ...interface{}) {
-	Logger.Warnf(fmt.Sprintf(format, args...))
+	logf(l.Warn, format, args...)
 }
 
 // Errorf logs an error-level message
 func Errorf(format string, args ...interface{}) {
-	Logger.Errorf(fmt.Sprintf(format, args...))
+	logf(l.Err, format, args...)
 }
 
 // Criticalf logs a critical-level message
 func Criticalf(format string, args ...interface{}) {
-	Logger.Criticalf(fmt.Sprintf(format, args...))
+	logf(l.Crit, format, args...)
 }
 
 // Fatalf logs a critical-level message, then exits
 func Fatalf(format string, args ...interface{}) {
-	Logger.Fatalf(fmt.Sprintf(format, args...))
+	logf(l.Crit, format, args...)
 	os.Exit(1)
 }
@@ -0,0 +1,59 @@
+// Package testhelper holds the filesystem setup shared by tests which work
+// on copies of on-disk fixtures, such as batches kept under testdata/
+package testhelper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+)
+
+// TempDir creates a temporary directory which is removed when the test ends
+func TempDir(t *testing.T) string {
+	var dir, err = ioutil.TempDir("", "nca-test-")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// CopyDir copies src into a new temporary directory, keeping its base name
+// (which matters for things like batch directories), and returns the copy's
+// path so tests can change files without touching the fixture
+func CopyDir(t *testing.T, src string) string {
+	var dst = filepath.Join(TempDir(t), filepath.Base(src))
+	var err = fileutil.CopyDirectory(src, dst)
+	if err != nil {
+		t.Fatalf("Unable to copy %q to %q: %s", src, dst, err)
+	}
+	return dst
+}
+
+// WriteFile writes contents to fpath, creating any missing directories
+func WriteFile(t *testing.T, fpath, contents string) {
+	var err = os.MkdirAll(filepath.Dir(fpath), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(fpath, []byte(contents), 0644)
+	}
+	if err != nil {
+		t.Fatalf("Unable to write %q: %s", fpath, err)
+	}
+}
+
+// Replace changes the first occurrence of old in fpath to new, failing the
+// test if the file doesn't contain old
+func Replace(t *testing.T, fpath, old, new string) {
+	var data, err = ioutil.ReadFile(fpath)
+	if err != nil {
+		t.Fatalf("Unable to read %q: %s", fpath, err)
+	}
+	if !strings.Contains(string(data), old) {
+		t.Fatalf("%q isn't in %q", old, fpath)
+	}
+	WriteFile(t, fpath, strings.Replace(string(data), old, new, 1))
+}
@@ -0,0 +1,358 @@
+// Package issuearchive expands the ZIP and TAR issue archives publishers may
+// upload in place of an issue folder.  Expansion is kept out of the issue
+// searchers so that reading upload data never changes anything on disk.
+package issuearchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+)
+
+// Limits for expanding publishers' issue archives.  These are far beyond what
+// a single newspaper issue should need, and exist to stop a bad (or hostile)
+// archive from filling the disk.
+const (
+	maxArchiveSize  = 4 << 30
+	maxArchiveFiles = 2000
+)
+
+// archiveQuietPeriod is how long an archive must be untouched before we
+// expand it, so we don't try to read one that's still being uploaded
+const archiveQuietPeriod = 10 * time.Minute
+
+// expandingPrefix is put on the temporary directory an archive is expanded
+// into.  The directory doubles as a lock so two processes scanning the same
+// title don't expand an archive at the same time.
+const expandingPrefix = ".nca-expanding-"
+
+// staleExpansion is how old an expansion directory has to be before we assume
+// its process died and clean it up
+const staleExpansion = time.Hour
+
+var archiveRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\.(zip|tar)$`)
+
+// errArchiveBusy means another process is already expanding an archive
+var errArchiveBusy = errors.New("archive is already being expanded")
+
+// archiveEntry describes a single file or directory inside an archive
+type archiveEntry struct {
+	Name string
+	Mode os.FileMode
+	Size int64
+}
+
+// walkArchive calls fn for each entry in the zip or tar file at loc.  The
+// reader is only valid for regular files, and only until fn returns.
+func walkArchive(loc string, fn func(e archiveEntry, r io.Reader) error) error {
+	if strings.ToLower(filepath.Ext(loc)) == ".zip" {
+		var zr, err = zip.OpenReader(loc)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		for _, f := range zr.File {
+			var e = archiveEntry{Name: f.Name, Mode: f.Mode(), Size: int64(f.UncompressedSize64)}
+			var r io.ReadCloser
+			if e.Mode.IsRegular() {
+				r, err = f.Open()
+				if err != nil {
+					return err
+				}
+			}
+			err = fn(e, r)
+			if r != nil {
+				r.Close()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var f, err = os.Open(loc)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var tr = tar.NewReader(f)
+	for {
+		var hdr *tar.Header
+		hdr, err = tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = fn(archiveEntry{Name: hdr.Name, Mode: hdr.FileInfo().Mode(), Size: hdr.Size}, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// cleanEntryName validates an archive entry's name, returning a clean,
+// relative, slash-separated path
+func cleanEntryName(name string) (string, error) {
+	if path.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("%q has an absolute or invalid path", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%q points outside the archive", name)
+		}
+	}
+	var clean = path.Clean(name)
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+// checkArchive validates the archive's entries and limits, returning a prefix
+// to strip from each entry: if everything is inside a single top-level
+// directory, that directory is ignored so "2021-01-02.zip" can hold either
+// the PDFs or a "2021-01-02" folder of PDFs.
+func checkArchive(loc string) (string, error) {
+	var count int
+	var total int64
+	var tops = make(map[string]bool)
+	var hasTopFile bool
+
+	var err = walkArchive(loc, func(e archiveEntry, _ io.Reader) error {
+		var name, err = cleanEntryName(e.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return nil
+		}
+		if !e.Mode.IsDir() && !e.Mode.IsRegular() {
+			return fmt.Errorf("%q is not a regular file or directory", e.Name)
+		}
+
+		count++
+		total += e.Size
+		if count > maxArchiveFiles {
+			return fmt.Errorf("archive has more than %d files", maxArchiveFiles)
+		}
+		if total > maxArchiveSize {
+			return fmt.Errorf("archive expands to more than %d bytes", int64(maxArchiveSize))
+		}
+
+		var parts = strings.SplitN(name, "/", 2)
+		tops[parts[0]] = true
+		if len(parts) == 1 && e.Mode.IsRegular() {
+			hasTopFile = true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(tops) == 1 && !hasTopFile {
+		for top := range tops {
+			return top + "/", nil
+		}
+	}
+	return "", nil
+}
+
+// extractArchive writes the archive's contents into dest, which must exist
+func extractArchive(loc, dest, strip string) error {
+	var total int64
+	return walkArchive(loc, func(e archiveEntry, r io.Reader) error {
+		var name, _ = cleanEntryName(e.Name)
+		name = strings.TrimPrefix(name+"/", strip)
+		name = strings.TrimSuffix(name, "/")
+		if name == "" {
+			return nil
+		}
+		var target = filepath.Join(dest, filepath.FromSlash(name))
+
+		if e.Mode.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		var err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		var f *os.File
+		f, err = os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+
+		// Headers can lie about sizes, so we enforce the limit on what's
+		// actually written, too
+		var n int64
+		n, err = io.Copy(f, io.LimitReader(r, maxArchiveSize-total+1))
+		total += n
+		var closeErr = f.Close()
+		if err == nil && total > maxArchiveSize {
+			err = fmt.Errorf("archive expands to more than %d bytes", int64(maxArchiveSize))
+		}
+		if err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+// expandArchive validates the archive at loc and expands it into dest, which
+// must not exist.  The archive is removed once it's been fully expanded.
+func expandArchive(loc, dest string) error {
+	var tmp = filepath.Join(filepath.Dir(dest), expandingPrefix+filepath.Base(dest))
+	var err = os.Mkdir(tmp, 0755)
+	if os.IsExist(err) {
+		var info, statErr = os.Stat(tmp)
+		if statErr != nil || time.Since(info.ModTime()) < staleExpansion {
+			return errArchiveBusy
+		}
+		logger.Warnf("Removing stale archive expansion directory %q", tmp)
+		os.RemoveAll(tmp)
+		err = os.Mkdir(tmp, 0755)
+	}
+	if err != nil {
+		return err
+	}
+
+	var strip string
+	strip, err = checkArchive(loc)
+	if err == nil {
+		err = extractArchive(loc, tmp, strip)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	err = os.Remove(loc)
+	if err != nil {
+		logger.Warnf("Unable to remove expanded archive %q: %s", loc, err)
+	}
+	return nil
+}
+
+// pending describes an archive which is old enough to be expanded
+type pending struct {
+	loc  string
+	dest string
+	date string
+}
+
+// findPending returns the YYYY-MM-DD.zip and YYYY-MM-DD.tar files in the title
+// path which have been untouched for the quiet period
+func findPending(titlePath string) []pending {
+	var infos, err = ioutil.ReadDir(titlePath)
+	if err != nil {
+		return nil
+	}
+
+	var list []pending
+	for _, info := range infos {
+		var m = archiveRegex.FindStringSubmatch(strings.ToLower(info.Name()))
+		if m == nil || !info.Mode().IsRegular() || time.Since(info.ModTime()) < archiveQuietPeriod {
+			continue
+		}
+		list = append(list, pending{
+			loc:  filepath.Join(titlePath, info.Name()),
+			dest: filepath.Join(titlePath, m[1]),
+			date: m[1],
+		})
+	}
+	return list
+}
+
+// problem returns the reason an archive can't be expanded, if any
+func (p pending) problem() error {
+	if _, err := os.Stat(p.dest); err == nil {
+		return fmt.Errorf("an issue folder named %q already exists", p.date)
+	}
+	var _, err = checkArchive(p.loc)
+	return err
+}
+
+// IsExpansionDir returns true if the given path is a temporary directory an
+// archive is being expanded into
+func IsExpansionDir(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), expandingPrefix)
+}
+
+// Failed returns the archives in titlePath which have been untouched for the
+// quiet period but can't be expanded, along with the reason for each.  Nothing
+// on disk is changed, so this is safe to use when reading upload data.
+func Failed(titlePath string) map[string]error {
+	var failed = make(map[string]error)
+	for _, p := range findPending(titlePath) {
+		var err = p.problem()
+		if err == nil {
+			continue
+		}
+
+		// An archive that was expanded while we were looking at it isn't a
+		// problem, even though its issue folder now exists
+		if _, statErr := os.Stat(p.loc); statErr != nil {
+			continue
+		}
+		failed[p.loc] = err
+	}
+	return failed
+}
+
+// ExpandTitle expands every archive in titlePath which has been untouched for
+// the quiet period, removing each archive once it's been expanded.  Archives
+// which can't be expanded are left alone for Failed to report.
+func ExpandTitle(titlePath string) {
+	for _, p := range findPending(titlePath) {
+		if _, err := os.Stat(p.dest); err == nil {
+			continue
+		}
+
+		var err = expandArchive(p.loc, p.dest)
+		switch {
+		case err == errArchiveBusy:
+			continue
+		case err != nil:
+			// Failures are shown to users with the issue, so they'd just be noise
+			// if logged loudly every time we retry
+			logger.Debugf("Unable to expand issue archive %q: %s", p.loc, err)
+		default:
+			logger.Infof("Expanded issue archive %q", p.loc)
+		}
+	}
+}
+
+// ExpandAll runs ExpandTitle against every title directory in the SFTP upload
+// root
+func ExpandAll(root string) error {
+	var titlePaths, err = fileutil.FindDirectories(root)
+	if err != nil {
+		return err
+	}
+	for _, titlePath := range titlePaths {
+		ExpandTitle(titlePath)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package issuearchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	name string
+	body string
+}
+
+func writeTestZip(t *testing.T, loc string, entries []testEntry) {
+	var f, err = os.Create(loc)
+	if err != nil {
+		t.Fatalf("Unable to create %q: %s", loc, err)
+	}
+	defer f.Close()
+
+	var zw = zip.NewWriter(f)
+	for _, e := range entries {
+		var w, err = zw.Create(e.name)
+		if err != nil {
+			t.Fatalf("Unable to add %q to zip: %s", e.name, err)
+		}
+		w.Write([]byte(e.body))
+	}
+	zw.Close()
+}
+
+func writeTestTar(t *testing.T, loc string, entries []testEntry) {
+	var f, err = os.Create(loc)
+	if err != nil {
+		t.Fatalf("Unable to create %q: %s", loc, err)
+	}
+	defer f.Close()
+
+	var tw = tar.NewWriter(f)
+	for _, e := range entries {
+		var hdr = &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(e.name, "/") {
+			hdr = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		tw.WriteHeader(hdr)
+		tw.Write([]byte(e.body))
+	}
+	tw.Close()
+}
+
+// makeOld backdates the file so it's past the archive quiet period
+func makeOld(loc string) {
+	var old = time.Now().Add(-2 * archiveQuietPeriod)
+	os.Chtimes(loc, old, old)
+}
+
+func listFiles(dir string) []string {
+	var names []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			var rel, _ = filepath.Rel(dir, p)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+func TestExpandTitle(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "archives")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A flat zip, a tar with a wrapping directory, an archive trying to escape,
+	// an archive whose folder already exists, and one that's too new
+	writeTestZip(t, filepath.Join(dir, "2021-01-02.zip"), []testEntry{{"0001.pdf", "a"}, {"0002.pdf", "b"}})
+	writeTestTar(t, filepath.Join(dir, "2021-01-03.tar"), []testEntry{{"2021-01-03/", ""}, {"2021-01-03/0001.pdf", "c"}})
+	writeTestZip(t, filepath.Join(dir, "2021-01-04.zip"), []testEntry{{"../../evil.pdf", "x"}})
+	writeTestZip(t, filepath.Join(dir, "2021-01-05.zip"), []testEntry{{"0001.pdf", "d"}})
+	os.Mkdir(filepath.Join(dir, "2021-01-05"), 0755)
+	writeTestZip(t, filepath.Join(dir, "2021-01-06.zip"), []testEntry{{"0001.pdf", "e"}})
+	for _, name := range []string{"2021-01-02.zip", "2021-01-03.tar", "2021-01-04.zip", "2021-01-05.zip"} {
+		makeOld(filepath.Join(dir, name))
+	}
+
+	// Reporting failures must not touch anything
+	var before = strings.Join(listFiles(dir), ",")
+	var failed = Failed(dir)
+	var after = strings.Join(listFiles(dir), ",")
+	if before != after {
+		t.Errorf("Failed changed files: before %q, after %q", before, after)
+	}
+
+	var checkFailed = func(failed map[string]error) {
+		if len(failed) != 2 {
+			t.Fatalf("Expected two failed archives, got %#v", failed)
+		}
+		if failed[filepath.Join(dir, "2021-01-04.zip")] == nil {
+			t.Errorf("Expected the path-traversal archive to fail")
+		}
+		if failed[filepath.Join(dir, "2021-01-05.zip")] == nil {
+			t.Errorf("Expected the archive with an existing folder to fail")
+		}
+	}
+	checkFailed(failed)
+
+	ExpandTitle(dir)
+
+	var got = strings.Join(listFiles(dir), ",")
+	var expected = "2021-01-02/0001.pdf,2021-01-02/0002.pdf,2021-01-03/0001.pdf,2021-01-04.zip,2021-01-05.zip,2021-01-06.zip"
+	if got != expected {
+		t.Errorf("Expected files %q, got %q", expected, got)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.pdf")); err == nil {
+		t.Errorf("Archive escaped the title directory")
+	}
+
+	// The bad archives are still there, and still reported
+	checkFailed(Failed(dir))
+}
+
+func TestCleanEntryName(t *testing.T) {
+	var tests = map[string]bool{
+		"0001.pdf":           true,
+		"2021-01-02/a.pdf":   true,
+		"./a.pdf":            true,
+		"/etc/passwd":        false,
+		"a/../../b.pdf":      false,
+		`..\windows\b.pdf`:   false,
+		"a/b/../../../c.pdf": false,
+	}
+	for name, ok := range tests {
+		var _, err = cleanEntryName(name)
+		if ok && err != nil {
+			t.Errorf("Expected %q to be valid, got %s", name, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
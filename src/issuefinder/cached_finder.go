@@ -53,6 +53,7 @@ type cachedIssue struct {
 	Edition      int
 	BatchID      cacheID
 	Location     string
+	Reel         string
 	WorkflowStep string
 	Files        []cachedFile
 	Errors       apperr.List
@@ -82,6 +82,7 @@ func (cs cachedSearcher) addSearcher() *Searcher {
 			RawDate:      ci.RawDate,
 			Edition:      ci.Edition,
 			Location:     ci.Location,
+			Reel:         ci.Reel,
 			WorkflowStep: schema.WorkflowStep(ci.WorkflowStep),
 			Errors:       ci.Errors,
 		}
@@ -133,6 +133,14 @@ func (f *Finder) FindWebBatches(hostname, cachePath string) (*Searcher, error) {
 	return f.createAndProcessSearcher(Website, hostname, searchFn)
 }
 
+// UpdateWebBatches creates and runs a website batch Searcher, reusing data
+// from prev wherever possible, then aggregates its data and returns any errors
+// encountered.  See Searcher.UpdateWebBatches.
+func (f *Finder) UpdateWebBatches(hostname, cachePath string, prev *Searcher) (*Searcher, error) {
+	var searchFn = func(s *Searcher) error { return s.UpdateWebBatches(cachePath, prev) }
+	return f.createAndProcessSearcher(Website, hostname, searchFn)
+}
+
 // FindInProcessIssues creates and runs an in-process issues (issues which are
 // in the workflow dir and have been indexed) searcher, aggregates its data,
 // and returns any errors encountered
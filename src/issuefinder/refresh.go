@@ -0,0 +1,147 @@
+package issuefinder
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// Copy returns a new Searcher with the same data as s, but with its own lists
+// and maps so that it can be modified (e.g., via RefreshTitlePath) without
+// affecting s.  The schema objects themselves are shared.
+func (s *Searcher) Copy() *Searcher {
+	var s2 = &Searcher{
+		Namespace:  s.Namespace,
+		Location:   s.Location,
+		Issues:     append(schema.IssueList(nil), s.Issues...),
+		Batches:    append([]*schema.Batch(nil), s.Batches...),
+		Titles:     append(schema.TitleList(nil), s.Titles...),
+		dbTitles:   s.dbTitles,
+		titleByLoc: make(map[string]*schema.Title, len(s.titleByLoc)),
+	}
+	for k, v := range s.titleByLoc {
+		s2.titleByLoc[k] = v
+	}
+	for _, e := range s.Errors.All() {
+		s2.Errors.Append(e)
+	}
+
+	return s2
+}
+
+// RefreshTitlePath re-reads a single title directory, replacing any issues
+// the searcher had for that title.  This is far cheaper than a full search
+// when we know only one title has changed, e.g., due to a new upload.  If the
+// directory no longer exists, its title and issues are simply removed.
+//
+// Only SFTPUpload and ScanUpload searchers can be refreshed this way.  For
+// SFTP searchers, orgCode is the MARC org code to assign to the issues; for
+// scan searchers it's ignored, as the org code is part of the path.
+//
+// The issues found in the title path are returned so callers can do any
+// further processing, such as checking for dupes.
+func (s *Searcher) RefreshTitlePath(titlePath, orgCode string) (schema.IssueList, error) {
+	if s.Namespace != SFTPUpload && s.Namespace != ScanUpload {
+		return nil, fmt.Errorf("cannot refresh a single title in namespace %d", s.Namespace)
+	}
+
+	// Titles may have been added since this searcher was built
+	var err error
+	s.dbTitles, err = models.Titles()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read titles from the database: %s", err)
+	}
+
+	return s.refreshTitlePath(titlePath, orgCode)
+}
+
+// refreshTitlePath does the work of RefreshTitlePath once the searcher's
+// database titles are current
+func (s *Searcher) refreshTitlePath(titlePath, orgCode string) (schema.IssueList, error) {
+	s.removeTitlePath(titlePath)
+
+	// Scan searchers' errors are all about invalid MOC directories, so we
+	// rebuild them rather than keep errors for directories which may have been
+	// fixed or removed since the last scan
+	var validMOCPaths []string
+	if s.Namespace == ScanUpload {
+		s.Errors.Clear()
+		var err error
+		validMOCPaths, err = s.findMOCPaths()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !fileutil.IsDir(titlePath) {
+		return nil, nil
+	}
+
+	var start = len(s.Issues)
+	var err error
+	switch s.Namespace {
+	case SFTPUpload:
+		err = s.findSFTPIssuesForTitlePath(titlePath, orgCode)
+	case ScanUpload:
+		// Invalid MOC directories are reported above; we just skip their titles
+		var mocPath = filepath.Dir(titlePath)
+		if !containsString(validMOCPaths, mocPath) {
+			return nil, nil
+		}
+		err = s.findScannedIssuesForTitlePath(filepath.Base(mocPath), titlePath)
+	}
+
+	return s.Issues[start:], err
+}
+
+// containsString returns true if list has an element equal to s
+func containsString(list []string, s string) bool {
+	for _, s2 := range list {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeTitlePath strips the title at the given location, and all its
+// issues, from the searcher's data
+func (s *Searcher) removeTitlePath(titlePath string) {
+	var t = s.titleByLoc[titlePath]
+	if t == nil {
+		return
+	}
+	delete(s.titleByLoc, titlePath)
+
+	var titles = make(schema.TitleList, 0, len(s.Titles))
+	for _, t2 := range s.Titles {
+		if t2 != t {
+			titles = append(titles, t2)
+		}
+	}
+	s.Titles = titles
+
+	var issues = make(schema.IssueList, 0, len(s.Issues))
+	for _, i := range s.Issues {
+		if i.Title != t {
+			issues = append(issues, i)
+		}
+	}
+	s.Issues = issues
+}
+
+// ReplaceSearcher returns a new Finder which has all of f's searchers except
+// that the searcher in s's namespace is replaced by s.  f is not modified.
+func (f *Finder) ReplaceSearcher(s *Searcher) *Finder {
+	var f2 = New()
+	for ns, srch := range f.Searchers {
+		f2.Searchers[ns] = srch
+	}
+	f2.storeSearcher(s)
+	f2.Aggregate()
+
+	return f2
+}
@@ -0,0 +1,124 @@
+package issuefinder
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// staleSFTPSearcher returns a searcher which has old data for the testdata
+// SFTP title, plus an unrelated title which a refresh mustn't touch
+func staleSFTPSearcher() *Searcher {
+	var s = &Searcher{
+		Namespace:  SFTPUpload,
+		Location:   filepath.Join("testdata", "sftp"),
+		titleByLoc: make(map[string]*schema.Title),
+		dbTitles:   models.TitleList{{LCCN: "sn12345678", Name: "Test"}, {LCCN: "sn87654321", Name: "Other"}},
+	}
+
+	for _, lccn := range []string{"sn12345678", "sn87654321"} {
+		var t = &schema.Title{LCCN: lccn, Location: filepath.Join(s.Location, lccn)}
+		var i = &schema.Issue{RawDate: "2020-12-25", Edition: 1, Location: filepath.Join(t.Location, "2020-12-25")}
+		t.AddIssue(i)
+		s.addTitle(t)
+		s.Issues = append(s.Issues, i)
+	}
+
+	return s
+}
+
+func issueDates(issues schema.IssueList) []string {
+	var dates []string
+	for _, i := range issues {
+		dates = append(dates, i.Title.LCCN+"/"+i.RawDate)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+func TestRefreshTitlePath(t *testing.T) {
+	var orig = staleSFTPSearcher()
+	var s = orig.Copy()
+
+	var titlePath = filepath.Join("testdata", "sftp", "sn12345678")
+	var issues, err = s.refreshTitlePath(titlePath, "oru")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var want = []string{"sn12345678/2021-01-02", "sn12345678/2021-01-09"}
+	if got := issueDates(issues); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected refreshed issues %q, got %q", want, got)
+	}
+
+	want = []string{"sn12345678/2021-01-02", "sn12345678/2021-01-09", "sn87654321/2020-12-25"}
+	if got := issueDates(s.Issues); len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expected searcher issues %q, got %q", want, got)
+	}
+	if len(s.Titles) != 2 {
+		t.Errorf("Expected 2 titles, got %d", len(s.Titles))
+	}
+
+	// The original searcher must be left alone so it can keep serving requests
+	if got := issueDates(orig.Issues); len(got) != 2 || got[0] != "sn12345678/2020-12-25" {
+		t.Errorf("Original searcher was modified: %q", got)
+	}
+}
+
+func TestRefreshTitlePathRemoved(t *testing.T) {
+	var s = staleSFTPSearcher()
+	var issues, err = s.refreshTitlePath(filepath.Join("testdata", "sftp", "sn87654321"), "oru")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues for a removed title, got %d", len(issues))
+	}
+	if got := issueDates(s.Issues); len(got) != 1 || got[0] != "sn12345678/2020-12-25" {
+		t.Errorf("Expected only the other title's issue to remain, got %q", got)
+	}
+	if len(s.Titles) != 1 || s.titleByLoc[filepath.Join("testdata", "sftp", "sn87654321")] != nil {
+		t.Errorf("Expected the removed title to be gone, got %#v", s.Titles)
+	}
+}
+
+func TestRefreshTitlePathNamespace(t *testing.T) {
+	var s = &Searcher{Namespace: Website}
+	var _, err = s.RefreshTitlePath("foo", "oru")
+	if err == nil {
+		t.Errorf("Expected an error refreshing a web searcher")
+	}
+}
+
+func TestFindSFTPIssuesImageTitle(t *testing.T) {
+	// The title's upload directory isn't its LCCN, so this only works if the
+	// image-upload flag comes from the title the directory belongs to
+	var s = &Searcher{
+		Namespace:  SFTPUpload,
+		Location:   filepath.Join("testdata", "sftp"),
+		titleByLoc: make(map[string]*schema.Title),
+		dbTitles:   models.TitleList{{LCCN: "sn11111111", Name: "Images", SFTPDir: "pub-images", ImageUploads: true}},
+	}
+
+	var err = s.findSFTPIssuesForTitlePath(filepath.Join(s.Location, "pub-images"), "oru")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(s.Issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(s.Issues))
+	}
+
+	var i = s.Issues[0]
+	if i.Title.LCCN != "sn11111111" {
+		t.Errorf("Expected title sn11111111, got %q", i.Title.LCCN)
+	}
+	for _, f := range i.Files {
+		if f.Errors.Len() != 0 {
+			t.Errorf("Expected no errors on %q, got %d", f.Name, f.Errors.Len())
+		}
+	}
+}
@@ -3,6 +3,7 @@ package issuefinder
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,25 +19,11 @@ import (
 func (s *Searcher) FindScannedIssues() error {
 	s.init()
 
-	// First find all MARC org code directories
-	var mocPaths, err = fileutil.FindDirectories(s.Location)
+	var validMOCPaths, err = s.findMOCPaths()
 	if err != nil {
 		return err
 	}
 
-	// Any MOCs not in the app are errors and we don't even try to handle them;
-	// this should be a pretty rare occurrence for us
-	var validMOCPaths []string
-	for _, mocPath := range mocPaths {
-		var mocName = filepath.Base(mocPath)
-		if !models.ValidMOC(mocName) {
-			s.Errors.Append(apperr.Errorf("unable to find MARC Org Code %#v in database", mocName))
-			continue
-		}
-
-		validMOCPaths = append(validMOCPaths, mocPath)
-	}
-
 	// Next, find titles
 	for _, mocPath := range validMOCPaths {
 		var paths, err = fileutil.FindDirectories(mocPath)
@@ -57,9 +44,42 @@ func (s *Searcher) FindScannedIssues() error {
 	return nil
 }
 
+// findMOCPaths returns all MARC org code directories in the searcher's
+// location which are in the database.  Any MOCs not in the app are recorded
+// as errors and we don't even try to handle them; this should be a pretty
+// rare occurrence for us.
+func (s *Searcher) findMOCPaths() ([]string, error) {
+	var mocPaths, err = fileutil.FindDirectories(s.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	var validMOCPaths []string
+	for _, mocPath := range mocPaths {
+		var mocName = filepath.Base(mocPath)
+		if !models.ValidMOC(mocName) {
+			s.Errors.Append(apperr.Errorf("unable to find MARC Org Code %#v in database", mocName))
+			continue
+		}
+
+		validMOCPaths = append(validMOCPaths, mocPath)
+	}
+
+	return validMOCPaths, nil
+}
+
+// reelDirRegex matches the directories microfilm scans are grouped into:
+// "reel-" followed by the reel number
+var reelDirRegex = regexp.MustCompile(`^reel-([A-Za-z0-9]+)$`)
+
+// reelTargetsDir is the name of the directory within a reel's directory which
+// holds the reel's target images rather than an issue
+const reelTargetsDir = "targets"
+
 // findScannedIssuesForTitlePath finds all issues within the given title's path
-// by looking for YYYY-MM-DD or YYYY-MM-DD_EE formatted directories.  The
-// following error conditions are checked and recorded:
+// by looking for YYYY-MM-DD or YYYY-MM-DD_EE formatted directories.  Issues
+// scanned from microfilm live one level deeper, in a "reel-<reel number>"
+// directory.  The following error conditions are checked and recorded:
 // - The last element of the path isn't a valid title name or LCCN
 // - The issue directory isn't a valid date or date/edition combo
 // - The issue has no TIFFs
@@ -73,47 +93,69 @@ func (s *Searcher) findScannedIssuesForTitlePath(moc, titlePath string) error {
 	}
 
 	for _, issuePath := range issuePaths {
-		var base = filepath.Base(issuePath)
-
-		// Set up the core of the issue data so we can start attaching errors
-		var issue = &schema.Issue{
-			Location:     issuePath,
-			WorkflowStep: schema.WSScan,
-			MARCOrgCode:  moc,
+		var m = reelDirRegex.FindStringSubmatch(filepath.Base(issuePath))
+		if m == nil {
+			s.addScannedIssue(moc, title, issuePath, "")
+			continue
 		}
 
-		// If we have an edition, split it off and store it, otherwise it's 1
-		var edition = 1
-		if len(base) == 13 {
-			var edStr = base[11:]
-			edition, err = strconv.Atoi(edStr)
-			if err != nil {
-				issue.ErrInvalidFolderName("non-numeric edition value")
-			} else if edition < 1 {
-				issue.ErrInvalidFolderName("edition must be 1 or greater")
+		var reelIssuePaths []string
+		reelIssuePaths, err = fileutil.FindDirectories(issuePath)
+		if err != nil {
+			return err
+		}
+		for _, reelIssuePath := range reelIssuePaths {
+			if filepath.Base(reelIssuePath) != reelTargetsDir {
+				s.addScannedIssue(moc, title, reelIssuePath, m[1])
 			}
-			base = base[:10]
 		}
+	}
+
+	return nil
+}
 
-		// Finish the issue metadata and do final validations
-		issue.RawDate = base
-		issue.Edition = edition
-		issue.FindFiles()
-		title.AddIssue(issue)
+// addScannedIssue builds an issue from the given directory and validates it
+func (s *Searcher) addScannedIssue(moc string, title *schema.Title, issuePath, reel string) {
+	var base = filepath.Base(issuePath)
 
-		var _, err = time.Parse("2006-01-02", base)
+	// Set up the core of the issue data so we can start attaching errors
+	var issue = &schema.Issue{
+		Location:     issuePath,
+		Reel:         reel,
+		WorkflowStep: schema.WSScan,
+		MARCOrgCode:  moc,
+	}
+
+	// If we have an edition, split it off and store it, otherwise it's 1
+	var edition = 1
+	if len(base) == 13 {
+		var edStr = base[11:]
+		var err error
+		edition, err = strconv.Atoi(edStr)
 		if err != nil {
-			issue.ErrInvalidFolderName("bad date format")
+			issue.ErrInvalidFolderName("non-numeric edition value")
+		} else if edition < 1 {
+			issue.ErrInvalidFolderName("edition must be 1 or greater")
 		}
+		base = base[:10]
+	}
 
-		// Make sure PDF and TIFF pairs match up properly
-		s.verifyScanIssuePDFTIFFPairs(issue)
+	// Finish the issue metadata and do final validations
+	issue.RawDate = base
+	issue.Edition = edition
+	issue.FindFiles()
+	title.AddIssue(issue)
 
-		s.Issues = append(s.Issues, issue)
-		s.verifyIssueFiles(issue, []string{".pdf", ".tif", ".tiff"})
+	var _, err = time.Parse("2006-01-02", base)
+	if err != nil {
+		issue.ErrInvalidFolderName("bad date format")
 	}
 
-	return nil
+	// Make sure PDF and TIFF pairs match up properly
+	s.verifyScanIssuePDFTIFFPairs(issue)
+
+	s.Issues = append(s.Issues, issue)
+	s.verifyIssueFiles(issue, []string{".pdf", ".tif", ".tiff"})
 }
 
 func (s *Searcher) verifyScanIssuePDFTIFFPairs(issue *schema.Issue) {
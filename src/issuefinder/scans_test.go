@@ -0,0 +1,58 @@
+package issuefinder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestFindScannedIssuesForTitlePathReels(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "scans")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var titlePath = filepath.Join(dir, "sn12345678")
+	for _, p := range []string{
+		"2021-01-01",
+		"reel-00271761999/1901-02-03",
+		"reel-00271761999/1901-02-04_02",
+		"reel-00271761999/targets",
+	} {
+		var full = filepath.Join(titlePath, filepath.FromSlash(p))
+		os.MkdirAll(full, 0755)
+		ioutil.WriteFile(filepath.Join(full, "0001.tif"), []byte("x"), 0644)
+		ioutil.WriteFile(filepath.Join(full, "0001.pdf"), []byte("x"), 0644)
+	}
+
+	var s = &Searcher{
+		titleByLoc: make(map[string]*schema.Title),
+		dbTitles:   models.TitleList{{LCCN: "sn12345678", Name: "Test"}},
+	}
+	err = s.findScannedIssuesForTitlePath("oru", titlePath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, i := range s.Issues {
+		got = append(got, i.DateEdition()+":"+i.Reel)
+	}
+	sort.Strings(got)
+	var want = []string{"1901020301:00271761999", "1901020402:00271761999", "2021010101:"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected issues %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected issues %q, got %q", want, got)
+			break
+		}
+	}
+}
@@ -59,6 +59,7 @@ func (s *Searcher) cachedSearcher() cachedSearcher {
 			RawDate:      i.RawDate,
 			Edition:      i.Edition,
 			Location:     i.Location,
+			Reel:         i.Reel,
 			WorkflowStep: string(i.WorkflowStep),
 			Errors:       i.Errors,
 		}
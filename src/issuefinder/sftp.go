@@ -6,9 +6,14 @@ import (
 	"time"
 
 	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuearchive"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
+// imageExtensions are the allowed file types for titles which upload page
+// images rather than PDFs
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".tif", ".tiff"}
+
 // FindSFTPIssues aggregates all the uploaded born-digital PDFs
 func (s *Searcher) FindSFTPIssues(orgCode string) error {
 	s.init()
@@ -31,17 +36,44 @@ func (s *Searcher) FindSFTPIssues(orgCode string) error {
 }
 
 // findSFTPIssuesForTitle finds all issues within the given title's path by
-// looking for YYYY-MM-DD formatted directories.  The last directory element in
+// looking for YYYY-MM-DD formatted directories.  Issue archives which can't be
+// expanded are reported as issues with errors.  The last directory element in
 // the path must be an SFTP title name or an LCCN.
 func (s *Searcher) findSFTPIssuesForTitlePath(titlePath, orgCode string) error {
 	var title = s.findOrCreateFilesystemTitle(titlePath)
 
+	// Most publishers send PDFs, but some titles are flagged as sending page
+	// images instead.  The title is looked up by LCCN, just as it is when
+	// issues are validated and queued, rather than by its upload directory.
+	var exts = []string{".pdf"}
+	var dbTitle = s.dbTitles.FindByLCCN(title.LCCN)
+	if dbTitle != nil && dbTitle.ImageUploads {
+		exts = imageExtensions
+	}
+
+	for loc, err := range issuearchive.Failed(titlePath) {
+		var base = filepath.Base(loc)
+		var issue = &schema.Issue{
+			MARCOrgCode:  orgCode,
+			Location:     loc,
+			WorkflowStep: schema.WSSFTP,
+			RawDate:      base[:len(base)-len(filepath.Ext(base))],
+			Edition:      1,
+		}
+		issue.ErrBadArchive(err.Error())
+		title.AddIssue(issue)
+		s.Issues = append(s.Issues, issue)
+	}
+
 	var issuePaths, err = fileutil.FindDirectories(titlePath)
 	if err != nil {
 		return err
 	}
 
 	for _, issuePath := range issuePaths {
+		if issuearchive.IsExpansionDir(issuePath) {
+			continue
+		}
 		var base = filepath.Base(issuePath)
 
 		// Set up the core of the issue data so we can start attaching errors
@@ -72,7 +104,7 @@ func (s *Searcher) findSFTPIssuesForTitlePath(titlePath, orgCode string) error {
 		title.AddIssue(issue)
 
 		s.Issues = append(s.Issues, issue)
-		s.verifyIssueFiles(issue, []string{".pdf"})
+		s.verifyIssueFiles(issue, exts)
 	}
 
 	return nil
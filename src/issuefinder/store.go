@@ -0,0 +1,603 @@
+package issuefinder
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StoreVersion is the version of the store's data layout.  When this changes,
+// existing stores are wiped on open, since all their data can be rebuilt by a
+// scan.
+const StoreVersion = "1"
+
+var (
+	bucketMeta    = []byte("meta")
+	bucketIndex   = []byte("issue-index")
+	bucketSearch  = []byte("searcher")
+	bucketTitles  = []byte("titles")
+	bucketBatches = []byte("batches")
+	bucketIssues  = []byte("issues")
+	keyVersion    = []byte("version")
+)
+
+// Store is an embedded, indexed database of issue data.  Unlike the old
+// single-file cache, data is stored per title, batch, and issue, so saving a
+// Finder only writes what changed, and issues can be looked up by key without
+// loading everything into memory.
+type Store struct {
+	db *bolt.DB
+}
+
+// storedSearcher holds the searcher-level data which isn't tied to any
+// particular issue, batch, or title
+type storedSearcher struct {
+	Location string
+	Errors   apperr.List
+}
+
+// storedIssue is the per-issue record.  Titles and batches are referenced by
+// location so that each record stands on its own.
+type storedIssue struct {
+	Key           string
+	RawDate       string
+	Edition       int
+	Location      string
+	Reel          string
+	WorkflowStep  string
+	Files         []cachedFile
+	Errors        apperr.List
+	TitleLocation string
+	BatchLocation string
+}
+
+// OpenStore opens (creating if necessary) the store at the given path.  If the
+// store's version doesn't match StoreVersion, all its data is removed.
+// Callers must Close the store when done, as only one process can have a
+// given store open at a time.
+func OpenStore(path string) (*Store, error) {
+	gob.Register(&apperr.BaseError{})
+	gob.Register(&schema.IssueError{})
+	gob.Register(&schema.DuplicateIssueError{})
+
+	var db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Minute})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open issue store %q: %s", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		var meta = tx.Bucket(bucketMeta)
+		if meta != nil && string(meta.Get(keyVersion)) == StoreVersion {
+			return nil
+		}
+
+		var names [][]byte
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			return nil
+		})
+		for _, name := range names {
+			var err = tx.DeleteBucket(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		meta, err = tx.CreateBucket(bucketMeta)
+		if err != nil {
+			return err
+		}
+		return meta.Put(keyVersion, []byte(StoreVersion))
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to initialize issue store %q: %s", path, err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the store's file lock
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+// Empty returns true if the store has no searcher data
+func (st *Store) Empty() bool {
+	var empty = true
+	st.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if strings.HasPrefix(string(name), "ns-") {
+				empty = false
+			}
+			return nil
+		})
+	})
+	return empty
+}
+
+func nsBucketName(ns Namespace) []byte {
+	return []byte(fmt.Sprintf("ns-%d", ns))
+}
+
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	var err = gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func decode(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// indexKey returns the key used in the issue index: the issue key first, so
+// prefix scans can find issues by partial key, then the namespace and the
+// issue's record key to make it unique
+func indexKey(issueKey string, ns Namespace, recordKey string) []byte {
+	return []byte(issueKey + "\x00" + string(rune('0'+ns)) + "\x00" + recordKey)
+}
+
+// issueRecordKey returns the key we use for an issue's record within its
+// namespace
+func issueRecordKey(i *schema.Issue) string {
+	return i.Location + "\x00" + i.Key() + "\x00" + string(i.WorkflowStep)
+}
+
+// Save writes the data for all of f's searchers.  Records which haven't
+// changed are left alone, and records which no longer exist are removed, so
+// a save after an incremental refresh does very little work.  Namespaces f
+// doesn't have a searcher for aren't touched.
+func (st *Store) Save(f *Finder) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		for _, s := range f.Searchers {
+			var err = saveSearcher(tx, s)
+			if err != nil {
+				return fmt.Errorf("unable to store namespace %d: %s", s.Namespace, err)
+			}
+		}
+		return nil
+	})
+}
+
+func saveSearcher(tx *bolt.Tx, s *Searcher) error {
+	var nsb, err = tx.CreateBucketIfNotExists(nsBucketName(s.Namespace))
+	if err != nil {
+		return err
+	}
+	var idx *bolt.Bucket
+	idx, err = tx.CreateBucketIfNotExists(bucketIndex)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	data, err = encode(storedSearcher{Location: s.Location, Errors: s.Errors})
+	if err == nil {
+		err = nsb.Put(bucketSearch, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	var titles = make(map[string]interface{})
+	var batches = make(map[string]interface{})
+	var issues = make(map[string]interface{})
+	var addTitle = func(t *schema.Title) {
+		titles[t.Location] = cachedTitle{
+			LCCN:               t.LCCN,
+			Name:               t.Name,
+			PlaceOfPublication: t.PlaceOfPublication,
+			Location:           t.Location,
+			Errors:             t.Errors,
+		}
+	}
+	for _, t := range s.Titles {
+		addTitle(t)
+	}
+	for _, b := range s.Batches {
+		batches[b.Location] = cachedBatch{
+			MARCOrgCode: b.MARCOrgCode,
+			Keyword:     b.Keyword,
+			Version:     b.Version,
+			Location:    b.Location,
+			Errors:      b.Errors,
+		}
+	}
+	for _, i := range s.Issues {
+		// Some issues' titles (e.g., unknown titles in the database) aren't in
+		// the searcher's title list, but we need them to rebuild the issue
+		if _, ok := titles[i.Title.Location]; !ok {
+			addTitle(i.Title)
+		}
+
+		var si = storedIssue{
+			Key:           i.Key(),
+			RawDate:       i.RawDate,
+			Edition:       i.Edition,
+			Location:      i.Location,
+			Reel:          i.Reel,
+			WorkflowStep:  string(i.WorkflowStep),
+			Errors:        i.Errors,
+			TitleLocation: i.Title.Location,
+		}
+		if i.Batch != nil {
+			si.BatchLocation = i.Batch.Location
+		}
+		for _, f := range i.Files {
+			si.Files = append(si.Files, cachedFile{File: *f.File, Location: f.Location, Errors: f.Errors})
+		}
+		issues[issueRecordKey(i)] = si
+	}
+
+	err = syncBucket(nsb, bucketTitles, titles, nil)
+	if err == nil {
+		err = syncBucket(nsb, bucketBatches, batches, nil)
+	}
+	if err == nil {
+		err = syncBucket(nsb, bucketIssues, issues, func(key string, old, new *storedIssue) error {
+			if old != nil {
+				var err = idx.Delete(indexKey(old.Key, s.Namespace, key))
+				if err != nil {
+					return err
+				}
+			}
+			if new != nil {
+				return idx.Put(indexKey(new.Key, s.Namespace, key), []byte{})
+			}
+			return nil
+		})
+	}
+
+	return err
+}
+
+// syncBucket makes the named sub-bucket of parent hold exactly the given
+// records, writing only those which are new or changed.  If onChange is
+// non-nil, the records must be storedIssues, and onChange is called for each
+// issue record added, removed, or modified.
+func syncBucket(parent *bolt.Bucket, name []byte, records map[string]interface{},
+	onChange func(key string, old, new *storedIssue) error) error {
+
+	var b, err = parent.CreateBucketIfNotExists(name)
+	if err != nil {
+		return err
+	}
+
+	var decodeIssue = func(data []byte) (*storedIssue, error) {
+		var si = &storedIssue{}
+		return si, decode(data, si)
+	}
+
+	// Remove anything that's gone
+	var stale [][]byte
+	b.ForEach(func(k, _ []byte) error {
+		if _, ok := records[string(k)]; !ok {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	for _, k := range stale {
+		if onChange != nil {
+			var old, err = decodeIssue(b.Get(k))
+			if err == nil {
+				err = onChange(string(k), old, nil)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err = b.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add or replace anything new or changed
+	for k, rec := range records {
+		var data []byte
+		data, err = encode(rec)
+		if err != nil {
+			return err
+		}
+		var existing = b.Get([]byte(k))
+		if existing != nil && bytes.Equal(existing, data) {
+			continue
+		}
+
+		if onChange != nil {
+			var old *storedIssue
+			if existing != nil {
+				old, err = decodeIssue(existing)
+				if err != nil {
+					return err
+				}
+			}
+			var si = rec.(storedIssue)
+			err = onChange(k, old, &si)
+			if err != nil {
+				return err
+			}
+		}
+		err = b.Put([]byte(k), data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finder reads all stored data and returns a Finder built from it
+func (st *Store) Finder() (*Finder, error) {
+	var f = New()
+	var err = st.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, nsb *bolt.Bucket) error {
+			var ns Namespace
+			var _, err = fmt.Sscanf(string(name), "ns-%d", &ns)
+			if err != nil {
+				return nil
+			}
+
+			var s *Searcher
+			s, err = loadSearcher(nsb, ns)
+			if err != nil {
+				return fmt.Errorf("unable to read namespace %d: %s", ns, err)
+			}
+			f.storeSearcher(s)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f.Aggregate()
+	return f, nil
+}
+
+// storeReader builds schema objects from a namespace bucket, caching titles
+// and batches so that issues share them
+type storeReader struct {
+	nsb     *bolt.Bucket
+	s       *Searcher
+	batches map[string]*schema.Batch
+}
+
+func newStoreReader(nsb *bolt.Bucket, ns Namespace) *storeReader {
+	var s = &Searcher{Namespace: ns, titleByLoc: make(map[string]*schema.Title)}
+	return &storeReader{nsb: nsb, s: s, batches: make(map[string]*schema.Batch)}
+}
+
+func loadSearcher(nsb *bolt.Bucket, ns Namespace) (*Searcher, error) {
+	var r = newStoreReader(nsb, ns)
+
+	var ss storedSearcher
+	var err = decode(nsb.Get(bucketSearch), &ss)
+	if err != nil {
+		return nil, err
+	}
+	r.s.Location = ss.Location
+	r.s.Errors = ss.Errors
+
+	// Titles and batches are loaded up front so that those without issues are
+	// still part of the searcher's data
+	var tb = nsb.Bucket(bucketTitles)
+	if tb != nil {
+		err = tb.ForEach(func(k, _ []byte) error {
+			var _, err = r.title(string(k))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var bb = nsb.Bucket(bucketBatches)
+	if bb != nil {
+		err = bb.ForEach(func(k, _ []byte) error {
+			var _, err = r.batch(string(k))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ib = nsb.Bucket(bucketIssues)
+	if ib != nil {
+		err = ib.ForEach(func(_, v []byte) error {
+			var _, err = r.issue(v)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r.s, nil
+}
+
+// title returns the title at the given location, reading it from the store
+// if it hasn't already been read
+func (r *storeReader) title(loc string) (*schema.Title, error) {
+	if t := r.s.titleByLoc[loc]; t != nil {
+		return t, nil
+	}
+
+	var ct cachedTitle
+	var data []byte
+	if tb := r.nsb.Bucket(bucketTitles); tb != nil {
+		data = tb.Get([]byte(loc))
+	}
+	if data == nil {
+		return nil, fmt.Errorf("title %q not found", loc)
+	}
+	var err = decode(data, &ct)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.addTitle(&schema.Title{
+		LCCN:               ct.LCCN,
+		Name:               ct.Name,
+		PlaceOfPublication: ct.PlaceOfPublication,
+		Location:           ct.Location,
+		Errors:             ct.Errors,
+	})
+	return r.s.titleByLoc[loc], nil
+}
+
+// batch returns the batch at the given location, reading it from the store
+// if it hasn't already been read
+func (r *storeReader) batch(loc string) (*schema.Batch, error) {
+	if b := r.batches[loc]; b != nil {
+		return b, nil
+	}
+
+	var cb cachedBatch
+	var data []byte
+	if bb := r.nsb.Bucket(bucketBatches); bb != nil {
+		data = bb.Get([]byte(loc))
+	}
+	if data == nil {
+		return nil, fmt.Errorf("batch %q not found", loc)
+	}
+	var err = decode(data, &cb)
+	if err != nil {
+		return nil, err
+	}
+
+	var b = &schema.Batch{
+		MARCOrgCode: cb.MARCOrgCode,
+		Keyword:     cb.Keyword,
+		Version:     cb.Version,
+		Location:    cb.Location,
+		Errors:      cb.Errors,
+	}
+	r.batches[loc] = b
+	r.s.Batches = append(r.s.Batches, b)
+	return b, nil
+}
+
+// issue decodes an issue record and attaches it to its title and batch
+func (r *storeReader) issue(data []byte) (*schema.Issue, error) {
+	var si storedIssue
+	var err = decode(data, &si)
+	if err != nil {
+		return nil, err
+	}
+
+	var i = &schema.Issue{
+		RawDate:      si.RawDate,
+		Edition:      si.Edition,
+		Location:     si.Location,
+		Reel:         si.Reel,
+		WorkflowStep: schema.WorkflowStep(si.WorkflowStep),
+		Errors:       si.Errors,
+	}
+	for _, cf := range si.Files {
+		var dupedFile = cf.File
+		i.Files = append(i.Files, &schema.File{File: &dupedFile, Location: cf.Location, Issue: i, Errors: cf.Errors})
+	}
+
+	var t *schema.Title
+	t, err = r.title(si.TitleLocation)
+	if err != nil {
+		return nil, err
+	}
+	t.AddIssue(i)
+
+	if si.BatchLocation != "" {
+		var b *schema.Batch
+		b, err = r.batch(si.BatchLocation)
+		if err != nil {
+			return nil, err
+		}
+		b.AddIssue(i)
+	}
+
+	r.s.Issues = append(r.s.Issues, i)
+	return i, nil
+}
+
+// Issues uses the store's index to find all issues matching the given search
+// key, without loading the rest of the store's data.  Issues are built fresh
+// on each call, and are linked to their titles and batches, but those titles
+// and batches only know about the issues returned here.
+func (st *Store) Issues(k *schema.Key) (schema.IssueList, error) {
+	var prefix = k.String()
+	if k.Year == 0 {
+		prefix += "/"
+	}
+
+	var issues schema.IssueList
+	var err = st.db.View(func(tx *bolt.Tx) error {
+		var idx = tx.Bucket(bucketIndex)
+		if idx == nil {
+			return nil
+		}
+
+		var readers = make(map[Namespace]*storeReader)
+		var c = idx.Cursor()
+		for key, _ := c.Seek([]byte(prefix)); key != nil && bytes.HasPrefix(key, []byte(prefix)); key, _ = c.Next() {
+			var parts = strings.SplitN(string(key), "\x00", 3)
+			if len(parts) != 3 || len(parts[1]) != 1 {
+				return fmt.Errorf("invalid index key %q", key)
+			}
+			// An exact key has an edition, so it mustn't match longer keys
+			if k.Ed != 0 && parts[0] != prefix {
+				continue
+			}
+
+			var ns = Namespace(parts[1][0] - '0')
+			var r = readers[ns]
+			if r == nil {
+				var nsb = tx.Bucket(nsBucketName(ns))
+				if nsb == nil {
+					return fmt.Errorf("index refers to missing namespace %d", ns)
+				}
+				r = newStoreReader(nsb, ns)
+				readers[ns] = r
+			}
+
+			var data []byte
+			if ib := r.nsb.Bucket(bucketIssues); ib != nil {
+				data = ib.Get([]byte(parts[2]))
+			}
+			if data == nil {
+				return fmt.Errorf("index refers to missing issue %q", parts[2])
+			}
+			var i, err = r.issue(data)
+			if err != nil {
+				return err
+			}
+			issues = append(issues, i)
+		}
+		return nil
+	})
+
+	issues.SortByKey()
+	return issues, err
+}
+
+// MigrateCacheFile reads the old gob-encoded cache file and writes its data
+// to the store.  On success, the cache file is renamed so it isn't migrated
+// again, but is still around in case something went wrong.
+func (st *Store) MigrateCacheFile(filename string) error {
+	var f, err = Deserialize(filename)
+	if err != nil {
+		return err
+	}
+	err = st.Save(f)
+	if err != nil {
+		return err
+	}
+	return os.Rename(filename, filename+".migrated")
+}
@@ -0,0 +1,131 @@
+package issuefinder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+// testSearcher builds a searcher without hitting the database
+func testSearcher(ns Namespace, loc string) *Searcher {
+	return &Searcher{Namespace: ns, Location: loc, titleByLoc: make(map[string]*schema.Title)}
+}
+
+func addTestIssue(s *Searcher, b *schema.Batch, lccn, date string) *schema.Issue {
+	var tloc = "/titles/" + lccn
+	if s.titleByLoc[tloc] == nil {
+		s.addTitle(&schema.Title{LCCN: lccn, Location: tloc})
+	}
+	var i = &schema.Issue{RawDate: date, Edition: 1, Location: tloc + "/" + date, WorkflowStep: schema.WSInProduction}
+	s.titleByLoc[tloc].AddIssue(i)
+	if b != nil {
+		b.AddIssue(i)
+	}
+	s.Issues = append(s.Issues, i)
+	return i
+}
+
+func openTestStore(t *testing.T) (*Store, func()) {
+	var dir, err = ioutil.TempDir("", "issuestore")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	var st *Store
+	st, err = OpenStore(filepath.Join(dir, "finder.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Unable to open store: %s", err)
+	}
+	return st, func() { st.Close(); os.RemoveAll(dir) }
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	var st, cleanup = openTestStore(t)
+	defer cleanup()
+
+	var s = testSearcher(Website, "https://example.org")
+	var b = &schema.Batch{MARCOrgCode: "oru", Keyword: "foo", Version: 1, Location: "https://example.org/batch_oru_foo_ver01"}
+	s.Batches = append(s.Batches, b)
+	addTestIssue(s, b, "sn12345678", "2001-01-02")
+	addTestIssue(s, b, "sn12345678", "2001-02-03")
+	addTestIssue(s, b, "sn87654321", "2001-01-02")
+
+	var f = New()
+	f.storeSearcher(s)
+	f.Aggregate()
+	var err = st.Save(f)
+	if err != nil {
+		t.Fatalf("Unable to save: %s", err)
+	}
+
+	var f2 *Finder
+	f2, err = st.Finder()
+	if err != nil {
+		t.Fatalf("Unable to load: %s", err)
+	}
+	if len(f2.Issues) != 3 || len(f2.Titles) != 2 || len(f2.Batches) != 1 {
+		t.Fatalf("Expected 3 issues, 2 titles, 1 batch; got %d, %d, %d", len(f2.Issues), len(f2.Titles), len(f2.Batches))
+	}
+	f.Issues.SortByKey()
+	f2.Issues.SortByKey()
+	for i := range f.Issues {
+		if f.Issues[i].TSV() != f2.Issues[i].TSV() {
+			t.Errorf("Issue %d: expected %q, got %q", i, f.Issues[i].TSV(), f2.Issues[i].TSV())
+		}
+	}
+}
+
+func TestStoreIssuesIndex(t *testing.T) {
+	var st, cleanup = openTestStore(t)
+	defer cleanup()
+
+	var s = testSearcher(SFTPUpload, "/sftp")
+	addTestIssue(s, nil, "sn12345678", "2001-01-02")
+	addTestIssue(s, nil, "sn12345678", "2001-02-03")
+	addTestIssue(s, nil, "sn123456789", "2001-01-02")
+
+	var f = New()
+	f.storeSearcher(s)
+	var err = st.Save(f)
+	if err != nil {
+		t.Fatalf("Unable to save: %s", err)
+	}
+
+	var tests = map[string]int{
+		"sn12345678":             2,
+		"sn12345678/2001":        2,
+		"sn12345678/200102":      1,
+		"sn12345678/2001010201":  1,
+		"sn123456789/2001010201": 1,
+		"sn99999999":             0,
+	}
+	for ik, want := range tests {
+		var k, err = schema.ParseSearchKey(ik)
+		if err != nil {
+			t.Fatalf("Invalid key %q: %s", ik, err)
+		}
+		var issues schema.IssueList
+		issues, err = st.Issues(k)
+		if err != nil {
+			t.Fatalf("Error querying %q: %s", ik, err)
+		}
+		if len(issues) != want {
+			t.Errorf("Expected %d issues for %q, got %d", want, ik, len(issues))
+		}
+	}
+
+	// Removing an issue and saving again must update the index
+	s.removeTitlePath("/titles/sn123456789")
+	err = st.Save(f)
+	if err != nil {
+		t.Fatalf("Unable to re-save: %s", err)
+	}
+	var k, _ = schema.ParseSearchKey("sn123456789")
+	var issues, _ = st.Issues(k)
+	if len(issues) != 0 {
+		t.Errorf("Expected removed issues to be gone from the index, got %d", len(issues))
+	}
+}
@@ -22,8 +22,24 @@ import (
 // As with other searches, this returns an error only on unexpected behaviors,
 // like the site not responding.
 func (s *Searcher) FindWebBatches(cachePath string) error {
+	return s.UpdateWebBatches(cachePath, nil)
+}
+
+// UpdateWebBatches works like FindWebBatches, but batches which are already
+// in prev are copied from it rather than being read and parsed again.  Live
+// batches don't change once loaded, so on a site with thousands of batches
+// this means only the handful of new batches need any real work.  prev may
+// be nil, in which case this is identical to FindWebBatches.
+func (s *Searcher) UpdateWebBatches(cachePath string, prev *Searcher) error {
 	s.init()
 
+	var known = make(map[string]*schema.Batch)
+	if prev != nil {
+		for _, b := range prev.Batches {
+			known[b.Location] = b
+		}
+	}
+
 	var batchMetadataList, err = s.findAllLiveBatches(cachePath)
 	if err != nil {
 		return fmt.Errorf("unable to load batch list from %#v: %s", s.Location, err)
@@ -41,6 +57,11 @@ func (s *Searcher) FindWebBatches(cachePath string) error {
 		batch.Location = batchMetadata.URL
 		s.Batches = append(s.Batches, batch)
 
+		if pb := known[batch.Location]; pb != nil {
+			s.copyBatchIssues(batch, pb)
+			continue
+		}
+
 		var issueMetadataList []*chronam.IssueMetadata
 		issueMetadataList, err = s.findBatchedIssueMetadata(c, batchMetadata.URL)
 		if err != nil {
@@ -58,6 +79,52 @@ func (s *Searcher) FindWebBatches(cachePath string) error {
 	return nil
 }
 
+// copyBatchIssues sets up batch to have copies of all of prev's issues and
+// errors, including the issues' files and errors.  The copies are new objects
+// so the two searchers never share data.
+func (s *Searcher) copyBatchIssues(batch, prev *schema.Batch) {
+	for _, e := range prev.Errors.All() {
+		batch.AddError(e)
+	}
+
+	for _, pi := range prev.Issues {
+		var t = s.titleByLoc[pi.Title.Location]
+		if t == nil {
+			s.addTitle(&schema.Title{
+				LCCN:               pi.Title.LCCN,
+				Name:               pi.Title.Name,
+				PlaceOfPublication: pi.Title.PlaceOfPublication,
+				Location:           pi.Title.Location,
+			})
+			t = s.titleByLoc[pi.Title.Location]
+		}
+
+		var issue = &schema.Issue{
+			DatabaseID:   pi.DatabaseID,
+			MARCOrgCode:  pi.MARCOrgCode,
+			RawDate:      pi.RawDate,
+			Edition:      pi.Edition,
+			Location:     pi.Location,
+			Reel:         pi.Reel,
+			WorkflowStep: pi.WorkflowStep,
+		}
+		for _, e := range pi.Errors.All() {
+			issue.Errors.Append(e)
+		}
+		for _, pf := range pi.Files {
+			var dupedFile = *pf.File
+			var f = &schema.File{File: &dupedFile, Location: pf.Location, Issue: issue}
+			for _, e := range pf.Errors.All() {
+				f.Errors.Append(e)
+			}
+			issue.Files = append(issue.Files, f)
+		}
+		t.AddIssue(issue)
+		batch.AddIssue(issue)
+		s.Issues = append(s.Issues, issue)
+	}
+}
+
 func (s *Searcher) cacheLiveIssue(batch *schema.Batch, title *schema.Title, meta *chronam.IssueMetadata) {
 	var _, err = time.Parse("2006-01-02", meta.Date)
 	if err != nil {
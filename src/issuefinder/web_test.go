@@ -0,0 +1,62 @@
+package issuefinder
+
+import (
+	"testing"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestCopyBatchIssues(t *testing.T) {
+	var prevTitle = &schema.Title{LCCN: "sn12345678", Location: "https://oni.example.edu/lccn/sn12345678.json"}
+	var prev = &schema.Batch{Location: "https://oni.example.edu/batches/batch_oru_apple_ver01.json"}
+	prev.AddError(apperr.New("batch error"))
+	var pi = &schema.Issue{RawDate: "2021-01-02", Edition: 1, Reel: "00271761999", Location: "https://oni.example.edu/issue.json", WorkflowStep: schema.WSInProduction}
+	pi.Errors.Append(apperr.New("issue error"))
+	var pf = &schema.File{File: &fileutil.File{Name: "0001.pdf"}, Location: "/tmp/0001.pdf", Issue: pi}
+	pf.Errors.Append(apperr.New("file error"))
+	pi.Files = append(pi.Files, pf)
+	prevTitle.AddIssue(pi)
+	prev.AddIssue(pi)
+
+	var s = &Searcher{titleByLoc: make(map[string]*schema.Title)}
+	var batch = &schema.Batch{Location: prev.Location}
+	s.copyBatchIssues(batch, prev)
+
+	if batch.Errors.Len() != 1 {
+		t.Errorf("Expected the batch error to be copied, got %d errors", batch.Errors.Len())
+	}
+	if len(s.Issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(s.Issues))
+	}
+
+	var i = s.Issues[0]
+	if i == pi || i.Title == prevTitle {
+		t.Errorf("Expected copies, not the previous searcher's objects")
+	}
+	if i.Reel != pi.Reel || i.Key() != pi.Key() || i.Batch != batch {
+		t.Errorf("Issue data wasn't copied: %#v", i)
+	}
+	if i.Errors.Len() != 1 {
+		t.Errorf("Expected the issue error to be copied, got %d errors", i.Errors.Len())
+	}
+	if len(i.Files) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(i.Files))
+	}
+
+	var f = i.Files[0]
+	if f == pf || f.File == pf.File || f.Issue != i || f.Name != "0001.pdf" || f.Location != pf.Location {
+		t.Errorf("File wasn't copied correctly: %#v", f)
+	}
+	if f.Errors.Len() != 1 {
+		t.Errorf("Expected the file error to be copied, got %d errors", f.Errors.Len())
+	}
+
+	// Errors added to the copies mustn't show up in the originals
+	i.Errors.Append(apperr.New("another"))
+	f.Errors.Append(apperr.New("another"))
+	if pi.Errors.Len() != 1 || pf.Errors.Len() != 1 {
+		t.Errorf("Copied error lists share data with the originals")
+	}
+}
@@ -0,0 +1,171 @@
+// Package issuesequence infers an issue's volume, issue number, and edition
+// label from the same title's issues published just before and after it
+package issuesequence
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Neighbor holds the metadata we need from an issue near the one being
+// described.  Issues without a volume and issue number (e.g., those which
+// haven't had metadata entered yet) are of no use and should be skipped.
+type Neighbor struct {
+	Date         string
+	Edition      int
+	Volume       string
+	Issue        string
+	EditionLabel string
+}
+
+func (n *Neighbor) key() string {
+	return fmt.Sprintf("%s/%02d", n.Date, n.Edition)
+}
+
+func (n *Neighbor) String() string {
+	return fmt.Sprintf("%s ed. %d (volume %q, issue %q)", n.Date, n.Edition, n.Volume, n.Issue)
+}
+
+// Suggestion holds the inferred metadata for an issue, and the neighbors it
+// was inferred from.  Any field may be blank if there wasn't enough
+// information to infer a value.
+type Suggestion struct {
+	Volume       string
+	Issue        string
+	EditionLabel string
+	Prev         *Neighbor
+	Next         *Neighbor
+}
+
+// Suggest finds the nearest prior and following issues to the given date and
+// edition, and infers what the issue's volume, issue number, and edition label
+// probably are
+func Suggest(date string, edition int, neighbors []*Neighbor) *Suggestion {
+	var s = &Suggestion{}
+	var self = &Neighbor{Date: date, Edition: edition}
+	var sameEd []*Neighbor
+	for _, n := range neighbors {
+		if n.Volume == "" && n.Issue == "" {
+			continue
+		}
+		var k = n.key()
+		switch {
+		case k < self.key() && (s.Prev == nil || k > s.Prev.key()):
+			s.Prev = n
+		case k > self.key() && (s.Next == nil || k < s.Next.key()):
+			s.Next = n
+		}
+		if n.Edition == edition && n.key() != self.key() {
+			sameEd = append(sameEd, n)
+		}
+	}
+
+	s.EditionLabel = nearestLabel(self, sameEd)
+
+	var prev, next = s.Prev, s.Next
+	switch {
+	// Another edition on the same day shares its volume and number
+	case prev != nil && prev.Date == date:
+		s.Volume, s.Issue = prev.Volume, prev.Issue
+	case next != nil && next.Date == date:
+		s.Volume, s.Issue = next.Volume, next.Issue
+
+	// A volume change between the neighbors where the next issue isn't the
+	// first in its volume means this issue started the new volume
+	case prev != nil && next != nil && prev.Volume != next.Volume && number(next.Issue) > 1:
+		s.Volume, s.Issue = next.Volume, step(next.Issue, -1)
+
+	case prev != nil:
+		s.Volume, s.Issue = prev.Volume, step(prev.Issue, 1)
+
+	// With only a following issue, we can't guess across a volume boundary
+	case next != nil && number(next.Issue) > 1:
+		s.Volume, s.Issue = next.Volume, step(next.Issue, -1)
+	}
+
+	return s
+}
+
+// nearestLabel returns the edition label of the closest issue with the same
+// edition number, preferring an earlier issue when two are equally close
+func nearestLabel(self *Neighbor, list []*Neighbor) string {
+	var best *Neighbor
+	for _, n := range list {
+		if best == nil || distance(self, n) < distance(self, best) ||
+			(distance(self, n) == distance(self, best) && n.key() < best.key()) {
+			best = n
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.EditionLabel
+}
+
+// distance returns a rough "how far apart" value for two issues' dates.  It
+// doesn't need to be accurate, just consistent, so dates are compared as
+// YYYYMMDD integers.
+func distance(a, b *Neighbor) int {
+	var ai, _ = strconv.Atoi(strings.Replace(a.Date, "-", "", -1))
+	var bi, _ = strconv.Atoi(strings.Replace(b.Date, "-", "", -1))
+	if ai > bi {
+		return ai - bi
+	}
+	return bi - ai
+}
+
+var numRegex = regexp.MustCompile(`\d+`)
+
+// number returns the last number found in s, or -1 if s has no number
+func number(s string) int {
+	var matches = numRegex.FindAllString(s, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	var n, _ = strconv.Atoi(matches[len(matches)-1])
+	return n
+}
+
+// step adds delta to the last number in s, keeping any surrounding text
+// ("NUMBER 10" becomes "NUMBER 11").  If s has no number, or the result would
+// be below 1, an empty string is returned.
+func step(s string, delta int) string {
+	var locs = numRegex.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return ""
+	}
+	var loc = locs[len(locs)-1]
+	var n, _ = strconv.Atoi(s[loc[0]:loc[1]])
+	if n+delta < 1 {
+		return ""
+	}
+	return s[:loc[0]] + strconv.Itoa(n+delta) + s[loc[1]:]
+}
+
+// Basis describes the issues a suggestion was inferred from
+func (s *Suggestion) Basis() string {
+	var parts []string
+	if s.Prev != nil {
+		parts = append(parts, "previous issue "+s.Prev.String())
+	}
+	if s.Next != nil {
+		parts = append(parts, "next issue "+s.Next.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Check compares entered values against the suggestion, returning a message
+// for each one which breaks the sequence of nearby issues.  Values we had no
+// suggestion for are never reported.
+func (s *Suggestion) Check(volume, issue string) []string {
+	var msgs []string
+	if s.Volume != "" && volume != "" && volume != s.Volume {
+		msgs = append(msgs, fmt.Sprintf("Volume %q doesn't follow the sequence of nearby issues (expected %q based on %s)", volume, s.Volume, s.Basis()))
+	}
+	if s.Issue != "" && issue != "" && issue != s.Issue {
+		msgs = append(msgs, fmt.Sprintf("Issue number %q doesn't follow the sequence of nearby issues (expected %q based on %s)", issue, s.Issue, s.Basis()))
+	}
+	return msgs
+}
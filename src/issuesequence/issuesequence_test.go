@@ -0,0 +1,85 @@
+package issuesequence
+
+import (
+	"testing"
+)
+
+func n(date string, ed int, vol, issue, label string) *Neighbor {
+	return &Neighbor{Date: date, Edition: ed, Volume: vol, Issue: issue, EditionLabel: label}
+}
+
+func TestSuggest(t *testing.T) {
+	var tests = map[string]struct {
+		date      string
+		edition   int
+		neighbors []*Neighbor
+		vol       string
+		issue     string
+		label     string
+	}{
+		"no neighbors": {"2020-01-08", 1, nil, "", "", ""},
+		"prev only": {"2020-01-08", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "5", ""),
+		}, "12", "6", ""},
+		"nearest prev wins": {"2020-01-15", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "5", ""),
+			n("2020-01-08", 1, "12", "6", ""),
+		}, "12", "7", ""},
+		"text around number": {"2020-01-08", 1, []*Neighbor{
+			n("2020-01-01", 1, "VOLUME 12", "NUMBER 9", ""),
+		}, "VOLUME 12", "NUMBER 10", ""},
+		"next only": {"2020-01-01", 1, []*Neighbor{
+			n("2020-01-08", 1, "12", "6", ""),
+		}, "12", "5", ""},
+		"next only, starts volume": {"2020-01-01", 1, []*Neighbor{
+			n("2020-01-08", 1, "13", "1", ""),
+		}, "", "", ""},
+		"volume ends here": {"2020-01-08", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "51", ""),
+			n("2020-01-15", 1, "13", "1", ""),
+		}, "12", "52", ""},
+		"volume starts here": {"2020-01-08", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "52", ""),
+			n("2020-01-15", 1, "13", "2", ""),
+		}, "13", "1", ""},
+		"second edition same day": {"2020-01-08", 2, []*Neighbor{
+			n("2020-01-01", 2, "12", "5", "Evening edition"),
+			n("2020-01-08", 1, "12", "6", "Morning edition"),
+		}, "12", "6", "Evening edition"},
+		"skips issues without metadata": {"2020-01-15", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "5", ""),
+			n("2020-01-08", 1, "", "", ""),
+		}, "12", "6", ""},
+		"non-numeric issue": {"2020-01-08", 1, []*Neighbor{
+			n("2020-01-01", 1, "12", "SPECIAL", ""),
+		}, "12", "", ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s = Suggest(tc.date, tc.edition, tc.neighbors)
+			if s.Volume != tc.vol || s.Issue != tc.issue || s.EditionLabel != tc.label {
+				t.Errorf("expected volume %q, issue %q, label %q; got %q, %q, %q",
+					tc.vol, tc.issue, tc.label, s.Volume, s.Issue, s.EditionLabel)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var s = Suggest("2020-01-08", 1, []*Neighbor{n("2020-01-01", 1, "12", "5", "")})
+	if msgs := s.Check("12", "6"); len(msgs) != 0 {
+		t.Errorf("expected no warnings for matching values, got %#v", msgs)
+	}
+	if msgs := s.Check("12", "7"); len(msgs) != 1 {
+		t.Errorf("expected one warning for a skipped issue number, got %#v", msgs)
+	}
+	if msgs := s.Check("13", "5"); len(msgs) != 2 {
+		t.Errorf("expected two warnings for wrong volume and issue number, got %#v", msgs)
+	}
+
+	s = Suggest("2020-01-08", 1, nil)
+	if msgs := s.Check("1", "1"); len(msgs) != 0 {
+		t.Errorf("expected no warnings without a suggestion, got %#v", msgs)
+	}
+}
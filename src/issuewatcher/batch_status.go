@@ -0,0 +1,87 @@
+package issuewatcher
+
+import (
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// webBatchNames returns a lookup of the full names of all batches in f's
+// website searcher, or nil if f has no website data
+func webBatchNames(f *issuefinder.Finder) map[string]bool {
+	if f == nil {
+		return nil
+	}
+	var s = f.Searchers[issuefinder.Website]
+	if s == nil {
+		return nil
+	}
+
+	var names = make(map[string]bool)
+	for _, b := range s.Batches {
+		names[b.Fullname()] = true
+	}
+	return names
+}
+
+// SyncBatchStatuses looks for in-process batches which have shown up on
+// staging or production, updating their statuses to match.  Batches which
+// are "qc_ready" and found on staging become "on_staging", and batches found
+// in production become "live" unless they failed QC, since those need a
+// human to figure out what happened.
+//
+// Nothing happens for a site the scanner hasn't read, so this is safe to call
+// with a scanner that skips web data or has no staging site configured.
+func (s *Scanner) SyncBatchStatuses() error {
+	var live = webBatchNames(s.Finder)
+	var staging = webBatchNames(s.Staging)
+	if live == nil && staging == nil {
+		return nil
+	}
+
+	var batches, err = models.InProcessBatches()
+	if err != nil {
+		return err
+	}
+
+	for _, b := range batches {
+		var name = b.FullName()
+		switch newBatchStatus(b, live, staging) {
+		case models.BatchStatusLive:
+			logger.Infof("Batch %q (id %d) found in production; setting status to %q", name, b.ID, models.BatchStatusLive)
+			err = b.SetLive()
+
+		case models.BatchStatusOnStaging:
+			logger.Infof("Batch %q (id %d) found on staging; setting status to %q", name, b.ID, models.BatchStatusOnStaging)
+			b.Status = models.BatchStatusOnStaging
+			err = b.Save()
+
+		default:
+			if live[name] {
+				logger.Warnf("Batch %q (id %d) failed QC but is in production; not changing its status", name, b.ID)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newBatchStatus returns the status b should have given the batches found on
+// the live and staging sites, or an empty string if b's status shouldn't
+// change
+func newBatchStatus(b *models.Batch, live, staging map[string]bool) string {
+	var name = b.FullName()
+	switch {
+	case live[name] && b.Status == models.BatchStatusFailedQC:
+		return ""
+	case live[name]:
+		return models.BatchStatusLive
+	case staging[name] && b.Status == models.BatchStatusQCReady:
+		return models.BatchStatusOnStaging
+	}
+	return ""
+}
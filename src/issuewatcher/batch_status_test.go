@@ -0,0 +1,65 @@
+package issuewatcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestWebBatchNames(t *testing.T) {
+	if webBatchNames(nil) != nil {
+		t.Errorf("Expected no names for a nil finder")
+	}
+
+	var f = issuefinder.New()
+	if webBatchNames(f) != nil {
+		t.Errorf("Expected no names for a finder without web data")
+	}
+
+	f.Searchers[issuefinder.Website] = &issuefinder.Searcher{
+		Namespace: issuefinder.Website,
+		Batches:   []*schema.Batch{{MARCOrgCode: "oru", Keyword: "apple", Version: 1}},
+	}
+	var names = webBatchNames(f)
+	if len(names) != 1 || !names["batch_oru_apple_ver01"] {
+		t.Errorf("Expected only batch_oru_apple_ver01, got %v", names)
+	}
+}
+
+func TestNewBatchStatus(t *testing.T) {
+	var b = &models.Batch{MARCOrgCode: "oru", Name: "apple", CreatedAt: time.Now(), Version: 1}
+	var name = b.FullName()
+	var found = map[string]bool{name: true}
+
+	var tests = map[string]struct {
+		status  string
+		live    map[string]bool
+		staging map[string]bool
+		want    string
+	}{
+		"qc ready, on staging":       {models.BatchStatusQCReady, nil, found, models.BatchStatusOnStaging},
+		"qc ready, live":             {models.BatchStatusQCReady, found, nil, models.BatchStatusLive},
+		"qc ready, live and staging": {models.BatchStatusQCReady, found, found, models.BatchStatusLive},
+		"qc ready, nowhere":          {models.BatchStatusQCReady, nil, nil, ""},
+		"on staging, on staging":     {models.BatchStatusOnStaging, nil, found, ""},
+		"on staging, live":           {models.BatchStatusOnStaging, found, found, models.BatchStatusLive},
+		"passed qc, live":            {models.BatchStatusPassedQC, found, nil, models.BatchStatusLive},
+		"passed qc, on staging":      {models.BatchStatusPassedQC, nil, found, ""},
+		"failed qc, live":            {models.BatchStatusFailedQC, found, nil, ""},
+		"failed qc, on staging":      {models.BatchStatusFailedQC, nil, found, ""},
+		"other batches found":        {models.BatchStatusQCReady, map[string]bool{"x": true}, map[string]bool{"y": true}, ""},
+	}
+
+	for tname, tc := range tests {
+		t.Run(tname, func(t *testing.T) {
+			b.Status = tc.status
+			var got = newBatchStatus(b, tc.live, tc.staging)
+			if got != tc.want {
+				t.Errorf("Expected new status %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package issuewatcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+)
+
+// quietPeriod is how long a title directory must go without changes before we
+// refresh its data.  Uploads generate a flurry of events, and there's no
+// sense rescanning a title for every chunk of every file written.
+const quietPeriod = 3 * time.Second
+
+// uploadRoot describes one of the upload trees we watch
+type uploadRoot struct {
+	path string
+	ns   issuefinder.Namespace
+
+	// depth is how far below path title directories live
+	depth int
+
+	// maxDepth is how far below path the deepest issue directories live, and
+	// therefore the deepest directories we need to watch
+	maxDepth int
+}
+
+// fsState holds the data needed for incremental, filesystem-driven updates
+type fsState struct {
+	sync.Mutex
+	fsw   *fsnotify.Watcher
+	roots []uploadRoot
+
+	// pending holds title paths awaiting a refresh, and the time of the most
+	// recent change to each
+	pending map[string]time.Time
+
+	// seen holds every title path which has changed since the last full
+	// refresh began, so a full refresh that started before a change doesn't
+	// clobber it
+	seen map[string]time.Time
+}
+
+// WatchFilesystem starts watching the SFTP and scan upload directories for
+// changes.  When a title directory changes, only that title's data is
+// refreshed, so new uploads show up within seconds rather than waiting for the
+// next full scan.  Watch must still be called: full scans act as a safety net
+// for anything the filesystem events don't catch, and are the only way web
+// and in-process issues are refreshed.
+func (w *Watcher) WatchFilesystem() error {
+	var fsw, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	var s = w.CurrentScanner()
+	var fs = &fsState{
+		fsw:     fsw,
+		pending: make(map[string]time.Time),
+		seen:    make(map[string]time.Time),
+	}
+	if !s.skipsftp {
+		fs.roots = append(fs.roots, uploadRoot{path: s.PDFUpload, ns: issuefinder.SFTPUpload, depth: 1, maxDepth: 2})
+	}
+	if !s.skipscan {
+		// Scanned issues are usually right under the title, but microfilm issues
+		// are in a reel directory: <moc>/<lccn>/reel-<number>/<issue>
+		fs.roots = append(fs.roots, uploadRoot{path: s.ScanUpload, ns: issuefinder.ScanUpload, depth: 2, maxDepth: 4})
+	}
+
+	for _, r := range fs.roots {
+		err = fs.addWatches(r, r.path)
+		if err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+
+	w.Lock()
+	w.fs = fs
+	w.Unlock()
+
+	go w.handleEvents()
+	go w.processChanges()
+	return nil
+}
+
+// addWatches watches dir and its subdirectories down to the issue level; the
+// fsnotify package doesn't do recursive watching on its own
+func (fs *fsState) addWatches(r uploadRoot, dir string) error {
+	var dirs, err = watchDirs(r, dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		err = fs.fsw.Add(d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchDirs returns dir and all its subdirectories which are no deeper than
+// the deepest issue directories in r
+func watchDirs(r uploadRoot, dir string) ([]string, error) {
+	var dirs = []string{dir}
+
+	// Issue directories are as deep as we care about
+	if depthBelow(r.path, dir) >= r.maxDepth {
+		return dirs, nil
+	}
+
+	var infos, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			var sub []string
+			sub, err = watchDirs(r, filepath.Join(dir, info.Name()))
+			if err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, sub...)
+		}
+	}
+
+	return dirs, nil
+}
+
+// depthBelow returns how many levels below root path is, or -1 if it isn't
+// within root at all
+func depthBelow(root, path string) int {
+	var rel, err = filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return -1
+	}
+	if rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// handleEvents reads filesystem events, adding watches for new directories
+// and flagging the affected titles for a refresh
+func (w *Watcher) handleEvents() {
+	var fs = w.fs
+	for {
+		select {
+		case ev, ok := <-fs.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(ev)
+
+		case err, ok := <-fs.fsw.Errors:
+			if !ok {
+				return
+			}
+			// An overflow means we've lost events; the next full scan will catch
+			// up, so all we can do is make noise
+			logger.Warnf("Error watching upload directories: %s", err)
+		}
+	}
+}
+
+func (w *Watcher) handleEvent(ev fsnotify.Event) {
+	var fs = w.fs
+	for _, r := range fs.roots {
+		var depth = depthBelow(r.path, ev.Name)
+		if depth < 1 {
+			continue
+		}
+
+		if ev.Op&fsnotify.Create != 0 {
+			var info, err = os.Stat(ev.Name)
+			if err == nil && info.IsDir() && depth <= r.maxDepth {
+				err = fs.addWatches(r, ev.Name)
+				if err != nil {
+					logger.Warnf("Unable to watch %q: %s", ev.Name, err)
+				}
+			}
+		}
+
+		var tp = TitlePath(r.path, ev.Name, r.depth)
+		if tp != "" {
+			fs.queue(tp)
+			return
+		}
+
+		// Changes above the title level (e.g., a MOC directory in the scans
+		// path) affect every title we know about under it, plus any titles that
+		// now exist under it
+		for _, tp := range w.titlesUnder(r, ev.Name) {
+			fs.queue(tp)
+		}
+		return
+	}
+}
+
+// titlesUnder returns all title paths in the given directory, both those the
+// scanner currently knows about and those on disk
+func (w *Watcher) titlesUnder(r uploadRoot, dir string) []string {
+	var found = make(map[string]bool)
+
+	var srch = w.CurrentScanner().Finder.Searchers[r.ns]
+	if srch != nil {
+		for _, t := range srch.Titles {
+			if strings.HasPrefix(t.Location, dir+string(filepath.Separator)) {
+				found[t.Location] = true
+			}
+		}
+	}
+
+	var pattern = dir + strings.Repeat(string(filepath.Separator)+"*", r.depth-depthBelow(r.path, dir))
+	var matches, _ = filepath.Glob(pattern)
+	for _, m := range matches {
+		found[m] = true
+	}
+
+	var paths []string
+	for p := range found {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// queue flags a title path as needing a refresh once changes settle down
+func (fs *fsState) queue(titlePath string) {
+	var now = time.Now()
+	fs.Lock()
+	fs.pending[titlePath] = now
+	fs.seen[titlePath] = now
+	fs.Unlock()
+}
+
+// ready pulls all title paths which haven't changed in the quiet period
+func (fs *fsState) ready() []string {
+	fs.Lock()
+	defer fs.Unlock()
+
+	var paths []string
+	for p, t := range fs.pending {
+		if time.Since(t) >= quietPeriod {
+			paths = append(paths, p)
+			delete(fs.pending, p)
+		}
+	}
+	return paths
+}
+
+// requeueSince flags every title path changed at or after t, and forgets
+// about anything older.  This is called after a full refresh, since the
+// refresh may have read a title before it changed.
+func (fs *fsState) requeueSince(t time.Time) {
+	fs.Lock()
+	defer fs.Unlock()
+
+	for p, changed := range fs.seen {
+		if changed.Before(t) {
+			delete(fs.seen, p)
+			continue
+		}
+		if _, ok := fs.pending[p]; !ok {
+			fs.pending[p] = changed
+		}
+	}
+}
+
+// processChanges loops forever, refreshing titles whose directories have
+// stopped changing
+func (w *Watcher) processChanges() {
+	for {
+		time.Sleep(time.Second)
+
+		w.RLock()
+		var stopped = w.status&finished != 0
+		w.RUnlock()
+		if stopped {
+			w.fs.fsw.Close()
+			return
+		}
+
+		var paths = w.fs.ready()
+		if len(paths) > 0 {
+			w.refreshPaths(paths)
+		}
+	}
+}
+
+// refreshPaths runs an incremental refresh of the given title paths.  If a
+// full refresh swaps out the scanner while we work, we discard our changes
+// and try again on the next pass.
+func (w *Watcher) refreshPaths(paths []string) {
+	logger.Debugf("Refreshing upload data for %q", paths)
+
+	var cur = w.CurrentScanner()
+	var next, err = cur.Refresh(paths)
+	if err != nil {
+		logger.Errorf("Unable to refresh upload data: %s", err)
+		return
+	}
+
+	w.Lock()
+	var swapped = w.Scanner != cur
+	if !swapped {
+		w.Scanner = next
+	}
+	w.Unlock()
+
+	if swapped {
+		for _, p := range paths {
+			w.fs.queue(p)
+		}
+		return
+	}
+	w.serialize()
+}
@@ -0,0 +1,128 @@
+package issuewatcher
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func newTestFSState() *fsState {
+	return &fsState{
+		roots: []uploadRoot{
+			{path: "/mnt/sftp", ns: issuefinder.SFTPUpload, depth: 1, maxDepth: 2},
+			{path: "/mnt/scans", ns: issuefinder.ScanUpload, depth: 2, maxDepth: 4},
+		},
+		pending: make(map[string]time.Time),
+		seen:    make(map[string]time.Time),
+	}
+}
+
+func pendingPaths(fs *fsState) []string {
+	var paths []string
+	for p := range fs.pending {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestHandleEvent(t *testing.T) {
+	var scans = &issuefinder.Searcher{
+		Namespace: issuefinder.ScanUpload,
+		Location:  "/mnt/scans",
+		Titles:    schema.TitleList{{LCCN: "sn1", Location: "/mnt/scans/oru/sn1"}},
+	}
+	var s = newScanner()
+	s.Finder.Searchers[issuefinder.ScanUpload] = scans
+
+	var tests = map[string]struct {
+		path string
+		want []string
+	}{
+		"sftp issue file": {"/mnt/sftp/foo/2020-01-02/0001.pdf", []string{"/mnt/sftp/foo"}},
+		"sftp title":      {"/mnt/sftp/foo", []string{"/mnt/sftp/foo"}},
+		"scan issue":      {"/mnt/scans/oru/sn2/2020-01-02", []string{"/mnt/scans/oru/sn2"}},
+		"scan reel issue": {"/mnt/scans/oru/sn2/reel-0001/2020-01-02/0001.tif", []string{"/mnt/scans/oru/sn2"}},
+		"scan moc":        {"/mnt/scans/oru", []string{"/mnt/scans/oru/sn1"}},
+		"outside roots":   {"/mnt/other/foo", nil},
+		"root itself":     {"/mnt/sftp", nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var w = &Watcher{Scanner: s, fs: newTestFSState()}
+			w.handleEvent(fsnotify.Event{Name: tc.path, Op: fsnotify.Write})
+
+			var got = pendingPaths(w.fs)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected pending paths %q, got %q", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected pending paths %q, got %q", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequeueSince(t *testing.T) {
+	var fs = newTestFSState()
+	var start = time.Now()
+	fs.seen["/mnt/sftp/old"] = start.Add(-time.Minute)
+	fs.seen["/mnt/sftp/new"] = start.Add(time.Second)
+	fs.seen["/mnt/sftp/pending"] = start.Add(time.Second)
+	fs.pending["/mnt/sftp/pending"] = start.Add(2 * time.Second)
+
+	fs.requeueSince(start)
+
+	if _, ok := fs.seen["/mnt/sftp/old"]; ok {
+		t.Errorf("Expected changes from before the refresh to be forgotten")
+	}
+	var got = pendingPaths(fs)
+	if len(got) != 2 || got[0] != "/mnt/sftp/new" || got[1] != "/mnt/sftp/pending" {
+		t.Errorf("Expected changes since the refresh to be pending, got %q", got)
+	}
+	if !fs.pending["/mnt/sftp/pending"].Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Expected an already-pending path to keep its most recent change time")
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	var root = filepath.Join("testdata", "scans")
+	var r = uploadRoot{path: root, ns: issuefinder.ScanUpload, depth: 2, maxDepth: 4}
+	var dirs, err = watchDirs(r, root)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var got = make(map[string]bool)
+	for _, d := range dirs {
+		var rel, _ = filepath.Rel(root, d)
+		got[filepath.ToSlash(rel)] = true
+	}
+
+	// Everything down to issues in a reel must be watched, but nothing deeper
+	var want = []string{
+		".",
+		"oru",
+		"oru/sn12345678",
+		"oru/sn12345678/2021-01-03",
+		"oru/sn12345678/reel-00271761999",
+		"oru/sn12345678/reel-00271761999/2021-01-02",
+		"oru/sn12345678/reel-00271761999/targets",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("Expected %q to be watched", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d watched dirs, got %d: %v", len(want), len(got), dirs)
+	}
+}
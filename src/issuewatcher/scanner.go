@@ -2,6 +2,8 @@ package issuewatcher
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
@@ -21,6 +23,18 @@ type Scanner struct {
 	Lookup              *schema.Lookup
 	CanonIssues         map[string]*schema.Issue
 
+	// StagingWebroot is the optional staging site URL.  Staging data is kept in
+	// its own Finder, since issues on staging are usually also in process, and
+	// we don't want those treated as dupes of themselves.
+	StagingWebroot string
+	Staging        *issuefinder.Finder
+
+	// previous and previousStaging hold the data from the scanner this was
+	// duplicated from, if any, so web batches we've already seen needn't be
+	// reprocessed
+	previous        *issuefinder.Finder
+	previousStaging *issuefinder.Finder
+
 	skipweb  bool
 	skipsftp bool
 	skipscan bool
@@ -43,6 +57,7 @@ func NewScanner(conf *config.Config) *Scanner {
 	s.ScanUpload = conf.ScanUploadPath
 	s.PDFUpload = conf.PDFUploadPath
 	s.PDFBatchMARCOrgCode = conf.PDFBatchMARCOrgCode
+	s.StagingWebroot = conf.StagingNewsWebroot
 
 	return s
 }
@@ -72,17 +87,22 @@ func (s *Scanner) DisableDB() *Scanner {
 }
 
 // Duplicate creates a new Scanner with the same configuration as this one, but
-// with no data
+// with no data.  The new scanner remembers s's data only to speed up its
+// scans of live batches.
 func (s *Scanner) Duplicate() *Scanner {
 	var s2 = newScanner()
 	s2.Webroot = s.Webroot
 	s2.Tempdir = s.Tempdir
 	s2.ScanUpload = s.ScanUpload
 	s2.PDFUpload = s.PDFUpload
+	s2.PDFBatchMARCOrgCode = s.PDFBatchMARCOrgCode
+	s2.StagingWebroot = s.StagingWebroot
 	s2.skipweb = s.skipweb
 	s2.skipsftp = s.skipsftp
 	s2.skipscan = s.skipscan
 	s2.skipdb = s.skipdb
+	s2.previous = s.Finder
+	s2.previousStaging = s.Staging
 
 	return s2
 }
@@ -101,12 +121,32 @@ func (s *Scanner) Scan() error {
 	var err error
 
 	if !s.skipweb {
-		_, err = f.FindWebBatches(s.Webroot, s.Tempdir)
+		var prev *issuefinder.Searcher
+		if s.previous != nil {
+			prev = s.previous.Searchers[issuefinder.Website]
+		}
+		_, err = f.UpdateWebBatches(s.Webroot, s.Tempdir, prev)
 		if err != nil {
 			return fmt.Errorf("unable to cache web batches: %s", err)
 		}
 	}
 
+	var staging *issuefinder.Finder
+	if !s.skipweb && s.StagingWebroot != "" {
+		var prev *issuefinder.Searcher
+		if s.previousStaging != nil {
+			prev = s.previousStaging.Searchers[issuefinder.Website]
+		}
+
+		// Staging's JSON must be cached separately: its URL paths are the same
+		// as production's
+		staging = issuefinder.New()
+		_, err = staging.UpdateWebBatches(s.StagingWebroot, filepath.Join(s.Tempdir, "staging"), prev)
+		if err != nil {
+			return fmt.Errorf("unable to cache staging batches: %s", err)
+		}
+	}
+
 	if !s.skipdb {
 		_, err = f.FindInProcessIssues()
 		if err != nil {
@@ -133,14 +173,101 @@ func (s *Scanner) Scan() error {
 	s.Lookup.Populate(f.Issues)
 
 	// If this is an "everything" scanner, we need to check all issues for dupes
-	if !s.skipweb && !s.skipdb && !s.skipsftp && !s.skipscan {
+	if s.isComplete() {
 		for _, i := range f.Issues {
 			i.CheckDupes(s.Lookup)
 		}
 	}
 
-	// Swap out the finder
+	// Swap out the finders
 	s.Finder = f
+	s.Staging = staging
 
 	return nil
 }
+
+// Refresh returns a new Scanner with updated data for the given upload
+// directories, which must be title directories in the SFTP or scan upload
+// paths, e.g., "<PDF upload path>/<sftpdir>" or "<scan upload
+// path>/<moc>/<lccn>".  Anything else in paths is ignored.  s is not modified,
+// so it can continue to be used safely while this runs.
+func (s *Scanner) Refresh(paths []string) (*Scanner, error) {
+	var searchers = make(map[issuefinder.Namespace]*issuefinder.Searcher)
+	var newIssues schema.IssueList
+
+	for _, path := range paths {
+		var ns, ok = s.namespaceFor(path)
+		if !ok {
+			continue
+		}
+
+		var srch = searchers[ns]
+		if srch == nil {
+			var orig = s.Finder.Searchers[ns]
+			if orig == nil {
+				continue
+			}
+			srch = orig.Copy()
+			searchers[ns] = srch
+		}
+
+		var issues, err = srch.RefreshTitlePath(path, s.PDFBatchMARCOrgCode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to refresh %q: %s", path, err)
+		}
+		newIssues = append(newIssues, issues...)
+	}
+
+	var s2 = s.Duplicate()
+	s2.Finder = s.Finder
+	s2.Staging = s.Staging
+	for _, srch := range searchers {
+		s2.Finder = s2.Finder.ReplaceSearcher(srch)
+	}
+	s2.Lookup = schema.NewLookup()
+	s2.Lookup.Populate(s2.Finder.Issues)
+
+	// Only the refreshed issues need a dupe check: everything else was checked
+	// when it was first found
+	if s2.isComplete() {
+		for _, i := range newIssues {
+			i.CheckDupes(s2.Lookup)
+		}
+	}
+
+	return s2, nil
+}
+
+// namespaceFor returns the namespace for a title path if it is in one of the
+// upload locations this scanner searches
+func (s *Scanner) namespaceFor(path string) (issuefinder.Namespace, bool) {
+	if !s.skipsftp && TitlePath(s.PDFUpload, path, 1) == path {
+		return issuefinder.SFTPUpload, true
+	}
+	if !s.skipscan && TitlePath(s.ScanUpload, path, 2) == path {
+		return issuefinder.ScanUpload, true
+	}
+	return 0, false
+}
+
+// isComplete returns true if this is an "everything" scanner
+func (s *Scanner) isComplete() bool {
+	return !s.skipweb && !s.skipdb && !s.skipsftp && !s.skipscan
+}
+
+// TitlePath returns the title directory containing path, where depth is how
+// many levels below root titles live: 1 for SFTP uploads (<root>/<title>) and
+// 2 for scans (<root>/<moc>/<title>).  If path isn't at least depth levels
+// below root, an empty string is returned.
+func TitlePath(root, path string, depth int) string {
+	var rel, err = filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	var parts = strings.Split(rel, string(filepath.Separator))
+	if len(parts) < depth {
+		return ""
+	}
+	return filepath.Join(append([]string{root}, parts[:depth]...)...)
+}
@@ -0,0 +1,29 @@
+package issuewatcher
+
+import "testing"
+
+func TestTitlePath(t *testing.T) {
+	var tests = map[string]struct {
+		root  string
+		path  string
+		depth int
+		want  string
+	}{
+		"sftp title":        {"/mnt/sftp", "/mnt/sftp/foo", 1, "/mnt/sftp/foo"},
+		"sftp issue file":   {"/mnt/sftp", "/mnt/sftp/foo/2020-01-02/0001.pdf", 1, "/mnt/sftp/foo"},
+		"sftp root":         {"/mnt/sftp", "/mnt/sftp", 1, ""},
+		"outside root":      {"/mnt/sftp", "/mnt/scans/foo", 1, ""},
+		"scan title":        {"/mnt/scans", "/mnt/scans/oru/sn12345678/2020-01-02_01", 2, "/mnt/scans/oru/sn12345678"},
+		"scan moc":          {"/mnt/scans", "/mnt/scans/oru", 2, ""},
+		"root prefix match": {"/mnt/sftp", "/mnt/sftp2/foo", 1, ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got = TitlePath(tc.root, tc.path, tc.depth)
+			if got != tc.want {
+				t.Errorf("TitlePath(%q, %q, %d): expected %q, got %q", tc.root, tc.path, tc.depth, tc.want, got)
+			}
+		})
+	}
+}
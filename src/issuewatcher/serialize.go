@@ -4,33 +4,96 @@ import (
 	"path/filepath"
 
 	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/issuefinder"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
-// CacheFile returns the standard path to the cache file based on the
+// CacheFile returns the standard path to the issue store based on the
 // configuration of the watcher
 func (s *Scanner) CacheFile() string {
+	return filepath.Join(s.Tempdir, "finder.db")
+}
+
+// legacyCacheFile returns the path to the old gob-encoded cache file, which
+// we migrate to the store if it's found
+func (s *Scanner) legacyCacheFile() string {
 	return filepath.Join(s.Tempdir, "finder.cache")
 }
 
-// Serialize writes all internal search data to the CacheFile
+// HasCache returns true if there's an issue store or an old cache file which
+// can be migrated to the store
+func (s *Scanner) HasCache() bool {
+	return fileutil.Exists(s.CacheFile()) || fileutil.Exists(s.legacyCacheFile())
+}
+
+// Serialize writes all internal search data to the issue store.  Only data
+// which has changed since the last save is written.
 func (s *Scanner) Serialize() error {
-	return s.Finder.Serialize(s.CacheFile())
+	var st, err = issuefinder.OpenStore(s.CacheFile())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	return st.Save(s.Finder)
 }
 
-// Deserialize attempts to read the CacheFile if it exists, populating the
-// searchers and issue lookup
+// Deserialize attempts to read the issue store if it exists, populating the
+// searchers and issue lookup.  If there's no store data, but there's an old
+// cache file, its data is migrated first.
 func (s *Scanner) Deserialize() error {
-	var cacheFile = s.CacheFile()
-	if fileutil.Exists(cacheFile) {
-		var finder, err = issuefinder.Deserialize(cacheFile)
+	if !s.HasCache() {
+		return nil
+	}
+
+	var st, err = issuefinder.OpenStore(s.CacheFile())
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	var legacy = s.legacyCacheFile()
+	if st.Empty() && fileutil.Exists(legacy) {
+		logger.Infof("Migrating issue cache %q to %q", legacy, s.CacheFile())
+		err = st.MigrateCacheFile(legacy)
 		if err != nil {
 			return err
 		}
-		s.Finder = finder
-		s.Lookup = schema.NewLookup()
-		s.Lookup.Populate(s.Finder.Issues)
 	}
+
+	if st.Empty() {
+		return nil
+	}
+
+	var finder *issuefinder.Finder
+	finder, err = st.Finder()
+	if err != nil {
+		return err
+	}
+	s.Finder = finder
+	s.Lookup = schema.NewLookup()
+	s.Lookup.Populate(s.Finder.Issues)
 	return nil
 }
+
+// StoredIssues returns the issues in the issue store matching the given key.
+// Unlike LookupIssues, this doesn't require the scanner's data to be loaded,
+// making it far faster for one-off queries.
+func (s *Scanner) StoredIssues(key *schema.Key) (schema.IssueList, error) {
+	// Make sure old cache data is migrated before we query the store
+	if !fileutil.Exists(s.CacheFile()) {
+		var err = s.Deserialize()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var st, err = issuefinder.OpenStore(s.CacheFile())
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	return st.Issues(key)
+}
@@ -22,6 +22,13 @@ type Watcher struct {
 	status          watcherStatus
 	lastFullRefresh time.Time
 	done            chan bool
+
+	// saving keeps full and incremental refreshes from writing to the issue
+	// store at the same time
+	saving sync.Mutex
+
+	// fs is only set up if WatchFilesystem is called
+	fs *fsState
 }
 
 type watcherStatus int
@@ -58,25 +65,73 @@ func New(conf *config.Config) *Watcher {
 	}
 }
 
+// CurrentScanner returns the watcher's scanner.  Refreshes replace the
+// scanner rather than modifying it, so the returned value is safe to use
+// without holding the watcher's lock.
+func (w *Watcher) CurrentScanner() *Scanner {
+	w.RLock()
+	defer w.RUnlock()
+	return w.Scanner
+}
+
+// Ready returns true once the watcher has issue data, whether from the issue
+// store or an initial scan
+func (w *Watcher) Ready() bool {
+	return w.CurrentScanner().Finder.Issues != nil
+}
+
+// serialize writes the current scanner's data to the issue store.  Every
+// refresh, full or incremental, does this so that a restart loses as little
+// as possible.
+func (w *Watcher) serialize() {
+	w.saving.Lock()
+	defer w.saving.Unlock()
+
+	var s = w.CurrentScanner()
+	var err = s.Serialize()
+	if err != nil {
+		logger.Warnf("Unable to cache to %#v: %s", s.CacheFile(), err)
+	}
+}
+
 // Watch loops forever, refreshing the data in the underlying Finder every so
 // often.  The refreshing happens on a new issuefinder.Finder which then
 // replaces the current finder data, preventing slow searches from holding up
 // read access.
 func (w *Watcher) Watch(interval time.Duration) {
-	w.Lock()
+	// Loading the initial data can be slow, so it's done on a separate scanner
+	// which is swapped in afterward, rather than holding a lock that would
+	// block every reader
+	var s = w.CurrentScanner().Duplicate()
 
 	// If a cache file is available, use it, but we'll still be refreshing data
 	// immediately; this just gets the watcher up and running more quickly
-	var err = w.Scanner.Deserialize()
+	var err = s.Deserialize()
 	if err != nil {
-		logger.Fatalf("Unable to deserialize the cache file %#v: %s", w.Scanner.CacheFile(), err)
+		logger.Fatalf("Unable to deserialize the cache file %#v: %s", s.CacheFile(), err)
 	}
 
+	// Without a cache, we do a quick scan of everything but the web so that
+	// there's some data to work with while the (potentially very slow) full
+	// scan runs
+	if s.Finder.Issues == nil && !s.skipweb {
+		var quick = s.Duplicate().DisableWeb()
+		err = quick.Scan()
+		if err != nil {
+			logger.Warnf("Unable to run initial local scan: %s", err)
+		} else {
+			s.Finder = quick.Finder
+			s.Lookup = quick.Lookup
+		}
+	}
+
+	w.Lock()
 	if w.status&running != 0 {
 		logger.Warnf("Trying to watch issues on an in-progress finder (status: %s)", w.status)
 		w.Unlock()
 		return
 	}
+	w.Scanner = s
 	w.status |= running
 	w.Unlock()
 
@@ -85,10 +140,7 @@ func (w *Watcher) Watch(interval time.Duration) {
 		if time.Since(lastRefresh) > interval {
 			w.refresh()
 			lastRefresh = time.Now()
-			var err = w.Scanner.Serialize()
-			if err != nil {
-				logger.Warnf("Unable to cache to %#v: %s", w.Scanner.CacheFile(), err)
-			}
+			w.serialize()
 		}
 		time.Sleep(time.Second * 1)
 
@@ -117,7 +169,7 @@ func (w *Watcher) Stop() {
 	w.Lock()
 	_ = <-w.done
 	w.status = finished
-	w.cleanupTempDir()
+	removeTempDir(w.Scanner.Tempdir)
 	w.Unlock()
 }
 
@@ -145,24 +197,28 @@ func (w *Watcher) refresh() {
 	w.status |= refreshing
 	w.Unlock()
 
-	// Every week, we force a full web refresh
-	var tempdir string
+	// Every week, we force a full web refresh, which includes rereading batches
+	// we've already seen
+	var cur = w.CurrentScanner()
+	var purged bool
 	if time.Since(w.lastFullRefresh) > time.Hour*24*7 {
 		logger.Debugf("Purging cache and reindexing all data from scratch")
-
-		// We don't want to delete tempdir when it's a routine cleaning!  TODO: make this way less hacky
-		tempdir = w.Scanner.Tempdir
-		w.cleanupTempDir()
-		w.Scanner.Tempdir = tempdir
+		removeTempDir(cur.Tempdir)
+		purged = true
 		w.lastFullRefresh = time.Now()
 	}
 
 	// This won't do anything if we already have a temp dir
-	w.makeTempDir()
+	makeTempDir(cur.Tempdir)
 
 	// Now actually run the scanner and replace it; during this process it's safe
 	// for other stuff to happen
-	var newScanner = w.Scanner.Duplicate()
+	var start = time.Now()
+	var newScanner = cur.Duplicate()
+	if purged {
+		newScanner.previous = nil
+		newScanner.previousStaging = nil
+	}
 	var err = newScanner.Scan()
 
 	// This is supposed to happen in the background, so an error can only be
@@ -179,15 +235,25 @@ func (w *Watcher) refresh() {
 	w.Lock()
 	w.Scanner = newScanner
 	w.status &= ^refreshing
+	var fs = w.fs
 	w.Unlock()
 
+	// Anything that changed on disk after we started scanning may have been
+	// missed, so the incremental watcher needs to look at it again
+	if fs != nil {
+		fs.requeueSince(start)
+	}
+
+	err = newScanner.SyncBatchStatuses()
+	if err != nil {
+		logger.Errorf("Unable to update batch statuses from web data: %s", err)
+	}
+
 	logger.Debugf("Issue data refreshed")
 }
 
-// cleanupTempDir removes the httpcache temp dir files and subdirectories.
-// This must have a lock to be used safely.
-func (w *Watcher) cleanupTempDir() {
-	var td = w.Scanner.Tempdir
+// removeTempDir removes the httpcache temp dir files and subdirectories
+func removeTempDir(td string) {
 	if td == "" {
 		return
 	}
@@ -195,13 +261,12 @@ func (w *Watcher) cleanupTempDir() {
 	if err != nil {
 		logger.Errorf("Unable to remove issuewatcher.Watcher's temp dir %#v: %s", td, err)
 	}
-	w.Scanner.Tempdir = ""
 }
 
 // makeTempDir creates the temporary directory for httpcache to use.  This does
 // nothing if a temporary directory already exists.
-func (w *Watcher) makeTempDir() {
-	var err = os.MkdirAll(w.Scanner.Tempdir, 0700)
+func makeTempDir(td string) {
+	var err = os.MkdirAll(td, 0700)
 	if err != nil {
 		logger.Errorf("Unable to create issuewatcher.Watcher's temp dir: %s", err)
 	}
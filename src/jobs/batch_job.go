@@ -36,6 +36,7 @@ func newBatchJob(dbJob *models.Job) (j *BatchJob, err error) {
 	if j.DBBatch == nil {
 		return j, fmt.Errorf("batch id %d does not exist", dbJob.ObjectID)
 	}
+	j.logFields.BatchID = j.DBBatch.ID
 
 	return j, nil
 }
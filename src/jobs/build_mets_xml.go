@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
@@ -17,6 +18,7 @@ type BuildMETS struct {
 	templatePath  string
 	outputXMLPath string
 	Title         *models.Title
+	Reel          *models.Reel
 }
 
 // Process generates the METS XML file for the job's issue
@@ -34,11 +36,22 @@ func (job *BuildMETS) Process(c *config.Config) bool {
 		return false
 	}
 
+	if job.DBIssue.ReelID != 0 {
+		job.Reel, err = models.FindReel(job.DBIssue.ReelID)
+		if err == nil && job.Reel == nil {
+			err = fmt.Errorf("no such reel")
+		}
+		if err != nil {
+			job.Logger.Errorf("Unable to look up reel %d for issue id %d: %s", job.DBIssue.ReelID, job.DBIssue.ID, err)
+			return false
+		}
+	}
+
 	return job.generateMETS()
 }
 
 func (job *BuildMETS) generateMETS() (ok bool) {
-	var err = mets.New(job.templatePath, job.outputXMLPath, job.DBIssue, job.Title, time.Now()).Transform()
+	var err = mets.New(job.templatePath, job.outputXMLPath, job.DBIssue, job.Title, time.Now()).WithReel(job.Reel).Transform()
 	if err == nil {
 		return true
 	}
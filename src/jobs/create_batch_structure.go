@@ -7,9 +7,12 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/uoregon-libraries/gopkg/fileutil"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/jp2"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/mets"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
@@ -19,8 +22,9 @@ type CreateBatchStructure struct {
 }
 
 // Process implements Processor by creating the batch directory structure and
-// hard-linking the necessary issue files
-func (j *CreateBatchStructure) Process(*config.Config) bool {
+// hard-linking the necessary issue files.  Microfilm reels get their own
+// directories with target images and reel METS.
+func (j *CreateBatchStructure) Process(c *config.Config) bool {
 	var err error
 
 	// Configure the paths
@@ -46,8 +50,26 @@ func (j *CreateBatchStructure) Process(*config.Config) bool {
 		j.Logger.Criticalf("Unable to read issues for %q: %s", j.DBBatch.FullName(), err)
 		return false
 	}
+	var reels []*models.Reel
+	reels, err = j.DBBatch.Reels()
+	if err != nil {
+		j.Logger.Criticalf("Unable to read reels for %q: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+	var reelByID = make(map[int]*models.Reel)
+	for _, reel := range reels {
+		reelByID[reel.ID] = reel
+		if !j.buildReel(c, reel, dataPath) {
+			return false
+		}
+	}
+
 	for _, issue := range iList {
-		var destPath = path.Join(dataPath, issue.LCCN, "print", issue.DateEdition())
+		if issue.ReelID != 0 && reelByID[issue.ReelID] == nil {
+			j.Logger.Criticalf("Issue %q is tied to reel %d, which doesn't exist", issue.Key(), issue.ReelID)
+			return false
+		}
+		var destPath = path.Join(dataPath, issue.BatchPath(reelByID[issue.ReelID]))
 		err = os.MkdirAll(destPath, 0755)
 		if err == nil {
 			err = linkFiles(issue.Location, destPath)
@@ -62,6 +84,71 @@ func (j *CreateBatchStructure) Process(*config.Config) bool {
 	return true
 }
 
+// buildReel creates the reel's directory within the batch, hard-links its
+// target TIFFs using NDNP's "<reel number>_<sequence>" naming, generates the
+// matching JP2s, and writes the reel METS.  Everything is written into the
+// batch: the reel's location may be shared with other batches, or may be a
+// batch NCA imported rather than built.
+func (j *CreateBatchStructure) buildReel(c *config.Config, reel *models.Reel, dataPath string) bool {
+	var destPath = path.Join(dataPath, reel.BatchPath())
+	var err = os.MkdirAll(destPath, 0755)
+	if err != nil {
+		j.Logger.Criticalf("Unable to create reel directory %q: %s", destPath, err)
+		return false
+	}
+
+	var infos []os.FileInfo
+	infos, err = fileutil.ReaddirSortedNumeric(reel.Location)
+	if err != nil {
+		j.Logger.Criticalf("Unable to read targets for reel %q: %s", reel.ReelNumber, err)
+		return false
+	}
+
+	var prefixes []string
+	for _, info := range infos {
+		var ext = strings.ToLower(filepath.Ext(info.Name()))
+		if !info.Mode().IsRegular() || (ext != ".tif" && ext != ".tiff") {
+			continue
+		}
+
+		var prefix = fmt.Sprintf("%s_%d", reel.ReelNumber, len(prefixes)+1)
+		var tiff = filepath.Join(reel.Location, info.Name())
+		err = os.Link(tiff, filepath.Join(destPath, prefix+".tif"))
+		if err != nil {
+			j.Logger.Criticalf("Unable to link reel %q targets into batch %q: %s", reel.ReelNumber, j.DBBatch.FullName(), err)
+			return false
+		}
+
+		var jp2Path = filepath.Join(destPath, prefix+".jp2")
+		var transformer = jp2.New(tiff, jp2Path, c.Quality, c.DPI, false)
+		transformer.Logger = j.Logger
+		transformer.Context = j.Context()
+		transformer.OPJCompress = c.OPJCompress
+		transformer.OPJDecompress = c.OPJDecompress
+		transformer.GhostScript = c.GhostScript
+		err = transformer.Transform()
+		if err != nil {
+			j.Logger.Errorf("Couldn't convert reel target %q to JP2: %s", tiff, err)
+			return false
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	if len(prefixes) == 0 {
+		j.Logger.Errorf("Reel %q has no target images in %q", reel.ReelNumber, reel.Location)
+		return false
+	}
+
+	var xmlPath = filepath.Join(destPath, reel.ReelNumber+".xml")
+	err = mets.NewReel(c.ReelXMLTemplatePath, xmlPath, reel, prefixes, time.Now()).Transform()
+	if err != nil {
+		j.Logger.Errorf("Unable to generate METS XML for reel %q: %s", reel.ReelNumber, err)
+		return false
+	}
+
+	return true
+}
+
 // linkFiles hard-links all regular, non-hidden files from src into dest
 func linkFiles(src string, dest string) error {
 	var files, err = ioutil.ReadDir(src)
@@ -178,6 +178,7 @@ func (md *MakeDerivatives) createAltoXML(file string, pageno int) (ok bool) {
 	var outputFile = strings.Replace(file, filepath.Ext(file), ".xml", 1)
 	var transformer = alto.New(file, outputFile, md.AltoDPI, pageno, md.Force)
 	transformer.Logger = md.Logger
+	transformer.Context = md.Context()
 	transformer.LangCode3 = md.IssueJob.DBIssue.Title.LangCode()
 	var err = transformer.Transform()
 
@@ -193,6 +194,7 @@ func (md *MakeDerivatives) createJP2(file string) (ok bool) {
 	var outputJP2 = strings.Replace(file, filepath.Ext(file), ".jp2", 1)
 	var transformer = jp2.New(file, outputJP2, md.JP2Quality, md.JP2DPI, md.Force)
 	transformer.Logger = md.Logger
+	transformer.Context = md.Context()
 	transformer.OPJCompress = md.OPJCompress
 	transformer.OPJDecompress = md.OPJDecompress
 	transformer.GhostScript = md.GhostScript
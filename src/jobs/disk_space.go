@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// spacePostponement is how long a job waits before trying again when it
+// estimates there isn't enough disk space for it
+const spacePostponement = 10 * time.Minute
+
+// maxSpacePostponements is how many times a job may be postponed for disk
+// space before it's failed instead, so a job waiting on space nobody is
+// freeing doesn't sit in the queue forever.  This works out to about a day.
+const maxSpacePostponements = 144
+
+// These are rough multipliers for estimating how much space a job needs from
+// the size of its source files.  They err on the high side: running out of
+// space mid-job is far worse than waiting a little longer than necessary.
+const (
+	derivativeSpaceFactor = 3 // JP2s and ALTO XML can easily be larger than the source PDFs
+	pageSplitSpaceFactor  = 2 // Split pages plus the combined PDF they're split from
+	reelJP2SpaceFactor    = 1 // Reel targets' JP2s are compressed, so no bigger than their TIFFs
+)
+
+// jobSpaceSettings maps job types to the path settings of the locations they
+// write to.  Jobs which write somewhere that varies, such as SyncDir, rely on
+// their space estimates instead.  Jobs which only rename, delete, or
+// hard-link files aren't listed since they don't need meaningful space.
+var jobSpaceSettings = map[models.JobType][]string{
+	models.JobTypePageSplit:            {"WORKFLOW_PATH"},
+	models.JobTypeImagesToPDF:          {"WORKFLOW_PATH"},
+	models.JobTypeMakeDerivatives:      {"WORKFLOW_PATH"},
+	models.JobTypeBuildMETS:            {"WORKFLOW_PATH"},
+	models.JobTypeArchiveBackups:       {"WORKFLOW_PATH"},
+	models.JobTypeMoveDerivatives:      {"ERRORED_ISSUES_PATH"},
+	models.JobTypeWriteActionLog:       {"ERRORED_ISSUES_PATH"},
+	models.JobTypeMakeBatchXML:         {"BATCH_OUTPUT_PATH"},
+	models.JobTypeWriteQCReport:        {"BATCH_OUTPUT_PATH"},
+	models.JobTypeWriteBagitManifest:   {"BATCH_OUTPUT_PATH"},
+	models.JobTypeCreateBatchStructure: {"BATCH_OUTPUT_PATH"},
+}
+
+// JobTypeSpaceSettings returns the path settings the given job type writes to
+func JobTypeSpaceSettings(t models.JobType) []string {
+	return jobSpaceSettings[t]
+}
+
+// SpaceEstimator is implemented by jobs which can estimate how much disk
+// space they'll need, so they can wait rather than fill a disk partway
+// through
+type SpaceEstimator interface {
+	// EstimateSpace returns where the job will write and roughly how many
+	// bytes it needs there.  An empty path means no estimate is needed.
+	EstimateSpace() (path string, bytes int64, err error)
+}
+
+// EstimateSpace implements SpaceEstimator: derivatives are written next to
+// the issue's files
+func (md *MakeDerivatives) EstimateSpace() (string, int64, error) {
+	var n, err = diskspace.DirBytes(md.DBIssue.Location)
+	return md.DBIssue.Location, n * derivativeSpaceFactor, err
+}
+
+// EstimateSpace implements SpaceEstimator: split pages are written to the
+// job's output directory
+func (ps *PageSplit) EstimateSpace() (string, int64, error) {
+	var n, err = diskspace.DirBytes(ps.DBIssue.Location)
+	return ps.db.Args[locArg], n * pageSplitSpaceFactor, err
+}
+
+// EstimateSpace implements SpaceEstimator: the source directory is copied to
+// the destination
+func (j *SyncDir) EstimateSpace() (string, int64, error) {
+	var n, err = diskspace.DirBytes(j.db.Args[srcArg])
+	return j.db.Args[destArg], n, err
+}
+
+// EstimateSpace implements SpaceEstimator: the backed-up originals are put
+// into a tarfile in the issue's directory
+func (j *ArchiveBackups) EstimateSpace() (string, int64, error) {
+	if j.DBIssue.BackupLocation == "" {
+		return "", 0, nil
+	}
+	var n, err = diskspace.DirBytes(j.DBIssue.BackupLocation)
+	return filepath.Join(j.DBIssue.Location, "original.tar"), n, err
+}
+
+// EstimateSpace implements SpaceEstimator: issue files are hard-linked into
+// the batch's WIP directory, but each reel's target TIFFs are converted to
+// JP2s which are written there
+func (j *CreateBatchStructure) EstimateSpace() (string, int64, error) {
+	var reels, err = j.DBBatch.Reels()
+	if err != nil {
+		return "", 0, err
+	}
+
+	var total int64
+	for _, reel := range reels {
+		var n int64
+		n, err = diskspace.DirBytes(reel.Location)
+		if err != nil {
+			return "", 0, err
+		}
+		total += n * reelJP2SpaceFactor
+	}
+	return j.db.Args[locArg], total, nil
+}
+
+// spaceVolumes returns the volumes any of the given job types write to
+func spaceVolumes(c *config.Config, types []models.JobType) []*diskspace.Volume {
+	var needed = make(map[string]bool)
+	for _, t := range types {
+		for _, setting := range jobSpaceSettings[t] {
+			needed[setting] = true
+		}
+	}
+
+	var list []*diskspace.Volume
+	for _, v := range diskspace.Volumes(c) {
+		if needed[v.Setting] {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -4,6 +4,7 @@
 package jobs
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -82,6 +83,61 @@ func (j *KillDir) Process(*config.Config) bool {
 	return err == nil
 }
 
+// CleanReelDir removes a microfilm reel's directory from the scan upload area
+// once none of the reel's issues are left in it.  The reel's targets are the
+// only thing expected to remain, and they're only removed after they've been
+// copied into the reel's workflow location.
+type CleanReelDir struct {
+	*Job
+}
+
+// Valid is always true since filesystem jobs identify their errors nicely, and
+// FS problems that would cause real problems in Process will also be problems
+// here (e.g., trying to validate the existence of a directory on an NFS mount
+// that dropped)
+func (j *CleanReelDir) Valid() bool {
+	return true
+}
+
+// Process removes the reel directory at j.Source if it holds no issue
+// directories and the targets exist at j.Dest
+func (j *CleanReelDir) Process(*config.Config) bool {
+	var loc = j.db.Args[srcArg]
+	var targets = j.db.Args[destArg]
+
+	if !fileutil.Exists(loc) {
+		j.Logger.Debugf("CleanReelDir: %q was already removed", loc)
+		return true
+	}
+
+	var infos, err = ioutil.ReadDir(loc)
+	if err != nil {
+		j.Logger.Errorf("CleanReelDir: unable to read %q: %s", loc, err)
+		return false
+	}
+	for _, info := range infos {
+		if info.IsDir() && info.Name() != "targets" {
+			j.Logger.Debugf("CleanReelDir: %q still has issues; leaving it alone", loc)
+			return true
+		}
+	}
+
+	// This shouldn't happen since the targets are copied before the reel's
+	// first issue is moved, but if it does, failing means we try again later
+	// rather than losing the only copy of the targets
+	if !fileutil.IsDir(targets) {
+		j.Logger.Errorf("CleanReelDir: reel targets haven't been copied to %q yet", targets)
+		return false
+	}
+
+	j.Logger.Infof("CleanReelDir: removing %q", loc)
+	err = os.RemoveAll(loc)
+	if err != nil {
+		j.Logger.Errorf("CleanReelDir: unable to remove %q: %s", loc, err)
+	}
+	return err == nil
+}
+
 // RenameDir renames a directory - for the .wip-* dirs we still have to manage
 // since a handful of dirs still have to be exposed to end users
 type RenameDir struct {
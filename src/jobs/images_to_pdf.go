@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
+)
+
+// imagePDFResolution is the resolution we assume page images have when
+// converting them to PDFs.  Publishers' images rarely carry trustworthy DPI
+// information, and this only affects the PDF's page size.
+const imagePDFResolution = 300
+
+// ImagesToPDF is the page splitter's counterpart for titles whose publishers
+// upload page images: each image becomes a single-page PDF, OCRed if
+// tesseract is configured, and is then handled exactly like a split PDF page
+type ImagesToPDF struct {
+	*PageSplit
+	Tesseract      string // Optional path to tesseract for OCR
+	GraphicsMagick string // The path to gm for converting images without OCR
+}
+
+// Process converts each image in the issue's location, in numeric order, to
+// a PDF/a page
+func (ip *ImagesToPDF) Process(config *config.Config) bool {
+	ip.Logger.Debugf("Processing issue id %d (%q)", ip.DBIssue.ID, ip.Issue.Key())
+	if !ip.makeTempFiles() {
+		return false
+	}
+	defer ip.removeTempFiles()
+
+	ip.OutputDir = ip.db.Args[locArg]
+	if !fileutil.MustNotExist(ip.OutputDir) {
+		ip.Logger.Errorf("Output dir %q already exists", ip.OutputDir)
+		return false
+	}
+
+	ip.GhostScript = config.GhostScript
+	ip.MinPages = config.MinimumIssuePages
+	ip.Tesseract = config.Tesseract
+	ip.GraphicsMagick = "gm"
+	return RunWhileTrue(
+		ip.convertImages,
+		ip.convertToPDFA,
+		ip.moveIssue,
+	)
+}
+
+// convertImages writes a seq-NNNN.pdf file into the temp dir for each image
+func (ip *ImagesToPDF) convertImages() (ok bool) {
+	var fileinfos, err = fileutil.ReaddirSortedNumeric(ip.DBIssue.Location)
+	if err != nil {
+		ip.Logger.Errorf("Unable to list files in %q: %s", ip.DBIssue.Location, err)
+		return false
+	}
+
+	var images []string
+	for _, fi := range fileinfos {
+		if fi.Mode().IsRegular() && !strings.HasPrefix(fi.Name(), ".") {
+			images = append(images, filepath.Join(ip.DBIssue.Location, fi.Name()))
+		}
+	}
+	if len(images) < ip.MinPages {
+		ip.Logger.Errorf("Too few pages to continue processing (found %d, need %d or more)", len(images), ip.MinPages)
+		return false
+	}
+
+	var res = fmt.Sprintf("%d", imagePDFResolution)
+	for n, img := range images {
+		var base = filepath.Join(ip.TempDir, fmt.Sprintf("seq-%04d", n+1))
+		if ip.Tesseract != "" {
+			ip.Logger.Infof("OCRing %q", img)
+			err = shell.ExecSubgroup(ip.Context(), ip.Tesseract, ip.Logger, img, base, "--dpi", res, "pdf")
+		} else {
+			ip.Logger.Infof("Converting %q to PDF", img)
+			err = shell.ExecSubgroup(ip.Context(), ip.GraphicsMagick, ip.Logger, "convert", img,
+				"-units", "PixelsPerInch", "-density", res, base+".pdf")
+		}
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
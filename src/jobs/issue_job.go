@@ -42,6 +42,9 @@ func newIssueJob(dbJob *models.Job) (j *IssueJob, err error) {
 		return j, fmt.Errorf("issue id %d does not exist", dbJob.ObjectID)
 	}
 
+	j.logFields.IssueID = j.DBIssue.ID
+	j.logFields.IssueKey = j.DBIssue.Key()
+
 	j.Issue, err = j.DBIssue.SchemaIssue()
 	return j, err
 }
@@ -1,10 +1,7 @@
 package jobs
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"time"
+	"context"
 
 	ltype "github.com/uoregon-libraries/gopkg/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
@@ -33,6 +30,14 @@ type Processor interface {
 	// SetConsoleLogLevel changes the level filtered by console logs.  The
 	// database always gets sent all logs.
 	SetConsoleLogLevel(ltype.LogLevel)
+
+	// SetContext gives the job a context which, when canceled, should stop any
+	// long-running operations (e.g., shell commands) the job has in progress
+	SetContext(context.Context)
+
+	// SetRunnerID tells the job which runner is processing it so its logs can
+	// be correlated with the runner's
+	SetRunnerID(int32)
 }
 
 // Job wraps the DB job data and provides business logic for things like
@@ -41,6 +46,31 @@ type Job struct {
 	db         *models.Job
 	Logger     *ltype.Logger
 	maxRetries int
+
+	// logFields are attached to every log entry this job writes to stderr
+	logFields logger.Fields
+
+	ctx context.Context
+}
+
+// SetContext implements Processor, storing ctx for use in anything the job
+// needs to be able to cancel
+func (j *Job) SetContext(ctx context.Context) {
+	j.ctx = ctx
+}
+
+// SetRunnerID implements Processor, attaching the runner's id to all the job's
+// log entries
+func (j *Job) SetRunnerID(id int32) {
+	j.logFields.RunnerID = id
+}
+
+// Context returns the job's context, or context.Background() if none was set
+func (j *Job) Context() context.Context {
+	if j.ctx == nil {
+		return context.Background()
+	}
+	return j.ctx
 }
 
 // SetConsoleLogLevel sets the job to only log messages of the given level or
@@ -55,20 +85,20 @@ func (j *Job) MaxRetries() int {
 	return j.maxRetries
 }
 
-// NewJob wraps the given models.Job and sets up a logger with INFO as the
-// default restriction for console output (to stderr)
+// NewJob wraps the given models.Job and sets up a logger which defers to the
+// "jobs" package log level for console output (to stderr)
 func NewJob(dbj *models.Job) *Job {
 	var j = &Job{db: dbj, maxRetries: 25}
-	j.setLogger(ltype.Info)
+	j.logFields = logger.Fields{JobID: dbj.ID, JobType: dbj.Type}
+	j.setLogger(ltype.Debug)
 	return j
 }
 
 func (j *Job) setLogger(level ltype.LogLevel) {
 	j.Logger = &ltype.Logger{
 		Loggable: &jobLogger{
-			Job:     j,
-			AppName: filepath.Base(os.Args[0]),
-			level:   level,
+			Job:   j,
+			level: level,
 		},
 	}
 }
@@ -107,19 +137,15 @@ func RunWhileTrue(subProcessors ...func() bool) (ok bool) {
 // jobLogger implements logger.Loggable to write to stderr and the database
 type jobLogger struct {
 	*Job
-	AppName string
-	level   ltype.LogLevel
+	level ltype.LogLevel
 }
 
 // Log writes the pertinent data to stderr and the database so we can
 // immediately see logs if we're watching for them, or search later against a
 // specific job id's logs
 func (l *jobLogger) Log(level ltype.LogLevel, message string) {
-	if level >= l.level {
-		var timeString = time.Now().Format(ltype.TimeFormat)
-		var msg = fmt.Sprintf("%s - %s - %s - [job %s:%d] %s\n",
-			timeString, l.AppName, level.String(), l.db.Type, l.db.ID, message)
-		os.Stderr.WriteString(msg)
+	if level >= l.level && logger.Enabled("jobs", level) {
+		logger.Write(l.logFields, level, message)
 	}
 
 	var err = l.db.WriteLog(level.String(), message)
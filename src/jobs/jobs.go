@@ -19,6 +19,8 @@ func DBJobToProcessor(dbJob *models.Job) Processor {
 		return &IgnoreIssue{IssueJob: NewIssueJob(dbJob)}
 	case models.JobTypePageSplit:
 		return &PageSplit{IssueJob: NewIssueJob(dbJob)}
+	case models.JobTypeImagesToPDF:
+		return &ImagesToPDF{PageSplit: &PageSplit{IssueJob: NewIssueJob(dbJob)}}
 	case models.JobTypeMakeDerivatives:
 		return &MakeDerivatives{IssueJob: NewIssueJob(dbJob)}
 	case models.JobTypeMoveDerivatives:
@@ -43,6 +45,8 @@ func DBJobToProcessor(dbJob *models.Job) Processor {
 		return &SyncDir{Job: NewJob(dbJob)}
 	case models.JobTypeKillDir:
 		return &KillDir{Job: NewJob(dbJob)}
+	case models.JobTypeCleanReelDir:
+		return &CleanReelDir{Job: NewJob(dbJob)}
 	case models.JobTypeRenameDir:
 		return &RenameDir{Job: NewJob(dbJob)}
 	case models.JobTypeCleanFiles:
@@ -51,6 +55,18 @@ func DBJobToProcessor(dbJob *models.Job) Processor {
 		return &RenumberPages{IssueJob: NewIssueJob(dbJob)}
 	case models.JobTypeIssueAction:
 		return &RecordIssueAction{IssueJob: NewIssueJob(dbJob)}
+	case models.JobTypeWriteQCReport:
+		return &WriteQCReport{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeONILoadBatch:
+		return &ONILoadBatch{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeONIPurgeBatch:
+		return &ONIPurgeBatch{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeStoreBatch:
+		return &StoreBatch{BatchJob: NewBatchJob(dbJob)}
+	case models.JobTypeStoreIssueArchive:
+		return &StoreIssueArchive{IssueJob: NewIssueJob(dbJob)}
+	case models.JobTypeValidateBatchXML:
+		return &ValidateBatchXML{BatchJob: NewBatchJob(dbJob)}
 	default:
 		logger.Errorf("Unknown job type %q for job id %d", dbJob.Type, dbJob.ID)
 	}
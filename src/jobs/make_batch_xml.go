@@ -5,6 +5,7 @@ import (
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/derivatives/batchxml"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
 // MakeBatchXML wraps a BatchJob and implements Processor to create the
@@ -28,7 +29,14 @@ func (j *MakeBatchXML) Process(c *config.Config) bool {
 		return false
 	}
 
-	err = batchxml.New(templatePath, outputXMLPath, j.DBBatch, issues).Transform()
+	var reels []*models.Reel
+	reels, err = j.DBBatch.Reels()
+	if err != nil {
+		j.Logger.Errorf("Unable to look up reels for batch id %d (%q): %s", j.DBBatch.ID, bName, err)
+		return false
+	}
+
+	err = batchxml.New(templatePath, outputXMLPath, j.DBBatch, issues, reels).Transform()
 	if err != nil {
 		j.Logger.Errorf("Unable to generate Batch XML for batch %d: %s", j.DBBatch.ID, err)
 		return false
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/oni"
+)
+
+// oniRetries is how many times a failed ONI command is retried.  ONI loads
+// and purges aren't idempotent, so most failures need a human to look at the
+// ONI side first, but a couple of retries covers brief network problems.
+const oniRetries = 2
+
+// oniCommand returns the configured command for the job's target, or nil if
+// the job should be skipped because no command is configured
+func oniCommand(j *BatchJob, c *config.Config, a oni.Action) (*oni.Command, bool) {
+	var t = oni.Target(j.db.Args[targetArg])
+	var cmd, err = oni.ConfigCommand(c, t, a)
+	if err != nil {
+		j.Logger.Errorf("Unable to set up ONI %s command: %s", a, err)
+		return nil, false
+	}
+	if cmd == nil {
+		j.Logger.Infof("No ONI %s %s command is configured; skipping", t, a)
+	}
+	return cmd, true
+}
+
+// ONILoadBatch runs the configured ONI load command for a batch, then
+// advances the batch's status: loading to staging puts the batch "on_staging",
+// and loading to production makes it "live"
+type ONILoadBatch struct {
+	*BatchJob
+}
+
+// MaxRetries overrides the default so failed loads aren't retried endlessly
+func (j *ONILoadBatch) MaxRetries() int {
+	return oniRetries
+}
+
+// loadStatus returns the status a batch must have to be loaded into the
+// given ONI target
+func loadStatus(t oni.Target) string {
+	if t == oni.Production {
+		return models.BatchStatusPassedQC
+	}
+	return models.BatchStatusQCReady
+}
+
+// loadedStatus returns the status a batch gets once it's loaded into the
+// given ONI target
+func loadedStatus(t oni.Target) string {
+	if t == oni.Production {
+		return models.BatchStatusLive
+	}
+	return models.BatchStatusOnStaging
+}
+
+// currentStatus re-reads the batch's status from the database, since the
+// batch may have passed or failed QC since this job started
+func (j *ONILoadBatch) currentStatus() (string, error) {
+	var b, err = models.FindBatch(j.DBBatch.ID)
+	if err != nil {
+		return "", err
+	}
+	if b == nil {
+		return "", fmt.Errorf("batch %d no longer exists", j.DBBatch.ID)
+	}
+	return b.Status, nil
+}
+
+// Process runs the load command and updates the batch status.  A batch which
+// isn't in the status the load expects (e.g., it failed QC before a staging
+// load could run) is skipped, and if its status changes while it's being
+// loaded, it's left alone for a human to sort out.  The one exception is the
+// status the load itself would set, since the server's batch status sync can
+// see the batch in ONI before this job gets to update it.
+func (j *ONILoadBatch) Process(c *config.Config) bool {
+	var cmd, ok = oniCommand(j.BatchJob, c, oni.Load)
+	if cmd == nil {
+		return ok
+	}
+
+	var want = loadStatus(cmd.Target)
+	var st, err = j.currentStatus()
+	if err != nil {
+		j.Logger.Errorf("Unable to check batch %q status: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+	if st != want {
+		j.Logger.Warnf("Not loading batch %q into ONI %s: its status is %q, not %q",
+			j.DBBatch.FullName(), cmd.Target, st, want)
+		return true
+	}
+
+	var out string
+	out, err = cmd.Run(j.Context(), j.Logger, j.DBBatch)
+	if err != nil {
+		j.Logger.Errorf("Unable to load batch %q: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+	j.Logger.Infof("Loaded batch %q into ONI %s; output:\n%s", j.DBBatch.FullName(), cmd.Target, out)
+
+	st, err = j.currentStatus()
+	if err == nil && st == loadedStatus(cmd.Target) {
+		j.Logger.Infof("Batch %q status was already set to %q", j.DBBatch.FullName(), st)
+		return true
+	}
+	if err == nil && st != want {
+		err = fmt.Errorf("its status changed to %q during the load", st)
+	}
+	if err == nil {
+		switch cmd.Target {
+		case oni.Staging:
+			j.DBBatch.Status = models.BatchStatusOnStaging
+			err = j.DBBatch.Save()
+		case oni.Production:
+			err = j.DBBatch.SetLive()
+		}
+	}
+	if err != nil {
+		j.Logger.Criticalf("Batch %q was loaded into ONI %s, but its status couldn't be updated: %s",
+			j.DBBatch.FullName(), cmd.Target, err)
+		return false
+	}
+
+	return true
+}
+
+// ONIPurgeBatch runs the configured ONI purge command for a batch.  The
+// batch's name is stored when the job is queued, since a batch's name changes
+// when it's rebuilt, and it's often the old name which has to be purged.
+type ONIPurgeBatch struct {
+	*BatchJob
+}
+
+// MaxRetries overrides the default so failed purges aren't retried endlessly
+func (j *ONIPurgeBatch) MaxRetries() int {
+	return oniRetries
+}
+
+// Process runs the purge command
+func (j *ONIPurgeBatch) Process(c *config.Config) bool {
+	var cmd, ok = oniCommand(j.BatchJob, c, oni.Purge)
+	if cmd == nil {
+		return ok
+	}
+
+	var name = j.db.Args[nameArg]
+	var out, err = cmd.RunNamed(j.Context(), j.Logger, name, j.DBBatch.Location)
+	if err != nil {
+		j.Logger.Errorf("Unable to purge batch %q: %s", name, err)
+		return false
+	}
+	j.Logger.Infof("Purged batch %q from ONI %s; output:\n%s", name, cmd.Target, out)
+
+	return true
+}
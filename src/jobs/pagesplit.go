@@ -108,13 +108,14 @@ func (ps *PageSplit) combinePDF() (ok bool) {
 	for _, fi := range fileinfos {
 		args = append(args, filepath.Join(ps.DBIssue.Location, fi.Name()))
 	}
-	return shell.ExecSubgroup(ps.GhostScript, ps.Logger, args...)
+	return shell.ExecSubgroup(ps.Context(), ps.GhostScript, ps.Logger, args...) == nil
 }
 
 // splitPages ensures we end up with exactly one PDF per page
 func (ps *PageSplit) splitPages() (ok bool) {
 	ps.Logger.Infof("Splitting PDF(s)")
-	return shell.ExecSubgroup("pdfseparate", ps.Logger, ps.CombinedFile, filepath.Join(ps.TempDir, "seq-%d.pdf"))
+	var err = shell.ExecSubgroup(ps.Context(), "pdfseparate", ps.Logger, ps.CombinedFile, filepath.Join(ps.TempDir, "seq-%d.pdf"))
+	return err == nil
 }
 
 // fixPageNames converts sequenced PDFs to have 4-digit page numbers
@@ -171,10 +172,10 @@ func (ps *PageSplit) convertToPDFA() (ok bool) {
 		var fullPath = filepath.Join(ps.TempDir, fi.Name())
 		ps.Logger.Debugf("Converting %q to PDF/a", fullPath)
 		var dotA = fullPath + ".a"
-		var ok = shell.ExecSubgroup(ps.GhostScript, ps.Logger, "-dPDFA=2", "-dBATCH", "-dNOPAUSE",
+		err = shell.ExecSubgroup(ps.Context(), ps.GhostScript, ps.Logger, "-dPDFA=2", "-dBATCH", "-dNOPAUSE",
 			"-sProcessColorModel=DeviceCMYK", "-sDEVICE=pdfwrite",
 			"-sPDFACompatibilityPolicy=1", "-sOutputFile="+dotA, fullPath)
-		if !ok {
+		if err != nil {
 			return false
 		}
 
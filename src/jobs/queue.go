@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"fmt"
 	"path/filepath"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/oni"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
@@ -21,6 +23,10 @@ const (
 	destArg   = "Destination"
 	forcedArg = "Forced"
 	msgArg    = "Message"
+	targetArg = "Target"
+	nameArg   = "Name"
+
+	postponedArg = "SpacePostponements"
 )
 
 // PrepareJobAdvanced gets a job of any kind set up with sensible defaults
@@ -115,13 +121,31 @@ func makeSrcDstArgs(src, dest string) map[string]string {
 // QueueSFTPIssueMove queues up an issue move into the workflow area followed
 // by a page-split and then a move to the page review area
 func QueueSFTPIssueMove(issue *models.Issue, c *config.Config) error {
+	var op = dbi.DB.Operation()
+	op.BeginTransaction()
+	defer op.EndTransaction()
+	return QueueSFTPIssueMoveOp(op, issue, c)
+}
+
+// QueueSFTPIssueMoveOp is QueueSFTPIssueMove, but saves the jobs using an
+// existing operation
+func QueueSFTPIssueMoveOp(op *magicsql.Operation, issue *models.Issue, c *config.Config) error {
 	var workflowDir = filepath.Join(c.WorkflowPath, issue.HumanName)
 	var workflowWIPDir = filepath.Join(c.WorkflowPath, ".wip-"+issue.HumanName)
 	var pageReviewDir = filepath.Join(c.PDFPageReviewPath, issue.HumanName)
 	var pageReviewWIPDir = filepath.Join(c.PDFPageReviewPath, ".wip-"+issue.HumanName)
 	var backupLoc = filepath.Join(c.PDFBackupPath, issue.HumanName)
 
-	return QueueSerial(
+	var t, err = models.FindTitle("lccn = ?", issue.LCCN)
+	if err != nil {
+		return fmt.Errorf("unable to look up title for issue: %s", err)
+	}
+	var splitter = models.JobTypePageSplit
+	if t != nil && t.ImageUploads {
+		splitter = models.JobTypeImagesToPDF
+	}
+
+	return QueueSerialOp(op,
 		PrepareIssueJobAdvanced(models.JobTypeSetIssueWS, issue, makeWSArgs(schema.WSAwaitingProcessing)),
 
 		// Move the issue to the workflow location
@@ -130,9 +154,10 @@ func QueueSFTPIssueMove(issue *models.Issue, c *config.Config) error {
 		PrepareJobAdvanced(models.JobTypeRenameDir, makeSrcDstArgs(workflowWIPDir, workflowDir)),
 		PrepareIssueJobAdvanced(models.JobTypeSetIssueLocation, issue, makeLocArgs(workflowDir)),
 
-		// Clean dotfiles and then kick off the page splitter
+		// Clean dotfiles and then kick off the page splitter (or image converter
+		// for titles which upload page images)
 		PrepareJobAdvanced(models.JobTypeCleanFiles, makeLocArgs(workflowDir)),
-		PrepareIssueJobAdvanced(models.JobTypePageSplit, issue, makeLocArgs(workflowWIPDir)),
+		PrepareIssueJobAdvanced(splitter, issue, makeLocArgs(workflowWIPDir)),
 
 		// This gets a bit weird.  What's in the issue location dir is the original
 		// upload, which we back up since we may need to reprocess the PDFs from
@@ -162,7 +187,7 @@ func QueueMoveIssueForDerivatives(issue *models.Issue, workflowPath string) erro
 	var workflowDir = filepath.Join(workflowPath, issue.HumanName)
 	var workflowWIPDir = filepath.Join(workflowPath, ".wip-"+issue.HumanName)
 
-	return QueueSerial(
+	var jobs = []*models.Job{
 		PrepareIssueJobAdvanced(models.JobTypeSetIssueWS, issue, makeWSArgs(schema.WSAwaitingProcessing)),
 
 		PrepareJobAdvanced(models.JobTypeSyncDir, makeSrcDstArgs(issue.Location, workflowWIPDir)),
@@ -175,7 +200,23 @@ func QueueMoveIssueForDerivatives(issue *models.Issue, workflowPath string) erro
 		PrepareIssueJobAdvanced(models.JobTypeMakeDerivatives, issue, nil),
 		PrepareIssueJobAdvanced(models.JobTypeSetIssueWS, issue, makeWSArgs(schema.WSReadyForMetadataEntry)),
 		PrepareIssueActionJob(issue, "Created issue derivatives"),
-	)
+	}
+
+	// Microfilm issues live in a reel directory alongside the reel's targets,
+	// which has to be cleaned up once the reel's last issue is gone.  This is
+	// done last so a problem with the cleanup can't hold up the issue.
+	if issue.ReelID != 0 {
+		var reel, err = models.FindReel(issue.ReelID)
+		if err != nil {
+			return fmt.Errorf("unable to look up reel %d: %s", issue.ReelID, err)
+		}
+		if reel != nil {
+			var scanReelDir = filepath.Dir(issue.Location)
+			jobs = append(jobs, PrepareJobAdvanced(models.JobTypeCleanReelDir, makeSrcDstArgs(scanReelDir, reel.Location)))
+		}
+	}
+
+	return QueueSerial(jobs...)
 }
 
 // QueueForceDerivatives will forcibly regenerate all derivatives for an issue.
@@ -195,7 +236,8 @@ func QueueForceDerivatives(issue *models.Issue) error {
 
 // QueueFinalizeIssue creates and queues jobs that get an issue ready for
 // batching.  Currently this means generating the METS XML file and copying
-// archived PDFs (if born-digital) into the issue directory.
+// archived PDFs (if born-digital) into the issue directory and object
+// storage.
 func QueueFinalizeIssue(issue *models.Issue) error {
 	// Some jobs aren't queued up unless there's a backup, so we actually
 	// generate a list of jobs programatically instead of inline
@@ -204,6 +246,7 @@ func QueueFinalizeIssue(issue *models.Issue) error {
 
 	if issue.BackupLocation != "" {
 		jobs = append(jobs, PrepareIssueJobAdvanced(models.JobTypeArchiveBackups, issue, nil))
+		jobs = append(jobs, PrepareIssueJobAdvanced(models.JobTypeStoreIssueArchive, issue, nil))
 		jobs = append(jobs, PrepareJobAdvanced(models.JobTypeKillDir, makeLocArgs(issue.BackupLocation)))
 		jobs = append(jobs, PrepareIssueJobAdvanced(models.JobTypeSetIssueBackupLoc, issue, makeLocArgs("")))
 	}
@@ -215,21 +258,83 @@ func QueueFinalizeIssue(issue *models.Issue) error {
 }
 
 // QueueMakeBatch sets up the jobs for generating a batch on disk: generating
-// the directories and hard-links, making the batch XML, putting the batch
-// where it can be loaded onto staging, and generating the bagit manifest.
+// the directories and hard-links, making and validating the batch XML,
+// putting the batch where it can be loaded onto staging, and generating the
+// bagit manifest.
 // Nothing can happen automatically after all this until the batch is verified
 // on staging.
 func QueueMakeBatch(batch *models.Batch, batchOutputPath string) error {
+	return QueueSerial(GetJobsForMakeBatch(batch, batchOutputPath)...)
+}
+
+// GetJobsForMakeBatch returns the list of jobs for generating the given batch
+// on disk, suitable for use in a QueueSerial or QueueSerialOp call
+func GetJobsForMakeBatch(batch *models.Batch, batchOutputPath string) []*models.Job {
 	var wipDir = filepath.Join(batchOutputPath, ".wip-"+batch.FullName())
 	var finalDir = filepath.Join(batchOutputPath, batch.FullName())
-	return QueueSerial(
+	return []*models.Job{
 		PrepareBatchJobAdvanced(models.JobTypeCreateBatchStructure, batch, makeLocArgs(wipDir)),
 		PrepareBatchJobAdvanced(models.JobTypeSetBatchLocation, batch, makeLocArgs(wipDir)),
 		PrepareBatchJobAdvanced(models.JobTypeMakeBatchXML, batch, nil),
+		PrepareBatchJobAdvanced(models.JobTypeValidateBatchXML, batch, nil),
 		PrepareJobAdvanced(models.JobTypeRenameDir, makeSrcDstArgs(wipDir, finalDir)),
 		PrepareBatchJobAdvanced(models.JobTypeSetBatchLocation, batch, makeLocArgs(finalDir)),
+		PrepareBatchJobAdvanced(models.JobTypeWriteQCReport, batch, nil),
 		PrepareBatchJobAdvanced(models.JobTypeSetBatchStatus, batch, makeBSArgs(models.BatchStatusQCReady)),
 		PrepareBatchJobAdvanced(models.JobTypeWriteBagitManifest, batch, nil),
+		PrepareONILoadJob(batch, oni.Staging),
+	}
+}
+
+// GetJobsForFailedQC returns the jobs needed after a batch fails QC: the
+// batch is purged from staging if it was loaded there, the old batch
+// directory is removed, and if the batch still has issues, it's rebuilt from
+// them.  oldLocation must be the batch's location prior to failing QC.
+func GetJobsForFailedQC(batch *models.Batch, oldLocation, batchOutputPath string, onStaging bool) []*models.Job {
+	var jobs []*models.Job
+	if onStaging {
+		jobs = append(jobs, PrepareONIPurgeJob(batch, oni.Staging, batch.FullName()))
+	}
+	if oldLocation != "" {
+		jobs = append(jobs, PrepareJobAdvanced(models.JobTypeKillDir, makeLocArgs(oldLocation)))
+	}
+	if batch.Status == models.BatchStatusDeleted {
+		return jobs
+	}
+	return append(jobs, GetJobsForMakeBatch(batch, batchOutputPath)...)
+}
+
+// PrepareONILoadJob sets up a job to load the batch into the given ONI
+// target.  The job does nothing if no load command is configured for the
+// target.
+func PrepareONILoadJob(batch *models.Batch, t oni.Target) *models.Job {
+	return PrepareBatchJobAdvanced(models.JobTypeONILoadBatch, batch, map[string]string{targetArg: string(t)})
+}
+
+// PrepareONIPurgeJob sets up a job to purge the named batch from the given
+// ONI target.  name is usually the batch's full name, but can be the name of
+// a previous version.  The job does nothing if no purge command is configured
+// for the target.
+func PrepareONIPurgeJob(batch *models.Batch, t oni.Target, name string) *models.Job {
+	return PrepareBatchJobAdvanced(models.JobTypeONIPurgeBatch, batch, map[string]string{targetArg: string(t), nameArg: name})
+}
+
+// GetJobsForPassQC returns the jobs to put a batch which passed QC into
+// production: the batch is purged from staging if it was loaded there, any
+// previous version is purged from production, the batch is loaded into
+// production, which flags it as live, and finally it's copied to object
+// storage.
+func GetJobsForPassQC(batch *models.Batch, onStaging bool) []*models.Job {
+	var jobs []*models.Job
+	if onStaging {
+		jobs = append(jobs, PrepareONIPurgeJob(batch, oni.Staging, batch.FullName()))
+	}
+	if batch.Version > 1 {
+		jobs = append(jobs, PrepareONIPurgeJob(batch, oni.Production, batch.VersionName(batch.Version-1)))
+	}
+	return append(jobs,
+		PrepareONILoadJob(batch, oni.Production),
+		PrepareBatchJobAdvanced(models.JobTypeStoreBatch, batch, nil),
 	)
 }
 
@@ -290,3 +395,16 @@ func GetJobsForRemoveErroredIssue(issue *models.Issue, erroredIssueRoot string)
 
 	return jobs
 }
+
+// QueueMoveReelTargets creates jobs to copy a microfilm reel's target images
+// from src into the reel's workflow location.  The source is left alone, as
+// the reel's other issues may still be waiting in the scan directory; the
+// jobs queued by QueueMoveIssueForDerivatives remove it once they're all gone.
+func QueueMoveReelTargets(reel *models.Reel, src string) error {
+	var wipDir = filepath.Join(filepath.Dir(reel.Location), ".wip-"+filepath.Base(reel.Location))
+
+	return QueueSerial(
+		PrepareJobAdvanced(models.JobTypeSyncDir, makeSrcDstArgs(src, wipDir)),
+		PrepareJobAdvanced(models.JobTypeRenameDir, makeSrcDstArgs(wipDir, reel.Location)),
+	)
+}
@@ -1,34 +1,20 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strconv"
 	"sync/atomic"
 	"time"
 
-	"github.com/uoregon-libraries/gopkg/logger"
+	ltype "github.com/uoregon-libraries/gopkg/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/diskspace"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/humanize"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
 )
 
-// runnerLogger implements logger.Loggable for logging runner-level information
-type runnerLogger struct {
-	ID      int32
-	AppName string
-	level   logger.LogLevel
-}
-
-func (l *runnerLogger) Log(level logger.LogLevel, message string) {
-	if level < l.level {
-		return
-	}
-
-	var timeString = time.Now().Format(logger.TimeFormat)
-	fmt.Fprintf(os.Stderr, "%s - %s - %s - [runner %d] %s\n",
-		timeString, l.AppName, level.String(), l.ID, message)
-}
-
 var runnerID int32
 
 // nextRunnerID atomically generates a unique id for a runner to use in logging
@@ -44,7 +30,17 @@ type Runner struct {
 	jobTypes   []models.JobType
 	identifier int32
 	isDone     int32
-	logger     *logger.Logger
+	logger     *ltype.Logger
+
+	// volumes are the locations the runner's job types write to, and low
+	// tracks which are below their minimum free space so we only log changes
+	volumes []*diskspace.Volume
+	low     map[string]bool
+
+	// ctx is handed to each job so that Stop can kill any external commands a
+	// job is waiting on
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // TODO: Put runners in the database so we can attach runner-level logs to the
@@ -52,14 +48,20 @@ type Runner struct {
 // tied to a job, and a "last ping" or something on the runner table would make
 // it easier to know when a runner died and needs to have its jobs restarted.
 
-// NewRunner creates a Runner set up to look for a given list of job types
-func NewRunner(c *config.Config, logLevel logger.LogLevel, jobTypes ...models.JobType) *Runner {
+// NewRunner creates a Runner set up to look for a given list of job types.
+// The runner's logs are filtered by the "jobs" package log level.
+func NewRunner(c *config.Config, jobTypes ...models.JobType) *Runner {
 	var rid = nextRunnerID()
+	var ctx, cancel = context.WithCancel(context.Background())
 	return &Runner{
 		config:     c,
 		jobTypes:   jobTypes,
 		identifier: rid,
-		logger:     &logger.Logger{Loggable: &runnerLogger{ID: rid, level: logLevel, AppName: filepath.Base(os.Args[0])}},
+		logger:     logger.New("jobs", logger.Fields{RunnerID: rid}),
+		volumes:    spaceVolumes(c, jobTypes),
+		low:        make(map[string]bool),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -97,17 +99,61 @@ func (r *Runner) Watch(interval time.Duration) {
 	r.logger.Infof("Done watching jobs")
 }
 
-// Stop signals this job to stop looping once the current job is done
+// Stop signals this runner to stop looping.  Any external command the current
+// job is running is killed, which fails the job so it can be run again once
+// runners are started up again.  This doesn't count against the job's
+// retries.
 func (r *Runner) Stop() {
 	r.logger.Infof("Received STOP request; attempting to clean up")
 	atomic.StoreInt32(&r.isDone, 1)
+	r.cancel()
+}
+
+// availableJobTypes returns the runner's job types which don't write to a
+// volume that's below its minimum free space
+func (r *Runner) availableJobTypes() []models.JobType {
+	var low = make(map[string]bool)
+	for _, v := range r.volumes {
+		var u = v.Check()
+		if u.Err != nil {
+			r.logger.Errorf("Unable to check free space: %s", u.Message())
+			continue
+		}
+		low[v.Setting] = u.Low()
+		if low[v.Setting] != r.low[v.Setting] {
+			if low[v.Setting] {
+				r.logger.Warnf("Low disk space: %s", u.Message())
+			} else {
+				r.logger.Infof("Disk space recovered: %s; resuming jobs", u.Message())
+			}
+		}
+	}
+	r.low = low
+
+	var types []models.JobType
+	for _, t := range r.jobTypes {
+		var ok = true
+		for _, setting := range jobSpaceSettings[t] {
+			ok = ok && !low[setting]
+		}
+		if ok {
+			types = append(types, t)
+		}
+	}
+	return types
 }
 
 // processNext gets the oldest job this runner can process, sets its status to
 // in-process, and processes it.  If no processor was found, the return is
-// false and nothing happens.
+// false and nothing happens.  Job types which write to a volume that's low on
+// space are skipped until space frees up.
 func (r *Runner) processNext() bool {
-	var dbJob, err = models.PopNextPendingJob(r.jobTypes)
+	var types = r.availableJobTypes()
+	if len(types) == 0 {
+		return false
+	}
+
+	var dbJob, err = models.PopNextPendingJob(types)
 
 	if err != nil {
 		r.logger.Errorf("Unable to pull next pending job: %s", err)
@@ -127,6 +173,7 @@ func (r *Runner) processNext() bool {
 
 func (r *Runner) process(pr Processor) {
 	var dbj = pr.DBJob()
+	pr.SetRunnerID(r.identifier)
 
 	// Invalid jobs shouldn't realistically exist, but database errors have
 	// occasionally been known to happen and we don't want runners panicking
@@ -135,12 +182,93 @@ func (r *Runner) process(pr Processor) {
 		return
 	}
 
+	if !r.hasRoom(pr) {
+		return
+	}
+
 	r.logger.Infof("Starting job id %d (%q)", dbj.ID, dbj.Type)
+	pr.SetContext(r.ctx)
 	if pr.Process(r.config) {
 		r.handleSuccess(pr)
+		return
+	}
+
+	// A job that failed because we're shutting down didn't really fail, so it
+	// always gets another chance, and that chance doesn't count as a retry
+	if r.done() {
+		r.logger.Warnf("Job id %d was interrupted by a STOP request", dbj.ID)
+		r.requeue(pr)
+		return
+	}
+	r.attemptRetry(pr)
+}
+
+// hasRoom checks the job's space estimate, if it has one.  A job which
+// doesn't fit is postponed, without counting as a retry, and false is
+// returned.  A job which can't fit even on an empty volume, or which has been
+// postponed too many times, is failed instead.
+func (r *Runner) hasRoom(pr Processor) bool {
+	var est, ok = pr.(SpaceEstimator)
+	if !ok {
+		return true
+	}
+
+	var dbj = pr.DBJob()
+	var path, n, err = est.EstimateSpace()
+	if err != nil {
+		r.logger.Warnf("Unable to estimate disk space for job id %d; running it anyway: %s", dbj.ID, err)
+		return true
+	}
+	if path == "" {
+		return true
+	}
+
+	var fits, u = diskspace.Fits(r.config, path, n)
+	if fits {
+		return true
+	}
+
+	var count, _ = strconv.Atoi(dbj.Args[postponedArg])
+	if u.TooSmall(n) || count >= maxSpacePostponements {
+		r.failForSpace(pr, n, u, count)
+		return false
+	}
+
+	r.logger.Warnf("Postponing job id %d (%q): it needs about %s, but %s",
+		dbj.ID, dbj.Type, humanize.Bytes(n), u.Message())
+	if dbj.Args == nil {
+		dbj.Args = make(map[string]string)
+	}
+	dbj.Args[postponedArg] = strconv.Itoa(count + 1)
+	err = dbj.Postpone(spacePostponement)
+	if err != nil {
+		r.logger.Criticalf("Unable to postpone job (job: %d): %s", dbj.ID, err)
+	}
+	return false
+}
+
+// failForSpace fails a job which doesn't have the disk space it needs and
+// isn't likely to get it, recording why in the job's logs.  The postponement
+// count is cleared so the job starts fresh if somebody retries it.
+func (r *Runner) failForSpace(pr Processor, n int64, u *diskspace.Usage, count int) {
+	var dbj = pr.DBJob()
+	var msg string
+	if u.TooSmall(n) {
+		msg = fmt.Sprintf("Job needs about %s, but %s (%s) only holds %s in total, with a minimum of %s kept free",
+			humanize.Bytes(n), u.Setting, u.Path, humanize.Bytes(u.Total), humanize.Bytes(u.Minimum))
 	} else {
-		r.attemptRetry(pr)
+		msg = fmt.Sprintf("Job needs about %s, and after %d postponements, %s",
+			humanize.Bytes(n), count, u.Message())
+	}
+
+	r.logger.Errorf("Failing job id %d (%q): %s", dbj.ID, dbj.Type, msg)
+	var err = dbj.WriteLog(ltype.Err.String(), msg)
+	if err != nil {
+		r.logger.Criticalf("Unable to write log message %q to the database: %s", msg, err)
 	}
+
+	delete(dbj.Args, postponedArg)
+	r.handleFailure(pr)
 }
 
 func (r *Runner) handleSuccess(pr Processor) {
@@ -164,7 +292,12 @@ func (r *Runner) attemptRetry(pr Processor) {
 		r.handleFailure(pr)
 		return
 	}
+	r.retry(pr)
+}
 
+// retry closes the job and queues up a clone for another attempt
+func (r *Runner) retry(pr Processor) {
+	var dbj = pr.DBJob()
 	var retryJob, err = dbj.FailAndRetry()
 	if err != nil {
 		r.logger.Criticalf("Unable to requeue failed job (job: %d): %s", dbj.ID, err)
@@ -174,6 +307,18 @@ func (r *Runner) attemptRetry(pr Processor) {
 		dbj.ID, retryJob.ID, retryJob.RunAt, retryJob.RetryCount)
 }
 
+// requeue closes the job and queues up a clone to run again without counting
+// the failure against the job's retries
+func (r *Runner) requeue(pr Processor) {
+	var dbj = pr.DBJob()
+	var newJob, err = dbj.Requeue()
+	if err != nil {
+		r.logger.Criticalf("Unable to requeue interrupted job (job: %d): %s", dbj.ID, err)
+		return
+	}
+	r.logger.Warnf("Requeued interrupted job %d via job %d", dbj.ID, newJob.ID)
+}
+
 func (r *Runner) handleFailure(pr Processor) {
 	var dbj = pr.DBJob()
 	dbj.Status = string(models.JobStatusFailed)
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/objstore"
+)
+
+// objectStore returns the configured object store, or nil if the job should
+// be skipped because no store is configured
+func objectStore(j *Job, c *config.Config) (objstore.Store, bool) {
+	var s, err = objstore.New(c)
+	if err != nil {
+		j.Logger.Errorf("Unable to set up object store: %s", err)
+		return nil, false
+	}
+	if s == nil {
+		j.Logger.Infof("No object store is configured; skipping")
+	}
+	return s, true
+}
+
+// StoreBatch copies a batch's files to object storage, verifying each file's
+// checksum, and records when the copy was made
+type StoreBatch struct {
+	*BatchJob
+}
+
+// Process implements Processor
+func (j *StoreBatch) Process(c *config.Config) bool {
+	var s, ok = objectStore(j.Job, c)
+	if s == nil {
+		return ok
+	}
+
+	var prefix = objstore.BatchPrefix(j.DBBatch)
+	var n, err = objstore.PutDir(j.Context(), s, j.Logger, prefix, j.DBBatch.Location)
+	if err != nil {
+		j.Logger.Errorf("Unable to store batch %q: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+	j.Logger.Infof("Stored %d file(s) from batch %q under %q", n, j.DBBatch.FullName(), prefix)
+
+	j.DBBatch.StoredAt = time.Now()
+	err = j.DBBatch.Save()
+	if err != nil {
+		j.Logger.Criticalf("Batch %q was stored, but its stored date couldn't be saved: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+
+	return true
+}
+
+// StoreIssueArchive copies the archive of an issue's original files (the
+// tarfile ArchiveBackups creates) to object storage, so the originals are
+// safely stored before the backups are removed
+type StoreIssueArchive struct {
+	*IssueJob
+}
+
+// Process implements Processor
+func (j *StoreIssueArchive) Process(c *config.Config) bool {
+	var s, ok = objectStore(j.Job, c)
+	if s == nil {
+		return ok
+	}
+
+	var key = objstore.IssueArchiveKey(j.DBIssue)
+	var _, err = s.Put(j.Context(), key, filepath.Join(j.DBIssue.Location, "original.tar"))
+	if err != nil {
+		j.Logger.Errorf("Unable to store original files for issue %q: %s", j.DBIssue.Key(), err)
+		return false
+	}
+	j.Logger.Infof("Stored original files for issue %q as %q", j.DBIssue.Key(), key)
+
+	return true
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/ndnpxml"
+)
+
+// ValidateBatchXML wraps a BatchJob and implements Processor to check the
+// batch's XML against NDNP's requirements before it's packaged
+type ValidateBatchXML struct {
+	*BatchJob
+
+	// invalid is set when problems are found in the batch, as opposed to
+	// validation being unable to run
+	invalid bool
+}
+
+// MaxRetries overrides the default when the batch is invalid: bad XML won't
+// fix itself, so only failures to run the validation are retried
+func (j *ValidateBatchXML) MaxRetries() int {
+	if j.invalid {
+		return 0
+	}
+	return j.BatchJob.MaxRetries()
+}
+
+// Process validates the batch, logging every problem found
+func (j *ValidateBatchXML) Process(c *config.Config) bool {
+	var v = ndnpxml.New(c.XMLLint, c.NDNPSchema)
+	v.Logger = j.Logger
+	v.Context = j.Context()
+
+	var problems, err = v.ValidateBatch(j.DBBatch.Location, j.DBBatch.FullName())
+	if err != nil {
+		j.Logger.Errorf("Unable to validate batch %q: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+	if len(problems) == 0 {
+		return true
+	}
+
+	j.invalid = true
+	for _, p := range problems {
+		j.Logger.Errorf("Invalid XML: %s", p)
+	}
+	j.Logger.Errorf("Batch %q failed validation with %d problem(s); fix the batch and requeue it", j.DBBatch.FullName(), len(problems))
+	return false
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/qcreport"
+)
+
+// WriteQCReport wraps a BatchJob and implements Processor to generate the
+// batch's QC report next to its bag
+type WriteQCReport struct {
+	*BatchJob
+}
+
+// Process builds and writes the QC report
+func (j *WriteQCReport) Process(c *config.Config) bool {
+	var g = qcreport.New(qcreport.Dir(c.BatchOutputPath, j.DBBatch))
+	g.Logger = j.Logger
+	g.Context = j.Context()
+	g.GhostScript = c.GhostScript
+
+	var err = g.Generate(j.DBBatch)
+	if err != nil {
+		j.Logger.Errorf("Unable to write QC report for batch %q: %s", j.DBBatch.FullName(), err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,115 @@
+package metadatarules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/frequency"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// checkFunc returns a description of the problem if the issue fails the
+// rule, or an empty string if it passes.  Checks which can't be run, such as
+// a frequency check on a title without a frequency, pass.
+type checkFunc func(r *Rule, i *models.Issue) string
+
+var checks = map[string]checkFunc{
+	"numeric-volume":    checkNumericVolume,
+	"numeric-issue":     checkNumericIssue,
+	"publication-range": checkPublicationRange,
+	"frequency-day":     checkFrequencyDay,
+	"date-as-labeled":   checkDateAsLabeled,
+}
+
+const dateFormat = "2006-01-02"
+
+var numericRegex = regexp.MustCompile(`^\d+$`)
+
+func checkNumericVolume(r *Rule, i *models.Issue) string {
+	if i.Volume != "" && !numericRegex.MatchString(i.Volume) {
+		return fmt.Sprintf("Volume number %q must be numeric", i.Volume)
+	}
+	return ""
+}
+
+func checkNumericIssue(r *Rule, i *models.Issue) string {
+	if i.Issue != "" && !numericRegex.MatchString(i.Issue) {
+		return fmt.Sprintf("Issue number %q must be numeric", i.Issue)
+	}
+	return ""
+}
+
+// year parses a MARC 008 year, which may have "u" for unknown digits.  The
+// unknown digits are replaced with fill ("0" for start years, "9" for end
+// years) so we err on the side of a wider range.
+func year(s, fill string) (int, bool) {
+	var y, err = strconv.Atoi(strings.Replace(s, "u", fill, -1))
+	return y, err == nil && y > 0
+}
+
+func checkPublicationRange(r *Rule, i *models.Issue) string {
+	if i.Title == nil {
+		return ""
+	}
+	var dt, err = time.Parse(dateFormat, i.Date)
+	if err != nil {
+		return ""
+	}
+
+	var start, hasStart = year(i.Title.MARCStartYear, "0")
+	var end, hasEnd = year(i.Title.MARCEndYear, "9")
+	if hasStart && dt.Year() < start {
+		return fmt.Sprintf("Issue date %s is before the title's first year of publication (%d)", i.Date, start)
+	}
+	if hasEnd && dt.Year() > end {
+		return fmt.Sprintf("Issue date %s is after the title's last year of publication (%d)", i.Date, end)
+	}
+	return ""
+}
+
+func checkFrequencyDay(r *Rule, i *models.Issue) string {
+	if i.Title == nil {
+		return ""
+	}
+	var dt, err = time.Parse(dateFormat, i.Date)
+	if err != nil {
+		return ""
+	}
+	var f frequency.Frequency
+	f, err = frequency.Parse(i.Title.PublicationFrequency())
+	if err != nil || len(f.Days) == 0 {
+		return ""
+	}
+
+	for _, d := range f.Days {
+		if d == dt.Weekday() {
+			return ""
+		}
+	}
+	return fmt.Sprintf("Issue date %s is a %s, which doesn't match the title's frequency (%q)",
+		i.Date, dt.Weekday(), i.Title.PublicationFrequency())
+}
+
+func checkDateAsLabeled(r *Rule, i *models.Issue) string {
+	var dt, err = time.Parse(dateFormat, i.Date)
+	if err != nil {
+		return ""
+	}
+	var labeled time.Time
+	labeled, err = time.Parse(dateFormat, i.DateAsLabeled)
+	if err != nil {
+		return ""
+	}
+
+	var diff = dt.Sub(labeled)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > time.Duration(r.Days)*24*time.Hour {
+		return fmt.Sprintf("Date as labeled (%s) must be within %d day(s) of the issue date (%s)", i.DateAsLabeled, r.Days, i.Date)
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+// Package metadatarules runs configurable checks against issue metadata.
+// Rules are read from a JSON file, apply to all titles or just the titles
+// listed, and are each marked as an error (which blocks an issue from moving
+// forward) or a warning (which curators may choose to ignore).
+package metadatarules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// Level says how severe a rule's failure is
+type Level string
+
+// All valid levels.  "off" is only useful for disabling a global rule for
+// specific titles.
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelOff     Level = "off"
+)
+
+// Rule is a single entry from the rules file.  A rule with no LCCNs is
+// global; a rule with LCCNs replaces any global rule of the same name for
+// those titles.
+type Rule struct {
+	Name  string   `json:"rule"`
+	Level Level    `json:"level"`
+	Days  int      `json:"days"`
+	LCCNs []string `json:"lccns"`
+}
+
+// RuleSet holds all rules read from the configured file
+type RuleSet struct {
+	rules []*Rule
+}
+
+// Load reads rules from the given file.  An empty filename returns an empty
+// RuleSet so callers don't need special handling when no rules are configured.
+func Load(filename string) (*RuleSet, error) {
+	if filename == "" {
+		return &RuleSet{}, nil
+	}
+
+	var data, err = ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %s", filename, err)
+	}
+	var rs *RuleSet
+	rs, err = Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %s", filename, err)
+	}
+	return rs, nil
+}
+
+// Parse reads rules from JSON and validates them
+func Parse(data []byte) (*RuleSet, error) {
+	var rs = &RuleSet{}
+	var err = json.Unmarshal(data, &rs.rules)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, r := range rs.rules {
+		if checks[r.Name] == nil {
+			return nil, fmt.Errorf("rule %d: unknown rule %q", n+1, r.Name)
+		}
+		switch r.Level {
+		case LevelError, LevelWarning, LevelOff:
+		default:
+			return nil, fmt.Errorf("rule %d (%s): level must be %q, %q, or %q", n+1, r.Name, LevelError, LevelWarning, LevelOff)
+		}
+		if r.Name == "date-as-labeled" && r.Days < 0 {
+			return nil, fmt.Errorf("rule %d (%s): days cannot be negative", n+1, r.Name)
+		}
+	}
+
+	return rs, nil
+}
+
+// rulesFor returns the rules which apply to the given LCCN, in the order
+// they were first named in the rules file, skipping any which are turned off
+func (rs *RuleSet) rulesFor(lccn string) []*Rule {
+	var names []string
+	var byName = make(map[string]*Rule)
+	var titleSpecific = make(map[string]bool)
+
+	for _, r := range rs.rules {
+		var forTitle = false
+		for _, l := range r.LCCNs {
+			if l == lccn {
+				forTitle = true
+			}
+		}
+		if len(r.LCCNs) > 0 && !forTitle {
+			continue
+		}
+		if byName[r.Name] == nil {
+			names = append(names, r.Name)
+		}
+		if forTitle || !titleSpecific[r.Name] {
+			byName[r.Name] = r
+			titleSpecific[r.Name] = forTitle
+		}
+	}
+
+	var list []*Rule
+	for _, name := range names {
+		if byName[name].Level != LevelOff {
+			list = append(list, byName[name])
+		}
+	}
+	return list
+}
+
+// Check runs all applicable rules against the issue, returning a Violation
+// for each failure.  A nil RuleSet has no rules.
+func (rs *RuleSet) Check(i *models.Issue) []apperr.Error {
+	if rs == nil {
+		return nil
+	}
+
+	var errs []apperr.Error
+	for _, r := range rs.rulesFor(i.LCCN) {
+		var msg = checks[r.Name](r, i)
+		if msg != "" {
+			errs = append(errs, &Violation{Rule: r.Name, Msg: msg, Warn: r.Level == LevelWarning})
+		}
+	}
+	return errs
+}
+
+// Violation implements apperr.Error to describe a failed rule
+type Violation struct {
+	Rule string
+	Msg  string
+	Warn bool
+}
+
+func (v *Violation) Error() string {
+	return v.Rule + ": " + v.Msg
+}
+
+// Message returns the human-friendly description of the failure
+func (v *Violation) Message() string {
+	return v.Msg
+}
+
+// Propagate is always false: a rule failure is specific to one issue
+func (v *Violation) Propagate() bool {
+	return false
+}
+
+// Warning is true when the rule was configured as a warning
+func (v *Violation) Warning() bool {
+	return v.Warn
+}
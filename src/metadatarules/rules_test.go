@@ -0,0 +1,89 @@
+package metadatarules
+
+import (
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func mustParse(t *testing.T, data string) *RuleSet {
+	var rs, err = Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Unable to parse rules: %s", err)
+	}
+	return rs
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = map[string]string{
+		"unknown rule":  `[{"rule": "nope", "level": "error"}]`,
+		"invalid level": `[{"rule": "numeric-issue", "level": "fatal"}]`,
+		"missing level": `[{"rule": "numeric-issue"}]`,
+		"negative days": `[{"rule": "date-as-labeled", "level": "error", "days": -1}]`,
+		"invalid JSON":  `[{"rule": "numeric-issue", `,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var _, err = Parse([]byte(data))
+			if err == nil {
+				t.Errorf("Expected an error parsing %s", data)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var rs = mustParse(t, `[
+		{"rule": "numeric-issue", "level": "error"},
+		{"rule": "publication-range", "level": "error"},
+		{"rule": "frequency-day", "level": "warning"},
+		{"rule": "date-as-labeled", "level": "warning", "days": 2},
+		{"rule": "numeric-issue", "level": "off", "lccns": ["sn2"]},
+		{"rule": "frequency-day", "level": "error", "lccns": ["sn3"]}
+	]`)
+
+	var title = &models.Title{Frequency: "Mon, Wed, Fri", MARCStartYear: "1900", MARCEndYear: "19uu"}
+	var issue = func(lccn, date, labeled, number string) *models.Issue {
+		return &models.Issue{LCCN: lccn, Date: date, DateAsLabeled: labeled, Issue: number, Title: title}
+	}
+
+	var tests = map[string]struct {
+		issue    *models.Issue
+		errors   int
+		warnings int
+	}{
+		"all good":           {issue("sn1", "1910-01-03", "1910-01-03", "12"), 0, 0},
+		"non-numeric issue":  {issue("sn1", "1910-01-03", "1910-01-03", "XII"), 1, 0},
+		"rule turned off":    {issue("sn2", "1910-01-03", "1910-01-03", "XII"), 0, 0},
+		"before range":       {issue("sn1", "1899-12-29", "1899-12-29", "12"), 1, 0},
+		"unknown end digits": {issue("sn1", "1999-12-29", "1999-12-29", "12"), 0, 0},
+		"after range":        {issue("sn1", "2000-01-03", "2000-01-03", "12"), 1, 0},
+		"wrong weekday":      {issue("sn1", "1910-01-04", "1910-01-04", "12"), 0, 1},
+		"title override":     {issue("sn3", "1910-01-04", "1910-01-04", "12"), 1, 0},
+		"labeled date close": {issue("sn1", "1910-01-03", "1910-01-01", "12"), 0, 0},
+		"labeled date far":   {issue("sn1", "1910-01-03", "1909-12-31", "12"), 0, 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var errors, warnings int
+			for _, err := range rs.Check(tc.issue) {
+				if err.Warning() {
+					warnings++
+				} else {
+					errors++
+				}
+			}
+			if errors != tc.errors || warnings != tc.warnings {
+				t.Errorf("Expected %d error(s) and %d warning(s), got %d and %d", tc.errors, tc.warnings, errors, warnings)
+			}
+		})
+	}
+}
+
+func TestNilRuleSet(t *testing.T) {
+	var rs *RuleSet
+	if errs := rs.Check(&models.Issue{Issue: "XII"}); len(errs) != 0 {
+		t.Errorf("Expected no errors from a nil rule set, got %#v", errs)
+	}
+}
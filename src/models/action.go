@@ -29,6 +29,9 @@ const (
 	ActionTypeRemoveErrorIssue     ActionType = "remove-error-issue"
 	ActionTypeClaim                ActionType = "claim-issue"
 	ActionTypeUnclaim              ActionType = "unclaim-issue"
+	ActionTypeWithdrawIssue        ActionType = "withdraw-issue"
+	ActionTypeReinstateIssue       ActionType = "reinstate-issue"
+	ActionTypeAcceptWarnings       ActionType = "accept-warnings"
 )
 
 // Describe gives a human-readable explanation of what happened when a given
@@ -57,6 +60,12 @@ func (at ActionType) Describe() string {
 		return "claimed the issue"
 	case ActionTypeUnclaim:
 		return "removed the issue from the prior owner's desk"
+	case ActionTypeWithdrawIssue:
+		return "withdrew the issue from its live batch"
+	case ActionTypeReinstateIssue:
+		return "reinstated the withdrawn issue for batching"
+	case ActionTypeAcceptWarnings:
+		return "accepted the issue's metadata warnings"
 	default:
 		return string(at)
 	}
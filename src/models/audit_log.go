@@ -36,30 +36,44 @@ const (
 	AuditActionAutosave
 	AuditActionSaveDraft
 	AuditActionSaveQueue
+	AuditActionSFTPUpload
+	AuditActionAutoQueue
+	AuditActionMarkUnpublished
+	AuditActionUnmarkUnpublished
+	AuditActionCreateBatches
+	AuditActionFailQC
+	AuditActionPassQC
 
 	AuditActionOverflow
 )
 
 var dbAuditActions = map[AuditAction]string{
-	AuditActionQueue:            "queue",
-	AuditActionSaveTitle:        "save-title",
-	AuditActionValidateTitle:    "validate-title",
-	AuditActionCreateMoc:        "create-moc",
-	AuditActionUpdateMoc:        "update-moc",
-	AuditActionDeleteMoc:        "delete-moc",
-	AuditActionSaveUser:         "save-user",
-	AuditActionDeactivateUser:   "deactivate-user",
-	AuditActionClaim:            "claim",
-	AuditActionUnclaim:          "unclaim",
-	AuditActionApproveMetadata:  "approve-metadata",
-	AuditActionRejectMetadata:   "reject-metadata",
-	AuditActionReportError:      "report-error",
-	AuditActionUndoErrorIssue:   "undo-error-issue",
-	AuditActionRemoveErrorIssue: "remove-error-issue",
-	AuditActionQueueForReview:   "queue-for-review",
-	AuditActionAutosave:         "autosave",
-	AuditActionSaveDraft:        "savedraft",
-	AuditActionSaveQueue:        "savequeue",
+	AuditActionQueue:             "queue",
+	AuditActionSaveTitle:         "save-title",
+	AuditActionValidateTitle:     "validate-title",
+	AuditActionCreateMoc:         "create-moc",
+	AuditActionUpdateMoc:         "update-moc",
+	AuditActionDeleteMoc:         "delete-moc",
+	AuditActionSaveUser:          "save-user",
+	AuditActionDeactivateUser:    "deactivate-user",
+	AuditActionClaim:             "claim",
+	AuditActionUnclaim:           "unclaim",
+	AuditActionApproveMetadata:   "approve-metadata",
+	AuditActionRejectMetadata:    "reject-metadata",
+	AuditActionReportError:       "report-error",
+	AuditActionUndoErrorIssue:    "undo-error-issue",
+	AuditActionRemoveErrorIssue:  "remove-error-issue",
+	AuditActionQueueForReview:    "queue-for-review",
+	AuditActionAutosave:          "autosave",
+	AuditActionSaveDraft:         "savedraft",
+	AuditActionSaveQueue:         "savequeue",
+	AuditActionSFTPUpload:        "sftp-upload",
+	AuditActionAutoQueue:         "auto-queue",
+	AuditActionMarkUnpublished:   "mark-unpublished",
+	AuditActionUnmarkUnpublished: "unmark-unpublished",
+	AuditActionCreateBatches:     "create-batches",
+	AuditActionFailQC:            "fail-qc",
+	AuditActionPassQC:            "pass-qc",
 }
 
 var auditActionLookup = map[string]AuditAction{
@@ -82,6 +96,13 @@ var auditActionLookup = map[string]AuditAction{
 	"autosave":           AuditActionAutosave,
 	"savedraft":          AuditActionSaveDraft,
 	"savequeue":          AuditActionSaveQueue,
+	"sftp-upload":        AuditActionSFTPUpload,
+	"auto-queue":         AuditActionAutoQueue,
+	"mark-unpublished":   AuditActionMarkUnpublished,
+	"unmark-unpublished": AuditActionUnmarkUnpublished,
+	"create-batches":     AuditActionCreateBatches,
+	"fail-qc":            AuditActionFailQC,
+	"pass-qc":            AuditActionPassQC,
 }
 
 // AuditActionFromString returns the action int for the given string, if the
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Nerdmaster/magicsql"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
 )
 
 // These are all possible batch status values
@@ -30,9 +31,23 @@ type Batch struct {
 	Name        string
 	CreatedAt   time.Time
 	ArchivedAt  time.Time
+	WentLiveAt  time.Time
 	Status      string
 	Location    string
 
+	// Version starts at 1, and is incremented each time a live batch has to
+	// be rebuilt, such as when an issue is withdrawn
+	Version int
+
+	// StoredAt is when the batch was last copied to object storage and
+	// verified; it's zeroed whenever a new version of the batch is built
+	StoredAt time.Time
+
+	// Imported is true for batches which were built outside NCA and imported
+	// later.  Their Name holds the entire keyword from the original batch
+	// name, so it's used as-is rather than prefixed with the creation date.
+	Imported bool
+
 	issues []*Issue
 }
 
@@ -48,6 +63,27 @@ func FindBatch(id int) (*Batch, error) {
 	return b, op.Err()
 }
 
+// FindBatchByFullName looks for a batch whose current full name is the given
+// name, returning nil if there isn't one
+func FindBatchByFullName(name string) (*Batch, error) {
+	var sb, err = schema.ParseBatchname(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var list []*Batch
+	op.Select("batches", &Batch{}).Where("marc_org_code = ? AND version = ?", sb.MARCOrgCode, sb.Version).AllObjects(&list)
+	for _, b := range list {
+		if b.FullName() == name {
+			return b, op.Err()
+		}
+	}
+	return nil, op.Err()
+}
+
 // InProcessBatches returns the full list of in-process batches (not live, not pending)
 func InProcessBatches() ([]*Batch, error) {
 	var op = dbi.DB.Operation()
@@ -93,7 +129,13 @@ func CreateBatch(webroot, moc string, issues []*Issue) (*Batch, error) {
 	op.BeginTransaction()
 	defer op.EndTransaction()
 
-	var b = &Batch{MARCOrgCode: moc, CreatedAt: time.Now(), issues: issues, Status: BatchStatusPending}
+	return CreateBatchOp(op, webroot, moc, issues)
+}
+
+// CreateBatchOp is CreateBatch, but run within the given operation so
+// multiple batches can be created (or jobs queued) in a single transaction
+func CreateBatchOp(op *magicsql.Operation, webroot, moc string, issues []*Issue) (*Batch, error) {
+	var b = &Batch{MARCOrgCode: moc, CreatedAt: time.Now(), issues: issues, Status: BatchStatusPending, Version: 1}
 	var err = b.SaveOp(op)
 	if err != nil {
 		return nil, err
@@ -101,7 +143,10 @@ func CreateBatch(webroot, moc string, issues []*Issue) (*Batch, error) {
 
 	for _, i := range issues {
 		i.BatchID = b.ID
-		i.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("added to batch %q", b.Name))
+		err = i.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("added to batch %q", b.Name))
+		if err != nil {
+			return nil, fmt.Errorf("adding issue %q to batch: %s", i.Key(), err)
+		}
 	}
 
 	var chksum = crc32.ChecksumIEEE([]byte(webroot))
@@ -110,6 +155,76 @@ func CreateBatch(webroot, moc string, issues []*Issue) (*Batch, error) {
 	return b, err
 }
 
+// ImportBatch saves a batch which was built outside NCA, along with its
+// issues and the reels they were scanned from, in a single transaction.  The
+// issues must not already be in the database, and must have their locations
+// set up.  reels maps microfilm issues to their reels; new or changed reels
+// are saved before the issues are tied to them.
+//
+// Issues are looked up again within the transaction, since any earlier check
+// for duplicates could have been beaten by another import or an upload.
+func ImportBatch(b *Batch, issues []*Issue, reels map[*Issue]*Reel) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	for _, i := range issues {
+		var existing = &Issue{}
+		// magicsql puts nothing after the WHERE clause unless there's an order or
+		// limit, which lets us tack on the locking clause here.  This also keeps
+		// a matching issue from being added until the import is done.
+		var cond = "lccn = ? AND date = ? AND edition = ? FOR UPDATE"
+		if op.Select("issues", &Issue{}).Where(cond, i.LCCN, i.Date, i.Edition).First(existing) {
+			op.Rollback()
+			return fmt.Errorf("issue %q is already in the database (id %d)", i.Key(), existing.ID)
+		}
+		if op.Err() != nil {
+			return op.Err()
+		}
+	}
+
+	b.Imported = true
+	b.issues = issues
+	var err = b.SaveOp(op)
+	if err != nil {
+		return err
+	}
+
+	var savedReels = make(map[*Reel]bool)
+	for _, i := range issues {
+		if i.ID != 0 {
+			op.Rollback()
+			return fmt.Errorf("issue %q is already in the database", i.Key())
+		}
+
+		var r = reels[i]
+		if r != nil && !savedReels[r] {
+			err = r.SaveOp(op)
+			if err != nil {
+				op.Rollback()
+				return err
+			}
+			savedReels[r] = true
+		}
+		if r != nil {
+			i.ReelID = r.ID
+		}
+
+		// New issues need an id before their action can be tied to them
+		i.BatchID = b.ID
+		err = i.saveOp(op)
+		if err == nil {
+			err = i.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("imported with batch %q", b.FullName()))
+		}
+		if err != nil {
+			op.Rollback()
+			return err
+		}
+	}
+	return op.Err()
+}
+
 // Issues pulls all issues from the database which have this batch's ID
 func (b *Batch) Issues() ([]*Issue, error) {
 	if len(b.issues) > 0 {
@@ -125,17 +240,61 @@ func (b *Batch) Issues() ([]*Issue, error) {
 	return b.issues, err
 }
 
+// WithdrawableIssues returns the issues in a live batch.  These are ignored by
+// NCA's workflow, so Issues won't return them, but they have to be listed in
+// order to choose one to withdraw.
+func (b *Batch) WithdrawableIssues() ([]*Issue, error) {
+	if len(b.issues) > 0 {
+		return b.issues, nil
+	}
+
+	if b.ID == 0 {
+		return nil, nil
+	}
+
+	return Issues().IncludeIgnored().BatchID(b.ID).Fetch()
+}
+
+// Reels returns the microfilm reels this batch's issues were scanned from, if
+// any, ordered by LCCN and reel number
+func (b *Batch) Reels() ([]*Reel, error) {
+	var issues, err = b.Issues()
+	if err != nil {
+		return nil, err
+	}
+
+	var seen = make(map[int]bool)
+	var ids []int
+	for _, i := range issues {
+		if i.ReelID != 0 && !seen[i.ReelID] {
+			seen[i.ReelID] = true
+			ids = append(ids, i.ReelID)
+		}
+	}
+	return FindReelsByIDs(ids)
+}
+
 // FullName returns the name of a batch as it is needed for chronam / ONI.
-//
-// Note that currently we assume all generated batches will be _ver01, because
-// we would usually generate a completely new batch if one were in such a state
-// as to need to be pulled from production.
+// Batches which predate versioning are treated as version 1.
 func (b *Batch) FullName() string {
-	return fmt.Sprintf("batch_%s_%s%s_ver01", b.MARCOrgCode, b.CreatedAt.Format("20060102"), b.Name)
+	return b.VersionName(b.Version)
+}
+
+// VersionName returns the batch's full name as of the given version, e.g.,
+// to refer to a previous version which is still in production
+func (b *Batch) VersionName(ver int) string {
+	if ver < 1 {
+		ver = 1
+	}
+	if b.Imported {
+		return fmt.Sprintf("batch_%s_%s_ver%02d", b.MARCOrgCode, b.Name, ver)
+	}
+	return fmt.Sprintf("batch_%s_%s%s_ver%02d", b.MARCOrgCode, b.CreatedAt.Format("20060102"), b.Name, ver)
 }
 
 // AwardYear uses the batch creation date to produce the "award year" - this is
-// the most similar value we can produce
+// the most similar value we can produce.  Imported batches have their
+// creation date set from the original batch XML's award year.
 func (b *Batch) AwardYear() int {
 	return b.CreatedAt.Year()
 }
@@ -182,6 +341,105 @@ func (b *Batch) Delete() error {
 	return op.Err()
 }
 
+// SetLive flags the batch as being live in production, and updates its issues
+// so they're no longer considered part of NCA's workflow
+func (b *Batch) SetLive() error {
+	var issues, err = b.Issues()
+	if err != nil {
+		return err
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	b.Status = BatchStatusLive
+	b.WentLiveAt = time.Now()
+	err = b.SaveOp(op)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range issues {
+		i.Ignored = true
+		i.WorkflowStep = schema.WSInProduction
+		i.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("batch %q went live", b.Name))
+	}
+	return op.Err()
+}
+
+// WithdrawIssueOp pulls an issue out of this live batch so a new version of
+// the batch can be built without it.  The issue is flagged as withdrawn and
+// ignored, which keeps it from being batched again unless it's reinstated.
+// The batch's remaining issues are put back into NCA's workflow, just as if
+// they'd been newly batched, so QC of the new version (including a QC
+// failure) treats them like any other batch's issues.  The batch's version is
+// incremented and its status set back to pending.  The caller must queue the
+// jobs to build the new version of the batch.
+func (b *Batch) WithdrawIssueOp(op *magicsql.Operation, i *Issue, userID int, reason string) error {
+	var oldName = b.FullName()
+	var err = b.withdrawIssue(i)
+	if err != nil {
+		return err
+	}
+
+	err = i.SaveOp(op, ActionTypeWithdrawIssue, userID, fmt.Sprintf("withdrawn from %q: %s", oldName, reason))
+	if err != nil {
+		return err
+	}
+	for _, issue := range b.issues {
+		err = issue.SaveOp(op, ActionTypeInternalProcess, SystemUser.ID, fmt.Sprintf("batch %q is being rebuilt as %q", oldName, b.FullName()))
+		if err != nil {
+			return err
+		}
+	}
+	return b.SaveOp(op)
+}
+
+// withdrawIssue verifies the issue can be withdrawn from the batch, then
+// updates the batch and all its issues in memory, leaving the caller to save
+// them
+func (b *Batch) withdrawIssue(i *Issue) error {
+	if b.Status != BatchStatusLive {
+		return fmt.Errorf("cannot withdraw issues unless the batch status is live")
+	}
+	if i.BatchID != b.ID {
+		return fmt.Errorf("issue %d isn't part of batch %d", i.ID, b.ID)
+	}
+	if i.WorkflowStep != schema.WSInProduction {
+		return fmt.Errorf("issue %d isn't in production (workflow step %q)", i.ID, i.WorkflowStep)
+	}
+
+	var issues, err = b.WithdrawableIssues()
+	if err != nil {
+		return err
+	}
+	if len(issues) < 2 {
+		return fmt.Errorf("cannot withdraw the only issue in a batch")
+	}
+
+	i.BatchID = 0
+	i.Withdrawn = true
+	i.Ignored = true
+	i.WorkflowStep = schema.WSReadyForBatching
+
+	var remaining []*Issue
+	for _, issue := range issues {
+		if issue.ID != i.ID {
+			issue.Ignored = false
+			issue.WorkflowStep = schema.WSReadyForBatching
+			remaining = append(remaining, issue)
+		}
+	}
+	b.issues = remaining
+	b.Version++
+	b.Status = BatchStatusPending
+	b.ArchivedAt = time.Time{}
+	b.StoredAt = time.Time{}
+	return nil
+}
+
 // Close finalizes a batch that's live and archived by setting its status to
 // BatchStatusLiveDone.  This has some of our "safety first" business logic you
 // don't get if you close the batch manually, e.g., it must be in the "live"
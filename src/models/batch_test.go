@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestBatchFullName(t *testing.T) {
+	var created = time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	var tests = map[string]struct {
+		version int
+		want    string
+	}{
+		"pre-versioning": {0, "batch_oru_20211201Apple_ver01"},
+		"first version":  {1, "batch_oru_20211201Apple_ver01"},
+		"rebuilt":        {2, "batch_oru_20211201Apple_ver02"},
+		"many rebuilds":  {12, "batch_oru_20211201Apple_ver12"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b = &Batch{MARCOrgCode: "oru", Name: "Apple", CreatedAt: created, Version: tc.version}
+			if got := b.FullName(); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestImportedBatchFullName(t *testing.T) {
+	var b = &Batch{MARCOrgCode: "oru", Name: "courage_3", CreatedAt: time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC), Version: 1, Imported: true}
+	if got, want := b.FullName(), "batch_oru_courage_3_ver01"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := b.VersionName(2), "batch_oru_courage_3_ver02"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// withdrawFixture returns a live batch with three issues in production
+func withdrawFixture() (*Batch, []*Issue) {
+	var b = &Batch{ID: 5, MARCOrgCode: "oru", Name: "Apple", Status: BatchStatusLive, Version: 1,
+		ArchivedAt: time.Now(), StoredAt: time.Now()}
+	var issues []*Issue
+	for id := 1; id <= 3; id++ {
+		issues = append(issues, &Issue{ID: id, BatchID: b.ID, WorkflowStep: schema.WSInProduction, Ignored: true})
+	}
+	b.issues = issues
+	return b, issues
+}
+
+func TestWithdrawIssueGuards(t *testing.T) {
+	var tests = map[string]struct {
+		setup func(b *Batch, i *Issue)
+		want  string
+	}{
+		"batch not live": {
+			func(b *Batch, i *Issue) { b.Status = BatchStatusQCReady },
+			"batch status is live",
+		},
+		"issue in another batch": {
+			func(b *Batch, i *Issue) { i.BatchID = 6 },
+			"isn't part of batch 5",
+		},
+		"issue not in production": {
+			func(b *Batch, i *Issue) { i.WorkflowStep = schema.WSReadyForBatching },
+			"isn't in production",
+		},
+		"only issue": {
+			func(b *Batch, i *Issue) { b.issues = []*Issue{i} },
+			"only issue in a batch",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b, issues = withdrawFixture()
+			var i = issues[1]
+			tc.setup(b, i)
+			var batchID, step = i.BatchID, i.WorkflowStep
+
+			var err = b.withdrawIssue(i)
+			if err == nil {
+				t.Fatalf("Expected an error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected an error containing %q; got %q", tc.want, err)
+			}
+			if i.Withdrawn || i.BatchID != batchID || i.WorkflowStep != step || b.Version != 1 {
+				t.Errorf("Issue or batch changed despite the error: %#v, %#v", i, b)
+			}
+		})
+	}
+}
+
+func TestWithdrawIssue(t *testing.T) {
+	var b, issues = withdrawFixture()
+	var i = issues[1]
+
+	var err = b.withdrawIssue(i)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if i.BatchID != 0 || !i.Withdrawn || !i.Ignored || i.WorkflowStep != schema.WSReadyForBatching {
+		t.Errorf("Issue wasn't withdrawn: %#v", i)
+	}
+	if len(b.issues) != 2 || b.issues[0] != issues[0] || b.issues[1] != issues[2] {
+		t.Errorf("Expected the batch to keep issues 1 and 3, got %v", b.issues)
+	}
+	for _, issue := range b.issues {
+		if issue.Ignored || issue.WorkflowStep != schema.WSReadyForBatching {
+			t.Errorf("Remaining issue %d wasn't put back into the workflow: %#v", issue.ID, issue)
+		}
+	}
+	if b.Version != 2 || b.Status != BatchStatusPending || !b.ArchivedAt.IsZero() || !b.StoredAt.IsZero() {
+		t.Errorf("Batch wasn't reset for a new version: %#v", b)
+	}
+}
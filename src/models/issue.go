@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -50,6 +51,7 @@ type Issue struct {
 	/* Workflow information to keep track of the issue and what it needs */
 
 	BatchID                int                 // Which batch (if any) is this issue a part of?
+	ReelID                 int                 // Which microfilm reel (if any) was this issue scanned from?
 	Location               string              // Where is this issue on disk?
 	BackupLocation         string              // Where is the original backup located?  (born-digital only)
 	HumanName              string              // What is the issue's "human" name (for consistent folder naming)?
@@ -64,6 +66,7 @@ type Issue struct {
 	MetadataApprovedAt     time.Time           // When was metadata approved / how long has this been waiting to batch?
 	RejectedByUserID       int                 // If not approved, who rejected the metadata?
 	Ignored                bool                // Is the issue bad / in prod / otherwise skipped from workflow scans?
+	Withdrawn              bool                // Was the issue pulled from a live batch, and therefore must not be batched again?
 	DraftComment           string              // Any comment the curator is passing on to the reviewer
 
 	// actions holds the lazy-loaded list of actions tied to an issue, ordered
@@ -95,6 +98,13 @@ func Issues() *IssueFinder {
 	return f
 }
 
+// IncludeIgnored removes the default restriction which hides ignored issues,
+// such as those which are in production
+func (f *IssueFinder) IncludeIgnored() *IssueFinder {
+	delete(f.conditions, "ignored = ?")
+	return f
+}
+
 // LCCN returns a scope for finding issues with a particular title
 func (f *IssueFinder) LCCN(lccn string) *IssueFinder {
 	f.conditions["lccn = ?"] = lccn
@@ -111,10 +121,13 @@ func (f *IssueFinder) date(date string) *IssueFinder {
 	f.conditions["date = ?"] = date
 	return f
 }
-func (f *IssueFinder) edition(ed int) *IssueFinder {
+
+// Edition returns a scope for finding issues with a particular edition number
+func (f *IssueFinder) Edition(ed int) *IssueFinder {
 	f.conditions["edition = ?"] = ed
 	return f
 }
+
 func (f *IssueFinder) location(loc string) *IssueFinder {
 	f.conditions["location = ?"] = loc
 	return f
@@ -163,6 +176,25 @@ func (f *IssueFinder) NotCuratedBy(userID int) *IssueFinder {
 	return f
 }
 
+// Before filters issues to those which come before the given date and
+// edition, e.g., for finding the issue published just prior to another
+func (f *IssueFinder) Before(date string, ed int) *IssueFinder {
+	f.conditions["(date, edition) < (?, ?)"] = []interface{}{date, ed}
+	return f
+}
+
+// After filters issues to those which come after the given date and edition
+func (f *IssueFinder) After(date string, ed int) *IssueFinder {
+	f.conditions["(date, edition) > (?, ?)"] = []interface{}{date, ed}
+	return f
+}
+
+// HasSequence filters issues to those with a volume or issue number entered
+func (f *IssueFinder) HasSequence() *IssueFinder {
+	f.conditions["volume <> '' OR issue <> ''"] = nil
+	return f
+}
+
 // Limit sets the max issues to return
 func (f *IssueFinder) Limit(limit int) *IssueFinder {
 	f.lim = limit
@@ -183,8 +215,12 @@ func (f *IssueFinder) selector() magicsql.Select {
 	var args []interface{}
 	for k, v := range f.conditions {
 		where = append(where, "("+k+")")
-		if v != nil {
-			args = append(args, v)
+		switch val := v.(type) {
+		case nil:
+		case []interface{}:
+			args = append(args, val...)
+		default:
+			args = append(args, val)
 		}
 	}
 	var sel = f.op.Select("issues", &Issue{}).Where(strings.Join(where, " AND "), args...)
@@ -211,6 +247,27 @@ func (f *IssueFinder) Count() (uint64, error) {
 	return f.selector().Count().RowCount(), f.op.Err()
 }
 
+// FindIssueForUpdateOp looks for an issue by its id within op's transaction,
+// locking the issue's row until the transaction ends so the issue can't be
+// changed between being checked and being saved
+func FindIssueForUpdateOp(op *magicsql.Operation, id int) (*Issue, error) {
+	var i = &Issue{}
+
+	// magicsql puts nothing after the WHERE clause unless there's an order or
+	// limit, which lets us tack on the locking clause here
+	var ok = op.Select("issues", &Issue{}).Where("id = ? FOR UPDATE", id).First(i)
+	if !ok {
+		return nil, op.Err()
+	}
+	var err error
+	i.Title, err = FindTitle("lccn = ?", i.LCCN)
+	if err != nil {
+		return nil, err
+	}
+	i.deserialize()
+	return i, op.Err()
+}
+
 // FindIssue looks for an issue by its id
 func FindIssue(id int) (*Issue, error) {
 	var op = dbi.DB.Operation()
@@ -234,24 +291,33 @@ func FindIssue(id int) (*Issue, error) {
 // metadata in "draft" form, so we have to be able to test for dupes later in
 // the process
 func FindIssuesByKey(key string) ([]*Issue, error) {
+	var lccn, date, ed, err = parseIssueKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return Issues().LCCN(lccn).date(date).Edition(ed).Fetch()
+}
+
+// parseIssueKey splits an issue key into the LCCN, date (formatted as it is
+// in the database), and edition
+func parseIssueKey(key string) (lccn, date string, ed int, err error) {
 	var parts = strings.Split(key, "/")
 	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid issue key %q", key)
+		return "", "", 0, fmt.Errorf("invalid issue key %q", key)
 	}
 	if len(parts[1]) != 10 {
-		return nil, fmt.Errorf("invalid issue key %q", key)
+		return "", "", 0, fmt.Errorf("invalid issue key %q", key)
 	}
 
-	var lccn = parts[0]
+	lccn = parts[0]
 	var dateShort = parts[1][:8]
-	var date = fmt.Sprintf("%s-%s-%s", dateShort[:4], dateShort[4:6], dateShort[6:8])
+	date = fmt.Sprintf("%s-%s-%s", dateShort[:4], dateShort[4:6], dateShort[6:8])
 
-	var ed, err = strconv.Atoi(parts[1][8:])
+	ed, err = strconv.Atoi(parts[1][8:])
 	if err != nil {
-		return nil, fmt.Errorf("invalid issue key %q", key)
+		return "", "", 0, fmt.Errorf("invalid issue key %q", key)
 	}
-
-	return Issues().LCCN(lccn).date(date).edition(ed).Fetch()
+	return lccn, date, ed, nil
 }
 
 // FindIssueByKey returns the first issue with the given key
@@ -266,6 +332,32 @@ func FindIssueByKey(key string) (*Issue, error) {
 	return list[0], nil
 }
 
+// FindIssueByKeyForUpdateOp returns the first issue with the given key
+// within op's transaction.  Matching rows are locked until the transaction
+// ends, and if there are none, a matching issue can't be added until then
+// either, so callers can safely check for an issue before creating it.
+func FindIssueByKeyForUpdateOp(op *magicsql.Operation, key string) (*Issue, error) {
+	var lccn, date, ed, err = parseIssueKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// magicsql puts nothing after the WHERE clause unless there's an order or
+	// limit, which lets us tack on the locking clause here
+	var i = &Issue{}
+	var cond = "lccn = ? AND date = ? AND edition = ? FOR UPDATE"
+	var ok = op.Select("issues", &Issue{}).Where(cond, lccn, date, ed).First(i)
+	if !ok {
+		return nil, op.Err()
+	}
+	i.Title, err = FindTitle("lccn = ?", i.LCCN)
+	if err != nil {
+		return nil, err
+	}
+	i.deserialize()
+	return i, op.Err()
+}
+
 // FindIssueByLocation returns the first issue with the given location
 func FindIssueByLocation(location string) (*Issue, error) {
 	var i *Issue
@@ -296,6 +388,16 @@ func (i *Issue) DateEdition() string {
 	return schema.IssueDateEdition(i.Date, i.Edition)
 }
 
+// BatchPath returns the issue's directory relative to a batch's data
+// directory.  NDNP puts issues scanned from microfilm under their reel number,
+// and everything else under "print".
+func (i *Issue) BatchPath(r *Reel) string {
+	if r != nil && i.ReelID != 0 {
+		return path.Join(i.LCCN, r.ReelNumber, i.DateEdition())
+	}
+	return path.Join(i.LCCN, "print", i.DateEdition())
+}
+
 // AllWorkflowActions loads all actions tied to this issue and orders them in
 // chronological order (the newest are at the end of the list)
 func (i *Issue) AllWorkflowActions() []*Action {
@@ -387,19 +489,37 @@ func (i *Issue) ApproveMetadata(reviewerID int) error {
 // RejectMetadata sends the issue back to the metadata entry user and saves the
 // reviewer's notes
 func (i *Issue) RejectMetadata(reviewerID int, notes string) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+	return i.RejectMetadataOp(op, reviewerID, notes)
+}
+
+// RejectMetadataOp is RejectMetadata with a custom operation
+func (i *Issue) RejectMetadataOp(op *magicsql.Operation, reviewerID int, notes string) error {
 	i.claim(i.MetadataEntryUserID)
 	i.RejectedByUserID = reviewerID
 	i.WorkflowStep = schema.WSReadyForMetadataEntry
-	return i.Save(ActionTypeMetadataRejection, reviewerID, notes)
+	return i.SaveOp(op, ActionTypeMetadataRejection, reviewerID, notes)
 }
 
 // ReportError adds an error message to the issue and flags it as being in the
 // "unfixable" state.  That state basically says that nobody can use NCA to fix
 // the problem, and it needs to be pulled and processed by hand.
 func (i *Issue) ReportError(userID int, message string) error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+	return i.ReportErrorOp(op, userID, message)
+}
+
+// ReportErrorOp is ReportError with a custom operation
+func (i *Issue) ReportErrorOp(op *magicsql.Operation, userID int, message string) error {
 	i.WorkflowStep = schema.WSUnfixableMetadataError
 	i.unclaim()
-	return i.Save(ActionTypeReportUnfixableError, userID, message)
+	return i.SaveOp(op, ActionTypeReportUnfixableError, userID, message)
 }
 
 // returnFor implements the issue and action logic we want when returning an
@@ -440,6 +560,19 @@ func (i *Issue) PrepForRemoval(managerID int, message string) error {
 	return i.Save(ActionTypeRemoveErrorIssue, managerID, message)
 }
 
+// Reinstate clears the withdrawn flag from an issue which was pulled from a
+// live batch and puts it back in the queue for batching
+func (i *Issue) Reinstate(userID int, reason string) error {
+	if !i.Withdrawn {
+		return fmt.Errorf("issue %d has not been withdrawn", i.ID)
+	}
+
+	i.Withdrawn = false
+	i.Ignored = false
+	i.WorkflowStep = schema.WSReadyForBatching
+	return i.Save(ActionTypeReinstateIssue, userID, reason)
+}
+
 // Save creates or updates the issue with an associated action and optional message
 func (i *Issue) Save(action ActionType, userID int, message string) error {
 	var op = dbi.DB.Operation()
@@ -552,13 +685,15 @@ func (i *Issue) SchemaIssue() (*schema.Issue, error) {
 
 // FindCompletedIssuesReadyForRemoval returns all issues which are be complete
 // and no longer needed in our workflow: tied to a closed (live_done) batch and
-// ignored by NCA, but still contain a location
+// ignored by NCA, but still contain a location.  Issues in imported batches
+// are never returned, since their files live in the batch directory NCA was
+// given rather than NCA's workflow area.
 func FindCompletedIssuesReadyForRemoval() ([]*Issue, error) {
 	var op = dbi.DB.Operation()
 	op.Dbg = dbi.Debug
 
 	var list []*Issue
-	var cond = "batch_id IN (SELECT id FROM batches WHERE status = ?) AND ignored = 1 AND location <> ''"
+	var cond = "batch_id IN (SELECT id FROM batches WHERE status = ? AND imported = 0) AND ignored = 1 AND location <> ''"
 	op.Select("issues", &Issue{}).Where(cond, BatchStatusLiveDone).AllObjects(&list)
 	deserializeIssues(list)
 	return list, op.Err()
@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestBatchPath(t *testing.T) {
+	var reel = &Reel{ID: 5, LCCN: "sn12345678", ReelNumber: "00271761999"}
+	var tests = map[string]struct {
+		reelID int
+		reel   *Reel
+		want   string
+	}{
+		"print":     {0, nil, "sn12345678/print/2021010102"},
+		"microfilm": {5, reel, "sn12345678/00271761999/2021010102"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = &Issue{LCCN: "sn12345678", Date: "2021-01-01", Edition: 2, ReelID: tc.reelID}
+			if got := i.BatchPath(tc.reel); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ const (
 	JobTypeIgnoreIssue          JobType = "ignore_issue"
 	JobTypeSetBatchStatus       JobType = "set_batch_status"
 	JobTypePageSplit            JobType = "page_split"
+	JobTypeImagesToPDF          JobType = "images_to_pdf"
 	JobTypeMakeDerivatives      JobType = "make_derivatives"
 	JobTypeMoveDerivatives      JobType = "move_derivatives"
 	JobTypeBuildMETS            JobType = "build_mets"
@@ -38,10 +39,17 @@ const (
 	JobTypeWriteBagitManifest   JobType = "write_bagit_manifest"
 	JobTypeSyncDir              JobType = "sync_directory"
 	JobTypeKillDir              JobType = "delete_directory"
+	JobTypeCleanReelDir         JobType = "clean_reel_directory"
 	JobTypeRenameDir            JobType = "rename_directory"
 	JobTypeCleanFiles           JobType = "clean_files"
 	JobTypeRenumberPages        JobType = "renumber_pages"
 	JobTypeIssueAction          JobType = "record_issue_action"
+	JobTypeWriteQCReport        JobType = "write_qc_report"
+	JobTypeONILoadBatch         JobType = "oni_load_batch"
+	JobTypeONIPurgeBatch        JobType = "oni_purge_batch"
+	JobTypeStoreBatch           JobType = "store_batch"
+	JobTypeStoreIssueArchive    JobType = "store_issue_archive"
+	JobTypeValidateBatchXML     JobType = "validate_batch_xml"
 )
 
 // ValidJobTypes is the full list of job types which can exist in the jobs
@@ -53,6 +61,7 @@ var ValidJobTypes = []JobType{
 	JobTypeIgnoreIssue,
 	JobTypeSetBatchStatus,
 	JobTypePageSplit,
+	JobTypeImagesToPDF,
 	JobTypeMakeDerivatives,
 	JobTypeMoveDerivatives,
 	JobTypeBuildMETS,
@@ -64,10 +73,17 @@ var ValidJobTypes = []JobType{
 	JobTypeWriteBagitManifest,
 	JobTypeSyncDir,
 	JobTypeKillDir,
+	JobTypeCleanReelDir,
 	JobTypeRenameDir,
 	JobTypeCleanFiles,
 	JobTypeRenumberPages,
 	JobTypeIssueAction,
+	JobTypeWriteQCReport,
+	JobTypeONILoadBatch,
+	JobTypeONIPurgeBatch,
+	JobTypeStoreBatch,
+	JobTypeStoreIssueArchive,
+	JobTypeValidateBatchXML,
 }
 
 // JobStatus represents the different states in which a job can exist
@@ -308,6 +324,16 @@ func (j *Job) Clone() *Job {
 	return clone
 }
 
+// Postpone puts a popped job back in the queue to run no sooner than d from
+// now, without counting it as a retry.  This is for jobs which couldn't start,
+// e.g., because there isn't enough disk space, rather than jobs which failed.
+func (j *Job) Postpone(d time.Duration) error {
+	j.Status = string(JobStatusPending)
+	j.StartedAt = time.Time{}
+	j.RunAt = time.Now().Add(d)
+	return j.Save()
+}
+
 // FailAndRetry closes out j and queues a new, duplicate job ready for
 // processing.  We do this instead of just rerunning a job so that the job logs
 // can be tied to a distinct instance of a job, making it easier to debug
@@ -337,6 +363,26 @@ func (j *Job) FailAndRetry() (*Job, error) {
 	return clone, op.Err()
 }
 
+// Requeue closes out j and queues a new, duplicate job to run right away.
+// Unlike FailAndRetry, the new job's retry count isn't incremented, so this is
+// for jobs which didn't really fail, such as those interrupted by a runner
+// being stopped.
+func (j *Job) Requeue() (*Job, error) {
+	var op = dbi.DB.Operation()
+	op.BeginTransaction()
+
+	var clone = j.Clone()
+	clone.Status = string(JobStatusPending)
+	clone.RunAt = time.Now()
+	clone.SaveOp(op)
+
+	j.Status = string(JobStatusFailedDone)
+	j.SaveOp(op)
+
+	op.EndTransaction()
+	return clone, op.Err()
+}
+
 // RenewDeadJob takes a failed (NOT failed_done) job and queues a new job as if
 // it were being created for the first time, and is set to run immediately.
 //
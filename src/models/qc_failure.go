@@ -0,0 +1,276 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// QCCategory is a machine-friendly reason an issue or page failed QC
+type QCCategory string
+
+// All QC failure categories.  Each category decides where a flagged issue is
+// sent: metadata problems go back to metadata entry, while problems with the
+// issue's files go to the error queue, since curators can't fix those.
+const (
+	QCCategoryPageLabels    QCCategory = "page-labels"
+	QCCategoryIssueMetadata QCCategory = "issue-metadata"
+	QCCategoryOtherMetadata QCCategory = "other-metadata"
+	QCCategoryWrongTitle    QCCategory = "wrong-title"
+	QCCategoryPageOrder     QCCategory = "page-order"
+	QCCategoryMissingPages  QCCategory = "missing-pages"
+	QCCategoryImageQuality  QCCategory = "image-quality"
+	QCCategoryOCR           QCCategory = "ocr"
+	QCCategoryOtherFiles    QCCategory = "other-files"
+)
+
+// QCCategories lists all categories in the order they should be presented
+var QCCategories = []QCCategory{
+	QCCategoryPageLabels,
+	QCCategoryIssueMetadata,
+	QCCategoryOtherMetadata,
+	QCCategoryWrongTitle,
+	QCCategoryPageOrder,
+	QCCategoryMissingPages,
+	QCCategoryImageQuality,
+	QCCategoryOCR,
+	QCCategoryOtherFiles,
+}
+
+// Valid returns true if c is one of the known QC categories
+func (c QCCategory) Valid() bool {
+	for _, cat := range QCCategories {
+		if c == cat {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe returns a human-readable explanation of the category
+func (c QCCategory) Describe() string {
+	switch c {
+	case QCCategoryPageLabels:
+		return "Wrong or missing page labels"
+	case QCCategoryIssueMetadata:
+		return "Wrong date, edition, volume, or issue number"
+	case QCCategoryOtherMetadata:
+		return "Other metadata problem"
+	case QCCategoryWrongTitle:
+		return "Issue is filed under the wrong title"
+	case QCCategoryPageOrder:
+		return "Pages are out of order"
+	case QCCategoryMissingPages:
+		return "Pages are missing or duplicated"
+	case QCCategoryImageQuality:
+		return "Poor or broken page images"
+	case QCCategoryOCR:
+		return "Missing or unusable OCR"
+	case QCCategoryOtherFiles:
+		return "Other problem with the issue's files"
+	default:
+		return string(c)
+	}
+}
+
+// ReturnsToCuration is true if an issue flagged with this category can be
+// fixed by a curator, and false if it has to go to the error queue
+func (c QCCategory) ReturnsToCuration() bool {
+	switch c {
+	case QCCategoryPageLabels, QCCategoryIssueMetadata, QCCategoryOtherMetadata:
+		return true
+	}
+	return false
+}
+
+// QCFailure records a single failed QC review of a batch: who failed it,
+// when, and which of the batch's issues and pages were flagged
+type QCFailure struct {
+	ID           int `sql:",primary"`
+	BatchID      int
+	BatchVersion int
+	UserID       int
+	Notes        string
+	CreatedAt    time.Time
+
+	Flags []*QCFlag `sql:"-"`
+}
+
+// QCFlag is a problem a reviewer found with an issue, or one of its pages, in
+// a batch that failed QC.  Page is the 1-based page number, or zero when the
+// problem applies to the whole issue.
+type QCFlag struct {
+	ID          int `sql:",primary"`
+	QCFailureID int
+	IssueID     int
+	Page        int
+	Category    string
+	Comment     string
+}
+
+// Describe returns a one-line summary of the flag, suitable for an issue's
+// action log
+func (f *QCFlag) Describe() string {
+	var s = QCCategory(f.Category).Describe()
+	if f.Page > 0 {
+		s = fmt.Sprintf("page %d: %s", f.Page, s)
+	}
+	if f.Comment != "" {
+		s += " - " + f.Comment
+	}
+	return s
+}
+
+// FindQCFailures returns a batch's QC failure history, oldest first, with
+// each failure's flags loaded
+func FindQCFailures(batchID int) ([]*QCFailure, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+
+	var list []*QCFailure
+	op.Select("batch_qc_failures", &QCFailure{}).Where("batch_id = ?", batchID).Order("created_at, id").AllObjects(&list)
+	for _, f := range list {
+		op.Select("batch_qc_flags", &QCFlag{}).Where("qc_failure_id = ?", f.ID).Order("issue_id, page, id").AllObjects(&f.Flags)
+	}
+	return list, op.Err()
+}
+
+// LatestQCFailure returns the batch's most recent QC failure, or nil if the
+// batch has never failed QC
+func LatestQCFailure(batchID int) (*QCFailure, error) {
+	var list, err = FindQCFailures(batchID)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[len(list)-1], nil
+}
+
+// SaveOp creates or updates the QC failure record.  Its flags are saved
+// separately via AddFlagOp.
+func (f *QCFailure) SaveOp(op *magicsql.Operation) error {
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+	op.Save("batch_qc_failures", f)
+	return op.Err()
+}
+
+// AddFlagOp saves a flag as part of this QC failure
+func (f *QCFailure) AddFlagOp(op *magicsql.Operation, flag *QCFlag) error {
+	flag.QCFailureID = f.ID
+	op.Save("batch_qc_flags", flag)
+	if op.Err() == nil {
+		f.Flags = append(f.Flags, flag)
+	}
+	return op.Err()
+}
+
+// NewQCFailure returns a QC failure record for the batch's current version
+func (b *Batch) NewQCFailure(userID int, notes string) *QCFailure {
+	return &QCFailure{BatchID: b.ID, BatchVersion: b.Version, UserID: userID, Notes: notes}
+}
+
+// AwaitingQC returns true if the batch is in a status where it can pass or
+// fail QC
+func (b *Batch) AwaitingQC() bool {
+	return b.Status == BatchStatusQCReady || b.Status == BatchStatusOnStaging
+}
+
+// PassQCOp flags the batch as having passed QC.  The caller is responsible
+// for queueing the jobs to get it into production.
+func (b *Batch) PassQCOp(op *magicsql.Operation) error {
+	if !b.AwaitingQC() {
+		return fmt.Errorf("batch status %q can't pass QC", b.Status)
+	}
+	b.Status = BatchStatusPassedQC
+	return b.SaveOp(op)
+}
+
+// FailQCOp records the QC failure and its flags, pulls every flagged issue
+// out of the batch, and sends each to metadata entry or the error queue
+// depending on its flags' categories.  The batch is set back to pending when
+// issues remain, or deleted when nothing is left.  The caller is responsible
+// for the filesystem: removing the old batch and queueing a rebuild.
+func (b *Batch) FailQCOp(op *magicsql.Operation, f *QCFailure) error {
+	if !b.AwaitingQC() {
+		return fmt.Errorf("batch status %q can't fail QC", b.Status)
+	}
+	if len(f.Flags) == 0 {
+		return fmt.Errorf("at least one issue must be flagged")
+	}
+
+	var issues, err = b.Issues()
+	if err != nil {
+		return err
+	}
+	var issueByID = make(map[int]*Issue)
+	for _, i := range issues {
+		issueByID[i.ID] = i
+	}
+
+	var flagsByIssue = make(map[int][]*QCFlag)
+	for _, flag := range f.Flags {
+		var i = issueByID[flag.IssueID]
+		if i == nil {
+			return fmt.Errorf("issue %d isn't part of batch %d", flag.IssueID, b.ID)
+		}
+		if !QCCategory(flag.Category).Valid() {
+			return fmt.Errorf("%q is not a valid QC category", flag.Category)
+		}
+		if flag.Page < 0 || flag.Page > len(i.PageLabels) {
+			return fmt.Errorf("issue %s has no page %d", i.Key(), flag.Page)
+		}
+		flagsByIssue[i.ID] = append(flagsByIssue[i.ID], flag)
+	}
+
+	var flags = f.Flags
+	f.Flags = nil
+	f.SaveOp(op)
+	for _, flag := range flags {
+		f.AddFlagOp(op, flag)
+	}
+	if op.Err() != nil {
+		return op.Err()
+	}
+
+	var remaining []*Issue
+	for _, i := range issues {
+		if flagsByIssue[i.ID] == nil {
+			remaining = append(remaining, i)
+			continue
+		}
+		err = b.routeFlaggedIssueOp(op, i, f.UserID, flagsByIssue[i.ID])
+		if err != nil {
+			return err
+		}
+	}
+
+	b.issues = remaining
+	b.Status = BatchStatusPending
+	if len(remaining) == 0 {
+		b.Status = BatchStatusDeleted
+	}
+	return b.SaveOp(op)
+}
+
+// routeFlaggedIssueOp takes an issue out of the batch and sends it back to
+// metadata entry if all its flags can be fixed by a curator, or to the error
+// queue otherwise
+func (b *Batch) routeFlaggedIssueOp(op *magicsql.Operation, i *Issue, userID int, flags []*QCFlag) error {
+	var curate = true
+	var lines = []string{fmt.Sprintf("failed QC in batch %q:", b.FullName())}
+	for _, flag := range flags {
+		curate = curate && QCCategory(flag.Category).ReturnsToCuration()
+		lines = append(lines, "- "+flag.Describe())
+	}
+	var msg = strings.Join(lines, "\n")
+
+	i.BatchID = 0
+	if curate {
+		return i.RejectMetadataOp(op, userID, msg)
+	}
+	return i.ReportErrorOp(op, userID, msg)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestQCFlagDescribe(t *testing.T) {
+	var tests = map[string]struct {
+		flag *QCFlag
+		want string
+	}{
+		"whole issue":  {&QCFlag{Category: string(QCCategoryIssueMetadata)}, "Wrong date, edition, volume, or issue number"},
+		"page":         {&QCFlag{Category: string(QCCategoryOCR), Page: 3}, "page 3: Missing or unusable OCR"},
+		"with comment": {&QCFlag{Category: string(QCCategoryPageLabels), Page: 2, Comment: "says 3"}, "page 2: Wrong or missing page labels - says 3"},
+		"unknown":      {&QCFlag{Category: "mystery"}, "mystery"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.flag.Describe(); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQCCategoryRouting(t *testing.T) {
+	var curate = map[QCCategory]bool{
+		QCCategoryPageLabels:    true,
+		QCCategoryIssueMetadata: true,
+		QCCategoryOtherMetadata: true,
+	}
+	for _, c := range QCCategories {
+		if !c.Valid() {
+			t.Errorf("%q should be valid", c)
+		}
+		if c.ReturnsToCuration() != curate[c] {
+			t.Errorf("%q: expected ReturnsToCuration to be %v", c, curate[c])
+		}
+	}
+	if QCCategory("mystery").Valid() {
+		t.Errorf("unknown categories shouldn't be valid")
+	}
+}
+
+func TestFailQCOpStatus(t *testing.T) {
+	var b = &Batch{Status: BatchStatusLive}
+	var err = b.FailQCOp(nil, b.NewQCFailure(1, ""))
+	if err == nil {
+		t.Errorf("live batches shouldn't be able to fail QC")
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Nerdmaster/magicsql"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// Reel describes a microfilm reel from which one or more issues were scanned.
+// NDNP batches include a METS file and target images for each reel.
+type Reel struct {
+	ID          int `sql:",primary"`
+	MARCOrgCode string
+	LCCN        string
+	ReelNumber  string
+
+	// Location is where the reel's target images live once they've been moved
+	// into the workflow
+	Location string
+}
+
+// FindReel looks up a reel by its id, returning nil if it isn't found
+func FindReel(id int) (*Reel, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var r = &Reel{}
+	var ok = op.Select("reels", &Reel{}).Where("id = ?", id).First(r)
+	if !ok {
+		return nil, op.Err()
+	}
+	return r, op.Err()
+}
+
+// FindReelByNumber looks up the given title's reel, returning nil if it isn't
+// found
+func FindReelByNumber(lccn, reelNumber string) (*Reel, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var r = &Reel{}
+	var ok = op.Select("reels", &Reel{}).Where("lccn = ? AND reel_number = ?", lccn, reelNumber).First(r)
+	if !ok {
+		return nil, op.Err()
+	}
+	return r, op.Err()
+}
+
+// FindReelsByIDs returns all reels with the given ids
+func FindReelsByIDs(ids []int) ([]*Reel, error) {
+	var list []*Reel
+	if len(ids) == 0 {
+		return list, nil
+	}
+
+	var args []interface{}
+	var placeholders []string
+	for _, id := range ids {
+		args = append(args, id)
+		placeholders = append(placeholders, "?")
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var clause = fmt.Sprintf("id IN (%s)", strings.Join(placeholders, ","))
+	op.Select("reels", &Reel{}).Where(clause, args...).Order("lccn, reel_number").AllObjects(&list)
+	return list, op.Err()
+}
+
+// BatchPath returns the reel's directory relative to a batch's data directory
+func (r *Reel) BatchPath() string {
+	return path.Join(r.LCCN, r.ReelNumber)
+}
+
+// Save creates or updates the reel in the database
+func (r *Reel) Save() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	return r.SaveOp(op)
+}
+
+// SaveOp creates or updates the reel in the database using a custom
+// operation, such as one within a transaction
+func (r *Reel) SaveOp(op *magicsql.Operation) error {
+	op.Save("reels", r)
+	return op.Err()
+}
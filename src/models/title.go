@@ -1,12 +1,15 @@
 package models
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/duration"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Title holds records from the titles table
@@ -23,6 +26,46 @@ type Title struct {
 	MARCTitle     string
 	MARCLocation  string
 	LangCode3     string
+
+	// SFTPPublicKeys holds zero or more public keys, in OpenSSH
+	// "authorized_keys" format, which may be used instead of a password
+	// when logging in to NCA's SFTP server
+	SFTPPublicKeys string
+
+	// SFTPQuota is the maximum number of bytes the title's SFTP directory may
+	// hold when uploading via NCA's SFTP server; zero means no limit
+	SFTPQuota int64
+
+	// AutoQueueHours is how long an uploaded issue must sit untouched, with no
+	// validation errors, before it's queued without a curator's help; zero
+	// disables auto-queueing for the title
+	AutoQueueHours int
+
+	// ImageUploads is true when the publisher sends page images instead of
+	// PDFs; their issues are converted to PDFs (and OCRed if possible) when
+	// they're moved into NCA
+	ImageUploads bool
+
+	// MARCFrequency is the publication frequency from the title's MARC record
+	// (e.g., "Daily (except Sunday)"), and Frequency is an admin-set override
+	// for when the MARC is missing, wrong, or too vague
+	MARCFrequency string
+	Frequency     string
+
+	// MARCStartYear and MARCEndYear are the title's publication years from its
+	// MARC 008 field.  Unknown digits are "u" (e.g., "18uu"), and a title which
+	// is still being published has an end year of "9999".
+	MARCStartYear string
+	MARCEndYear   string
+}
+
+// PublicationFrequency returns the admin-set frequency if there is one, and
+// the MARC frequency otherwise
+func (t *Title) PublicationFrequency() string {
+	if t.Frequency != "" {
+		return t.Frequency
+	}
+	return t.MARCFrequency
 }
 
 // FindTitle searches the database for a single title
@@ -73,6 +116,16 @@ func (tl TitleList) FindByDirectory(dir string) *Title {
 	return nil
 }
 
+// FindBySFTPUser looks up a title by its SFTP username
+func (tl TitleList) FindBySFTPUser(user string) *Title {
+	for _, t := range tl {
+		if t.SFTPUser == user {
+			return t
+		}
+	}
+	return nil
+}
+
 // Find looks for the title by either directory name or LCCN to give a simpler
 // way to find titles in a general case
 func (tl TitleList) Find(identifier string) *Title {
@@ -96,6 +149,30 @@ func (t *Title) Save() error {
 	return op.Err()
 }
 
+// SFTPPasswordMatches checks pass against the title's SFTP password, which may
+// be stored as either a bcrypt hash or plain text.  A title with no password
+// never matches.
+func (t *Title) SFTPPasswordMatches(pass string) bool {
+	var stored = t.SFTPPass
+	if stored == "" {
+		return false
+	}
+	if strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(pass)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(pass)) == 1
+}
+
+// SetSFTPPassword stores a bcrypt hash of pass as the title's SFTP password
+func (t *Title) SetSFTPPassword(pass string) error {
+	var hash, err = bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	t.SFTPPass = string(hash)
+	return nil
+}
+
 // CalculateEmbargoLiftDate returns the date an embargo will lift relative to
 // the given time (usually this would be an issue's publication date)
 func (t *Title) CalculateEmbargoLiftDate(dt time.Time) (time.Time, error) {
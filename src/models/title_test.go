@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSFTPPasswordMatches(t *testing.T) {
+	var hash, _ = bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	var tests = map[string]struct {
+		stored string
+		pass   string
+		want   bool
+	}{
+		"plain match":    {"secret", "secret", true},
+		"plain mismatch": {"secret", "nope", false},
+		"hash match":     {string(hash), "secret", true},
+		"hash mismatch":  {string(hash), "nope", false},
+		"hash as plain":  {string(hash), string(hash), false},
+		"empty":          {"", "", false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var title = &Title{SFTPPass: tc.stored}
+			if got := title.SFTPPasswordMatches(tc.pass); got != tc.want {
+				t.Errorf("SFTPPasswordMatches(%q) with stored %q: expected %v", tc.pass, tc.stored, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetSFTPPassword(t *testing.T) {
+	var title = &Title{}
+	var err = title.SetSFTPPassword("secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if title.SFTPPass == "secret" {
+		t.Errorf("Expected the password to be hashed")
+	}
+	if !title.SFTPPasswordMatches("secret") {
+		t.Errorf("Expected the hashed password to match")
+	}
+	if title.SFTPPasswordMatches("nope") {
+		t.Errorf("Expected a different password not to match")
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
+)
+
+// UnpublishedDate records a date on which a title would normally have
+// published, but staff have confirmed it didn't (holidays, strikes, etc.), so
+// it shouldn't be reported as a missing issue
+type UnpublishedDate struct {
+	ID        int `sql:",primary"`
+	LCCN      string
+	Date      string
+	Note      string
+	UserID    int
+	CreatedAt time.Time
+}
+
+// FindUnpublishedDate looks up a single unpublished date by id, returning nil
+// if it doesn't exist
+func FindUnpublishedDate(id int) (*UnpublishedDate, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var u = &UnpublishedDate{}
+	var ok = op.Select("unpublished_dates", &UnpublishedDate{}).Where("id = ?", id).First(u)
+	if !ok {
+		return nil, op.Err()
+	}
+	return u, op.Err()
+}
+
+// UnpublishedDates returns all dates which have been marked as not published,
+// ordered by LCCN and date
+func UnpublishedDates() ([]*UnpublishedDate, error) {
+	var list []*UnpublishedDate
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Select("unpublished_dates", &UnpublishedDate{}).Order("lccn, date").AllObjects(&list)
+	return list, op.Err()
+}
+
+// UnpublishedDatesForTitle returns the dates which have been marked as not
+// published for a single title, ordered by date
+func UnpublishedDatesForTitle(lccn string) ([]*UnpublishedDate, error) {
+	var list []*UnpublishedDate
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Select("unpublished_dates", &UnpublishedDate{}).Where("lccn = ?", lccn).Order("date").AllObjects(&list)
+	return list, op.Err()
+}
+
+// FindUnpublishedDateFor looks up the title's "not published" record for the
+// given date, returning nil if the date isn't marked
+func FindUnpublishedDateFor(lccn, date string) (*UnpublishedDate, error) {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	var u = &UnpublishedDate{}
+	var ok = op.Select("unpublished_dates", &UnpublishedDate{}).Where("lccn = ? AND date = ?", lccn, date).First(u)
+	if !ok {
+		return nil, op.Err()
+	}
+	return u, op.Err()
+}
+
+// Save creates or updates the unpublished date
+func (u *UnpublishedDate) Save() error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Save("unpublished_dates", u)
+	return op.Err()
+}
+
+// Delete removes the unpublished date, meaning the date will be reported as
+// missing again if there's no issue for it
+func (u *UnpublishedDate) Delete() error {
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.Exec("DELETE FROM unpublished_dates WHERE id = ?", u.ID)
+	return op.Err()
+}
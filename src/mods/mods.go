@@ -4,9 +4,11 @@ package mods
 
 // Data holds the meat of the issue XML
 type Data struct {
+	IDs          []ID         `xml:"identifier"`
 	RelatedItems []RelItem    `xml:"relatedItem"`
 	OriginInfos  []OriginInfo `xml:"originInfo"`
 	Parts        []Part       `xml:"part"`
+	Notes        []Note       `xml:"note"`
 	Rights       string       `xml:"accessCondition"`
 }
 
@@ -16,6 +18,10 @@ type RelItem struct {
 	Type  string `xml:"type,attr"`
 	IDs   []ID   `xml:"identifier"`
 	Parts []Part `xml:"part"`
+
+	// PhysicalLocations holds the MARC org codes of the institutions holding
+	// the original; it's only used in page metadata
+	PhysicalLocations []string `xml:"location>physicalLocation"`
 }
 
 // OriginInfo holds issue date and date-as-labeled
@@ -37,6 +43,12 @@ type ID struct {
 	Type  string `xml:"type,attr"`
 }
 
+// Note holds a typed note, such as the agency responsible for reproduction
+type Note struct {
+	Value string `xml:",chardata"`
+	Type  string `xml:"type,attr"`
+}
+
 // DateIssued holds dates and qualifiers like "questionable"
 type DateIssued struct {
 	Date      string `xml:",chardata"`
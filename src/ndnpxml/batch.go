@@ -0,0 +1,71 @@
+package ndnpxml
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+)
+
+// batchXML is the top-level batch.xml file
+type batchXML struct {
+	XMLName   xml.Name
+	Name      string       `xml:"name,attr"`
+	Awardee   string       `xml:"awardee,attr"`
+	AwardYear string       `xml:"awardYear,attr"`
+	Issues    []batchIssue `xml:"issue"`
+	Reels     []batchReel  `xml:"reel"`
+}
+
+// batchIssue is an <issue> element in the batch XML
+type batchIssue struct {
+	LCCN         string `xml:"lccn,attr"`
+	Date         string `xml:"issueDate,attr"`
+	EditionOrder string `xml:"editionOrder,attr"`
+	Path         string `xml:",chardata"`
+}
+
+// batchReel is a <reel> element in the batch XML
+type batchReel struct {
+	Number string `xml:"reelNumber,attr"`
+	Path   string `xml:",chardata"`
+}
+
+// check validates the batch XML and everything it references
+func (b *batch) check() {
+	var fpath = filepath.Join(b.dir, "data", "batch.xml")
+	var bx batchXML
+	if !b.decode(fpath, &bx) {
+		return
+	}
+
+	if bx.XMLName.Space != ndnpNS || bx.XMLName.Local != "batch" {
+		b.addf(fpath, "root element must be <batch> in the %q namespace", ndnpNS)
+		return
+	}
+	if bx.Name != b.name {
+		b.addf(fpath, "batch name %q doesn't match the expected name, %q", bx.Name, b.name)
+	}
+	if bx.Awardee == "" {
+		b.addf(fpath, "batch has no awardee")
+	}
+	if bx.AwardYear == "" {
+		b.addf(fpath, "batch has no award year")
+	}
+	if len(bx.Issues) == 0 {
+		b.addf(fpath, "batch has no issues")
+	}
+
+	var seen = make(map[string]bool)
+	for _, bi := range bx.Issues {
+		var ref = strings.TrimSpace(bi.Path)
+		if seen[ref] {
+			b.addf(fpath, "issue %q is listed more than once", ref)
+			continue
+		}
+		seen[ref] = true
+		b.checkIssue(fpath, bi)
+	}
+	for _, br := range bx.Reels {
+		b.checkReel(fpath, br)
+	}
+}
@@ -0,0 +1,365 @@
+package ndnpxml
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/chronam"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/mods"
+)
+
+// metsXML holds the parts of an issue or reel METS file we check
+type metsXML struct {
+	XMLName  xml.Name
+	Profile  string                        `xml:"PROFILE,attr"`
+	DMDSecs  []chronam.DescriptiveMetadata `xml:"dmdSec"`
+	FileGrps []fileGrp                     `xml:"fileSec>fileGrp"`
+	Divs     []div                         `xml:"structMap>div"`
+}
+
+type fileGrp struct {
+	ID    string     `xml:"ID,attr"`
+	Files []metsFile `xml:"file"`
+}
+
+type metsFile struct {
+	ID     string   `xml:"ID,attr"`
+	Use    string   `xml:"USE,attr"`
+	FLocat []flocat `xml:"FLocat"`
+}
+
+type flocat struct {
+	Href string `xml:"http://www.w3.org/1999/xlink href,attr"`
+}
+
+type div struct {
+	Type  string `xml:"TYPE,attr"`
+	DMDID string `xml:"DMDID,attr"`
+	Fptrs []fptr `xml:"fptr"`
+	Divs  []div  `xml:"div"`
+}
+
+type fptr struct {
+	FileID string `xml:"FILEID,attr"`
+}
+
+// structPage is one page (or reel target) from a METS structMap, along with
+// its descriptive metadata and files
+type structPage struct {
+	id    string
+	mods  *mods.Data
+	files map[string]string
+}
+
+// readMETS parses the METS file and checks that it uses the given NDNP
+// profile.  The returned map holds the file's descriptive metadata by id.
+func (b *batch) readMETS(fpath, profile string) (*metsXML, map[string]*mods.Data, bool) {
+	var doc metsXML
+	if !b.decode(fpath, &doc) {
+		return nil, nil, false
+	}
+	if doc.XMLName.Space != metsNS || doc.XMLName.Local != "mets" {
+		b.addf(fpath, "root element must be <mets> in the %q namespace", metsNS)
+		return nil, nil, false
+	}
+	if !strings.HasPrefix(doc.Profile, profile) {
+		b.addf(fpath, "METS profile %q must be %q followed by a version", doc.Profile, profile)
+	}
+
+	var dmd = make(map[string]*mods.Data)
+	for i := range doc.DMDSecs {
+		var sec = &doc.DMDSecs[i]
+		if dmd[sec.ID] != nil {
+			b.addf(fpath, "dmdSec %q is defined more than once", sec.ID)
+		}
+		dmd[sec.ID] = &sec.Data
+	}
+
+	b.xsdFiles = append(b.xsdFiles, fpath)
+	return &doc, dmd, true
+}
+
+// checkStructure verifies the METS file's fileSec and structMap: every file
+// must exist, every page div of the given type must have descriptive
+// metadata and a file for each required use, and every file must belong to a
+// page.  The pages are returned in structMap order.
+func (b *batch) checkStructure(fpath string, doc *metsXML, dmd map[string]*mods.Data, pageType string, uses []string) []*structPage {
+	var dir = filepath.Dir(fpath)
+	var files = make(map[string]*metsFile)
+	for gi := range doc.FileGrps {
+		for fi := range doc.FileGrps[gi].Files {
+			var f = &doc.FileGrps[gi].Files[fi]
+			if files[f.ID] != nil {
+				b.addf(fpath, "file id %q is used more than once", f.ID)
+			}
+			files[f.ID] = f
+			if len(f.FLocat) == 0 || f.FLocat[0].Href == "" {
+				b.addf(fpath, "file %q has no location", f.ID)
+				continue
+			}
+			var href = f.FLocat[0].Href
+			if !fileutil.IsFile(filepath.Join(dir, filepath.FromSlash(href))) {
+				b.addf(fpath, "file %q refers to %q, which doesn't exist", f.ID, href)
+			}
+		}
+	}
+
+	var pages []*structPage
+	var used = make(map[string]bool)
+	var visit func(divs []div)
+	visit = func(divs []div) {
+		for _, d := range divs {
+			visit(d.Divs)
+			if d.Type != pageType {
+				continue
+			}
+
+			var p = &structPage{id: d.DMDID, mods: dmd[d.DMDID], files: make(map[string]string)}
+			pages = append(pages, p)
+			if p.mods == nil {
+				b.addf(fpath, "%s %d refers to dmdSec %q, which doesn't exist", pageType, len(pages), d.DMDID)
+			}
+			for _, ptr := range d.Fptrs {
+				var f = files[ptr.FileID]
+				if f == nil {
+					b.addf(fpath, "%s %d refers to file %q, which isn't in the fileSec", pageType, len(pages), ptr.FileID)
+					continue
+				}
+				used[ptr.FileID] = true
+				if len(f.FLocat) > 0 {
+					p.files[f.Use] = filepath.Join(dir, filepath.FromSlash(f.FLocat[0].Href))
+				}
+			}
+			for _, use := range uses {
+				if p.files[use] == "" {
+					b.addf(fpath, "%s %d has no %q file", pageType, len(pages), use)
+				}
+			}
+		}
+	}
+	visit(doc.Divs)
+
+	var unused []string
+	for id := range files {
+		if !used[id] {
+			unused = append(unused, id)
+		}
+	}
+	sort.Strings(unused)
+	for _, id := range unused {
+		b.addf(fpath, "file %q isn't part of any %s in the structMap", id, pageType)
+	}
+
+	if len(pages) == 0 {
+		b.addf(fpath, "structMap has no %s divs", pageType)
+	}
+	if len(pages) != len(doc.FileGrps) {
+		b.addf(fpath, "structMap has %d %s divs, but the fileSec has %d file groups", len(pages), pageType, len(doc.FileGrps))
+	}
+
+	return pages
+}
+
+// checkIssue validates the issue METS and its files
+func (b *batch) checkIssue(batchXML string, bi batchIssue) {
+	if bi.LCCN == "" || bi.Date == "" || bi.EditionOrder == "" {
+		b.addf(batchXML, "issue %q must have an lccn, issueDate, and editionOrder", strings.TrimSpace(bi.Path))
+	}
+	var fpath, ok = b.dataPath(batchXML, "issue", bi.Path)
+	if !ok {
+		return
+	}
+	var doc, dmd, valid = b.readMETS(fpath, issueProfile)
+	if !valid {
+		return
+	}
+
+	var issueMODS = dmd["issueModsBib"]
+	if issueMODS == nil {
+		b.addf(fpath, "issue metadata (dmdSec \"issueModsBib\") is missing")
+	} else {
+		b.checkIssueMODS(fpath, bi, issueMODS)
+	}
+
+	var pages = b.checkStructure(fpath, doc, dmd, "np:page", []string{"service", "derivative", "ocr"})
+	if len(doc.DMDSecs)-1 != len(pages) {
+		b.addf(fpath, "%d page dmdSecs for %d pages", len(doc.DMDSecs)-1, len(pages))
+	}
+	for i, p := range pages {
+		if p.mods != nil {
+			b.checkPageMODS(fpath, i+1, p.mods)
+		}
+		b.checkOCR(p.files["ocr"])
+	}
+	b.checkPageCounts(fpath, len(pages))
+}
+
+// checkIssueMODS verifies the issue's required MODS fields are present and
+// agree with the batch XML
+func (b *batch) checkIssueMODS(fpath string, bi batchIssue, data *mods.Data) {
+	var lccn, edition string
+	for _, ri := range data.RelatedItems {
+		if ri.Type != "host" {
+			continue
+		}
+		for _, id := range ri.IDs {
+			if id.Type == "lccn" {
+				lccn = strings.TrimSpace(id.Label)
+			}
+		}
+		for _, part := range ri.Parts {
+			for _, d := range part.Details {
+				if d.Type == "edition" {
+					edition = strings.TrimSpace(d.Number)
+				}
+			}
+		}
+	}
+
+	var date string
+	for _, oi := range data.OriginInfos {
+		for _, d := range oi.Dates {
+			if d.Qualifier == "" && date == "" {
+				date = strings.TrimSpace(d.Date)
+			}
+		}
+	}
+
+	switch {
+	case lccn == "":
+		b.addf(fpath, "issue MODS has no LCCN")
+	case bi.LCCN != "" && lccn != bi.LCCN:
+		b.addf(fpath, "issue MODS LCCN %q doesn't match the batch XML's %q", lccn, bi.LCCN)
+	}
+	switch {
+	case date == "":
+		b.addf(fpath, "issue MODS has no issue date")
+	case bi.Date != "" && date != bi.Date:
+		b.addf(fpath, "issue MODS date %q doesn't match the batch XML's %q", date, bi.Date)
+	}
+
+	var ed, err = strconv.Atoi(edition)
+	var batchEd, batchErr = strconv.Atoi(bi.EditionOrder)
+	switch {
+	case err != nil:
+		b.addf(fpath, "issue MODS edition %q must be a number", edition)
+	case bi.EditionOrder != "" && (batchErr != nil || ed != batchEd):
+		b.addf(fpath, "issue MODS edition %d doesn't match the batch XML's %q", ed, bi.EditionOrder)
+	}
+}
+
+// checkPageMODS verifies a page's required MODS fields
+func (b *batch) checkPageMODS(fpath string, n int, data *mods.Data) {
+	var seq string
+	for _, part := range data.Parts {
+		for _, ext := range part.Extents {
+			if ext.Unit == "pages" {
+				seq = strings.TrimSpace(ext.Start)
+			}
+		}
+	}
+	if seq != strconv.Itoa(n) {
+		b.addf(fpath, "page %d has sequence number %q; expected %d", n, seq, n)
+	}
+
+	var location string
+	for _, ri := range data.RelatedItems {
+		if ri.Type == "original" && len(ri.PhysicalLocations) > 0 {
+			location = strings.TrimSpace(ri.PhysicalLocations[0])
+		}
+	}
+	if location == "" {
+		b.addf(fpath, "page %d MODS has no physical location", n)
+	}
+
+	var agency string
+	for _, note := range data.Notes {
+		if note.Type == "agencyResponsibleForReproduction" {
+			agency = strings.TrimSpace(note.Value)
+		}
+	}
+	if agency == "" {
+		b.addf(fpath, "page %d MODS has no agency responsible for reproduction", n)
+	}
+}
+
+// checkOCR verifies that a page's OCR file is ALTO and queues it for schema
+// validation.  Only the root element is read here, as OCR files can be large
+// and numerous.
+func (b *batch) checkOCR(fpath string) {
+	if fpath == "" || !fileutil.IsFile(fpath) {
+		return
+	}
+	var name, err = rootElement(fpath)
+	if err != nil {
+		b.addf(fpath, "unable to read XML: %s", err)
+		return
+	}
+	if name.Local != "alto" {
+		b.addf(fpath, "OCR root element is <%s>; expected <alto>", name.Local)
+		return
+	}
+	b.xsdFiles = append(b.xsdFiles, fpath)
+}
+
+// checkPageCounts verifies that the issue directory holds a PDF, JP2, and OCR
+// file for each page, and no more
+func (b *batch) checkPageCounts(fpath string, pages int) {
+	var infos, err = ioutil.ReadDir(filepath.Dir(fpath))
+	if err != nil {
+		b.addf(fpath, "unable to read issue directory: %s", err)
+		return
+	}
+
+	var counts = make(map[string]int)
+	for _, info := range infos {
+		var name = info.Name()
+		if !info.Mode().IsRegular() || strings.HasPrefix(name, ".") || name == filepath.Base(fpath) {
+			continue
+		}
+		counts[strings.ToLower(filepath.Ext(name))]++
+	}
+
+	for _, ext := range []string{".pdf", ".jp2", ".xml"} {
+		if counts[ext] != pages {
+			b.addf(fpath, "METS lists %d pages, but the issue directory has %d %s files", pages, counts[ext], ext)
+		}
+	}
+}
+
+// checkReel validates the reel METS and its target files
+func (b *batch) checkReel(batchXML string, br batchReel) {
+	if br.Number == "" {
+		b.addf(batchXML, "reel %q has no reel number", strings.TrimSpace(br.Path))
+	}
+	var fpath, ok = b.dataPath(batchXML, "reel", br.Path)
+	if !ok {
+		return
+	}
+	var doc, dmd, valid = b.readMETS(fpath, reelProfile)
+	if !valid {
+		return
+	}
+
+	var reelMODS = dmd["reelModsBib"]
+	if reelMODS == nil {
+		b.addf(fpath, "reel metadata (dmdSec \"reelModsBib\") is missing")
+	} else {
+		var number string
+		for _, id := range reelMODS.IDs {
+			if id.Type == "reel number" {
+				number = strings.TrimSpace(id.Label)
+			}
+		}
+		if number != br.Number {
+			b.addf(fpath, "reel MODS number %q doesn't match the batch XML's %q", number, br.Number)
+		}
+	}
+
+	b.checkStructure(fpath, doc, dmd, "np:target", []string{"master", "service"})
+}
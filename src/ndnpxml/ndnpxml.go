@@ -0,0 +1,171 @@
+// Package ndnpxml checks a batch's XML against NDNP's requirements before
+// the batch is packaged.  The batch XML, issue and reel METS, and OCR files
+// are checked for the metadata and file references NDNP's profiles require,
+// and can also be validated against the METS, MODS, and ALTO schemas using
+// xmllint.
+package ndnpxml
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ltype "github.com/uoregon-libraries/gopkg/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+)
+
+// XML namespaces we check for explicitly
+const (
+	metsNS = "http://www.loc.gov/METS/"
+	ndnpNS = "http://www.loc.gov/ndnp"
+)
+
+// METS profile prefixes: any version of NDNP's issue and reel profiles is
+// accepted so older batches can be checked
+const (
+	issueProfile = "urn:library-of-congress:mets:profiles:ndnp:issue:"
+	reelProfile  = "urn:library-of-congress:mets:profiles:ndnp:reel:"
+)
+
+// Problem describes one way a batch fails validation
+type Problem struct {
+	// File is the path to the file with the problem, relative to the batch
+	// directory
+	File string
+
+	// Line is the line number of the problem, if known
+	Line int
+
+	Message string
+}
+
+func (p *Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	if p.File != "" {
+		return p.File + ": " + p.Message
+	}
+	return p.Message
+}
+
+// Validator checks batches on disk
+type Validator struct {
+	// XMLLint and Schema are optional: when both are set, every METS and OCR
+	// file is validated against the schema
+	XMLLint string
+	Schema  string
+
+	Logger *ltype.Logger
+
+	// Context is used to kill xmllint when a job is told to stop
+	Context context.Context
+}
+
+// New returns a Validator using the given xmllint binary and schema, and the
+// default logger.  If either xmllint or schema is empty, only NDNP's profile
+// rules are checked.
+func New(xmllint, schema string) *Validator {
+	return &Validator{XMLLint: xmllint, Schema: schema, Logger: logger.Logger, Context: context.Background()}
+}
+
+// ValidateBatch checks the batch in dir, which should be named name, and
+// returns every problem found.  The name is given separately since it won't
+// match the directory when a batch is validated before it's moved into place.
+// An error is only returned if validation couldn't be done, such as xmllint
+// failing to run.
+func (v *Validator) ValidateBatch(dir, name string) ([]*Problem, error) {
+	var b = &batch{dir: filepath.Clean(dir), name: name}
+	b.check()
+
+	if v.XMLLint != "" && v.Schema != "" && len(b.xsdFiles) > 0 {
+		var problems, err = v.validateXSD(b)
+		if err != nil {
+			return nil, err
+		}
+		b.problems = append(b.problems, problems...)
+	}
+
+	return b.problems, nil
+}
+
+// batch holds the problems found in a single batch as well as the list of
+// files which should be validated against the schema
+type batch struct {
+	dir      string
+	name     string
+	problems []*Problem
+	xsdFiles []string
+}
+
+// addf records a problem with the given file
+func (b *batch) addf(fpath, format string, args ...interface{}) {
+	b.problems = append(b.problems, &Problem{File: b.rel(fpath), Message: fmt.Sprintf(format, args...)})
+}
+
+// rel returns fpath relative to the batch directory
+func (b *batch) rel(fpath string) string {
+	var rel, err = filepath.Rel(b.dir, fpath)
+	if err != nil {
+		return fpath
+	}
+	return rel
+}
+
+// decode reads and unmarshals the XML file at fpath into v, recording a
+// problem and returning false if that can't be done
+func (b *batch) decode(fpath string, v interface{}) bool {
+	var data, err = ioutil.ReadFile(fpath)
+	if err == nil {
+		err = xml.Unmarshal(data, v)
+	}
+	if err != nil {
+		b.addf(fpath, "unable to read XML: %s", err)
+		return false
+	}
+	return true
+}
+
+// dataPath returns the full path to a file referenced from the batch XML,
+// recording a problem if it's outside the batch or doesn't exist
+func (b *batch) dataPath(batchXML, kind, ref string) (string, bool) {
+	var rel = filepath.Clean(filepath.FromSlash(strings.TrimSpace(ref)))
+	if rel == "." || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		b.addf(batchXML, "%s path %q must be relative to the data directory", kind, ref)
+		return "", false
+	}
+
+	var fpath = filepath.Join(b.dir, "data", rel)
+	var info, err = os.Stat(fpath)
+	if err != nil || !info.Mode().IsRegular() {
+		b.addf(batchXML, "%s %q doesn't exist", kind, ref)
+		return "", false
+	}
+	return fpath, true
+}
+
+// rootElement returns the name of the XML file's root element without
+// parsing the rest of the file
+func rootElement(fpath string) (xml.Name, error) {
+	var f, err = os.Open(fpath)
+	if err != nil {
+		return xml.Name{}, err
+	}
+	defer f.Close()
+
+	var dec = xml.NewDecoder(f)
+	for {
+		var tok, err = dec.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		var se, ok = tok.(xml.StartElement)
+		if ok {
+			return se.Name, nil
+		}
+	}
+}
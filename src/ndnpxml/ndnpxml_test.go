@@ -0,0 +1,177 @@
+package ndnpxml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/testhelper"
+)
+
+const batchName = "batch_oru_test_ver01"
+
+// makeBatch copies the test batch to a temp dir and returns the copy's path
+// and its issue's path
+func makeBatch(t *testing.T) (string, string) {
+	var dir = testhelper.CopyDir(t, filepath.Join("testdata", batchName))
+	return dir, filepath.Join(dir, "data", "sn12345678", "print", "2001010101")
+}
+
+func TestValidBatch(t *testing.T) {
+	var dir, _ = makeBatch(t)
+	var problems, err = New("", "").ValidateBatch(dir, batchName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, p := range problems {
+		t.Errorf("Unexpected problem: %s", p)
+	}
+}
+
+func TestValidWIPBatch(t *testing.T) {
+	var dir, _ = makeBatch(t)
+	var wipDir = filepath.Join(filepath.Dir(dir), ".wip-"+batchName)
+	var err = os.Rename(dir, wipDir)
+	if err != nil {
+		t.Fatalf("Unable to rename batch: %s", err)
+	}
+
+	var problems []*Problem
+	problems, err = New("", "").ValidateBatch(wipDir, batchName)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, p := range problems {
+		t.Errorf("Unexpected problem: %s", p)
+	}
+}
+
+func TestProblems(t *testing.T) {
+	var replace = func(file, old, new string) func(*testing.T, string, string) {
+		return func(t *testing.T, dir, issueDir string) {
+			var fpath = filepath.Join(dir, file)
+			if file == "mets" {
+				fpath = filepath.Join(issueDir, "2001010101.xml")
+			}
+			testhelper.Replace(t, fpath, old, new)
+		}
+	}
+
+	var tests = map[string]struct {
+		change func(t *testing.T, dir, issueDir string)
+		want   string
+	}{
+		"missing jp2": {
+			func(t *testing.T, _, issueDir string) { os.Remove(filepath.Join(issueDir, "0002.jp2")) },
+			`sn12345678/print/2001010101/2001010101.xml: file "serviceFile2" refers to "0002.jp2", which doesn't exist`,
+		},
+		"extra pdf": {
+			func(t *testing.T, _, issueDir string) {
+				testhelper.WriteFile(t, filepath.Join(issueDir, "0003.pdf"), "pdf")
+			},
+			"METS lists 2 pages, but the issue directory has 3 .pdf files",
+		},
+		"wrong batch name": {
+			replace("data/batch.xml", `name="batch_oru_test_ver01"`, `name="batch_oru_test_ver02"`),
+			`batch name "batch_oru_test_ver02" doesn't match the expected name`,
+		},
+		"missing issue": {
+			replace("data/batch.xml", "print/2001010101/2001010101.xml", "print/2001010102/2001010102.xml"),
+			`issue "sn12345678/print/2001010102/2001010102.xml" doesn't exist`,
+		},
+		"escaping path": {
+			replace("data/batch.xml", "sn12345678/print", "../sn12345678/print"),
+			"must be relative to the data directory",
+		},
+		"lccn mismatch": {
+			replace("mets", `type="lccn">sn12345678`, `type="lccn">sn87654321`),
+			`issue MODS LCCN "sn87654321" doesn't match the batch XML's "sn12345678"`,
+		},
+		"missing date": {
+			replace("mets", `<mods:dateIssued encoding="iso8601">2001-01-01</mods:dateIssued>`, ""),
+			"issue MODS has no issue date",
+		},
+		"edition mismatch": {
+			replace("mets", "<mods:number>1</mods:number>", "<mods:number>2</mods:number>"),
+			`issue MODS edition 2 doesn't match the batch XML's "01"`,
+		},
+		"missing physical location": {
+			replace("mets", `<mods:physicalLocation authority="marcorg">oru</mods:physicalLocation>`, ""),
+			"page 1 MODS has no physical location",
+		},
+		"bad sequence": {
+			replace("mets", "<mods:start>2</mods:start>", "<mods:start>3</mods:start>"),
+			`page 2 has sequence number "3"; expected 2`,
+		},
+		"missing ocr pointer": {
+			replace("mets", `<fptr FILEID="ocrFile2" />`, ""),
+			`np:page 2 has no "ocr" file`,
+		},
+		"bad file reference": {
+			replace("mets", `<fptr FILEID="ocrFile2" />`, `<fptr FILEID="ocrFile3" />`),
+			`refers to file "ocrFile3", which isn't in the fileSec`,
+		},
+		"wrong profile": {
+			replace("mets", "ndnp:issue:v1.5", "ndnp:reel:v1.1"),
+			"METS profile",
+		},
+		"not alto": {
+			func(t *testing.T, _, issueDir string) {
+				testhelper.WriteFile(t, filepath.Join(issueDir, "0001.xml"), "<html />")
+			},
+			"OCR root element is <html>; expected <alto>",
+		},
+		"malformed mets": {
+			replace("mets", "</mets>", ""),
+			"unable to read XML",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var dir, issueDir = makeBatch(t)
+			tc.change(t, dir, issueDir)
+
+			var problems, err = New("", "").ValidateBatch(dir, batchName)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			var list []string
+			for _, p := range problems {
+				list = append(list, p.String())
+			}
+			if !strings.Contains(strings.Join(list, "\n"), tc.want) {
+				t.Errorf("Expected a problem containing %q; got %q", tc.want, list)
+			}
+		})
+	}
+}
+
+func TestParseXMLLint(t *testing.T) {
+	var b = &batch{dir: "/mnt/batches/" + batchName}
+	var out = strings.Join([]string{
+		"/mnt/batches/batch_oru_test_ver01/data/sn12345678/print/2001010101/2001010101.xml:12: " +
+			"Schemas validity error : Element '{http://www.loc.gov/mods/v3}bogus': This element is not expected.",
+		"/mnt/batches/batch_oru_test_ver01/data/sn12345678/print/2001010101/2001010101.xml fails to validate",
+		"/mnt/batches/batch_oru_test_ver01/data/sn12345678/print/2001010101/0001.xml:3: parser error : Premature end of data",
+		"<alto>",
+		"      ^",
+		"/mnt/batches/batch_oru_test_ver01/data/sn12345678/print/2001010101/0002.xml validates",
+	}, "\n")
+
+	var problems = b.parseXMLLint(out)
+	var expected = []string{
+		"data/sn12345678/print/2001010101/2001010101.xml:12: Schemas validity error : " +
+			"Element '{http://www.loc.gov/mods/v3}bogus': This element is not expected.",
+		"data/sn12345678/print/2001010101/0001.xml:3: parser error : Premature end of data",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, p := range problems {
+		if p.String() != expected[i] {
+			t.Errorf("Problem %d: expected %q, got %q", i, expected[i], p.String())
+		}
+	}
+}
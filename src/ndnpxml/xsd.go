@@ -0,0 +1,69 @@
+package ndnpxml
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
+)
+
+// xsdBatchSize is how many files are given to each xmllint run.  Batches can
+// have tens of thousands of files, so they can't all go on one command line,
+// but running xmllint once per file would mean parsing the schemas just as
+// many times.
+const xsdBatchSize = 100
+
+// xmllintInvalid holds the exit codes xmllint uses when it ran but found
+// files which aren't well-formed or valid.  Anything else, such as a schema
+// which can't be compiled, means validation couldn't be done.
+var xmllintInvalid = map[int]bool{1: true, 3: true, 4: true}
+
+// xmllintMessage matches xmllint's "file:line: message" output
+var xmllintMessage = regexp.MustCompile(`^(.+?):(\d+): (.+)$`)
+
+// validateXSD runs xmllint against the batch's METS and OCR files
+func (v *Validator) validateXSD(b *batch) ([]*Problem, error) {
+	var problems []*Problem
+	for start := 0; start < len(b.xsdFiles); start += xsdBatchSize {
+		var end = start + xsdBatchSize
+		if end > len(b.xsdFiles) {
+			end = len(b.xsdFiles)
+		}
+
+		var args = append([]string{"--noout", "--nonet", "--schema", v.Schema}, b.xsdFiles[start:end]...)
+		var out, err = shell.ExecOutput(v.Context, v.XMLLint, v.Logger, args...)
+		var found = b.parseXMLLint(out)
+
+		// xmllint exits non-zero when files are invalid, so an error only
+		// means validation failed if xmllint didn't tell us why
+		if err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || !xmllintInvalid[exitErr.ExitCode()] || len(found) == 0 {
+				return nil, fmt.Errorf("running xmllint: %s", err)
+			}
+		}
+		problems = append(problems, found...)
+	}
+
+	return problems, nil
+}
+
+// parseXMLLint converts xmllint's error output to a list of problems.  Lines
+// which don't report an error, such as the excerpts xmllint shows for parse
+// errors, are skipped.
+func (b *batch) parseXMLLint(out string) []*Problem {
+	var problems []*Problem
+	for _, line := range strings.Split(out, "\n") {
+		var m = xmllintMessage.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		var n, _ = strconv.Atoi(m[2])
+		problems = append(problems, &Problem{File: b.rel(m[1]), Line: n, Message: m[3]})
+	}
+	return problems
+}
@@ -0,0 +1,99 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Dir is a Store which copies files under a local directory, for sites
+// which keep their long-term copies on a mounted filesystem rather than an
+// object store
+type Dir struct {
+	Root string
+}
+
+func (d *Dir) path(key string) string {
+	return filepath.Join(d.Root, filepath.FromSlash(key))
+}
+
+// Put implements Store.  Files are copied to a hidden temp file and renamed
+// into place so a failed copy never leaves a partial file behind.
+func (d *Dir) Put(ctx context.Context, key, fpath string) (*Object, error) {
+	var local, err = localObject(key, fpath)
+	if err != nil {
+		return nil, err
+	}
+	var stored *Object
+	stored, err = d.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if matches(stored, local) {
+		return stored, nil
+	}
+
+	var dest = d.path(key)
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err == nil {
+		err = copyFile(fpath, dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err = d.Stat(ctx, key)
+	if err == nil {
+		err = verify(stored, local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Stat implements Store
+func (d *Dir) Stat(_ context.Context, key string) (*Object, error) {
+	var obj, err = localObject(key, d.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+// copyFile copies src to a temp file next to dest, then renames it to dest
+func copyFile(src, dest string) error {
+	var in, err = os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out *os.File
+	out, err = ioutil.TempFile(filepath.Dir(dest), ".objstore-")
+	if err != nil {
+		return err
+	}
+	var tmp = out.Name()
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	var closeErr = out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,239 @@
+// Package objstore copies finished files, such as batches and archived
+// originals, to long-term storage: an S3-compatible object store or a local
+// (usually network-mounted) directory.  Every copy is checked against the
+// local file's size and SHA-256 checksum.
+package objstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	ltype "github.com/uoregon-libraries/gopkg/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// tagManifest lists the checksums of a bag's tag files, including the data
+// manifest, so between the two every file in a bag is listed
+const tagManifest = "tagmanifest-sha256.txt"
+
+// bagManifests are the manifests a stored batch is checked against
+var bagManifests = []string{"manifest-sha256.txt", tagManifest}
+
+// Object describes a stored file
+type Object struct {
+	Key    string
+	Size   int64
+	SHA256 string
+}
+
+// Store is anything NCA can copy files into for safe keeping
+type Store interface {
+	// Put copies the file at the given path to key, returning an error if the
+	// store doesn't report the local file's size and checksum for the copy.
+	// Files which were already stored with the same checksum aren't copied
+	// again.
+	Put(ctx context.Context, key, path string) (*Object, error)
+
+	// Stat returns information about the object stored at key, or nil if
+	// nothing is stored there
+	Stat(ctx context.Context, key string) (*Object, error)
+}
+
+// New returns the store configured by the OBJECT_STORE_* settings, or nil if
+// no object store is configured
+func New(c *config.Config) (Store, error) {
+	if c.ObjectStoreURL == "" {
+		return nil, nil
+	}
+
+	var u, err = url.Parse(c.ObjectStoreURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store URL %q: %s", c.ObjectStoreURL, err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		var endpoint *url.URL
+		endpoint, err = url.Parse(c.ObjectStoreEndpoint)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid object store endpoint %q", c.ObjectStoreEndpoint)
+		}
+		return &S3{
+			Endpoint:  endpoint,
+			Bucket:    u.Host,
+			Prefix:    strings.Trim(u.Path, "/"),
+			Region:    c.ObjectStoreRegion,
+			AccessKey: c.ObjectStoreAccessKey,
+			SecretKey: c.ObjectStoreSecretKey,
+		}, nil
+	case "file":
+		return &Dir{Root: u.Path}, nil
+	}
+
+	return nil, fmt.Errorf("invalid object store URL %q: scheme must be s3 or file", c.ObjectStoreURL)
+}
+
+// BatchPrefix returns the key prefix under which a batch's files are stored
+func BatchPrefix(b *models.Batch) string {
+	return path.Join("batches", b.FullName())
+}
+
+// IssueArchiveKey returns the key for an issue's archive of original files
+func IssueArchiveKey(i *models.Issue) string {
+	return path.Join("originals", i.Key(), "original.tar")
+}
+
+// VerifyBatch returns an error unless the batch's current version has been
+// stored and every file listed in its bag manifests, plus the tag manifest
+// itself, is in the store with the local file's size and the manifest's
+// checksum
+func VerifyBatch(ctx context.Context, s Store, b *models.Batch) error {
+	if b.StoredAt.IsZero() {
+		return fmt.Errorf("batch %q has not been stored", b.FullName())
+	}
+
+	var prefix = BatchPrefix(b)
+	var tm, err = localObject(path.Join(prefix, tagManifest), filepath.Join(b.Location, tagManifest))
+	if err != nil {
+		return fmt.Errorf("reading batch %q's tag manifest: %s", b.FullName(), err)
+	}
+	var expected = []*Object{tm}
+	for _, name := range bagManifests {
+		var list []*Object
+		list, err = readManifest(prefix, b.Location, name)
+		if err != nil {
+			return fmt.Errorf("reading batch %q's manifests: %s", b.FullName(), err)
+		}
+		expected = append(expected, list...)
+	}
+
+	for _, local := range expected {
+		var stored *Object
+		stored, err = s.Stat(ctx, local.Key)
+		if err == nil {
+			err = verify(stored, local)
+		}
+		if err != nil {
+			return fmt.Errorf("batch %q doesn't match its stored copy: %s", b.FullName(), err)
+		}
+	}
+	return nil
+}
+
+// readManifest parses the bag manifest in dir, returning an object for each
+// file it lists, keyed by prefix, with the local file's size and the
+// manifest's checksum
+func readManifest(prefix, dir, name string) ([]*Object, error) {
+	var f, err = os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []*Object
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if line == "" {
+			continue
+		}
+		var parts = strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line in %q: %q", name, line)
+		}
+		var rel = strings.TrimLeft(parts[1], " ")
+
+		var info os.FileInfo
+		info, err = os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &Object{Key: path.Join(prefix, rel), Size: info.Size(), SHA256: strings.ToLower(parts[0])})
+	}
+	err = scanner.Err()
+	if err == nil && len(list) == 0 {
+		err = fmt.Errorf("%q lists no files", name)
+	}
+	return list, err
+}
+
+// PutDir stores every regular, non-hidden file under dir, keyed by prefix
+// and the file's path relative to dir, and returns how many files were
+// stored
+func PutDir(ctx context.Context, s Store, l *ltype.Logger, prefix, dir string) (int, error) {
+	var n int
+	var err = filepath.Walk(dir, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && fpath != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		var rel string
+		rel, err = filepath.Rel(dir, fpath)
+		if err != nil {
+			return err
+		}
+		var key = path.Join(prefix, filepath.ToSlash(rel))
+		l.Debugf("Storing %q as %q", fpath, key)
+		_, err = s.Put(ctx, key, fpath)
+		if err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+
+	return n, err
+}
+
+// localObject describes the local file at fpath as if it were stored at key
+func localObject(key, fpath string) (*Object, error) {
+	var info, err = os.Stat(fpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum []byte
+	sum, err = fileutil.SHA256(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{Key: key, Size: info.Size(), SHA256: hex.EncodeToString(sum)}, nil
+}
+
+// verify returns an error if the stored object's size or recorded checksum
+// doesn't match the expected object
+func verify(stored, local *Object) error {
+	if stored == nil {
+		return fmt.Errorf("%q is missing from the store", local.Key)
+	}
+	if stored.Size != local.Size {
+		return fmt.Errorf("%q was stored with %d bytes; expected %d", local.Key, stored.Size, local.Size)
+	}
+	if stored.SHA256 != local.SHA256 {
+		return fmt.Errorf("%q was stored with checksum %q; expected %q", local.Key, stored.SHA256, local.SHA256)
+	}
+	return nil
+}
+
+// matches returns true if the stored object is identical to the local one
+func matches(stored, local *Object) bool {
+	return stored != nil && stored.Size == local.Size && stored.SHA256 == local.SHA256
+}
@@ -0,0 +1,261 @@
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the checksum of an empty request body
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// checksumHeader is the metadata header objects are stored with so their
+// checksum can be read back.  S3's ETag isn't a reliable checksum: it isn't
+// an MD5 for encrypted objects, and it's never a SHA-256.
+const checksumHeader = "X-Amz-Meta-Sha256"
+
+// S3 is a Store for Amazon S3 and compatible services such as MinIO.  It
+// uses path-style requests (https://endpoint/bucket/key), which all
+// S3-compatible services support, and signs them with AWS Signature Version
+// 4.  Uploads send the file's SHA-256 checksum, which S3 verifies before
+// storing anything.  After each upload the object's size is read back to
+// make sure it's there; the checksum read back is only the metadata header
+// NCA sent, so it says nothing about the stored bytes.
+//
+// Files are sent in a single request, so they must be under S3's 5 GB limit.
+type S3 struct {
+	Endpoint  *url.URL
+	Bucket    string
+	Prefix    string
+	Region    string
+	AccessKey string
+	SecretKey string
+
+	// Client is the HTTP client requests are sent with; if nil,
+	// http.DefaultClient is used
+	Client *http.Client
+}
+
+// objectURL returns the URL of the object at key, with every path segment
+// escaped the way S3's signatures expect
+func (s *S3) objectURL(key string) *url.URL {
+	var parts = []string{s.Bucket}
+	if s.Prefix != "" {
+		parts = append(parts, strings.Split(s.Prefix, "/")...)
+	}
+	parts = append(parts, strings.Split(key, "/")...)
+
+	var raw, escaped []string
+	for _, p := range parts {
+		raw = append(raw, p)
+		escaped = append(escaped, uriEncode(p))
+	}
+
+	var u = *s.Endpoint
+	var base = strings.TrimRight(u.Path, "/")
+	u.Path = base + "/" + strings.Join(raw, "/")
+	u.RawPath = base + "/" + strings.Join(escaped, "/")
+	return &u
+}
+
+// Put implements Store
+func (s *S3) Put(ctx context.Context, key, fpath string) (*Object, error) {
+	var local, err = localObject(key, fpath)
+	if err != nil {
+		return nil, err
+	}
+	var stored *Object
+	stored, err = s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if matches(stored, local) {
+		return stored, nil
+	}
+
+	var f *os.File
+	f, err = os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), f)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = local.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(checksumHeader, local.SHA256)
+
+	var resp *http.Response
+	resp, err = s.do(req, local.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("storing %q: %s", key, err)
+	}
+	resp.Body.Close()
+
+	stored, err = s.Stat(ctx, key)
+	if err == nil {
+		err = verify(stored, local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Stat implements Store
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	var req, err = http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	resp, err = s.do(req, emptySHA256)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %s", key, err)
+	}
+	resp.Body.Close()
+
+	return &Object{Key: key, Size: resp.ContentLength, SHA256: resp.Header.Get(checksumHeader)}, nil
+}
+
+// do signs and sends the request, returning an error for any non-2xx
+// response.  The response is returned even on errors so callers can check
+// its status.
+func (s *S3) do(req *http.Request, payloadSHA256 string) (*http.Response, error) {
+	s.sign(req, payloadSHA256, time.Now())
+
+	var client = s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var resp, err = client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var body, _ = ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var s3err struct {
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &s3err) == nil && s3err.Code != "" {
+		return resp, fmt.Errorf("%s: %s (%s)", resp.Status, s3err.Code, s3err.Message)
+	}
+	return resp, fmt.Errorf("%s", resp.Status)
+}
+
+// sign adds AWS Signature Version 4 headers to req.  Every header already
+// set on the request is signed, along with the host.
+func (s *S3) sign(req *http.Request, payloadSHA256 string, now time.Time) {
+	var t = now.UTC()
+	var amzDate = t.Format("20060102T150405Z")
+	var scope = strings.Join([]string{t.Format("20060102"), s.Region, "s3", "aws4_request"}, "/")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadSHA256)
+
+	var host = req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	var headers = map[string]string{"host": host}
+	for name, vals := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(vals, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	var signedHeaders = strings.Join(names, ";")
+
+	var canonRequest = strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		payloadSHA256,
+	}, "\n")
+
+	var stringToSign = strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonRequest),
+	}, "\n")
+
+	var key = hmacSHA256([]byte("AWS4"+s.SecretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	var sig = hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, sig))
+}
+
+// canonicalQuery returns the query string sorted and encoded for signing
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vals := range q {
+		for _, v := range vals {
+			pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes everything but RFC 3986's unreserved characters, as
+// required by S3 signatures
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	var h = hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	var sum = sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
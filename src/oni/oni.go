@@ -0,0 +1,156 @@
+// Package oni runs the configured commands which load batches into, and purge
+// batches from, an ONI instance
+package oni
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	ltype "github.com/uoregon-libraries/gopkg/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
+)
+
+// Target is the ONI instance a command is run against
+type Target string
+
+// All ONI targets NCA knows about
+const (
+	Staging    Target = "staging"
+	Production Target = "production"
+)
+
+// Action is what a command does to a batch
+type Action string
+
+// All ONI actions NCA can run
+const (
+	Load  Action = "load"
+	Purge Action = "purge"
+)
+
+// Shell is the binary command templates are run with.  Its base name ("sh")
+// is what EXEC_TIMEOUTS uses to limit how long ONI commands can run.
+const Shell = "sh"
+
+// failureMarkers are output lines which mean an ONI command failed even if it
+// exited cleanly.  Django management commands don't always exit non-zero when
+// something goes wrong, but they always print one of these.
+var failureMarkers = []string{"Traceback (most recent call last):", "CommandError:"}
+
+// Command is a shell command template for running an ONI action against a
+// batch.  Templates use Go's text/template syntax, and can refer to
+// {{.Name}}, the batch's full name, and {{.Location}}, its path on NCA's
+// filesystem.  Both values are quoted for the shell.
+type Command struct {
+	Target Target
+	Action Action
+	tmpl   *template.Template
+}
+
+// templateData holds the values a command template can use
+type templateData struct {
+	Name     string
+	Location string
+}
+
+// NewCommand parses the template for the given target and action
+func NewCommand(t Target, a Action, tmpl string) (*Command, error) {
+	var parsed, err = template.New(string(t) + "-" + string(a)).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s command: %s", t, a, err)
+	}
+	return &Command{Target: t, Action: a, tmpl: parsed}, nil
+}
+
+// ConfigCommand returns the configured command for the given target and
+// action, or nil if no command is configured
+func ConfigCommand(c *config.Config, t Target, a Action) (*Command, error) {
+	var tmpl string
+	switch {
+	case t == Staging && a == Load:
+		tmpl = c.ONIStagingLoadCommand
+	case t == Staging && a == Purge:
+		tmpl = c.ONIStagingPurgeCommand
+	case t == Production && a == Load:
+		tmpl = c.ONIProductionLoadCommand
+	case t == Production && a == Purge:
+		tmpl = c.ONIProductionPurgeCommand
+	default:
+		return nil, fmt.Errorf("unknown ONI command %q %q", t, a)
+	}
+
+	if tmpl == "" {
+		return nil, nil
+	}
+	return NewCommand(t, a, tmpl)
+}
+
+// Expand returns the shell command for running this command against a batch
+// with the given name and location
+func (c *Command) Expand(name, location string) (string, error) {
+	var buf bytes.Buffer
+	var err = c.tmpl.Execute(&buf, templateData{Name: quote(name), Location: quote(location)})
+	return buf.String(), err
+}
+
+// Run executes the command for the given batch, returning the command's
+// output.  An error is returned if the command exits with a non-zero status
+// or its output shows that it failed.
+func (c *Command) Run(ctx context.Context, l *ltype.Logger, b *models.Batch) (string, error) {
+	return c.RunNamed(ctx, l, b.FullName(), b.Location)
+}
+
+// RunNamed is like Run, but for a batch name and location which may not
+// match a batch's current values, e.g., a previous version of a batch
+func (c *Command) RunNamed(ctx context.Context, l *ltype.Logger, name, location string) (string, error) {
+	var cmd, err = c.Expand(name, location)
+	if err != nil {
+		return "", fmt.Errorf("expanding %s %s command: %s", c.Target, c.Action, err)
+	}
+
+	var out string
+	out, err = shell.ExecOutput(ctx, Shell, l, "-c", cmd)
+	if err != nil {
+		return out, fmt.Errorf("running %s %s command: %w", c.Target, c.Action, err)
+	}
+	err = checkOutput(out)
+	if err != nil {
+		return out, fmt.Errorf("running %s %s command: %s", c.Target, c.Action, err)
+	}
+	return out, nil
+}
+
+// checkOutput looks for signs of failure in a command's output, returning an
+// error with the output's last line (usually the actual exception) if any
+// are found
+func checkOutput(out string) error {
+	var failed bool
+	var last string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		last = line
+		for _, marker := range failureMarkers {
+			if strings.HasPrefix(line, marker) {
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("command reported an error: %q", last)
+	}
+	return nil
+}
+
+// quote wraps s in single quotes so the shell treats it as one literal word
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
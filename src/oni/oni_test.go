@@ -0,0 +1,108 @@
+package oni
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/gopkg/logger"
+)
+
+type nullLog struct{}
+
+func (nullLog) Log(logger.LogLevel, string) {}
+
+var l = &logger.Logger{Loggable: nullLog{}}
+
+// fakeONI sets up the fake ONI script with its own state directory, and
+// returns load and purge commands which run it
+func fakeONI(t *testing.T) (load, purge *Command, cleanup func()) {
+	var script, err = filepath.Abs(filepath.Join("..", "..", "test", "fake-oni.sh"))
+	if err != nil {
+		t.Fatalf("Unable to find fake ONI script: %s", err)
+	}
+
+	var dir string
+	dir, err = ioutil.TempDir("", "fake-oni")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	os.Setenv("FAKE_ONI_DIR", dir)
+
+	load, err = NewCommand(Staging, Load, script+" load_batch {{.Location}}")
+	if err == nil {
+		purge, err = NewCommand(Staging, Purge, script+" purge_batch {{.Name}}")
+	}
+	if err != nil {
+		t.Fatalf("Unable to parse commands: %s", err)
+	}
+
+	return load, purge, func() { os.RemoveAll(dir); os.Unsetenv("FAKE_ONI_DIR") }
+}
+
+func TestLoadAndPurge(t *testing.T) {
+	var load, purge, cleanup = fakeONI(t)
+	defer cleanup()
+
+	var ctx = context.Background()
+	var name = "batch_oru_test_ver01"
+	var out, err = load.RunNamed(ctx, l, name, "/mnt/batches/"+name)
+	if err != nil {
+		t.Fatalf("Load failed: %s (output: %q)", err, out)
+	}
+	if !strings.Contains(out, "loaded batch "+name) {
+		t.Errorf("Unexpected load output %q", out)
+	}
+
+	_, err = load.RunNamed(ctx, l, name, "/mnt/batches/"+name)
+	if err == nil {
+		t.Errorf("Loading a batch twice should fail")
+	}
+
+	_, err = purge.RunNamed(ctx, l, name, "")
+	if err != nil {
+		t.Errorf("Purge failed: %s", err)
+	}
+	_, err = purge.RunNamed(ctx, l, name, "")
+	if err == nil {
+		t.Errorf("Purging a batch which isn't loaded should fail")
+	}
+}
+
+func TestFailures(t *testing.T) {
+	var load, _, cleanup = fakeONI(t)
+	defer cleanup()
+
+	var _, err = load.RunNamed(context.Background(), l, "batch_failme", "/mnt/batch_failme")
+	if err == nil {
+		t.Errorf("A non-zero exit status should fail")
+	}
+
+	_, err = load.RunNamed(context.Background(), l, "batch_traceback", "/mnt/batch_traceback")
+	if err == nil {
+		t.Fatalf("A traceback should fail even with a zero exit status")
+	}
+	if !strings.Contains(err.Error(), "simulated failure") {
+		t.Errorf("Expected the traceback's exception in the error; got %q", err)
+	}
+}
+
+func TestExpandQuotes(t *testing.T) {
+	var c, err = NewCommand(Production, Load, "ssh oni 'manage.py load_batch /batches/'{{.Name}}")
+	if err != nil {
+		t.Fatalf("Unable to parse command: %s", err)
+	}
+
+	var got string
+	got, err = c.Expand("it's; rm -rf /", "")
+	if err != nil {
+		t.Fatalf("Unable to expand command: %s", err)
+	}
+	var expected = `ssh oni 'manage.py load_batch /batches/''it'"'"'s; rm -rf /'`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -32,6 +32,22 @@ var (
 	// the moment
 	SearchIssues = newPrivilege(RoleWorkflowManager)
 
+	// Mark dates on which a title didn't publish, so they aren't reported as
+	// missing issues
+	MarkUnpublishedDates = newPrivilege(RoleWorkflowManager)
+
+	// Review the batch plan and create batches from it
+	QueueBatches = newPrivilege(RoleWorkflowManager)
+
+	// View in-process batches and their QC reports
+	ViewQCReports = newPrivilege(RoleBatchReviewer, RoleWorkflowManager)
+
+	// Fail a batch's QC, flagging the issues which need to be fixed
+	FailQC = newPrivilege(RoleBatchReviewer, RoleWorkflowManager)
+
+	// Pass a batch's QC, queueing it to be loaded into production
+	PassQC = newPrivilege(RoleBatchReviewer, RoleWorkflowManager)
+
 	// Admins only
 	ModifyValidatedLCCNs = newPrivilege()
 	ModifyTitleSFTP      = newPrivilege()
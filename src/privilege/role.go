@@ -39,7 +39,9 @@ var (
 		`Can add, edit, and deactivate users.  User managers can assign any rights to
 		others which have been assigned to them.`)
 	RoleMOCManager      = newRole("marc org code manager", "Has access to add new MARC Org Codes")
-	RoleWorkflowManager = newRole("workflow manager", "Can queue SFTP and scanned issues for processing")
+	RoleWorkflowManager = newRole("workflow manager", `Can queue SFTP and scanned issues for processing, and create batches from
+		issues which are ready for batching`)
+	RoleBatchReviewer = newRole("batch reviewer", "Can view batches awaiting QC and their QC reports, and pass or fail them")
 )
 
 // roles is our internal map of string to Role object
@@ -55,6 +57,7 @@ var AssignableRoles = []*Role{
 	RoleUserManager,
 	RoleMOCManager,
 	RoleWorkflowManager,
+	RoleBatchReviewer,
 }
 
 // newRole is internal as the list of roles shouldn't be modified by anything external
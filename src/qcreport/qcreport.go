@@ -0,0 +1,250 @@
+// Package qcreport builds a batch's quality-control report: an HTML page and
+// a JSON summary describing every issue in the batch, written next to the
+// batch's bag so QC staff have an overview without browsing staging by hand.
+package qcreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ltype "github.com/uoregon-libraries/gopkg/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
+)
+
+// ReportsDir is the directory, within the batch output path, which holds
+// every batch's QC report
+const ReportsDir = "qc-reports"
+
+// Filenames within a report's directory
+const (
+	HTMLFile  = "index.html"
+	JSONFile  = "report.json"
+	thumbsDir = "thumbnails"
+)
+
+// Dir returns the directory a batch's QC report is written to
+func Dir(batchOutputPath string, b *models.Batch) string {
+	return filepath.Join(batchOutputPath, ReportsDir, b.FullName())
+}
+
+// Report is the full QC report for a single batch
+type Report struct {
+	Batch       string    `json:"batch"`
+	MARCOrgCode string    `json:"moc"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Pages       int       `json:"pages"`
+	Words       int       `json:"words"`
+	Issues      []*Issue  `json:"issues"`
+}
+
+// Issue describes one of a batch's issues
+type Issue struct {
+	Key          string  `json:"key"`
+	LCCN         string  `json:"lccn"`
+	Title        string  `json:"title"`
+	Date         string  `json:"date"`
+	Edition      int     `json:"edition"`
+	EditionLabel string  `json:"edition_label"`
+	Volume       string  `json:"volume"`
+	Number       string  `json:"number"`
+	Pages        []*Page `json:"pages"`
+	Words        int     `json:"words"`
+
+	// Thumbnail is page 1's thumbnail image, relative to the report directory
+	Thumbnail string `json:"thumbnail"`
+
+	// Warnings holds the metadata warnings a curator accepted when queueing
+	// the issue for review
+	Warnings []string `json:"warnings"`
+
+	// Problems holds anything wrong found while building the report, such as
+	// pages without OCR
+	Problems []string `json:"problems"`
+}
+
+// Page describes a single page of an issue
+type Page struct {
+	Number int    `json:"number"`
+	Label  string `json:"label"`
+	Words  int    `json:"words"`
+}
+
+// Generator builds and writes QC reports
+type Generator struct {
+	OutputDir   string
+	GhostScript string
+	Logger      *ltype.Logger
+
+	// Context is used to kill shell commands when a job is told to stop
+	Context context.Context
+
+	// thumbnail writes a thumbnail of the given PDF to the given path.  It's a
+	// field so tests can avoid needing ghostscript.
+	thumbnail func(pdf, out string) error
+}
+
+// New returns a Generator which writes a report to outputDir, using the
+// default logger and ghostscript binary
+func New(outputDir string) *Generator {
+	var g = &Generator{OutputDir: outputDir, GhostScript: "gs", Logger: logger.Logger, Context: context.Background()}
+	g.thumbnail = g.ghostscriptThumbnail
+	return g
+}
+
+// Generate reads the batch's issues from its bag and writes the QC report.
+// Any existing report for the batch is replaced.
+func (g *Generator) Generate(b *models.Batch) error {
+	var issues, err = b.Issues()
+	if err != nil {
+		return fmt.Errorf("reading issues: %s", err)
+	}
+	var reels []*models.Reel
+	reels, err = b.Reels()
+	if err != nil {
+		return fmt.Errorf("reading reels: %s", err)
+	}
+	var reelByID = make(map[int]*models.Reel)
+	for _, r := range reels {
+		reelByID[r.ID] = r
+	}
+
+	// Build in a work-in-progress directory so a partial report never
+	// replaces a complete one
+	var wip = filepath.Join(filepath.Dir(g.OutputDir), ".wip-"+filepath.Base(g.OutputDir))
+	err = os.RemoveAll(wip)
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(wip, thumbsDir), 0755)
+	}
+	if err != nil {
+		return fmt.Errorf("creating report directory: %s", err)
+	}
+
+	var r = &Report{Batch: b.FullName(), MARCOrgCode: b.MARCOrgCode, GeneratedAt: time.Now()}
+	for _, i := range issues {
+		var dir = filepath.Join(b.Location, "data", i.BatchPath(reelByID[i.ReelID]))
+		var qi = g.buildIssue(wip, i, dir, i.AllWorkflowActions())
+		r.Issues = append(r.Issues, qi)
+		r.Pages += len(qi.Pages)
+		r.Words += qi.Words
+	}
+
+	err = write(wip, r)
+	if err == nil {
+		err = os.RemoveAll(g.OutputDir)
+	}
+	if err == nil {
+		err = os.Rename(wip, g.OutputDir)
+	}
+	return err
+}
+
+// buildIssue gathers the report data for a single issue from the files in
+// dir, writing page 1's thumbnail into reportDir
+func (g *Generator) buildIssue(reportDir string, i *models.Issue, dir string, actions []*models.Action) *Issue {
+	var qi = &Issue{
+		Key:          i.Key(),
+		LCCN:         i.LCCN,
+		Date:         i.Date,
+		Edition:      i.Edition,
+		EditionLabel: i.EditionLabel,
+		Volume:       i.Volume,
+		Number:       i.Issue,
+		Warnings:     acceptedWarnings(actions),
+	}
+	if i.Title != nil {
+		qi.Title = i.Title.Name
+	}
+
+	var pdfs, err = findPDFs(dir)
+	if err != nil {
+		qi.Problems = append(qi.Problems, fmt.Sprintf("unable to read issue files: %s", err))
+		return qi
+	}
+	if len(pdfs) != len(i.PageLabels) {
+		qi.Problems = append(qi.Problems, fmt.Sprintf("%d page labels, but %d PDFs", len(i.PageLabels), len(pdfs)))
+	}
+
+	for n, pdf := range pdfs {
+		var p = &Page{Number: n + 1}
+		if n < len(i.PageLabels) {
+			p.Label = i.PageLabels[n]
+		}
+		var alto = strings.TrimSuffix(pdf, filepath.Ext(pdf)) + ".xml"
+		p.Words, err = countWords(alto)
+		if err != nil {
+			qi.Problems = append(qi.Problems, fmt.Sprintf("page %d: unable to count OCR words: %s", p.Number, err))
+		}
+		qi.Words += p.Words
+		qi.Pages = append(qi.Pages, p)
+	}
+
+	if len(pdfs) > 0 {
+		var thumb = filepath.Join(thumbsDir, strings.Replace(qi.Key, "/", "-", -1)+".jpg")
+		err = g.thumbnail(pdfs[0], filepath.Join(reportDir, thumb))
+		if err != nil {
+			g.Logger.Warnf("Unable to generate thumbnail for %q: %s", qi.Key, err)
+			qi.Problems = append(qi.Problems, "unable to generate page 1 thumbnail")
+		} else {
+			qi.Thumbnail = thumb
+		}
+	}
+
+	return qi
+}
+
+// findPDFs returns the full path to all PDFs in dir, sorted by name
+func findPDFs(dir string) ([]string, error) {
+	var infos, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pdfs []string
+	for _, info := range infos {
+		if info.Mode().IsRegular() && strings.ToLower(filepath.Ext(info.Name())) == ".pdf" {
+			pdfs = append(pdfs, filepath.Join(dir, info.Name()))
+		}
+	}
+	return pdfs, nil
+}
+
+// ghostscriptThumbnail renders the first page of pdf as a small JPEG
+func (g *Generator) ghostscriptThumbnail(pdf, out string) error {
+	return shell.Exec(g.Context, g.GhostScript, g.Logger, "-q", "-dNOPAUSE", "-dBATCH", "-dSAFER",
+		"-dUseCropBox", "-sDEVICE=jpeg", "-dJPEGQ=80", "-r20", "-dFirstPage=1", "-dLastPage=1",
+		"-sOutputFile="+out, pdf)
+}
+
+// write saves the JSON and HTML reports to dir
+func write(dir string, r *Report) error {
+	var data, err = json.MarshalIndent(r, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, JSONFile), append(data, '\n'), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("writing JSON report: %s", err)
+	}
+
+	var f *os.File
+	f, err = os.Create(filepath.Join(dir, HTMLFile))
+	if err != nil {
+		return fmt.Errorf("writing HTML report: %s", err)
+	}
+	err = htmlTemplate.Execute(f, r)
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing HTML report: %s", err)
+	}
+	return nil
+}
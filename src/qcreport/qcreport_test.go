@@ -0,0 +1,104 @@
+package qcreport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/testhelper"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func TestBuildIssue(t *testing.T) {
+	var dir = testhelper.TempDir(t)
+	var issueDir = filepath.Join("testdata", "sn12345678-2001010101")
+	var reportDir = filepath.Join(dir, "report")
+	os.MkdirAll(filepath.Join(reportDir, thumbsDir), 0755)
+
+	var i = models.NewIssue("oru", "sn12345678", "2001-01-01", 1)
+	i.PageLabels = []string{"1", "2"}
+	i.Volume = "3"
+	i.Issue = "12"
+
+	var actions = []*models.Action{
+		{ActionType: string(models.ActionTypeAcceptWarnings), Message: "ignoring warnings (approved by \"x\"):\n\nfirst\nsecond"},
+		{ActionType: string(models.ActionTypeInternalProcess), Message: "ignoring warnings (approved by \"y\"):\n\nsecond\nthird"},
+		{ActionType: string(models.ActionTypeComment), Message: "ignoring warnings: not really\n\nnope"},
+	}
+
+	var thumbSource string
+	var g = New(filepath.Join(dir, "final"))
+	g.thumbnail = func(pdf, out string) error {
+		thumbSource = pdf
+		return ioutil.WriteFile(out, []byte("jpg"), 0644)
+	}
+
+	var qi = g.buildIssue(reportDir, i, issueDir, actions)
+	if len(qi.Pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(qi.Pages))
+	}
+	if qi.Pages[0].Words != 3 || qi.Words != 3 {
+		t.Errorf("Expected 3 words on page 1 and in the issue; got %d and %d", qi.Pages[0].Words, qi.Words)
+	}
+	if qi.Pages[1].Label != "2" {
+		t.Errorf("Expected page 2 to be labeled %q; got %q", "2", qi.Pages[1].Label)
+	}
+	if len(qi.Problems) != 1 || !strings.Contains(qi.Problems[0], "page 2") {
+		t.Errorf("Expected a single problem for page 2's missing OCR; got %#v", qi.Problems)
+	}
+	if strings.Join(qi.Warnings, ",") != "first,second,third" {
+		t.Errorf("Expected warnings first, second, and third; got %#v", qi.Warnings)
+	}
+	if filepath.Base(thumbSource) != "0001.pdf" {
+		t.Errorf("Expected a thumbnail of 0001.pdf; got %q", thumbSource)
+	}
+	if qi.Thumbnail != "thumbnails/sn12345678-2001010101.jpg" {
+		t.Errorf("Unexpected thumbnail path %q", qi.Thumbnail)
+	}
+	if qi.Volume != "3" || qi.Number != "12" {
+		t.Errorf("Expected volume 3, issue 12; got %q, %q", qi.Volume, qi.Number)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var dir = testhelper.TempDir(t)
+
+	var r = &Report{
+		Batch:  "batch_oru_test_ver01",
+		Pages:  2,
+		Issues: []*Issue{{Key: "sn12345678/2001010101", Title: "Fish & <Chips>", Warnings: []string{"odd date"}}},
+	}
+	var err = write(dir, r)
+	if err != nil {
+		t.Fatalf("Unable to write report: %s", err)
+	}
+
+	var data []byte
+	data, err = ioutil.ReadFile(filepath.Join(dir, JSONFile))
+	if err != nil {
+		t.Fatalf("Unable to read JSON report: %s", err)
+	}
+	var got Report
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unable to parse JSON report: %s", err)
+	}
+	if got.Batch != r.Batch || len(got.Issues) != 1 || got.Issues[0].Warnings[0] != "odd date" {
+		t.Errorf("JSON report doesn't match: %#v", got)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, HTMLFile))
+	if err != nil {
+		t.Fatalf("Unable to read HTML report: %s", err)
+	}
+	var html = string(data)
+	if !strings.Contains(html, "Fish &amp; &lt;Chips&gt;") {
+		t.Errorf("HTML report doesn't contain the escaped title")
+	}
+	if !strings.Contains(html, "odd date") {
+		t.Errorf("HTML report doesn't contain the accepted warning")
+	}
+}
@@ -0,0 +1,68 @@
+package qcreport
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// legacyWarningsPrefix is how accepted warnings were recorded before they
+// had their own action type
+const legacyWarningsPrefix = "ignoring warnings"
+
+// countWords returns the number of words in an ALTO XML file: each String
+// element is a single word
+func countWords(path string) (int, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n int
+	var dec = xml.NewDecoder(f)
+	for {
+		var tok, err = dec.Token()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		var el, ok = tok.(xml.StartElement)
+		if ok && el.Name.Local == "String" {
+			n++
+		}
+	}
+}
+
+// acceptedWarnings pulls the list of warnings curators explicitly accepted
+// from an issue's actions.  The action message is a header line, a blank
+// line, and then one warning per line.
+func acceptedWarnings(actions []*models.Action) []string {
+	var seen = make(map[string]bool)
+	var warnings []string
+	for _, a := range actions {
+		var t = models.ActionType(a.ActionType)
+		var legacy = t == models.ActionTypeInternalProcess && strings.HasPrefix(a.Message, legacyWarningsPrefix)
+		if t != models.ActionTypeAcceptWarnings && !legacy {
+			continue
+		}
+
+		var parts = strings.SplitN(a.Message, "\n\n", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		for _, w := range strings.Split(parts[1], "\n") {
+			w = strings.TrimSpace(w)
+			if w != "" && !seen[w] {
+				seen[w] = true
+				warnings = append(warnings, w)
+			}
+		}
+	}
+	return warnings
+}
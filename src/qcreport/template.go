@@ -0,0 +1,80 @@
+package qcreport
+
+import (
+	"html/template"
+)
+
+// htmlTemplate renders the standalone HTML report.  It's kept in code rather
+// than the app's templates directory since the report is written by the job
+// runner and must work when opened straight from disk.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date": func(r *Report) string { return r.GeneratedAt.Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>QC report: {{.Batch}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 1em 2em; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { border: 1px solid #ccc; padding: 0.4em; text-align: left; vertical-align: top; }
+    th { background: #eee; }
+    img { max-width: 150px; border: 1px solid #999; }
+    .warnings { color: #8a6d3b; }
+    .problems { color: #a94442; }
+    ul { margin: 0; padding-left: 1.2em; }
+  </style>
+</head>
+<body>
+  <h1>QC report: {{.Batch}}</h1>
+  <p>
+    MARC Org Code {{.MARCOrgCode}}: {{len .Issues}} issues, {{.Pages}} pages,
+    {{.Words}} OCR words.  Generated {{date .}}.
+  </p>
+
+  <table>
+    <thead>
+      <tr>
+        <th scope="col">Page 1</th>
+        <th scope="col">Issue</th>
+        <th scope="col">Pages</th>
+        <th scope="col">OCR words</th>
+        <th scope="col">Notes</th>
+      </tr>
+    </thead>
+    <tbody>
+      {{range .Issues}}
+      <tr>
+        <td>{{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="Page 1 of {{.Key}}">{{else}}No thumbnail{{end}}</td>
+        <td>
+          <strong>{{.Title}}</strong> ({{.LCCN}})<br>
+          {{.Date}}, edition {{.Edition}}{{if .EditionLabel}} ({{.EditionLabel}}){{end}}<br>
+          {{if .Volume}}Volume {{.Volume}}{{end}}{{if .Number}}, issue {{.Number}}{{end}}
+        </td>
+        <td>
+          {{len .Pages}}:
+          {{range $n, $p := .Pages}}{{if $n}}, {{end}}{{$p.Label}}{{end}}
+        </td>
+        <td>
+          {{.Words}}
+          <ul>{{range .Pages}}<li>Page {{.Number}}: {{.Words}}</li>{{end}}</ul>
+        </td>
+        <td>
+          {{if .Warnings}}
+          <div class="warnings">Warnings accepted during review:
+            <ul>{{range .Warnings}}<li>{{.}}</li>{{end}}</ul>
+          </div>
+          {{end}}
+          {{if .Problems}}
+          <div class="problems">Problems found building this report:
+            <ul>{{range .Problems}}<li>{{.}}</li>{{end}}</ul>
+          </div>
+          {{end}}
+        </td>
+      </tr>
+      {{end}}
+    </tbody>
+  </table>
+</body>
+</html>
+`))
@@ -71,6 +71,34 @@ func (i *Issue) ErrFolderContents(extra string) {
 	})
 }
 
+// ErrBadArchive tells us an uploaded issue archive couldn't be expanded
+func (i *Issue) ErrBadArchive(extra string) {
+	i.addError(&IssueError{
+		Err:  "invalid archive",
+		Msg:  "Issue archive could not be expanded: " + extra,
+		Prop: true,
+	})
+}
+
+// ErrNoReelTargets tells us a microfilm reel's target images are missing
+func (i *Issue) ErrNoReelTargets() {
+	i.addError(&IssueError{
+		Err:  "missing reel targets",
+		Msg:  fmt.Sprintf("Microfilm reel %q has no target images", i.Reel),
+		Prop: true,
+	})
+}
+
+// ErrReelLookup tells us the issue's microfilm reel couldn't be looked up, so
+// we can't know whether its targets are already in NCA
+func (i *Issue) ErrReelLookup() {
+	i.addError(&IssueError{
+		Err:  "reel lookup failed",
+		Msg:  fmt.Sprintf("Unable to look up microfilm reel %q; please try again later", i.Reel),
+		Prop: true,
+	})
+}
+
 // ErrTooNew adds an error for issues which are too new to be processed.  hours
 // should be set to the minimum number of hours an issue should be untouched
 // before being considered "safe".
@@ -126,3 +154,55 @@ func (i *Issue) ErrBadTitle() {
 		Prop: false,
 	})
 }
+
+// ErrBadPDF adds an error for a PDF which can't be processed, such as one
+// which is encrypted or damaged
+func (i *Issue) ErrBadPDF(filename, problem string) {
+	i.addError(&IssueError{
+		Err:  "invalid PDF",
+		Msg:  fmt.Sprintf("%s can't be processed: %s", filename, problem),
+		Prop: true,
+	})
+}
+
+// WarnPDF sets a warning-level error for PDFs which can be processed, but may
+// not produce good results (no text layer, mismatched page sizes, etc.)
+func (i *Issue) WarnPDF(filename, problem string) {
+	i.addError(&IssueError{
+		Err:  "questionable PDF",
+		Msg:  fmt.Sprintf("%s may not process correctly: %s", filename, problem),
+		Prop: false,
+		Warn: true,
+	})
+}
+
+// ErrBadImage adds an error for a page image which can't be read
+func (i *Issue) ErrBadImage(filename, problem string) {
+	i.addError(&IssueError{
+		Err:  "invalid image",
+		Msg:  fmt.Sprintf("%s can't be processed: %s", filename, problem),
+		Prop: true,
+	})
+}
+
+// WarnImage sets a warning-level error for page images which can be
+// processed, but may not produce good results
+func (i *Issue) WarnImage(filename, problem string) {
+	i.addError(&IssueError{
+		Err:  "questionable image",
+		Msg:  fmt.Sprintf("%s may not process correctly: %s", filename, problem),
+		Prop: false,
+		Warn: true,
+	})
+}
+
+// WarnSequence sets a warning-level error for metadata which doesn't fit the
+// sequence of the title's nearby issues (e.g., an issue number was skipped)
+func (i *Issue) WarnSequence(msg string) {
+	i.addError(&IssueError{
+		Err:  "out of sequence",
+		Msg:  msg,
+		Prop: false,
+		Warn: true,
+	})
+}
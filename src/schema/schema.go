@@ -239,6 +239,9 @@ type Issue struct {
 	// Location is where this issue can be found, either a URL or filesystem path
 	Location string
 
+	// Reel is the microfilm reel number for issues scanned from microfilm
+	Reel string
+
 	WorkflowStep WorkflowStep
 }
 
@@ -0,0 +1,119 @@
+package sftpd
+
+import (
+	"os"
+	"sync"
+)
+
+// quotaTracker hands out the usage tracker for each title directory, so
+// every session for a title shares one
+type quotaTracker struct {
+	m      sync.Mutex
+	titles map[string]*titleUsage
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{titles: make(map[string]*titleUsage)}
+}
+
+// forRoot returns the usage tracker for the given title directory
+func (q *quotaTracker) forRoot(root string) *titleUsage {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	var u = q.titles[root]
+	if u == nil {
+		u = newTitleUsage()
+		q.titles[root] = u
+	}
+	return u
+}
+
+// titleUsage keeps a running total of a title directory's usage while
+// uploads are open, so concurrent uploads can't each pass the quota check
+// and together exceed it.  The total is the size of everything on disk
+// other than open uploads, plus the size each open upload has reached.
+type titleUsage struct {
+	m       sync.Mutex
+	base    int64
+	uploads map[*upload]bool
+}
+
+func newTitleUsage() *titleUsage {
+	return &titleUsage{uploads: make(map[*upload]bool)}
+}
+
+// total returns the title's current usage.  The caller must hold the lock.
+func (tu *titleUsage) total() int64 {
+	var n = tu.base
+	for u := range tu.uploads {
+		n += u.size
+	}
+	return n
+}
+
+// open recomputes the title's on-disk usage and registers a new upload.
+// The file being written is excluded from the on-disk usage whether or not
+// it's truncated, since the upload's size replaces it.  ErrQuotaExceeded is
+// returned if the title has no space left.
+func (tu *titleUsage) open(root, real string, quota int64, openFile func() (*upload, error)) (*upload, error) {
+	tu.m.Lock()
+	defer tu.m.Unlock()
+
+	var disk, err = dirSize(root)
+	if err != nil {
+		return nil, err
+	}
+	var replaced bool
+	for u := range tu.uploads {
+		var info, err = u.File.Stat()
+		if err == nil {
+			disk -= info.Size()
+		}
+		if u.real == real {
+			replaced = true
+		}
+	}
+	if info, err := os.Stat(real); err == nil && info.Mode().IsRegular() && !replaced {
+		disk -= info.Size()
+	}
+	tu.base = disk
+
+	if tu.total() >= quota {
+		return nil, ErrQuotaExceeded
+	}
+
+	var u *upload
+	u, err = openFile()
+	if err != nil {
+		return nil, err
+	}
+	tu.uploads[u] = true
+	return u, nil
+}
+
+// grow reserves space for the upload to reach the given size, returning
+// false if that would put the title over its quota.  The caller must hold
+// the upload's lock.
+func (tu *titleUsage) grow(u *upload, size int64, quota int64) bool {
+	tu.m.Lock()
+	defer tu.m.Unlock()
+
+	if tu.total()-u.size+size > quota {
+		return false
+	}
+	u.size = size
+	return true
+}
+
+// close stops tracking the upload; its file now counts as part of the
+// title's on-disk usage
+func (tu *titleUsage) close(u *upload) {
+	tu.m.Lock()
+	defer tu.m.Unlock()
+
+	if tu.uploads[u] {
+		delete(tu.uploads, u)
+		tu.base += u.size
+	}
+}
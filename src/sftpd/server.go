@@ -0,0 +1,224 @@
+// Package sftpd is NCA's optional built-in SFTP server.  Publishers log in
+// with the SFTP credentials stored on their title, and are confined to that
+// title's directory under the PDF upload path.
+package sftpd
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// titleIDKey is the ssh.Permissions extension we use to remember which title
+// a connection authenticated as
+const titleIDKey = "nca-title-id"
+
+// Server holds the configuration for accepting SFTP connections
+type Server struct {
+	// Root is the PDF upload path; titles' SFTP directories live here
+	Root string
+
+	// titles returns the list of titles to authenticate against.  It's a
+	// function purely so tests can avoid the database.
+	titles func() (models.TitleList, error)
+
+	// audit records uploads.  As with titles, this is only a function so tests
+	// can avoid the database.
+	audit func(ip, user string, action models.AuditAction, message string) error
+
+	// quotas tracks each title's usage across all its sessions
+	quotas *quotaTracker
+
+	config *ssh.ServerConfig
+}
+
+// New returns a server rooted at the given upload path, using the PEM-encoded
+// private key at hostKeyPath to identify itself
+func New(root, hostKeyPath string) (*Server, error) {
+	var data, err = ioutil.ReadFile(hostKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SFTP host key: %s", err)
+	}
+	var key ssh.Signer
+	key, err = ssh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse SFTP host key: %s", err)
+	}
+
+	var s = &Server{Root: root, titles: models.Titles, audit: models.CreateAuditLog, quotas: newQuotaTracker()}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback:  s.checkPassword,
+		PublicKeyCallback: s.checkPublicKey,
+	}
+	s.config.AddHostKey(key)
+	return s, nil
+}
+
+// findTitle returns the title with the given SFTP username, or nil if there
+// isn't one or the title has no SFTP directory
+func (s *Server) findTitle(user string) *models.Title {
+	if user == "" {
+		return nil
+	}
+
+	var titles, err = s.titles()
+	if err != nil {
+		logger.Errorf("Unable to read titles for SFTP login: %s", err)
+		return nil
+	}
+
+	var t = titles.FindBySFTPUser(user)
+	if t == nil || t.SFTPDir == "" || strings.ContainsAny(t.SFTPDir, `/\`) || t.SFTPDir == ".." {
+		return nil
+	}
+	return t
+}
+
+func permissions(t *models.Title) *ssh.Permissions {
+	return &ssh.Permissions{Extensions: map[string]string{titleIDKey: strconv.Itoa(t.ID)}}
+}
+
+func (s *Server) checkPassword(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+	var t = s.findTitle(c.User())
+	if t != nil && t.SFTPPasswordMatches(string(pass)) {
+		return permissions(t), nil
+	}
+
+	logger.Warnf("Failed SFTP password login for %q from %s", c.User(), c.RemoteAddr())
+	return nil, fmt.Errorf("invalid credentials")
+}
+
+func (s *Server) checkPublicKey(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	var t = s.findTitle(c.User())
+	if t != nil {
+		var rest = []byte(t.SFTPPublicKeys)
+		for len(rest) > 0 {
+			var authKey ssh.PublicKey
+			var err error
+			authKey, _, _, rest, err = ssh.ParseAuthorizedKey(rest)
+			if err != nil {
+				break
+			}
+			if subtle.ConstantTimeCompare(authKey.Marshal(), key.Marshal()) == 1 {
+				return permissions(t), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("invalid credentials")
+}
+
+// ListenAndServe accepts SFTP connections on addr until the listener fails
+func (s *Server) ListenAndServe(addr string) error {
+	var l, err = net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Infof("SFTP server listening on %s", addr)
+
+	for {
+		var conn net.Conn
+		conn, err = l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(time.Second)
+				continue
+			}
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	var sconn, chans, reqs, err = ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		logger.Debugf("SFTP handshake from %s failed: %s", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	var id, _ = strconv.Atoi(sconn.Permissions.Extensions[titleIDKey])
+	var t *models.Title
+	var titles models.TitleList
+	titles, err = s.titles()
+	if err == nil {
+		for _, t2 := range titles {
+			if t2.ID == id {
+				t = t2
+			}
+		}
+	}
+	if t == nil {
+		logger.Errorf("Unable to find title %d for SFTP user %q", id, sconn.User())
+		return
+	}
+
+	var root = filepath.Join(s.Root, t.SFTPDir)
+	err = os.MkdirAll(root, 0755)
+	if err != nil {
+		logger.Errorf("Unable to create SFTP directory %q: %s", root, err)
+		return
+	}
+
+	var ip, _, _ = net.SplitHostPort(sconn.RemoteAddr().String())
+	logger.Infof("SFTP user %q (title %q) logged in from %s", sconn.User(), t.Name, ip)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		var ch, requests, err = nc.Accept()
+		if err != nil {
+			logger.Errorf("Unable to accept SFTP channel for %q: %s", sconn.User(), err)
+			continue
+		}
+		go s.serveSession(ch, requests, &session{
+			root:  root,
+			quota: t.SFTPQuota,
+			usage: s.quotas.forRoot(root),
+			user:  sconn.User(),
+			ip:    ip,
+			title: t.Name,
+			audit: s.audit,
+		})
+	}
+}
+
+// serveSession waits for a request for the sftp subsystem, rejecting
+// anything else (shells, commands, etc.), then serves SFTP on the channel
+func (s *Server) serveSession(ch ssh.Channel, requests <-chan *ssh.Request, sess *session) {
+	defer ch.Close()
+
+	for req := range requests {
+		var ok = req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+		req.Reply(ok, nil)
+		if !ok {
+			continue
+		}
+
+		var h = sftp.Handlers{FileGet: sess, FilePut: sess, FileCmd: sess, FileList: sess}
+		var rs = sftp.NewRequestServer(ch, h)
+		var err = rs.Serve()
+		if err != nil && err != io.EOF {
+			logger.Warnf("SFTP session for %q ended with an error: %s", sess.user, err)
+		}
+		rs.Close()
+		return
+	}
+}
@@ -0,0 +1,276 @@
+package sftpd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// ErrQuotaExceeded is returned to the client when a write would put the
+// title's directory over its quota
+var ErrQuotaExceeded = errors.New("upload quota exceeded")
+
+// session implements the pkg/sftp request handlers for a single logged-in
+// publisher, mapping all paths into their title's directory
+type session struct {
+	root  string
+	quota int64
+	usage *titleUsage
+	user  string
+	ip    string
+	title string
+	audit func(ip, user string, action models.AuditAction, message string) error
+}
+
+// resolve converts an SFTP path into a real path inside the session's root.
+// Paths can't climb above the root via "..", and if any existing part of the
+// path is a symlink leading outside the root, the path is rejected.
+func (s *session) resolve(p string) (string, error) {
+	var clean = path.Clean("/" + p)
+	var real = filepath.Join(s.root, filepath.FromSlash(clean))
+
+	var root, err = filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return "", err
+	}
+
+	// Find the deepest part of the path that exists, and make sure it's really
+	// within the root
+	var existing = real
+	for {
+		var resolved, err = filepath.EvalSymlinks(existing)
+		if err == nil {
+			if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+				return "", sftp.ErrSSHFxPermissionDenied
+			}
+			return real, nil
+		}
+		if !os.IsNotExist(err) || existing == s.root {
+			return "", err
+		}
+		existing = filepath.Dir(existing)
+	}
+}
+
+// Fileread opens a file for download
+func (s *session) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	var real, err = s.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(real)
+}
+
+// Filewrite opens a file for upload, enforcing the title's quota.  A title
+// which is already at or over its quota can't open files for writing.
+func (s *session) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	var real, err = s.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pf = r.Pflags()
+	var flags = os.O_WRONLY | os.O_CREATE
+	if pf.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pf.Excl {
+		flags |= os.O_EXCL
+	}
+
+	var openFile = func() (*upload, error) {
+		var f, err = os.OpenFile(real, flags, 0644)
+		if err != nil {
+			return nil, err
+		}
+		var w = &upload{File: f, s: s, real: real, path: path.Clean("/" + r.Filepath)}
+		if info, err := f.Stat(); err == nil {
+			w.size = info.Size()
+		}
+		return w, nil
+	}
+
+	var w *upload
+	if s.quota > 0 {
+		w, err = s.usage.open(s.root, real, s.quota, openFile)
+	} else {
+		w, err = openFile()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Filecmd handles everything that isn't reading, writing, or listing
+func (s *session) Filecmd(r *sftp.Request) error {
+	var real, err = s.resolve(r.Filepath)
+	if err != nil {
+		return err
+	}
+
+	switch r.Method {
+	case "Setstat":
+		// We don't let publishers set permissions or ownership, but clients
+		// often try to set file times after an upload, and failing that would
+		// make uploads look like they failed
+		return nil
+
+	case "Rename":
+		var target string
+		target, err = s.resolve(r.Target)
+		if err != nil {
+			return err
+		}
+		if real == s.root || target == s.root {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+		return os.Rename(real, target)
+
+	case "Rmdir", "Remove":
+		if real == s.root {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+		return os.Remove(real)
+
+	case "Mkdir":
+		return os.Mkdir(real, 0755)
+	}
+
+	// Links could escape the title directory, and nothing else is supported
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// Filelist handles directory listings and file stats
+func (s *session) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	var real, err = s.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		var infos []os.FileInfo
+		infos, err = ioutil.ReadDir(real)
+		return listerAt(infos), err
+
+	case "Stat":
+		var info os.FileInfo
+		info, err = os.Stat(real)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// listerAt is a simple slice-based implementation of sftp.ListerAt
+type listerAt []os.FileInfo
+
+// ListAt copies file info into ls starting at the given offset
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	var n = copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// dirSize returns the total size of all files under dir
+func dirSize(dir string) (int64, error) {
+	var total int64
+	var err = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// upload wraps a file being written so we can enforce quotas and log the
+// upload once it's complete
+type upload struct {
+	*os.File
+	s    *session
+	real string
+	path string
+
+	m       sync.Mutex
+	size    int64
+	written bool
+	failed  bool
+}
+
+// WriteAt writes to the file unless doing so would exceed the quota
+func (u *upload) WriteAt(p []byte, off int64) (int, error) {
+	var end = off + int64(len(p))
+
+	u.m.Lock()
+	if end > u.size {
+		if u.s.quota > 0 && !u.s.usage.grow(u, end, u.s.quota) {
+			u.failed = true
+			u.m.Unlock()
+			return 0, ErrQuotaExceeded
+		}
+		u.size = end
+	}
+	u.written = true
+	u.m.Unlock()
+
+	return u.File.WriteAt(p, off)
+}
+
+// TransferError is called by the sftp library if the connection dies
+// mid-upload, so we don't log an incomplete file as uploaded
+func (u *upload) TransferError(error) {
+	u.m.Lock()
+	u.failed = true
+	u.m.Unlock()
+}
+
+// Close closes the file and writes the upload to the audit log
+func (u *upload) Close() error {
+	var err = u.File.Close()
+	if u.s.quota > 0 {
+		u.s.usage.close(u)
+	}
+
+	u.m.Lock()
+	var written, failed, size = u.written, u.failed, u.size
+	u.m.Unlock()
+
+	if !written {
+		return err
+	}
+
+	var msg = fmt.Sprintf("uploaded %q (%d bytes) for title %q", u.path, size, u.s.title)
+	if failed || err != nil {
+		msg = fmt.Sprintf("incomplete upload of %q for title %q", u.path, u.s.title)
+	}
+	logger.Infof("SFTP user %q %s", u.s.user, msg)
+	var auditErr = u.s.audit(u.s.ip, u.s.user, models.AuditActionSFTPUpload, msg)
+	if auditErr != nil {
+		logger.Criticalf("Unable to write audit log for SFTP user %q (%s), %q: %s", u.s.user, u.s.ip, msg, auditErr)
+	}
+
+	return err
+}
@@ -0,0 +1,195 @@
+package sftpd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+func testSession(t *testing.T, quota int64) (*session, *[]string, func()) {
+	var dir, err = ioutil.TempDir("", "sftpd")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	var root = filepath.Join(dir, "title")
+	os.Mkdir(root, 0755)
+
+	var logs []string
+	var s = &session{root: root, quota: quota, usage: newTitleUsage(), user: "pub", title: "Test Title",
+		audit: func(_, _ string, _ models.AuditAction, msg string) error {
+			logs = append(logs, msg)
+			return nil
+		},
+	}
+	return s, &logs, func() { os.RemoveAll(dir) }
+}
+
+func TestResolve(t *testing.T) {
+	var s, _, cleanup = testSession(t, 0)
+	defer cleanup()
+
+	var outside = filepath.Join(filepath.Dir(s.root), "outside")
+	os.Mkdir(outside, 0755)
+	os.Symlink(outside, filepath.Join(s.root, "escape"))
+
+	var tests = map[string]struct {
+		path string
+		want string
+		ok   bool
+	}{
+		"root":          {"/", s.root, true},
+		"file":          {"/2020-01-02/a.pdf", filepath.Join(s.root, "2020-01-02", "a.pdf"), true},
+		"dotdot":        {"../../etc/passwd", filepath.Join(s.root, "etc", "passwd"), true},
+		"symlink":       {"/escape/foo.pdf", "", false},
+		"symlink exact": {"/escape", "", false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got, err = s.resolve(tc.path)
+			if tc.ok && err != nil {
+				t.Fatalf("resolve(%q): unexpected error %s", tc.path, err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("resolve(%q): expected an error, got %q", tc.path, got)
+			}
+			if got != tc.want {
+				t.Errorf("resolve(%q): expected %q, got %q", tc.path, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	var s, logs, cleanup = testSession(t, 10)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(s.root, "existing"), []byte("1234"), 0644)
+
+	var req = sftp.NewRequest("Put", "/new.pdf")
+	req.Flags = 0x0A // write and create
+	var w, err = s.Filewrite(req)
+	if err != nil {
+		t.Fatalf("Unable to open file for writing: %s", err)
+	}
+
+	_, err = w.WriteAt([]byte("123456"), 0)
+	if err != nil {
+		t.Fatalf("Write within quota failed: %s", err)
+	}
+	_, err = w.WriteAt([]byte("7"), 6)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected quota error, got %v", err)
+	}
+	w.(*upload).Close()
+
+	if len(*logs) != 1 || (*logs)[0] != `incomplete upload of "/new.pdf" for title "Test Title"` {
+		t.Errorf("Expected an incomplete upload audit log, got %q", *logs)
+	}
+}
+
+func openForWrite(t *testing.T, s *session, fpath string, flags uint32) *upload {
+	var req = sftp.NewRequest("Put", fpath)
+	req.Flags = flags
+	var w, err = s.Filewrite(req)
+	if err != nil {
+		t.Fatalf("Unable to open %q for writing: %s", fpath, err)
+	}
+	return w.(*upload)
+}
+
+func TestQuotaFullTitle(t *testing.T) {
+	for name, size := range map[string]int{"at quota": 10, "over quota": 12} {
+		t.Run(name, func(t *testing.T) {
+			var s, _, cleanup = testSession(t, 10)
+			defer cleanup()
+			ioutil.WriteFile(filepath.Join(s.root, "existing"), make([]byte, size), 0644)
+
+			var req = sftp.NewRequest("Put", "/new.pdf")
+			req.Flags = 0x0A
+			var w, err = s.Filewrite(req)
+			if !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("Expected quota error, got %v", err)
+			}
+			if w != nil {
+				t.Errorf("Expected no writer, got %#v", w)
+			}
+		})
+	}
+}
+
+func TestQuotaTruncatedOverwrite(t *testing.T) {
+	var s, _, cleanup = testSession(t, 10)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(s.root, "a.pdf"), []byte("12345678"), 0644)
+
+	// Write, create, and truncate: the old file's size mustn't count against
+	// the new upload
+	var w = openForWrite(t, s, "/a.pdf", 0x1A)
+	var _, err = w.WriteAt([]byte("abcdefghij"), 0)
+	if err != nil {
+		t.Fatalf("Overwrite within quota failed: %s", err)
+	}
+	w.Close()
+
+	// Now the title is full, so nothing else can be opened
+	var req = sftp.NewRequest("Put", "/b.pdf")
+	req.Flags = 0x0A
+	_, err = s.Filewrite(req)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected quota error, got %v", err)
+	}
+}
+
+func TestQuotaConcurrentUploads(t *testing.T) {
+	var s, _, cleanup = testSession(t, 10)
+	defer cleanup()
+
+	var a = openForWrite(t, s, "/a.pdf", 0x0A)
+	var b = openForWrite(t, s, "/b.pdf", 0x0A)
+	defer a.Close()
+	defer b.Close()
+
+	var _, err = a.WriteAt([]byte("123456"), 0)
+	if err != nil {
+		t.Fatalf("First upload's write failed: %s", err)
+	}
+	_, err = b.WriteAt([]byte("1234"), 0)
+	if err != nil {
+		t.Fatalf("Second upload's write within quota failed: %s", err)
+	}
+	_, err = b.WriteAt([]byte("5"), 4)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected quota error once both uploads together hit the quota, got %v", err)
+	}
+
+	// A session opened while both are in progress sees their usage
+	var req = sftp.NewRequest("Put", "/c.pdf")
+	req.Flags = 0x0A
+	_, err = s.Filewrite(req)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected quota error for a third upload, got %v", err)
+	}
+}
+
+func TestUploadAudit(t *testing.T) {
+	var s, logs, cleanup = testSession(t, 0)
+	defer cleanup()
+
+	var req = sftp.NewRequest("Put", "/a.pdf")
+	req.Flags = 0x0A
+	var w, err = s.Filewrite(req)
+	if err != nil {
+		t.Fatalf("Unable to open file for writing: %s", err)
+	}
+	w.WriteAt([]byte("hello"), 0)
+	w.(*upload).Close()
+
+	if len(*logs) != 1 || (*logs)[0] != `uploaded "/a.pdf" (5 bytes) for title "Test Title"` {
+		t.Errorf("Expected an upload audit log, got %q", *logs)
+	}
+}
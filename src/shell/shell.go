@@ -2,40 +2,168 @@
 package shell
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/uoregon-libraries/gopkg/logger"
 )
 
-func _exec(cmd *exec.Cmd, binary string, jobLogger *logger.Logger, args ...string) (ok bool) {
+// ErrTimeout is returned when a command runs longer than its configured
+// timeout and has to be killed
+var ErrTimeout = errors.New("command timed out")
+
+// ErrCanceled is returned when a command is killed because its context was
+// canceled, e.g., a job runner was told to stop
+var ErrCanceled = errors.New("command canceled")
+
+// tailLines is how many lines of a failed command's output we log
+const tailLines = 25
+
+// outputLines is how many lines of output ExecOutput returns
+const outputLines = 500
+
+// waitDelay is how long we keep reading a command's output after it exits.
+// Children of the command may hold its output open, and we don't want them
+// to keep us waiting forever.
+var waitDelay = 5 * time.Second
+
+var timeouts struct {
+	sync.RWMutex
+	m   map[string]time.Duration
+	def time.Duration
+}
+
+// SetTimeouts tells the shell package how long each binary may run before
+// being killed.  Binaries are keyed by their base name ("gs", not
+// "/usr/bin/gs"), and the special key "default" applies to binaries which
+// aren't otherwise listed.  A zero (or missing) duration means no timeout.
+func SetTimeouts(t map[string]time.Duration) {
+	timeouts.Lock()
+	defer timeouts.Unlock()
+
+	timeouts.m = make(map[string]time.Duration)
+	for k, v := range t {
+		timeouts.m[k] = v
+	}
+	timeouts.def = timeouts.m["default"]
+}
+
+// Timeout returns the configured timeout for the given binary
+func Timeout(binary string) time.Duration {
+	timeouts.RLock()
+	defer timeouts.RUnlock()
+
+	var d, ok = timeouts.m[filepath.Base(binary)]
+	if !ok {
+		d = timeouts.def
+	}
+	return d
+}
+
+func _exec(ctx context.Context, cmd *exec.Cmd, out *tail, binary string, jobLogger *logger.Logger, args ...string) error {
 	jobLogger.Debugf(`Running "%s %s"`, binary, strings.Replace(strings.Join(args, " "), "%", "%%", -1))
-	var output, err = cmd.CombinedOutput()
+
+	var timeout = Timeout(binary)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// We give the command a pipe of our own rather than letting exec.Cmd copy
+	// its output, because cmd.Wait won't return until every process holding
+	// the output open has exited
+	var r, w, err = os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	err = cmd.Start()
+	w.Close()
+	if err == nil {
+		var copied = make(chan struct{})
+		go func() { io.Copy(out, r); close(copied) }()
+		err = wait(ctx, cmd)
+
+		select {
+		case <-copied:
+		case <-time.After(waitDelay):
+			r.Close()
+			<-copied
+		}
+	}
 	if err != nil {
 		jobLogger.Errorf(`Failed to run "%s %s": %s`, binary, strings.Join(args, " "), err)
-		for _, line := range bytes.Split(output, []byte("\n")) {
-			jobLogger.Debugf("--> %s", line)
+		var output, n = out.last(tailLines)
+		if output != "" {
+			jobLogger.Errorf("Last %d line(s) of output:\n%s", n, output)
 		}
-
-		return false
+		return err
 	}
 
-	return true
+	return nil
+}
+
+// wait waits for cmd to finish, killing it (or its process group, if it has
+// one) if ctx is done first
+func wait(ctx context.Context, cmd *exec.Cmd) error {
+	var done = make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		var pid = cmd.Process.Pid
+		if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+			pid = -pid
+		}
+		syscall.Kill(pid, syscall.SIGKILL)
+		<-done
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w (limit: %s)", ErrTimeout, Timeout(cmd.Path))
+		}
+		return ErrCanceled
+	}
 }
 
 // Exec attempts to run the given command, using logger to give consistent
-// formatting to whatever the command spits out if an error occurs
-func Exec(binary string, jobLogger *logger.Logger, args ...string) (ok bool) {
+// formatting to whatever the command spits out if an error occurs.  The
+// command is killed if ctx is canceled or the binary's timeout is reached.
+func Exec(ctx context.Context, binary string, jobLogger *logger.Logger, args ...string) error {
 	var cmd = exec.Command(binary, args...)
-	return _exec(cmd, binary, jobLogger, args...)
+	return _exec(ctx, cmd, &tail{max: tailLines}, binary, jobLogger, args...)
 }
 
 // ExecSubgroup is just like Exec, but sets the process to run in its own group
-// so it doesn't get killed on CTRL+C
-func ExecSubgroup(binary string, jobLogger *logger.Logger, args ...string) (ok bool) {
+// so it doesn't get killed on CTRL+C.  On timeout or cancellation, the whole
+// group is killed so that children of the process don't linger.
+func ExecSubgroup(ctx context.Context, binary string, jobLogger *logger.Logger, args ...string) error {
+	var cmd = exec.Command(binary, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return _exec(ctx, cmd, &tail{max: tailLines}, binary, jobLogger, args...)
+}
+
+// ExecOutput is just like ExecSubgroup, but returns the command's combined
+// stdout and stderr (up to the last few hundred lines) so callers can inspect
+// it.  The output is returned even if the command fails.
+func ExecOutput(ctx context.Context, binary string, jobLogger *logger.Logger, args ...string) (string, error) {
 	var cmd = exec.Command(binary, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	return _exec(cmd, binary, jobLogger, args...)
+	var out = &tail{max: outputLines}
+	var err = _exec(ctx, cmd, out, binary, jobLogger, args...)
+	return out.String(), err
 }
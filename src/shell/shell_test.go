@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/gopkg/logger"
+)
+
+type nullLog struct{}
+
+func (nullLog) Log(logger.LogLevel, string) {}
+
+var l = &logger.Logger{Loggable: nullLog{}}
+
+func TestTimeout(t *testing.T) {
+	SetTimeouts(map[string]time.Duration{"sleep": 50 * time.Millisecond})
+	defer SetTimeouts(nil)
+
+	var start = time.Now()
+	var err = ExecSubgroup(context.Background(), "sleep", l, "5")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected timeout error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Command wasn't killed in a timely manner")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	var err = Exec(ctx, "sleep", l, "5")
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("Expected cancel error, got %v", err)
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected cancellation not to be reported as a timeout")
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	SetTimeouts(map[string]time.Duration{"default": time.Minute, "gs": time.Second})
+	defer SetTimeouts(nil)
+
+	if Timeout("/usr/bin/gs") != time.Second {
+		t.Errorf("Expected gs to use its explicit timeout")
+	}
+	if Timeout("opj_compress") != time.Minute {
+		t.Errorf("Expected opj_compress to use the default timeout")
+	}
+}
+
+func TestTail(t *testing.T) {
+	var tl = &tail{max: 2}
+	tl.Write([]byte("one\ntwo\nth"))
+	tl.Write([]byte("ree\nfour"))
+
+	var got = tl.String()
+	if got != "three\nfour" {
+		t.Errorf("Expected last two lines, got %q", got)
+	}
+}
+
+func TestExecOutput(t *testing.T) {
+	var out, err = ExecOutput(context.Background(), "sh", l, "-c", "echo out; echo err >&2; exit 3")
+	if err == nil {
+		t.Errorf("Expected a non-zero exit to return an error")
+	}
+	if out != "out\nerr" {
+		t.Errorf("Expected stdout and stderr, got %q", out)
+	}
+}
+
+func TestCancelWithChildHoldingOutput(t *testing.T) {
+	var oldDelay = waitDelay
+	waitDelay = 100 * time.Millisecond
+	defer func() { waitDelay = oldDelay }()
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// The backgrounded sleep inherits the shell's output and isn't killed
+	// along with the shell, so it must not keep Exec from returning
+	var start = time.Now()
+	var err = Exec(ctx, "sh", l, "-c", "sleep 30 & wait")
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("Expected cancel error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Exec waited on the orphaned child")
+	}
+}
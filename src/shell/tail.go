@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// tail is an io.Writer which only holds onto the last few lines written to
+// it, so we can report a failed command's output without storing megabytes of
+// ghostscript chatter
+type tail struct {
+	sync.Mutex
+	max     int
+	lines   []string
+	partial []byte
+}
+
+// Write implements io.Writer, splitting p into lines and discarding the
+// oldest lines once we have more than t.max
+func (t *tail) Write(p []byte) (int, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	var data = append(t.partial, p...)
+	for {
+		var i = bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		t.add(string(data[:i]))
+		data = data[i+1:]
+	}
+	t.partial = append([]byte(nil), data...)
+
+	return len(p), nil
+}
+
+func (t *tail) add(line string) {
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+}
+
+// all returns the complete lines plus any trailing partial line
+func (t *tail) all() []string {
+	if len(t.partial) == 0 {
+		return t.lines
+	}
+	var lines = append(append([]string(nil), t.lines...), string(t.partial))
+	if len(lines) > t.max {
+		lines = lines[len(lines)-t.max:]
+	}
+	return lines
+}
+
+// last returns up to n of the most recent captured lines joined by newlines,
+// and how many lines that is
+func (t *tail) last(n int) (string, int) {
+	t.Lock()
+	defer t.Unlock()
+	var lines = t.all()
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// String returns the captured lines joined by newlines
+func (t *tail) String() string {
+	t.Lock()
+	defer t.Unlock()
+	return strings.Join(t.all(), "\n")
+}
@@ -0,0 +1,80 @@
+package uploads
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+
+	// Image decoders for page image uploads
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+)
+
+// minImageDimension is the smallest width or height, in pixels, we expect of
+// a newspaper page image.  Anything smaller is still processed, but it's
+// unlikely to be readable once it's on the site.
+const minImageDimension = 1500
+
+// Overridable image inspection function for testing
+var _imageconfigfunc = func(loc string) (image.Config, string, error) {
+	var f, err = os.Open(loc)
+	if err != nil {
+		return image.Config{}, "", err
+	}
+	defer f.Close()
+	return image.DecodeConfig(f)
+}
+
+// Overridable title lookup for testing
+var _findtitlefunc = func(lccn string) (*models.Title, error) {
+	return models.FindTitle("lccn = ?", lccn)
+}
+
+// sendsImages returns true if the issue's title is flagged as sending page
+// images instead of PDFs
+func (i *Issue) sendsImages() bool {
+	if i.Title == nil {
+		return false
+	}
+	var t, err = _findtitlefunc(i.Title.LCCN)
+	if err != nil {
+		logger.Errorf("Unable to look up title %q for issue %q: %s", i.Title.LCCN, i.Key(), err)
+		return false
+	}
+	return t != nil && t.ImageUploads
+}
+
+func isPageImage(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".tif", ".tiff":
+		return true
+	}
+	return false
+}
+
+// ValidateImages checks each page image in the issue, adding errors for
+// images which can't be read and warnings for those too small to be useful.
+// It's only meant for titles which send page images.
+func (i *Issue) ValidateImages() {
+	for _, f := range i.Files {
+		if !isPageImage(f.Name) {
+			continue
+		}
+
+		var cfg, _, err = _imageconfigfunc(f.Location)
+		if err != nil {
+			i.ErrBadImage(f.Name, "the file could not be read as an image")
+			continue
+		}
+		if cfg.Width < minImageDimension || cfg.Height < minImageDimension {
+			i.WarnImage(f.Name, fmt.Sprintf("the image is only %dx%d pixels", cfg.Width, cfg.Height))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+func TestValidateImages(t *testing.T) {
+	var configs = map[string]image.Config{
+		"/tmp/good.jpg":  {Width: 5000, Height: 7000},
+		"/tmp/small.png": {Width: 800, Height: 1000},
+	}
+	var orig = _imageconfigfunc
+	_imageconfigfunc = func(loc string) (image.Config, string, error) {
+		var cfg, ok = configs[loc]
+		if !ok {
+			return cfg, "", errors.New("unknown format")
+		}
+		return cfg, "jpeg", nil
+	}
+	defer func() { _imageconfigfunc = orig }()
+
+	var tests = map[string]struct {
+		files    []string
+		errors   int
+		warnings int
+	}{
+		"good":        {[]string{"good.jpg", "good.jpg"}, 0, 0},
+		"unreadable":  {[]string{"good.jpg", "broken.tif"}, 1, 0},
+		"small":       {[]string{"small.png"}, 0, 1},
+		"pdf ignored": {[]string{"file.pdf"}, 0, 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = fakeUploadIssue(tc.files...)
+			i.ValidateImages()
+			if i.Errors.Major().Len() != tc.errors || i.Errors.Minor().Len() != tc.warnings {
+				var elist []string
+				for _, err := range i.Errors.All() {
+					elist = append(elist, err.Message())
+				}
+				t.Errorf("Expected %d errors and %d warnings, got %q", tc.errors, tc.warnings, elist)
+			}
+		})
+	}
+}
+
+func TestValidateFiles(t *testing.T) {
+	var origImage, origTitle = _imageconfigfunc, _findtitlefunc
+	_imageconfigfunc = func(loc string) (image.Config, string, error) {
+		return image.Config{Width: 800, Height: 1000}, "png", nil
+	}
+	_findtitlefunc = func(lccn string) (*models.Title, error) {
+		return &models.Title{LCCN: lccn, ImageUploads: lccn == "images"}, nil
+	}
+	_pdfinspectfunc = func(_ context.Context, loc string) (*pdfReport, error) {
+		return &pdfReport{Pages: 1}, nil
+	}
+	defer func() {
+		_imageconfigfunc, _findtitlefunc, _pdfinspectfunc = origImage, origTitle, inspectPDF
+	}()
+
+	var tests = map[string]struct {
+		lccn     string
+		ws       schema.WorkflowStep
+		files    []string
+		warnings int
+	}{
+		"image title":            {"images", schema.WSSFTP, []string{"small.png"}, 1},
+		"PDFs in an image title": {"images", schema.WSSFTP, []string{"notext.pdf"}, 0},
+		"PDF title":              {"pdfs", schema.WSSFTP, []string{"notext.pdf"}, 1},
+		"images in a PDF title":  {"pdfs", schema.WSSFTP, []string{"small.png"}, 0},
+		"scans":                  {"images", schema.WSScan, []string{"small.tif", "notext.pdf"}, 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = fakeUploadIssue(tc.files...)
+			i.Title = &schema.Title{LCCN: tc.lccn}
+			i.WorkflowStep = tc.ws
+			i.validateFiles(context.Background())
+			if i.Errors.Len() != tc.warnings || i.Errors.Minor().Len() != tc.warnings {
+				var elist []string
+				for _, err := range i.Errors.All() {
+					elist = append(elist, err.Message())
+				}
+				t.Errorf("Expected %d warnings, got %q", tc.warnings, elist)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package uploads
 
 import (
+	"context"
 	"time"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/config"
@@ -67,13 +68,15 @@ func (i *Issue) ValidateFast() {
 		i.ErrBadTitle()
 	}
 	i.CheckDupes(i.scanner.Lookup)
+	i.ValidateReel()
 }
 
 // ValidateAll runs through all upload-queue-specific validations and adds
-// errors which are only relevant to these issues.  This validator runs the DPI
-// check and is therefore fairly slow for scanned uploads.  It shouldn't be run
-// in bulk across a large number of issues.
-func (i *Issue) ValidateAll() {
+// errors which are only relevant to these issues.  This validator inspects
+// every PDF and page image and runs the DPI check, and is therefore fairly
+// slow.  It shouldn't be run in bulk across a large number of issues.  ctx
+// is used to cancel the external tools which inspect PDFs.
+func (i *Issue) ValidateAll(ctx context.Context) {
 	i.ValidateFast()
 
 	// Only validate if we haven't already done so *or* if we had no prior
@@ -86,9 +89,24 @@ func (i *Issue) ValidateAll() {
 	}
 	i.validatedAll = true
 
+	i.validateFiles(ctx)
 	if i.WorkflowStep == schema.WSScan {
 		for _, f := range i.Files {
 			f.ValidateDPI(i.conf.ScannedPDFDPI)
 		}
 	}
 }
+
+// validateFiles inspects the issue's PDFs or page images.  This is only
+// meant for publishers' born-digital uploads, and only for the kind of files
+// the title sends: the folder-contents checks already flag anything else.
+func (i *Issue) validateFiles(ctx context.Context) {
+	if i.WorkflowStep != schema.WSSFTP {
+		return
+	}
+	if i.sendsImages() {
+		i.ValidateImages()
+	} else {
+		i.ValidatePDFs(ctx)
+	}
+}
@@ -0,0 +1,250 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/shell"
+)
+
+// pageSizeTolerance is how much (as a fraction) page dimensions may differ
+// before we consider them inconsistent
+const pageSizeTolerance = 0.02
+
+// pdfReport holds everything we learned about a PDF from poppler's tools
+type pdfReport struct {
+	Encrypted bool
+
+	// Damaged is set when pdfinfo couldn't read the file at all, while
+	// RepairedXRef is set when poppler reported a broken cross-reference table
+	// or trailer, but was able to repair it and read the file anyway
+	Damaged      bool
+	RepairedXRef bool
+
+	Pages           int
+	Sizes           []pageSize
+	Fonts           int
+	UnembeddedFonts []string
+}
+
+// pageSize is a single page's width and height in points
+type pageSize struct {
+	W, H float64
+}
+
+func (a pageSize) matches(b pageSize) bool {
+	var near = func(x, y float64) bool {
+		return math.Abs(x-y) <= math.Max(x, y)*pageSizeTolerance
+	}
+	return near(a.W, b.W) && near(a.H, b.H)
+}
+
+func (a pageSize) String() string {
+	return fmt.Sprintf("%gx%g pts", a.W, a.H)
+}
+
+// Overridable PDF inspection function for testing
+var _pdfinspectfunc = inspectPDF
+
+// pdfBadExitCodes are the exit codes poppler's tools use when the PDF itself
+// is the problem: 1 means it couldn't be opened, and 3 means permissions
+// (e.g., encryption) kept it from being read.  Anything else means the tool
+// failed for reasons that have nothing to do with the file.
+var pdfBadExitCodes = map[int]bool{1: true, 3: true}
+
+// errPDFTool wraps failures to run one of poppler's tools, as opposed to the
+// tool reporting that the PDF is bad
+type errPDFTool struct {
+	binary string
+	err    error
+}
+
+func (e *errPDFTool) Error() string {
+	return fmt.Sprintf("unable to run %s: %s", e.binary, e.err)
+}
+
+func (e *errPDFTool) Unwrap() error {
+	return e.err
+}
+
+// runPDFTool runs one of poppler's command-line tools against the PDF at loc,
+// returning its combined output.  badPDF is true if the tool ran but
+// reported that the PDF couldn't be read.  Any other failure, such as a
+// missing binary or a timeout, is returned as an *errPDFTool.
+func runPDFTool(ctx context.Context, binary, loc string, args ...string) (out string, badPDF bool, err error) {
+	out, err = shell.ExecOutput(ctx, binary, logger.Logger, append(args, loc)...)
+	if err == nil {
+		return out, false, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && pdfBadExitCodes[exitErr.ExitCode()] {
+		return out, true, nil
+	}
+	return out, false, &errPDFTool{binary: binary, err: err}
+}
+
+// inspectPDF gathers a pdfReport for the PDF at loc using pdfinfo and
+// pdffonts.  An error is only returned if the tools couldn't do their job;
+// problems with the PDF itself are described by the report.  The tools are
+// killed if ctx is canceled.
+func inspectPDF(ctx context.Context, loc string) (*pdfReport, error) {
+	var r = new(pdfReport)
+
+	// pdfinfo only reports per-page sizes when given a page range; asking for
+	// far more pages than a newspaper issue could have just reports them all
+	var out, bad, err = runPDFTool(ctx, "pdfinfo", loc, "-f", "1", "-l", "100000")
+	if err != nil {
+		return nil, err
+	}
+	parsePDFInfo(r, out)
+	if bad {
+		if !r.Encrypted {
+			r.Damaged = true
+		}
+		return r, nil
+	}
+
+	out, bad, err = runPDFTool(ctx, "pdffonts", loc)
+	if err != nil {
+		return nil, err
+	}
+	if bad {
+		r.Damaged = true
+		return r, nil
+	}
+	parsePDFFonts(r, out)
+
+	return r, nil
+}
+
+var pageSizeRegex = regexp.MustCompile(`^Page\s+\d+\s+size:\s+([\d.]+)\s+x\s+([\d.]+)`)
+
+// popplerMessageRegex matches the errors and warnings poppler's tools print
+// (to stderr, which we get mixed in with the rest of the output)
+var popplerMessageRegex = regexp.MustCompile(`^(Syntax|Internal|Command Line|I/O|Permission) (Error|Warning)`)
+
+// parsePDFInfo reads pdfinfo's output into r.  poppler warns about broken
+// cross-reference tables and trailers even when it repairs them, so these
+// only mean the file is damaged if pdfinfo also fails.
+func parsePDFInfo(r *pdfReport, out string) {
+	for _, line := range strings.Split(out, "\n") {
+		if popplerMessageRegex.MatchString(line) {
+			var lower = strings.ToLower(line)
+			if strings.Contains(lower, "xref") || strings.Contains(lower, "trailer") {
+				r.RepairedXRef = true
+			}
+			if strings.Contains(lower, "incorrect password") {
+				r.Encrypted = true
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "Encrypted:"):
+			r.Encrypted = strings.HasPrefix(strings.TrimSpace(line[10:]), "yes")
+		case strings.HasPrefix(line, "Pages:"):
+			r.Pages, _ = strconv.Atoi(strings.TrimSpace(line[6:]))
+		default:
+			var m = pageSizeRegex.FindStringSubmatch(line)
+			if m != nil {
+				var w, _ = strconv.ParseFloat(m[1], 64)
+				var h, _ = strconv.ParseFloat(m[2], 64)
+				r.Sizes = append(r.Sizes, pageSize{w, h})
+			}
+		}
+	}
+}
+
+// parsePDFFonts reads pdffonts' output into r.  Fonts are listed after a
+// header and a line of dashes.  Font names and types can contain spaces, so
+// the "emb" column is found by counting from the end of the line.
+func parsePDFFonts(r *pdfReport, out string) {
+	var inList bool
+	for _, line := range strings.Split(out, "\n") {
+		if !inList {
+			inList = strings.HasPrefix(line, "---")
+			continue
+		}
+		if strings.TrimSpace(line) == "" || popplerMessageRegex.MatchString(line) {
+			continue
+		}
+		var fields = strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		r.Fonts++
+		if fields[len(fields)-5] != "yes" {
+			r.UnembeddedFonts = append(r.UnembeddedFonts, fields[0])
+		}
+	}
+}
+
+// ValidatePDFs inspects each PDF in the issue, adding errors for files which
+// would fail processing (encrypted, damaged, empty) and warnings for files
+// which would process but likely produce poor results.  Page sizes are
+// compared across the whole issue, not just within each file.  The warnings
+// are aimed at publishers' born-digital PDFs, so this shouldn't be used on
+// in-house scans.
+//
+// If the inspection tools can't be run, that's our problem, not the
+// publisher's: it's logged, and the file isn't flagged.  The inspection tools
+// are killed if ctx is canceled, e.g., when a web request is abandoned.
+func (i *Issue) ValidatePDFs(ctx context.Context) {
+	var first pageSize
+	var firstFile string
+	var sizeWarned bool
+
+	for _, f := range i.Files {
+		if strings.ToUpper(filepath.Ext(f.Name)) != ".PDF" {
+			continue
+		}
+
+		var r, err = _pdfinspectfunc(ctx, f.Location)
+		if err != nil {
+			logger.Errorf("Unable to inspect PDF %q: %s", f.Location, err)
+			continue
+		}
+
+		switch {
+		case r.Encrypted:
+			i.ErrBadPDF(f.Name, "the file is encrypted or password-protected")
+			continue
+		case r.Damaged:
+			i.ErrBadPDF(f.Name, "the file is damaged and can't be read")
+			continue
+		case r.Pages == 0:
+			i.ErrBadPDF(f.Name, "the file has no pages")
+			continue
+		}
+
+		if r.RepairedXRef {
+			i.WarnPDF(f.Name, "the file's cross-reference table is damaged, though it could be repaired")
+		}
+
+		if r.Fonts == 0 {
+			i.WarnPDF(f.Name, "the file has no text layer")
+		} else if len(r.UnembeddedFonts) > 0 {
+			i.WarnPDF(f.Name, "the file uses fonts which aren't embedded: "+strings.Join(r.UnembeddedFonts, ", "))
+		}
+
+		for _, sz := range r.Sizes {
+			if firstFile == "" {
+				first, firstFile = sz, f.Name
+				continue
+			}
+			if !sizeWarned && !sz.matches(first) {
+				i.WarnPDF(f.Name, fmt.Sprintf("page size %s doesn't match %s (%s)", sz, firstFile, first))
+				sizeWarned = true
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/uoregon-libraries/gopkg/fileutil"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/schema"
+)
+
+const samplePDFInfo = `Producer:       Acrobat Distiller 10.0
+Tagged:         no
+Encrypted:      no
+Pages:          2
+Page    1 size: 792 x 1224 pts
+Page    1 rot:  0
+Page    2 size: 792.5 x 1224 pts
+Page    2 rot:  0
+File size:      1234567 bytes
+PDF version:    1.6
+`
+
+const samplePDFFonts = `name                                 type              encoding         emb sub uni object ID
+------------------------------------ ----------------- ---------------- --- --- --- ---------
+ABCDEE+Times-Roman                   Type 1C           WinAnsi          yes yes no      12  0
+Helvetica                            Type 1            Standard         no  no  no      14  0
+`
+
+func TestParsePDFInfo(t *testing.T) {
+	var r = new(pdfReport)
+	parsePDFInfo(r, samplePDFInfo)
+	if r.Encrypted || r.Damaged || r.RepairedXRef {
+		t.Errorf("Expected a clean report, got %#v", r)
+	}
+	if r.Pages != 2 {
+		t.Errorf("Expected 2 pages, got %d", r.Pages)
+	}
+	if len(r.Sizes) != 2 || r.Sizes[1] != (pageSize{792.5, 1224}) {
+		t.Errorf("Expected two page sizes, got %#v", r.Sizes)
+	}
+	if !r.Sizes[0].matches(r.Sizes[1]) {
+		t.Errorf("Expected tiny size differences to be tolerated")
+	}
+
+	r = new(pdfReport)
+	parsePDFInfo(r, strings.Replace(samplePDFInfo, "Encrypted:      no", "Encrypted:      yes (print:yes copy:no)", 1))
+	if !r.Encrypted {
+		t.Errorf("Expected encryption to be detected")
+	}
+
+	r = new(pdfReport)
+	parsePDFInfo(r, "Syntax Error: Couldn't read xref table\n"+samplePDFInfo)
+	if !r.RepairedXRef || r.Damaged {
+		t.Errorf("Expected a repaired xref without damage, got %#v", r)
+	}
+}
+
+func TestParsePDFFonts(t *testing.T) {
+	var r = new(pdfReport)
+	parsePDFFonts(r, samplePDFFonts)
+	if r.Fonts != 2 {
+		t.Errorf("Expected 2 fonts, got %d", r.Fonts)
+	}
+	if len(r.UnembeddedFonts) != 1 || r.UnembeddedFonts[0] != "Helvetica" {
+		t.Errorf("Expected Helvetica to be unembedded, got %#v", r.UnembeddedFonts)
+	}
+
+	// poppler's complaints end up mixed into the output and mustn't be
+	// mistaken for fonts
+	r = new(pdfReport)
+	parsePDFFonts(r, "Syntax Error (1234): Illegal character <2f> in hex string\n"+samplePDFFonts)
+	if r.Fonts != 2 {
+		t.Errorf("Expected 2 fonts with a warning in the output, got %d", r.Fonts)
+	}
+}
+
+func TestRunPDFTool(t *testing.T) {
+	var tests = map[string]struct {
+		binary  string
+		args    []string
+		bad     bool
+		toolErr bool
+	}{
+		"success":        {"sh", []string{"-c", "echo ok"}, false, false},
+		"unreadable PDF": {"sh", []string{"-c", "exit 1"}, true, false},
+		"permissions":    {"sh", []string{"-c", "exit 3"}, true, false},
+		"other failure":  {"sh", []string{"-c", "exit 99"}, false, true},
+		"missing binary": {"/nonexistent/pdfinfo", nil, false, true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var _, bad, err = runPDFTool(context.Background(), tc.binary, "", tc.args...)
+			if bad != tc.bad {
+				t.Errorf("Expected bad PDF to be %v, got %v", tc.bad, bad)
+			}
+			var toolErr *errPDFTool
+			if errors.As(err, &toolErr) != tc.toolErr {
+				t.Errorf("Expected tool error to be %v, got %v", tc.toolErr, err)
+			}
+		})
+	}
+}
+
+func TestRunPDFToolCanceled(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	var _, bad, err = runPDFTool(ctx, "sh", "", "-c", "sleep 5")
+	var toolErr *errPDFTool
+	if bad || !errors.As(err, &toolErr) {
+		t.Fatalf("Expected a canceled command to be a tool error, got bad=%v, err=%v", bad, err)
+	}
+}
+
+func fakeUploadIssue(names ...string) *Issue {
+	var si = &schema.Issue{}
+	for _, n := range names {
+		si.Files = append(si.Files, &schema.File{Issue: si, File: &fileutil.File{Name: n}, Location: "/tmp/" + n})
+	}
+	return New(si, nil, nil)
+}
+
+func TestValidatePDFs(t *testing.T) {
+	var good = pageSize{792, 1224}
+	var reports = map[string]*pdfReport{
+		"/tmp/good.pdf":      {Pages: 1, Sizes: []pageSize{good}, Fonts: 1},
+		"/tmp/encrypted.pdf": {Encrypted: true},
+		"/tmp/damaged.pdf":   {Damaged: true, RepairedXRef: true},
+		"/tmp/repaired.pdf":  {Pages: 1, Sizes: []pageSize{good}, Fonts: 1, RepairedXRef: true},
+		"/tmp/empty.pdf":     {Fonts: 1},
+		"/tmp/notext.pdf":    {Pages: 1, Sizes: []pageSize{good}},
+		"/tmp/small.pdf":     {Pages: 1, Sizes: []pageSize{{612, 792}}, Fonts: 1},
+	}
+	_pdfinspectfunc = func(_ context.Context, loc string) (*pdfReport, error) {
+		if loc == "/tmp/unchecked.pdf" {
+			return nil, &errPDFTool{binary: "pdfinfo", err: errors.New("command timed out")}
+		}
+		return reports[loc], nil
+	}
+	defer func() { _pdfinspectfunc = inspectPDF }()
+
+	var tests = map[string]struct {
+		files    []string
+		errors   int
+		warnings int
+	}{
+		"good":        {[]string{"good.pdf", "good.pdf", "0001.xml"}, 0, 0},
+		"encrypted":   {[]string{"good.pdf", "encrypted.pdf"}, 1, 0},
+		"damaged":     {[]string{"good.pdf", "damaged.pdf"}, 1, 0},
+		"repaired":    {[]string{"good.pdf", "repaired.pdf"}, 0, 1},
+		"no pages":    {[]string{"empty.pdf"}, 1, 0},
+		"no text":     {[]string{"notext.pdf"}, 0, 1},
+		"mismatched":  {[]string{"good.pdf", "small.pdf", "small.pdf"}, 0, 1},
+		"tool failed": {[]string{"good.pdf", "unchecked.pdf"}, 0, 0},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var i = fakeUploadIssue(tc.files...)
+			i.ValidatePDFs(context.Background())
+			if i.Errors.Major().Len() != tc.errors || i.Errors.Minor().Len() != tc.warnings {
+				var elist []string
+				for _, err := range i.Errors.All() {
+					elist = append(elist, err.Message())
+				}
+				t.Errorf("Expected %d errors and %d warnings, got %q", tc.errors, tc.warnings, elist)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package uploads
 
 import (
+	"context"
 	"fmt"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/dbi"
 
 	"github.com/uoregon-libraries/newspaper-curation-app/src/apperr"
 	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
@@ -42,26 +44,50 @@ func badStepErr() apperr.Error {
 	}
 }
 
-// Queue attempts to send the issue to the workflow by queueing up a move job
-func (i *Issue) Queue() apperr.Error {
-	// Make sure the issue is definitely valid
-	i.ValidateAll()
+func alreadyInNCAErr() apperr.Error {
+	return &schema.IssueError{
+		Err:  "issue is already in NCA",
+		Msg:  "This issue is already in NCA, and may have been queued or imported since it was last checked.",
+		Prop: true,
+	}
+}
+
+// validateForQueue runs all validations on the issue, returning an error if
+// the issue isn't safe to queue
+func (i *Issue) validateForQueue(ctx context.Context) apperr.Error {
+	// Make sure the issue is definitely valid.  If ctx was canceled, the PDF
+	// checks may not have run, so we can't trust the issue is valid.
+	i.ValidateAll(ctx)
+	if ctx.Err() != nil {
+		logger.Warnf("Not queueing issue %q: validation was canceled: %s", i.Key(), ctx.Err())
+		return invalidErr()
+	}
 	if i.Errors.Major().Len() > 0 {
 		// This should be rare, but it can happen during normal operation, so we
 		// just log an info message in case more digging needs to happen
-		logger.Infof("Issue %q isn't able to be queued in uploads.Issue.Queue(): %#v", i.Key(), i.Errors)
+		logger.Infof("Issue %q isn't able to be queued: %#v", i.Key(), i.Errors)
 		return invalidErr()
 	}
+	return nil
+}
+
+// Queue attempts to send the issue to the workflow by queueing up a move job.
+// ctx is passed along to ValidateAll.
+func (i *Issue) Queue(ctx context.Context) apperr.Error {
+	var vErr = i.validateForQueue(ctx)
+	if vErr != nil {
+		return vErr
+	}
 
 	// Find a DB issue or create one
-	var dbi, err = models.FindIssueByKey(i.Key())
+	var dbIssue, err = models.FindIssueByKey(i.Key())
 	if err != nil {
 		logger.Criticalf("Unable to search for database issue %q: %s", i.Key(), err)
 		return dbErr()
 	}
 
-	if dbi == nil {
-		dbi, err = i.createDatabaseIssue()
+	if dbIssue == nil {
+		dbIssue, err = i.createDatabaseIssue()
 		if err != nil {
 			logger.Criticalf("Unable to save a new database issue: %s", err)
 			return dbErr()
@@ -72,7 +98,7 @@ func (i *Issue) Queue() apperr.Error {
 	// make sure they're all failed move jobs.  We're okay closing and retrying a
 	// failed move, but anything else is a problem.
 	var jobList []*models.Job
-	jobList, err = models.FindJobsForIssueID(dbi.ID)
+	jobList, err = models.FindJobsForIssueID(dbIssue.ID)
 	if err != nil {
 		logger.Criticalf("Unable to query jobs associated with issue %q: %s", i.Key(), err)
 		return dbErr()
@@ -90,7 +116,7 @@ func (i *Issue) Queue() apperr.Error {
 			continue
 		default:
 			logger.Criticalf("Unexpected job detected for issue %q (db id %d): job id %d, status %q",
-				i.Key(), dbi.ID, job.ID, job.Status)
+				i.Key(), dbIssue.ID, job.ID, job.Status)
 			return brokenJobErr()
 		}
 	}
@@ -98,9 +124,9 @@ func (i *Issue) Queue() apperr.Error {
 	// All's well - queue up the job
 	switch i.WorkflowStep {
 	case schema.WSSFTP:
-		err = jobs.QueueSFTPIssueMove(dbi, i.conf)
+		err = jobs.QueueSFTPIssueMove(dbIssue, i.conf)
 	case schema.WSScan:
-		err = jobs.QueueMoveIssueForDerivatives(dbi, i.conf.WorkflowPath)
+		err = jobs.QueueMoveIssueForDerivatives(dbIssue, i.conf.WorkflowPath)
 	default:
 		logger.Criticalf("Invalid issue %q: workflow step %q isn't allowed for issue move jobs", i.Key(), i.WorkflowStep)
 		return badStepErr()
@@ -113,19 +139,87 @@ func (i *Issue) Queue() apperr.Error {
 	return nil
 }
 
+// QueueNew is like Queue, but only for publisher uploads NCA has never seen.
+// The check for an existing issue, the new database issue, and its move jobs
+// all share one transaction, so an issue queued or imported since the caller
+// last looked can't be queued a second time.
+func (i *Issue) QueueNew(ctx context.Context) apperr.Error {
+	if i.WorkflowStep != schema.WSSFTP {
+		logger.Criticalf("Invalid issue %q: workflow step %q isn't allowed for new issue moves", i.Key(), i.WorkflowStep)
+		return badStepErr()
+	}
+
+	var vErr = i.validateForQueue(ctx)
+	if vErr != nil {
+		return vErr
+	}
+
+	var op = dbi.DB.Operation()
+	op.Dbg = dbi.Debug
+	op.BeginTransaction()
+	defer op.EndTransaction()
+
+	var existing, err = models.FindIssueByKeyForUpdateOp(op, i.Key())
+	if err != nil {
+		logger.Criticalf("Unable to search for database issue %q: %s", i.Key(), err)
+		return dbErr()
+	}
+	if existing != nil {
+		op.Rollback()
+		logger.Infof("Not queueing issue %q: already in the database (id %d)", i.Key(), existing.ID)
+		return alreadyInNCAErr()
+	}
+
+	var newIssue *models.Issue
+	newIssue, err = i.newDatabaseIssue()
+	if err == nil {
+		err = newIssue.SaveOp(op, models.ActionTypeInternalProcess, models.SystemUser.ID, "Issue data initialized in NCA")
+	}
+	if err != nil {
+		logger.Criticalf("Unable to save a new database issue: %s", err)
+		return dbErr()
+	}
+
+	err = jobs.QueueSFTPIssueMoveOp(op, newIssue, i.conf)
+	if err != nil {
+		logger.Criticalf("Unable to queue issue %q for move: %s", i.Key(), err)
+		return dbErr()
+	}
+
+	return nil
+}
+
 func (i *Issue) createDatabaseIssue() (*models.Issue, error) {
-	var dbi = models.NewIssue(i.MARCOrgCode, i.Title.LCCN, i.RawDate, i.Edition)
+	var dbIssue, err = i.newDatabaseIssue()
+	if err != nil {
+		return nil, err
+	}
+	return dbIssue, dbIssue.Save(models.ActionTypeInternalProcess, models.SystemUser.ID, "Issue data initialized in NCA")
+}
+
+// newDatabaseIssue sets up, but doesn't save, a database issue for i
+func (i *Issue) newDatabaseIssue() (*models.Issue, error) {
+	var dbIssue = models.NewIssue(i.MARCOrgCode, i.Title.LCCN, i.RawDate, i.Edition)
 
 	// SFTP issues (for now) don't get their MOC set, so we have to do that here
-	if dbi.MARCOrgCode == "" && i.WorkflowStep == schema.WSSFTP {
-		dbi.MARCOrgCode = i.conf.PDFBatchMARCOrgCode
+	if dbIssue.MARCOrgCode == "" && i.WorkflowStep == schema.WSSFTP {
+		dbIssue.MARCOrgCode = i.conf.PDFBatchMARCOrgCode
 	}
 
 	// Scanned issues need to be marked as such
 	if i.WorkflowStep == schema.WSScan {
-		dbi.IsFromScanner = true
+		dbIssue.IsFromScanner = true
+	}
+
+	// Microfilm scans need to be tied to their reel
+	if i.Reel != "" {
+		var reel, err = i.findOrCreateReel()
+		if err != nil {
+			return nil, fmt.Errorf("unable to set up reel %q: %s", i.Reel, err)
+		}
+		dbIssue.ReelID = reel.ID
 	}
 
-	dbi.Location = i.Location
-	return dbi, dbi.Save(models.ActionTypeInternalProcess, models.SystemUser.ID, "Issue data initialized in NCA")
+	dbIssue.Location = i.Location
+	return dbIssue, nil
 }
@@ -0,0 +1,69 @@
+package uploads
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/uoregon-libraries/newspaper-curation-app/src/internal/logger"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/jobs"
+	"github.com/uoregon-libraries/newspaper-curation-app/src/models"
+)
+
+// reelTargetsPath returns where a microfilm issue's reel targets are found in
+// the scan directory: the "targets" directory alongside the reel's issues
+func (i *Issue) reelTargetsPath() string {
+	return filepath.Join(filepath.Dir(i.Location), "targets")
+}
+
+// ValidateReel checks that issues scanned from microfilm will have target
+// images for their reel: either the reel is already in NCA, or its targets
+// are waiting in the scan directory
+func (i *Issue) ValidateReel() {
+	if i.Reel == "" {
+		return
+	}
+
+	var reel, err = models.FindReelByNumber(i.Title.LCCN, i.Reel)
+	if err != nil {
+		logger.Errorf("Unable to look up reel %q for issue %q: %s", i.Reel, i.Key(), err)
+		i.ErrReelLookup()
+		return
+	}
+	if reel != nil && reel.Location != "" {
+		return
+	}
+
+	var infos, _ = ioutil.ReadDir(i.reelTargetsPath())
+	for _, info := range infos {
+		var ext = strings.ToLower(filepath.Ext(info.Name()))
+		if info.Mode().IsRegular() && (ext == ".tif" || ext == ".tiff") {
+			return
+		}
+	}
+	i.ErrNoReelTargets()
+}
+
+// findOrCreateReel returns the database reel this issue was scanned from,
+// creating it if necessary.  When a reel's targets haven't been brought into
+// NCA yet, they're queued to be copied into the workflow area.  The scanned
+// targets stay put until the reel's last issue has been moved.
+func (i *Issue) findOrCreateReel() (*models.Reel, error) {
+	var reel, err = models.FindReelByNumber(i.Title.LCCN, i.Reel)
+	if err != nil {
+		return nil, err
+	}
+	if reel == nil {
+		reel = &models.Reel{MARCOrgCode: i.MARCOrgCode, LCCN: i.Title.LCCN, ReelNumber: i.Reel}
+	}
+	if reel.Location != "" {
+		return reel, nil
+	}
+
+	reel.Location = filepath.Join(i.conf.WorkflowPath, "reels", i.Title.LCCN+"-"+i.Reel)
+	err = reel.Save()
+	if err != nil {
+		return nil, err
+	}
+	return reel, jobs.QueueMoveReelTargets(reel, i.reelTargetsPath())
+}
@@ -0,0 +1,61 @@
+#!/bin/bash
+#
+# fake-oni.sh pretends to be ONI's manage.py for testing NCA's ONI load and
+# purge jobs without a real ONI instance.  Point the ONI_* command settings at
+# it, e.g.:
+#
+#     ONI_STAGING_LOAD_COMMAND="/path/to/test/fake-oni.sh load_batch {{.Location}}"
+#     ONI_STAGING_PURGE_COMMAND="/path/to/test/fake-oni.sh purge_batch {{.Name}}"
+#
+# Loaded batches are tracked as files in $FAKE_ONI_DIR (default
+# /tmp/fake-oni).  Batch names containing "failme" fail with a CommandError,
+# and names containing "traceback" print a traceback but exit cleanly, the way
+# some ONI errors do.
+set -u
+
+dir=${FAKE_ONI_DIR:-/tmp/fake-oni}
+mkdir -p "$dir"
+
+if [[ $# -ne 2 ]]; then
+  echo "usage: $0 <load_batch|purge_batch> <batch path or name>" >&2
+  exit 2
+fi
+
+cmd=$1
+name=$(basename "$2")
+
+case "$name" in
+  *failme*)
+    echo "CommandError: unable to $cmd $name" >&2
+    exit 1
+    ;;
+  *traceback*)
+    echo "Traceback (most recent call last):" >&2
+    echo "  File \"manage.py\", line 1, in <module>" >&2
+    echo "Exception: simulated failure for $name" >&2
+    exit 0
+    ;;
+esac
+
+case "$cmd" in
+  load_batch)
+    if [[ -e "$dir/$name" ]]; then
+      echo "CommandError: batch $name is already loaded" >&2
+      exit 1
+    fi
+    touch "$dir/$name"
+    echo "loaded batch $name"
+    ;;
+  purge_batch)
+    if [[ ! -e "$dir/$name" ]]; then
+      echo "CommandError: batch $name doesn't exist" >&2
+      exit 1
+    fi
+    rm "$dir/$name"
+    echo "purged batch $name"
+    ;;
+  *)
+    echo "Unknown command: $cmd" >&2
+    exit 2
+    ;;
+esac